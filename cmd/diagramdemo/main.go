@@ -37,6 +37,9 @@ func main() {
 	w.SetMaster()
 
 	diagramWidget := diagramwidget.NewDiagramWidget("Diagram1")
+	diagramWidget.GridVisible = true
+	diagramWidget.SnapToGrid = true
+	diagramWidget.AlignmentGuidesEnabled = true
 
 	scrollContainer := container.NewScroll(diagramWidget)
 
@@ -92,7 +95,12 @@ func main() {
 	}), "Node4")
 	node4.Move(fyne.Position{X: 400, Y: 400})
 
-	node5 := diagramwidget.NewDiagramNode(diagramWidget, widget.NewLabel("Node5"), "Node5")
+	// Node 5
+	node5 := diagramwidget.NewDiagramNode(diagramWidget, widget.NewButton("Node5: Hierarchical layout", func() {
+		options := diagramwidget.NewHierarchicalLayoutOptions()
+		options.Animate = true
+		diagramwidget.HierarchicalLayout(diagramWidget, options)
+	}), "Node5")
 	node5.Move(fyne.NewPos(600, 200))
 
 	// Link0
@@ -133,12 +141,16 @@ func main() {
 	link3.AddMidpointAnchoredText("linkName", "Link 3")
 	link3.AddTargetAnchoredText("targetRole", "targetRole")
 	link3.AddMidpointDecoration(createTriangleDecoration())
+	link3.SetRouter(diagramwidget.NewOrthogonalLinkRouter())
+	// Double-clicking a segment adds further waypoints
+	link3.SetWaypoints([]fyne.Position{fyne.NewPos(300, 330)})
 
 	// Link4
 	link4 := diagramwidget.NewDiagramLink(diagramWidget, "Link4")
 	link4.SetSourcePad(node4.GetEdgePad())
 	link4.SetTargetPad(node3.GetEdgePad())
 	link4.AddMidpointAnchoredText("linkName", "Link 4")
+	link4.SetRouter(diagramwidget.NewSplineLinkRouter())
 
 	// Link5
 	link5 := diagramwidget.NewDiagramLink(diagramWidget, "Link5")
@@ -147,7 +159,58 @@ func main() {
 	link5.AddMidpointAnchoredText("linkName", "Link 5")
 	link5.AddTargetDecoration(diagramwidget.NewArrowhead())
 
-	w.SetContent(scrollContainer)
+	// Node6 and Node7 have typed ports, as in a dataflow editor
+	node6 := diagramwidget.NewDiagramNode(diagramWidget, widget.NewLabel("Node6: producer"), "Node6")
+	node6.Move(fyne.NewPos(700, 400))
+	node6Output := diagramwidget.NewPortPad(node6, "value", diagramwidget.PortOutput, "number")
+	diagramwidget.NewPortPad(node6, "name", diagramwidget.PortOutput, "text")
+	node7 := diagramwidget.NewDiagramNode(diagramWidget, widget.NewLabel("Node7: consumer"), "Node7")
+	node7.Move(fyne.NewPos(900, 400))
+	node7Input := diagramwidget.NewPortPad(node7, "value", diagramwidget.PortInput, "number")
+	node7Input.MaxConnections = 1
+	diagramwidget.NewPortPad(node7, "name", diagramwidget.PortInput, "text")
+
+	// Link6
+	link6 := diagramwidget.NewDiagramLink(diagramWidget, "Link6")
+	link6.SetSourcePad(node6Output)
+	link6.SetTargetPad(node7Input)
+	link6.AddTargetDecoration(diagramwidget.NewArrowhead())
+
+	// Node8 is an ellipse and Node9 a UML class box
+	node8 := diagramwidget.NewDiagramNode(diagramWidget, widget.NewLabel("Node8: ellipse"), "Node8")
+	node8.SetShape(diagramwidget.EllipseShape)
+	node8.Move(fyne.NewPos(700, 550))
+	node9 := diagramwidget.NewClassNode(diagramWidget, "Node9", "Node9", []string{"- shape: NodeShape"},
+		[]string{"+ GetShape(): NodeShape", "+ SetShape(NodeShape)"})
+	node9.Move(fyne.NewPos(900, 550))
+
+	// Node10 runs the force layout in the background; nodes dragged meanwhile stay where they are dropped
+	diagramWidget.PinDraggedNodes = true
+	node10 := diagramwidget.NewDiagramNode(diagramWidget, widget.NewButton("Node10: Barnes-Hut layout", func() {
+		diagramwidget.NewForceLayout(diagramWidget, diagramwidget.NewForceLayoutOptions()).RunInBackground(nil)
+	}), "Node10")
+	node10.Move(fyne.NewPos(900, 400))
+
+	// Link7
+	link7 := diagramwidget.NewDiagramLink(diagramWidget, "Link7")
+	link7.SetSourcePad(node8.GetEdgePad())
+	link7.SetTargetPad(node9.GetEdgePad())
+	link7.AddSourceDecoration(diagramwidget.NewFilledDiamond())
+	link7.AddTargetDecoration(diagramwidget.NewOpenTriangle())
+	link7.SetLineStyle(diagramwidget.LineStyleDashed)
+
+	zoomBar := container.NewHBox(
+		widget.NewButton("Zoom In", diagramWidget.ZoomIn),
+		widget.NewButton("Zoom Out", diagramWidget.ZoomOut),
+		widget.NewButton("Zoom to Fit", diagramWidget.ZoomToFit),
+		widget.NewButton("Zoom to Selection", diagramWidget.ZoomToSelection),
+	)
+
+	minimap := diagramwidget.NewMinimap(diagramWidget)
+
+	w.SetContent(container.NewBorder(zoomBar, nil, nil, minimap, scrollContainer))
+	diagramWidget.RegisterShortcuts(w.Canvas())
+	diagramWidget.ClearUndoHistory()
 
 	w.Resize(fyne.NewSize(600, 400))
 	w.ShowAndRun()
@@ -0,0 +1,132 @@
+package diagramwidget
+
+import (
+	"math"
+	"sort"
+
+	"fyne.io/fyne/v2"
+)
+
+// NodeAlignment identifies the edge or center line along which nodes are aligned
+type NodeAlignment int
+
+const (
+	// AlignLeft aligns the left edges of the nodes
+	AlignLeft NodeAlignment = iota
+	// AlignCenter aligns the vertical center lines of the nodes
+	AlignCenter
+	// AlignRight aligns the right edges of the nodes
+	AlignRight
+	// AlignTop aligns the top edges of the nodes
+	AlignTop
+	// AlignMiddle aligns the horizontal center lines of the nodes
+	AlignMiddle
+	// AlignBottom aligns the bottom edges of the nodes
+	AlignBottom
+)
+
+// DistributionDirection identifies the axis along which nodes are distributed
+type DistributionDirection int
+
+const (
+	// DistributeHorizontally makes the horizontal gaps between the nodes equal
+	DistributeHorizontally DistributionDirection = iota
+	// DistributeVertically makes the vertical gaps between the nodes equal
+	DistributeVertically
+)
+
+// AlignSelectedNodes aligns the selected nodes with the corresponding edge or center line of the
+// box enclosing them. The change is a single undo entry.
+func (dw *DiagramWidget) AlignSelectedNodes(alignment NodeAlignment) {
+	nodes := dw.getSelectedNodes()
+	if len(nodes) < 2 {
+		return
+	}
+	elements := []DiagramElement{}
+	for _, node := range nodes {
+		elements = append(elements, node)
+	}
+	topLeft, bottomRight := getElementBounds(elements)
+	dw.StartUndoGroup("Align")
+	for _, node := range nodes {
+		position := node.Position()
+		size := node.Size()
+		target := position
+		switch alignment {
+		case AlignLeft:
+			target.X = topLeft.X
+		case AlignCenter:
+			target.X = (topLeft.X+bottomRight.X)/2 - size.Width/2
+		case AlignRight:
+			target.X = bottomRight.X - size.Width
+		case AlignTop:
+			target.Y = topLeft.Y
+		case AlignMiddle:
+			target.Y = (topLeft.Y+bottomRight.Y)/2 - size.Height/2
+		case AlignBottom:
+			target.Y = bottomRight.Y - size.Height
+		}
+		if target != position {
+			dw.DisplaceNode(node, target.Subtract(position))
+		}
+	}
+	dw.EndUndoGroup()
+}
+
+// DistributeSelectedNodes moves the selected nodes so that the gaps between adjacent nodes along the indicated
+// direction are equal. The first and last nodes do not move. At least three nodes must be selected. The change
+// is a single undo entry.
+func (dw *DiagramWidget) DistributeSelectedNodes(direction DistributionDirection) {
+	nodes := dw.getSelectedNodes()
+	if len(nodes) < 3 {
+		return
+	}
+	start := func(node DiagramNode) float32 {
+		if direction == DistributeVertically {
+			return node.Position().Y
+		}
+		return node.Position().X
+	}
+	length := func(node DiagramNode) float32 {
+		if direction == DistributeVertically {
+			return node.Size().Height
+		}
+		return node.Size().Width
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return start(nodes[i]) < start(nodes[j])
+	})
+	first := nodes[0]
+	last := nodes[len(nodes)-1]
+	totalLength := float32(0)
+	for _, node := range nodes {
+		totalLength += length(node)
+	}
+	span := float32(math.Max(float64(start(last)+length(last)), float64(start(first)+length(first)))) - start(first)
+	gap := (span - totalLength) / float32(len(nodes)-1)
+	dw.StartUndoGroup("Distribute")
+	next := start(first) + length(first) + gap
+	for _, node := range nodes[1 : len(nodes)-1] {
+		delta := next - start(node)
+		if delta != 0 {
+			if direction == DistributeVertically {
+				dw.DisplaceNode(node, fyne.NewPos(0, delta))
+			} else {
+				dw.DisplaceNode(node, fyne.NewPos(delta, 0))
+			}
+		}
+		next += length(node) + gap
+	}
+	dw.EndUndoGroup()
+}
+
+// getSelectedNodes returns the selected nodes in display order
+func (dw *DiagramWidget) getSelectedNodes() []DiagramNode {
+	nodes := []DiagramNode{}
+	for _, node := range dw.GetDiagramNodes() {
+		if dw.IsSelected(node) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
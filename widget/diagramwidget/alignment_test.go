@@ -0,0 +1,44 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlignAndDistributeSelectedNodes(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	diagram.ElementTappedExtendsSelection = true
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(150, 250))
+	node3 := NewDiagramNode(diagram, nil, "Node3")
+	node3.Move(fyne.NewPos(500, 180))
+	diagram.ClearUndoHistory()
+	for _, node := range []DiagramNode{node1, node2, node3} {
+		diagram.DiagramElementTapped(node)
+	}
+
+	diagram.AlignSelectedNodes(AlignTop)
+	for _, node := range []DiagramNode{node1, node2, node3} {
+		assert.Equal(t, float32(100), node.Position().Y)
+	}
+	assert.Equal(t, "Align", diagram.GetUndoName())
+
+	diagram.AlignSelectedNodes(AlignRight)
+	right := node3.Position().X + node3.Size().Width
+	assert.Equal(t, right, node1.Position().X+node1.Size().Width)
+
+	diagram.Undo()
+	diagram.DistributeSelectedNodes(DistributeHorizontally)
+	gap1 := node2.Position().X - (node1.Position().X + node1.Size().Width)
+	gap2 := node3.Position().X - (node2.Position().X + node2.Size().Width)
+	assert.InDelta(t, gap1, gap2, 0.01)
+	assert.Equal(t, float32(100), node1.Position().X)
+	assert.Equal(t, float32(500), node3.Position().X)
+	assert.Equal(t, "Distribute", diagram.GetUndoName())
+}
@@ -4,6 +4,7 @@ import (
 	"image/color"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/driver/desktop"
@@ -51,6 +52,7 @@ func NewAnchoredText(text string) *AnchoredText {
 func (at *AnchoredText) CreateRenderer() fyne.WidgetRenderer {
 	atr := &anchoredTextRenderer{
 		widget: at,
+		dimmer: canvas.NewRectangle(color.Transparent),
 	}
 	atr.Refresh()
 
@@ -136,6 +138,8 @@ func (at *AnchoredText) SetReferencePosition(position fyne.Position) {
 // anchoredTextRenderer
 type anchoredTextRenderer struct {
 	widget *AnchoredText
+	// dimmer covers the text when its link is dimmed by a search
+	dimmer *canvas.Rectangle
 }
 
 func (atr *anchoredTextRenderer) Destroy() {
@@ -153,6 +157,9 @@ func (atr *anchoredTextRenderer) Objects() []fyne.CanvasObject {
 	canvasObjects := []fyne.CanvasObject{
 		atr.widget.textEntry,
 	}
+	if link := atr.widget.link; link != nil && link.diagram.isDimmed(link.id) {
+		canvasObjects = append(canvasObjects, atr.dimmer)
+	}
 	return canvasObjects
 }
 
@@ -161,4 +168,11 @@ func (atr *anchoredTextRenderer) Refresh() {
 	atr.widget.textEntry.Resize(atr.widget.textEntry.MinSize())
 	atr.widget.textEntry.Move(fyne.NewPos(5, 5))
 	atr.widget.textEntry.Refresh()
+	if link := atr.widget.link; link != nil {
+		if dimmer := link.diagram.getDimmer(link.id); dimmer != nil {
+			atr.dimmer.FillColor = dimmer
+			atr.dimmer.Resize(atr.widget.Size())
+			atr.dimmer.Refresh()
+		}
+	}
 }
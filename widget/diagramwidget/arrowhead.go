@@ -68,7 +68,7 @@ func (a *Arrowhead) CreateRenderer() fyne.WidgetRenderer {
 
 // GetReferenceLength returns the length of the decoration along the reference axis
 func (a *Arrowhead) GetReferenceLength() float32 {
-	return float32(math.Abs(math.Cos(float64(a.Theta)) * float64(a.Length)))
+	return float32(math.Abs(math.Cos(float64(a.Theta)) * a.scaledLength()))
 }
 
 // LeftPoint returns the position of the end of the left half of the arrowhead
@@ -76,8 +76,8 @@ func (a *Arrowhead) LeftPoint() fyne.Position {
 	leftAngle := r2.AddAngles(a.baseAngle, -a.Theta)
 	// We have to change the sign of Y because the window coordinate Y axis goes down rather than up
 	leftPosition := fyne.Position{
-		X: float32(float64(a.Length) * math.Cos(leftAngle)),
-		Y: -float32(float64(a.Length) * math.Sin(leftAngle)),
+		X: float32(a.scaledLength() * math.Cos(leftAngle)),
+		Y: -float32(a.scaledLength() * math.Sin(leftAngle)),
 	}
 	return leftPosition
 }
@@ -103,12 +103,17 @@ func (a *Arrowhead) RightPoint() fyne.Position {
 	rightAngle := r2.AddAngles(a.baseAngle, a.Theta)
 	// We have to change the sign of Y because the window coordinate Y axis goes down rather than up
 	rightPosition := fyne.Position{
-		X: float32(float64(a.Length) * math.Cos(rightAngle)),
-		Y: -float32(float64(a.Length) * math.Sin(rightAngle)),
+		X: float32(a.scaledLength() * math.Cos(rightAngle)),
+		Y: -float32(a.scaledLength() * math.Sin(rightAngle)),
 	}
 	return rightPosition
 }
 
+// scaledLength returns the length of the tails adjusted for the diagram's zoom
+func (a *Arrowhead) scaledLength() float64 {
+	return float64(a.Length) * getDecorationZoom(a.link)
+}
+
 // setBaseAngle sets the angle (in radians) of the reference axis
 func (a *Arrowhead) setBaseAngle(angle float64) {
 	a.baseAngle = angle
@@ -0,0 +1,122 @@
+package diagramwidget
+
+import (
+	"encoding/json"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Validate that ClassNode implements DiagramNode and SerializableElement
+var _ DiagramNode = (*ClassNode)(nil)
+var _ SerializableElement = (*ClassNode)(nil)
+
+// ClassNode is a node drawn as a UML class box. The class name is displayed in bold in the top compartment,
+// followed by a compartment listing the attributes and one listing the operations. The compartments are
+// separated by lines spanning the width of the node, drawn in the node's foreground color. The edge pad of
+// a ClassNode is a RectanglePad.
+type ClassNode struct {
+	BaseDiagramNode
+	className  string
+	attributes []string
+	operations []string
+	separators []*canvas.Rectangle
+}
+
+// NewClassNode creates a ClassNode and adds it to the DiagramWidget. The nodeID must be unique across all
+// of the DiagramElements in the diagram. The node has no padding so that the separators between the
+// compartments meet its border.
+func NewClassNode(diagram *DiagramWidget, nodeID string, className string, attributes []string, operations []string) *ClassNode {
+	cn := &ClassNode{}
+	InitializeBaseDiagramNode(cn, diagram, nil, nodeID)
+	properties := cn.GetProperties()
+	properties.Padding = 0
+	cn.SetProperties(properties)
+	cn.SetCompartments(className, attributes, operations)
+	return cn
+}
+
+// GetAttributes returns the entries of the attribute compartment
+func (cn *ClassNode) GetAttributes() []string {
+	return append([]string{}, cn.attributes...)
+}
+
+// GetClassName returns the name displayed in the top compartment
+func (cn *ClassNode) GetClassName() string {
+	return cn.className
+}
+
+// GetElementType returns the type under which the ClassNode factory is registered
+func (cn *ClassNode) GetElementType() string {
+	return ClassNodeType
+}
+
+// GetOperations returns the entries of the operation compartment
+func (cn *ClassNode) GetOperations() []string {
+	return append([]string{}, cn.operations...)
+}
+
+// MarshalElementData returns the contents of the compartments
+func (cn *ClassNode) MarshalElementData() (json.RawMessage, error) {
+	return json.Marshal(classNodeData{
+		ClassName:  cn.className,
+		Attributes: cn.attributes,
+		Operations: cn.operations,
+	})
+}
+
+// Refresh updates the separators between the compartments and refreshes the node
+func (cn *ClassNode) Refresh() {
+	for _, separator := range cn.separators {
+		separator.FillColor = cn.properties.ForegroundColor
+		separator.SetMinSize(fyne.NewSize(0, cn.diagram.scaled(cn.properties.StrokeWidth)))
+		separator.Refresh()
+	}
+	cn.BaseDiagramNode.Refresh()
+}
+
+// SetCompartments replaces the contents of the compartments
+func (cn *ClassNode) SetCompartments(className string, attributes []string, operations []string) {
+	cn.className = className
+	cn.attributes = append([]string{}, attributes...)
+	cn.operations = append([]string{}, operations...)
+	content := container.NewVBox(widget.NewLabelWithStyle(className, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}))
+	cn.separators = []*canvas.Rectangle{}
+	for _, compartment := range [][]string{cn.attributes, cn.operations} {
+		separator := canvas.NewRectangle(color.Transparent)
+		cn.separators = append(cn.separators, separator)
+		content.Add(separator)
+		for _, entry := range compartment {
+			content.Add(widget.NewLabel(entry))
+		}
+	}
+	cn.innerObject = content
+	cn.Refresh()
+	cn.refreshParentGroup()
+}
+
+// SetForegroundColor sets the color of the border and the separators
+func (cn *ClassNode) SetForegroundColor(foregroundColor color.Color) {
+	cn.properties.ForegroundColor = foregroundColor
+	cn.Refresh()
+}
+
+// classNodeData is the element data for a ClassNode
+type classNodeData struct {
+	ClassName  string   `json:"className"`
+	Attributes []string `json:"attributes,omitempty"`
+	Operations []string `json:"operations,omitempty"`
+}
+
+func newClassNodeFromData(diagram *DiagramWidget, nodeID string, data json.RawMessage) (DiagramNode, error) {
+	nodeData := classNodeData{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &nodeData); err != nil {
+			return nil, err
+		}
+	}
+	return NewClassNode(diagram, nodeID, nodeData.ClassName, nodeData.Attributes, nodeData.Operations), nil
+}
@@ -0,0 +1,193 @@
+package diagramwidget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+)
+
+// diagramClipboardFormat identifies clipboard text that contains diagram elements
+const diagramClipboardFormat = "fyne.io/x/fyne/widget/diagramwidget"
+
+// defaultPasteOffset is the default displacement (at zoom 1) of pasted elements from the copied ones
+var defaultPasteOffset = fyne.NewPos(20, 20)
+
+// clipboardModel is the clipboard representation of copied diagram elements
+type clipboardModel struct {
+	Format  string        `json:"format"`
+	Diagram *DiagramModel `json:"diagram"`
+}
+
+// Copy places the selected nodes (including the contents of selected groups) on the application
+// clipboard, together with the links whose source and target are both among the copied elements.
+// The clipboard content is JSON text, so it can be pasted into any DiagramWidget of the application.
+func (dw *DiagramWidget) Copy() error {
+	elements := dw.getClipboardElements()
+	if len(elements) == 0 {
+		return nil
+	}
+	model, err := newDiagramModelForElements(elements)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(clipboardModel{Format: diagramClipboardFormat, Diagram: model})
+	if err != nil {
+		return err
+	}
+	fyne.CurrentApp().Clipboard().SetContent(string(data))
+	dw.pasteCount = 0
+	return nil
+}
+
+// Cut copies the selection to the clipboard and then removes the selected elements from the diagram.
+// The removal is a single undo entry.
+func (dw *DiagramWidget) Cut() error {
+	if err := dw.Copy(); err != nil {
+		return err
+	}
+	dw.removeSelection("Cut")
+	return nil
+}
+
+// Paste adds the diagram elements on the application clipboard to the diagram. The new elements
+// are given new IDs (see GenerateElementIDCallback), displaced by the PasteOffset (repeatedly for
+// consecutive pastes of the same content), and become the selection. The paste is a single undo entry.
+func (dw *DiagramWidget) Paste() error {
+	content := fyne.CurrentApp().Clipboard().Content()
+	clipboard := clipboardModel{}
+	if err := json.Unmarshal([]byte(content), &clipboard); err != nil || clipboard.Format != diagramClipboardFormat || clipboard.Diagram == nil {
+		return errors.New("the clipboard does not contain diagram elements")
+	}
+	if content == dw.lastPasteContent {
+		dw.pasteCount++
+	} else {
+		dw.lastPasteContent = content
+		dw.pasteCount = 1
+	}
+	model := clipboard.Diagram
+	offset := fyne.NewPos(dw.PasteOffset.X*float32(dw.pasteCount), dw.PasteOffset.Y*float32(dw.pasteCount))
+	if err := dw.prepareModelForPaste(model, offset); err != nil {
+		return err
+	}
+	if err := model.AddToDiagram(dw); err != nil {
+		return err
+	}
+	dw.recordCommand("Paste", &addElementsCommand{diagram: dw, elementIDs: model.ZOrder})
+	dw.ClearSelectionNoCallback()
+	for _, id := range model.ZOrder {
+		if element := dw.GetDiagramElement(id); element != nil {
+			dw.addElementToSelection(element)
+		}
+	}
+	return nil
+}
+
+// getClipboardElements returns, in display order, the selected nodes and the links whose ends are
+// both connected to nodes or links among the returned elements. An unconnected end of a selected
+// link does not prevent it from being copied.
+func (dw *DiagramWidget) getClipboardElements() []DiagramElement {
+	included := map[string]bool{}
+	for id, element := range dw.selection {
+		if element.IsNode() {
+			included[id] = true
+			if group, ok := element.(DiagramNode).getBaseDiagramNode().typedNode.(DiagramGroup); ok {
+				for _, descendant := range group.getBaseDiagramGroup().getDescendants() {
+					included[descendant.GetDiagramElementID()] = true
+				}
+			}
+		}
+	}
+	isIncluded := func(pad ConnectionPad, linkSelected bool) bool {
+		if pad == nil {
+			return linkSelected
+		}
+		return included[pad.GetPadOwner().GetDiagramElementID()]
+	}
+	// Links may connect to other links, so we repeat until no more links are added
+	for added := true; added; {
+		added = false
+		for _, link := range dw.GetDiagramLinks() {
+			bdl := link.getBaseDiagramLink()
+			selected := dw.IsSelected(link)
+			if !included[bdl.id] && isIncluded(bdl.sourcePad, selected) && isIncluded(bdl.targetPad, selected) {
+				included[bdl.id] = true
+				added = true
+			}
+		}
+	}
+	elements := []DiagramElement{}
+	for _, element := range dw.GetDiagramElements() {
+		if included[element.GetDiagramElementID()] {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// prepareModelForPaste gives the elements of the model new IDs and displaces them by the offset
+func (dw *DiagramWidget) prepareModelForPaste(model *DiagramModel, offset fyne.Position) error {
+	newIDs := map[string]string{}
+	for _, id := range model.ZOrder {
+		newID := dw.generateElementID(id, newIDs)
+		if dw.GetDiagramElement(newID) != nil {
+			return fmt.Errorf("diagram element %s already exists", newID)
+		}
+		for _, used := range newIDs {
+			if used == newID {
+				return fmt.Errorf("duplicate diagram element ID %s", newID)
+			}
+		}
+		newIDs[id] = newID
+	}
+	for i := range model.ZOrder {
+		model.ZOrder[i] = newIDs[model.ZOrder[i]]
+	}
+	for i := range model.Nodes {
+		nodeModel := &model.Nodes[i]
+		nodeModel.ID = newIDs[nodeModel.ID]
+		if nodeModel.Parent != "" {
+			nodeModel.Parent = newIDs[nodeModel.Parent]
+		}
+		nodeModel.Position = PositionModel{X: nodeModel.Position.X + offset.X, Y: nodeModel.Position.Y + offset.Y}
+	}
+	for i := range model.Links {
+		linkModel := &model.Links[i]
+		linkModel.ID = newIDs[linkModel.ID]
+		for j, point := range linkModel.Points {
+			linkModel.Points[j] = PositionModel{X: point.X + offset.X, Y: point.Y + offset.Y}
+		}
+		for _, padReference := range []*PadReference{linkModel.Source, linkModel.Target} {
+			if padReference != nil {
+				padReference.ElementID = newIDs[padReference.ElementID]
+			}
+		}
+	}
+	return nil
+}
+
+// generateElementID returns the ID for the pasted copy of an element, using the GenerateElementIDCallback
+// if it is present. The assigned map contains the IDs that have already been assigned during this paste.
+func (dw *DiagramWidget) generateElementID(originalID string, assigned map[string]string) string {
+	if dw.GenerateElementIDCallback != nil {
+		return dw.GenerateElementIDCallback(originalID)
+	}
+	isUsed := func(id string) bool {
+		if dw.GetDiagramElement(id) != nil {
+			return true
+		}
+		for _, used := range assigned {
+			if used == id {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 1; ; i++ {
+		id := fmt.Sprintf("%s-%d", originalID, i)
+		if !isUsed(id) {
+			return id
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyPaste(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(300, 100))
+	node3 := NewDiagramNode(diagram, nil, "Node3")
+	node3.Move(fyne.NewPos(300, 300))
+	link1 := NewDiagramLink(diagram, "Link1")
+	link1.SetSourcePad(node1.GetEdgePad())
+	link1.SetTargetPad(node2.GetEdgePad())
+	link2 := NewDiagramLink(diagram, "Link2")
+	link2.SetSourcePad(node2.GetEdgePad())
+	link2.SetTargetPad(node3.GetEdgePad())
+	diagram.ClearUndoHistory()
+	diagram.SelectDiagramElement(node1)
+	diagram.ElementTappedExtendsSelection = true
+	diagram.DiagramElementTapped(node2)
+
+	assert.NoError(t, diagram.Copy())
+	assert.NoError(t, diagram.Paste())
+	copy1 := diagram.GetDiagramNode("Node1-1")
+	copy2 := diagram.GetDiagramNode("Node2-1")
+	assert.NotNil(t, copy1)
+	assert.NotNil(t, copy2)
+	assert.Equal(t, fyne.NewPos(120, 120), copy1.Position())
+	// Only the link between the copied nodes is copied
+	link := diagram.GetDiagramLink("Link1-1")
+	assert.NotNil(t, link)
+	assert.Nil(t, diagram.GetDiagramElement("Link2-1"))
+	assert.Equal(t, copy1, link.GetSourcePad().GetPadOwner())
+	assert.Equal(t, copy2, link.GetTargetPad().GetPadOwner())
+	assert.True(t, diagram.IsSelected(copy1))
+	assert.False(t, diagram.IsSelected(node1))
+	assert.Equal(t, "Paste", diagram.GetUndoName())
+
+	// A second paste of the same content is offset further
+	assert.NoError(t, diagram.Paste())
+	assert.Equal(t, fyne.NewPos(140, 140), diagram.GetDiagramNode("Node1-2").Position())
+
+	diagram.Undo()
+	assert.Nil(t, diagram.GetDiagramElement("Node1-2"))
+	assert.Nil(t, diagram.GetDiagramElement("Link1-2"))
+	diagram.Redo()
+	assert.NotNil(t, diagram.GetDiagramElement("Node1-2"))
+	assert.NotNil(t, diagram.GetDiagramElement("Link1-2"))
+	assert.Equal(t, 11, len(diagram.GetDiagramElements()))
+
+	// Paste into another diagram with application-generated IDs
+	other := NewDiagramWidget("Diagram2")
+	other.GenerateElementIDCallback = func(originalID string) string {
+		return "Copy of " + originalID
+	}
+	assert.NoError(t, other.Paste())
+	assert.Equal(t, 3, len(other.GetDiagramElements()))
+	assert.NotNil(t, other.GetDiagramNode("Copy of Node1"))
+	assert.NotNil(t, other.GetDiagramLink("Copy of Link1"))
+	assert.Error(t, other.Paste())
+}
+
+func TestCutAndPasteRejectsOtherContent(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(300, 100))
+	node3 := NewDiagramNode(diagram, nil, "Node3")
+	node3.Move(fyne.NewPos(300, 300))
+	link1 := NewDiagramLink(diagram, "Link1")
+	link1.SetSourcePad(node1.GetEdgePad())
+	link1.SetTargetPad(node2.GetEdgePad())
+	link2 := NewDiagramLink(diagram, "Link2")
+	link2.SetSourcePad(node2.GetEdgePad())
+	link2.SetTargetPad(node3.GetEdgePad())
+	diagram.ClearUndoHistory()
+	diagram.SelectDiagramElement(node2)
+
+	assert.NoError(t, diagram.Cut())
+	assert.Nil(t, diagram.GetDiagramElement("Node2"))
+	assert.Nil(t, diagram.GetDiagramElement("Link1"))
+	assert.Equal(t, "Cut", diagram.GetUndoName())
+	diagram.Undo()
+	assert.NotNil(t, diagram.GetDiagramElement("Node2"))
+	assert.NotNil(t, diagram.GetDiagramElement("Link1"))
+	assert.NotNil(t, diagram.GetDiagramElement("Link2"))
+
+	fyne.CurrentApp().Clipboard().SetContent("not a diagram")
+	assert.Error(t, diagram.Paste())
+}
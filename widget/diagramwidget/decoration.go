@@ -2,6 +2,7 @@ package diagramwidget
 
 import (
 	"image/color"
+	"math"
 
 	"fyne.io/fyne/v2"
 )
@@ -10,6 +11,15 @@ const (
 	defaultStrokeWidth float32 = 1
 )
 
+// getDecorationZoom returns the zoom of the diagram on which the decoration appears, or 1 if the decoration
+// is not (yet) part of a diagram. Decorations are defined at zoom 1.
+func getDecorationZoom(link *BaseDiagramLink) float64 {
+	if link == nil || link.diagram == nil {
+		return 1
+	}
+	return float64(link.diagram.zoom)
+}
+
 // Decoration is a widget intended to be used as a decoration on a Link widget
 // The graphical representation of the widget is defined along a reference axis with
 // one point on that axis designated as the reference point (generally the origin).
@@ -38,3 +48,103 @@ type Decoration interface {
 	// GetReferenceLength returns the length of the decoration along the reference axis
 	GetReferenceLength() float32
 }
+
+const (
+	// decorationLength is the length along the reference axis of the ready-made triangles, diamonds,
+	// circles and crow's feet at zoom 1
+	decorationLength float32 = 15
+	// decorationHalfWidth is half the width across the reference axis of the ready-made decorations
+	decorationHalfWidth float32 = 7
+	// barOffset is the distance of a bar from the reference point
+	barOffset float32 = 6
+	// circleSegments is the number of segments used to approximate a circle decoration
+	circleSegments = 24
+)
+
+// NewOpenTriangle creates a decoration consisting of two lines meeting at the reference point, as used
+// for navigable associations and dependencies in UML
+func NewOpenTriangle() *Polygon {
+	polygon := NewPolygon([]fyne.Position{
+		{X: decorationLength, Y: decorationHalfWidth},
+		{X: 0, Y: 0},
+		{X: decorationLength, Y: -decorationHalfWidth},
+	})
+	polygon.SetClosed(false)
+	return polygon
+}
+
+// NewClosedTriangle creates a hollow triangle with its tip at the reference point, as used for
+// generalization and realization in UML
+func NewClosedTriangle() *Polygon {
+	return NewPolygon([]fyne.Position{
+		{X: 0, Y: 0},
+		{X: decorationLength, Y: decorationHalfWidth},
+		{X: decorationLength, Y: -decorationHalfWidth},
+	})
+}
+
+// NewFilledTriangle creates a triangle with its tip at the reference point, filled with the stroke color
+func NewFilledTriangle() *Polygon {
+	polygon := NewClosedTriangle()
+	polygon.SetSolid(true)
+	return polygon
+}
+
+// NewDiamond creates a hollow diamond with one point at the reference point, as used for aggregation in UML
+func NewDiamond() *Polygon {
+	return NewPolygon([]fyne.Position{
+		{X: 0, Y: 0},
+		{X: decorationLength / 2, Y: decorationHalfWidth * 2 / 3},
+		{X: decorationLength, Y: 0},
+		{X: decorationLength / 2, Y: -decorationHalfWidth * 2 / 3},
+	})
+}
+
+// NewFilledDiamond creates a diamond with one point at the reference point, filled with the stroke color,
+// as used for composition in UML
+func NewFilledDiamond() *Polygon {
+	polygon := NewDiamond()
+	polygon.SetSolid(true)
+	return polygon
+}
+
+// NewCircle creates a hollow circle touching the reference point, as used for an optional ("zero")
+// cardinality in ER diagrams. The circle is approximated by a polygon.
+func NewCircle() *Polygon {
+	radius := decorationHalfWidth * 2 / 3
+	points := []fyne.Position{}
+	for i := 0; i < circleSegments; i++ {
+		angle := 2 * math.Pi * float64(i) / circleSegments
+		points = append(points, fyne.Position{
+			X: radius - radius*float32(math.Cos(angle)),
+			Y: radius * float32(math.Sin(angle)),
+		})
+	}
+	return NewPolygon(points)
+}
+
+// NewCrowsFoot creates three lines fanning out from a point on the reference axis to the reference point,
+// as used for a "many" cardinality in ER diagrams
+func NewCrowsFoot() *Polygon {
+	// The center line is traversed twice so that the three lines form a single polyline
+	polygon := NewPolygon([]fyne.Position{
+		{X: 0, Y: decorationHalfWidth},
+		{X: decorationLength, Y: 0},
+		{X: 0, Y: 0},
+		{X: decorationLength, Y: 0},
+		{X: 0, Y: -decorationHalfWidth},
+	})
+	polygon.SetClosed(false)
+	return polygon
+}
+
+// NewBar creates a line across the reference axis close to the reference point, as used for a "one"
+// cardinality in ER diagrams
+func NewBar() *Polygon {
+	polygon := NewPolygon([]fyne.Position{
+		{X: barOffset, Y: decorationHalfWidth},
+		{X: barOffset, Y: -decorationHalfWidth},
+	})
+	polygon.SetClosed(false)
+	return polygon
+}
@@ -0,0 +1,118 @@
+package diagramwidget
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadyMadeDecorations(t *testing.T) {
+	test.NewApp()
+	assert.False(t, NewOpenTriangle().closed)
+	assert.True(t, NewClosedTriangle().closed)
+	assert.False(t, NewClosedTriangle().solid)
+	assert.True(t, NewFilledTriangle().solid)
+	assert.False(t, NewDiamond().solid)
+	assert.True(t, NewFilledDiamond().solid)
+	assert.True(t, NewCircle().closed)
+	assert.False(t, NewCrowsFoot().closed)
+	assert.False(t, NewBar().closed)
+
+	// The decorations rotate about the reference point
+	triangle := NewClosedTriangle()
+	triangle.setBaseAngle(math.Pi / 2)
+	rotated := triangle.getRotatedPoints()
+	assert.InDelta(t, -decorationHalfWidth, rotated[1].X, 0.001)
+	assert.InDelta(t, -decorationLength, rotated[1].Y, 0.001)
+
+	// Stacked decorations follow one another along the link
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(200, 0))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	crowsFoot := NewCrowsFoot()
+	circle := NewCircle()
+	link.AddTargetDecoration(crowsFoot)
+	link.AddTargetDecoration(circle)
+	link.Refresh()
+	assert.Equal(t, link.getTargetPosition(), crowsFoot.Position())
+	assert.InDelta(t, crowsFoot.Position().X-crowsFoot.GetReferenceLength(), circle.Position().X, 0.001)
+	assert.InDelta(t, crowsFoot.Position().Y, circle.Position().Y, 0.001)
+}
+
+func TestLineStyles(t *testing.T) {
+	test.NewApp()
+	dashes := splitIntoDashes(fyne.NewPos(0, 0), fyne.NewPos(25, 0), LineStyleDashed.getDashPattern(1), 0)
+	assert.Equal(t, [][2]fyne.Position{
+		{fyne.NewPos(0, 0), fyne.NewPos(6, 0)},
+		{fyne.NewPos(10, 0), fyne.NewPos(16, 0)},
+		{fyne.NewPos(20, 0), fyne.NewPos(25, 0)},
+	}, dashes)
+	assert.Equal(t, 1, len(splitIntoDashes(fyne.NewPos(0, 0), fyne.NewPos(25, 0), LineStyleSolid.getDashPattern(1), 0)))
+
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(200, 0))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	renderer := test.TempWidgetRenderer(t, link.linkSegments[0])
+	assert.Equal(t, 1, len(renderer.Objects()))
+	link.SetLineStyle(LineStyleDotted)
+	assert.Less(t, 10, len(renderer.Objects()))
+
+	// The line style survives serialization and export
+	data, err := Marshal(diagram)
+	assert.NoError(t, err)
+	restored := NewDiagramWidget("Diagram2")
+	assert.NoError(t, Unmarshal(data, restored))
+	assert.Equal(t, LineStyleDotted, restored.GetDiagramLink("Link1").getBaseDiagramLink().GetLineStyle())
+	var buffer bytes.Buffer
+	assert.NoError(t, ExportSVG(restored, &buffer))
+	assert.True(t, strings.Contains(buffer.String(), "stroke-dasharray=\"1 3\""))
+}
+
+func TestDashPatternContinuesAcrossSegments(t *testing.T) {
+	test.NewApp()
+	// A line starting 8 into the pattern starts with the rest of the gap, and one starting 3 into it with the rest
+	// of the dash
+	pattern := LineStyleDashed.getDashPattern(1)
+	assert.Equal(t, fyne.NewPos(2, 0), splitIntoDashes(fyne.NewPos(0, 0), fyne.NewPos(25, 0), pattern, 8)[0][0])
+	assert.Equal(t, [2]fyne.Position{fyne.NewPos(0, 0), fyne.NewPos(3, 0)},
+		splitIntoDashes(fyne.NewPos(0, 0), fyne.NewPos(25, 0), pattern, 13)[0])
+
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(300, 200))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	link.SetRouter(NewSplineLinkRouter())
+	link.SetLineStyle(LineStyleDashed)
+
+	// The segments of the spline are shorter than a dash, but the dashes cover the same proportion of the path
+	// as of a straight line
+	pathLength, dashedLength := float32(0), float32(0)
+	for _, segment := range link.linkSegments {
+		assert.InDelta(t, pathLength, segment.dashOffset, 1e-3)
+		pathLength += float32(math.Hypot(float64(segment.p2.X-segment.p1.X), float64(segment.p2.Y-segment.p1.Y)))
+		for _, object := range test.TempWidgetRenderer(t, segment).Objects() {
+			dash := object.(*canvas.Line)
+			dashedLength += float32(math.Hypot(float64(dash.Position2.X-dash.Position1.X), float64(dash.Position2.Y-dash.Position1.Y)))
+		}
+	}
+	pattern = LineStyleDashed.getDashPattern(link.linkSegments[0].link.properties.StrokeWidth)
+	assert.Greater(t, len(link.linkSegments), 10)
+	assert.InDelta(t, pathLength*pattern[0]/(pattern[0]+pattern[1]), dashedLength, float64(pattern[0]))
+}
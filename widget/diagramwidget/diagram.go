@@ -6,6 +6,7 @@ import (
 	"math"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/theme"
@@ -14,6 +15,7 @@ import (
 
 // Verify that interfaces are fully implemented
 var _ fyne.Tappable = (*drawingArea)(nil)
+var _ fyne.Scrollable = (*drawingArea)(nil)
 
 type linkPadPair struct {
 	link *BaseDiagramLink
@@ -26,6 +28,8 @@ type linkPadPair struct {
 type DiagramWidget struct {
 	widget.BaseWidget
 	scrollingContainer *container.Scroll
+	// zoomContainer applies the zoom to the theme sizes used by the diagram elements
+	zoomContainer *container.ThemeOverride
 	// drawingArea is public only to support application-level testing scenarios in which simylated
 	// Drag, Mouse, and Tap events need to be sent to the diagram. It should not be otherwise accessed
 	drawingArea *drawingArea
@@ -72,10 +76,96 @@ type DiagramWidget struct {
 	OnTappedCallback func(*DiagramWidget, *fyne.PointEvent)
 	// PrimaryDiagramElementSelectionChangedCallback is called when the primary element selection changes
 	PrimaryDiagramElementSelectionChangedCallback func(string)
+	// MarqueeSelectionMode determines whether dragging a marquee over the diagram background selects the
+	// elements fully contained in the marquee or those intersecting it. Holding Shift while starting the
+	// marquee adds the elements to the existing selection.
+	MarqueeSelectionMode MarqueeSelectionMode
+	// marquee holds the state of the marquee selection in progress, if any
+	marquee *marquee
+	// BackgroundDragMovesElements restores the behavior of earlier versions, in which dragging the diagram
+	// background moves all of the elements instead of dragging out a marquee. Holding Alt while starting the
+	// drag does the same when it is false.
+	BackgroundDragMovesElements bool
+	// backgroundMoveInProgress is true while a background drag is moving all of the elements
+	backgroundMoveInProgress bool
 	// ElementTappedExtendsSelection determines the behavior when one or more elements are already selected and
 	// an element that is not currently selected is tapped. When true, the new element is added to the selection.
 	// When false, the selection is cleared and the new element is made the only selected element.
 	ElementTappedExtendsSelection bool
+	// UndoRedoStateChangedCallback is called when the availability of Undo or Redo changes, e.g. to
+	// enable or disable menu items
+	UndoRedoStateChangedCallback func(canUndo bool, canRedo bool)
+	// MaxUndoDepth is the maximum number of undo entries retained. Zero means there is no limit. Defaults to 100
+	MaxUndoDepth int
+	commands     commandStack
+	// nodeDragInProgress is true between the first Dragged event on a node and the DragEnd
+	nodeDragInProgress bool
+	// boundsShift is the unscaled displacement of all the elements by adjustBounds since the diagram was created
+	boundsShift fyne.Position
+	// groupCount is the number of groups in the diagram. The display order is arranged for groups only when it is
+	// not zero.
+	groupCount int
+	// PinDraggedNodes determines whether the nodes dragged by the user are pinned, so that force layouts
+	// leave them where they were dropped
+	PinDraggedNodes bool
+	// GenerateElementIDCallback is called to obtain the ID of each element created by Paste. It is passed
+	// the ID of the copied element and must return an ID that is unique across the diagram. If it is nil,
+	// the ID of the copied element with a numeric suffix is used.
+	GenerateElementIDCallback func(originalID string) string
+	// PasteOffset is the displacement (at zoom 1) of pasted elements from the copied elements. Defaults to 20, 20
+	PasteOffset fyne.Position
+	// lastPasteContent and pasteCount are used to offset consecutive pastes of the same content
+	lastPasteContent string
+	pasteCount       int
+	// GridVisible determines whether the background grid is displayed. Call Refresh after changing it.
+	GridVisible bool
+	// GridSpacing is the distance between grid lines at zoom 1. Defaults to 20
+	GridSpacing float32
+	// GridColor is the color of the grid lines
+	GridColor color.Color
+	// SnapToGrid determines whether node drags, handle resizes and link point drags snap to the grid
+	SnapToGrid bool
+	// AlignmentGuidesEnabled determines whether guides are displayed (and snapped to) when an edge or the
+	// center of a dragged node lines up with that of another node
+	AlignmentGuidesEnabled bool
+	// guides are the alignment guides currently displayed
+	guides []*canvas.Line
+	// dragResidual is the difference between the unsnapped and the snapped position of the dragged object
+	dragResidual fyne.Position
+	// ZoomChangedCallback is called when the zoom factor changes
+	ZoomChangedCallback func(float32)
+	zoom                float32
+	// unscaledGeometry holds the unscaled values of the geometry last scaled by the zoom (see rescale)
+	unscaledGeometry map[geometryKey]unscaledValue
+	// panInProgress is true while the diagram is being panned with the middle mouse button
+	panInProgress bool
+	// shiftPressed is true while a Shift key is held down with the diagram focused
+	shiftPressed bool
+	// minimaps are the Minimaps displaying an overview of the diagram
+	minimaps []*Minimap
+	// graphBinders are the GraphBinders keeping GraphModels synchronized with the diagram
+	graphBinders []*GraphBinder
+	// obstacleRefreshSuspended is non-zero while many nodes are moved at once, e.g. by a layout, so that the
+	// obstacle avoiding links are re-routed once rather than after each move
+	obstacleRefreshSuspended int
+	// PortTypesCompatibleCallback is called to determine whether a link can connect an output port of the first
+	// data type to an input port of the second one. If it is nil, the types must be equal or one of them empty.
+	PortTypesCompatibleCallback func(outputType string, inputType string) bool
+	// InvalidPortColor is used to highlight the ports that cannot accept the link end being connected
+	InvalidPortColor color.Color
+	// HighlightColor is the color of the outlines of the elements highlighted by a search. Call Refresh after
+	// changing it.
+	HighlightColor color.Color
+	// DimmedOpacity is the opacity, between 0 and 1, of the elements dimmed by a search. Defaults to 0.25
+	DimmedOpacity float32
+	// highlightedElements are the IDs of the elements highlighted by the last search
+	highlightedElements map[string]bool
+	// dimUnhighlightedElements is true when the elements that are not highlighted are dimmed
+	dimUnhighlightedElements bool
+	// searchResults are the elements highlighted by the last search, and searchIndex is the index of the
+	// result most recently scrolled to
+	searchResults []DiagramElement
+	searchIndex   int
 }
 
 // NewDiagramWidget creates a DiagramWidget. The user-supplied ID can be used to map the diagram
@@ -90,10 +180,19 @@ func NewDiagramWidget(id string) *DiagramWidget {
 		// Links:                          map[string]DiagramLink{},
 		selection:                      map[string]DiagramElement{},
 		diagramElementLinkDependencies: map[string][]linkPadPair{},
+		MaxUndoDepth:                   defaultMaxUndoDepth,
+		zoom:                           1,
+		PasteOffset:                    defaultPasteOffset,
+		GridSpacing:                    defaultGridSpacing,
+		DimmedOpacity:                  defaultDimmedOpacity,
+		highlightedElements:            map[string]bool{},
+		searchIndex:                    -1,
 	}
 	dw.drawingArea = newDrawingArea(dw)
 	dw.drawingArea.Resize(dw.DesiredSize)
-	dw.scrollingContainer = container.NewScroll(dw.drawingArea)
+	dw.zoomContainer = container.NewThemeOverride(dw.drawingArea, &zoomTheme{diagram: dw})
+	dw.scrollingContainer = container.NewScroll(dw.zoomContainer)
+	dw.scrollingContainer.OnScrolled = func(fyne.Position) { dw.refreshMinimapViewports() }
 	appTheme := fyne.CurrentApp().Settings().Theme()
 	appVariant := fyne.CurrentApp().Settings().ThemeVariant()
 	dw.DefaultDiagramElementProperties.ForegroundColor = appTheme.Color(theme.ColorNameForeground, appVariant)
@@ -106,6 +205,9 @@ func NewDiagramWidget(id string) *DiagramWidget {
 	dw.DefaultDiagramElementProperties.HandleStrokeWidth = 1
 	dw.DefaultDiagramElementProperties.PadStrokeWidth = 3
 	dw.DefaultDiagramElementProperties.PadColor = color.RGBA{121, 237, 119, 255}
+	dw.GridColor = appTheme.Color(theme.ColorNameSeparator, appVariant)
+	dw.InvalidPortColor = appTheme.Color(theme.ColorNameError, appVariant)
+	dw.HighlightColor = appTheme.Color(theme.ColorNamePrimary, appVariant)
 
 	dw.ExtendBaseWidget(dw)
 
@@ -114,9 +216,10 @@ func NewDiagramWidget(id string) *DiagramWidget {
 
 func (dw *DiagramWidget) addElementToSelection(de DiagramElement) {
 	if !dw.IsSelected(de) {
+		dw.removeGroupRelativesFromSelection(de)
 		if dw.primarySelection == nil {
 			dw.primarySelection = de
-			dw.BringToFront(de.GetDiagramElementID())
+			dw.bringToFront(de.GetDiagramElementID())
 			if dw.PrimaryDiagramElementSelectionChangedCallback != nil {
 				dw.PrimaryDiagramElementSelectionChangedCallback(de.GetDiagramElementID())
 			}
@@ -129,7 +232,9 @@ func (dw *DiagramWidget) addElementToSelection(de DiagramElement) {
 // addLink adds a link to the diagram
 func (dw *DiagramWidget) addLink(link DiagramLink) {
 	dw.DiagramElements.PushBack(link)
+	dw.applyZoomTheme(link)
 	link.Refresh()
+	dw.graphBindersElementChanged(link)
 }
 
 func (dw *DiagramWidget) addLinkDependency(diagramElement DiagramElement, link *BaseDiagramLink, pad ConnectionPad) {
@@ -151,8 +256,13 @@ func (dw *DiagramWidget) addLinkDependency(diagramElement DiagramElement, link *
 // addNode adds a node to the diagram
 func (dw *DiagramWidget) addNode(node DiagramNode) {
 	dw.DiagramElements.PushBack(node)
+	if _, ok := node.(DiagramGroup); ok {
+		dw.groupCount++
+	}
+	dw.applyZoomTheme(node)
 	dw.adjustBounds()
 	node.Refresh()
+	dw.graphBindersElementChanged(node)
 }
 
 // adjustBounds calculates the bounds of the diagram elements and adjusts the size of the drawing area accordingly
@@ -203,11 +313,22 @@ func (dw *DiagramWidget) adjustBounds() {
 	}
 	dw.DesiredSize = fyne.NewSize(right-left, bottom-top)
 	dw.drawingArea.Resize(dw.DesiredSize)
-	dw.scrollingContainer.Refresh()
+	// Only the grid and the scroll bars depend on the size of the drawing area. Refreshing the scrolling container
+	// would also refresh the zoom container, which applies the zoom theme to every element again.
+	dw.drawingArea.refreshGrid()
+	dw.scrollingContainer.Base.Refresh()
+	dw.refreshMinimapViewports()
 }
 
 // BringToFront moves the diagram element to the top of the display list (which is the back of the DiagramElements list)
 func (dw *DiagramWidget) BringToFront(elementID string) {
+	oldOrder := dw.GetDiagramElements()
+	dw.bringToFront(elementID)
+	dw.recordZOrderChange(oldOrder)
+}
+
+// bringToFront moves the diagram element to the top of the display list without recording the change
+func (dw *DiagramWidget) bringToFront(elementID string) {
 	for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
 		value := listElement.Value
 		diagramElement := value.(DiagramElement)
@@ -216,18 +337,22 @@ func (dw *DiagramWidget) BringToFront(elementID string) {
 			dw.drawingArea.Refresh()
 		}
 	}
+	dw.arrangeGroupOrder()
 }
 
 // BringForward moves the diagram element on top of the next element of the display list
 func (dw *DiagramWidget) BringForward(elementID string) {
+	oldOrder := dw.GetDiagramElements()
 	for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
 		value := listElement.Value
 		diagramElement := value.(DiagramElement)
-		if diagramElement.GetDiagramElementID() == elementID {
+		if diagramElement.GetDiagramElementID() == elementID && listElement.Next() != nil {
 			dw.DiagramElements.MoveAfter(listElement, listElement.Next())
 			dw.drawingArea.Refresh()
 		}
 	}
+	dw.arrangeGroupOrder()
+	dw.recordZOrderChange(oldOrder)
 }
 
 // CreateRenderer creates the renderer for the diagram
@@ -260,8 +385,10 @@ func (dw *DiagramWidget) Cursor() desktop.Cursor {
 	return desktop.DefaultCursor
 }
 
-// DiagramElementTapped adds the element to the selection when the element is tapped
+// DiagramElementTapped adds the element to the selection when the element is tapped. The diagram
+// receives the keyboard focus.
 func (dw *DiagramWidget) DiagramElementTapped(de DiagramElement) {
+	dw.requestFocus()
 	if !dw.ElementTappedExtendsSelection {
 		dw.ClearSelectionNoCallback()
 	}
@@ -273,14 +400,46 @@ func (dw *DiagramWidget) DiagramElementTapped(de DiagramElement) {
 // DiagramNodeDragged moves the indicated node and refreshes any links that may be attached
 // to it
 func (dw *DiagramWidget) DiagramNodeDragged(node *BaseDiagramNode, event *fyne.DragEvent) {
+	if dw.panInProgress {
+		dw.panBy(fyne.NewPos(event.Dragged.DX, event.Dragged.DY))
+		return
+	}
+	if !dw.nodeDragInProgress {
+		// The whole drag gesture is a single undo entry
+		dw.nodeDragInProgress = true
+		dw.dragResidual = fyne.NewPos(0, 0)
+		dw.StartUndoGroup("Move")
+	}
 	delta := fyne.Position{X: event.Dragged.DX, Y: event.Dragged.DY}
-	dw.DisplaceNode(node, delta)
+	dw.DisplaceNode(node, dw.snapNodeDrag(node, delta))
+	if dw.PinDraggedNodes {
+		node.typedNode.SetPinned(true)
+	}
+}
+
+// diagramNodeDragEnd completes the undo entry for a node drag gesture
+func (dw *DiagramWidget) diagramNodeDragEnd() {
+	dw.panInProgress = false
+	if dw.nodeDragInProgress {
+		dw.nodeDragInProgress = false
+		dw.clearAlignmentGuides()
+		dw.EndUndoGroup()
+	}
 }
 
 // DisplaceNode moves the indicated node, refreshes any links that may be attached
-// to it, and adjusts the bounds of the drawing area
+// to it, and adjusts the bounds of the drawing area. The children of a group move with it.
 func (dw *DiagramWidget) DisplaceNode(node DiagramNode, delta fyne.Position) {
+	dw.recordCommand("Move", newDisplaceNodeCommand(dw, node, delta))
 	node.Move(node.Position().Add(delta))
+	if group, ok := node.getBaseDiagramNode().typedNode.(DiagramGroup); ok {
+		// The displacement of the children is implied by that of the group, so it is not recorded
+		dw.suspendUndoRecording()
+		for _, child := range group.GetChildren() {
+			dw.DisplaceNode(child, delta)
+		}
+		dw.resumeUndoRecording()
+	}
 	dw.refreshDependentLinks(node)
 	dw.adjustBounds()
 }
@@ -304,6 +463,14 @@ func (dw *DiagramWidget) GetDiagramElement(elementID string) DiagramElement {
 	return nil
 }
 
+// getCurrentKeyModifiers returns the keyboard modifiers that are currently pressed
+func getCurrentKeyModifiers() fyne.KeyModifier {
+	if driver, ok := fyne.CurrentApp().Driver().(desktop.Driver); ok {
+		return driver.CurrentKeyModifiers()
+	}
+	return 0
+}
+
 // GetForegroundColor returns the foreground color from the diagram's theme, which may
 // be different from the application's theme
 func (dw *DiagramWidget) GetForegroundColor() color.Color {
@@ -378,7 +545,10 @@ func (dw *DiagramWidget) hideAllPads() {
 	for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
 		diagramElement := listElement.Value.(DiagramElement)
 		for _, pad := range diagramElement.GetConnectionPads() {
-			pad.Hide()
+			// Ports are always visible
+			if _, ok := pad.(*PortPad); !ok {
+				pad.Hide()
+			}
 		}
 	}
 }
@@ -390,11 +560,50 @@ func (dw *DiagramWidget) IsSelected(de DiagramElement) bool {
 
 // moveDiagramElements moves all of the diagram elements
 func (dw *DiagramWidget) moveDiagramElements(delta fyne.Position) {
+	dw.boundsShift = dw.boundsShift.Add(dw.unscalePosition(delta))
+	// The links are drawn relative to their positions, so they keep their shapes as they move with their nodes
 	for _, diagramElement := range dw.GetDiagramElements() {
-		diagramElement.Move(diagramElement.Position().Add(delta))
+		if node, ok := diagramElement.(DiagramNode); ok {
+			dw.placeNode(node, node.Position().Add(delta))
+		} else {
+			diagramElement.Move(diagramElement.Position().Add(delta))
+		}
 	}
 }
 
+// moveNodes moves the nodes to the positions as a batch. Unlike moving each node with Move, the nodes are not
+// refreshed, since only their positions change, and each link connected to them is refreshed once. The caller
+// adjusts the bounds of the diagram afterwards.
+func (dw *DiagramWidget) moveNodes(nodes []DiagramNode, positions []fyne.Position) {
+	dw.suspendObstacleRefresh()
+	links := []*BaseDiagramLink{}
+	linkMoved := map[*BaseDiagramLink]bool{}
+	for i, node := range nodes {
+		dw.placeNode(node, positions[i])
+		for _, pair := range dw.diagramElementLinkDependencies[node.GetDiagramElementID()] {
+			if !linkMoved[pair.link] {
+				linkMoved[pair.link] = true
+				links = append(links, pair.link)
+			}
+		}
+	}
+	for _, link := range links {
+		link.Refresh()
+	}
+	dw.resumeObstacleRefresh()
+}
+
+// placeNode moves the node like Move does, but without refreshing it or the links connected to it
+func (dw *DiagramWidget) placeNode(node DiagramNode, position fyne.Position) {
+	bdn := node.getBaseDiagramNode()
+	bdn.BaseWidget.Move(position)
+	if bdn.MovedCallback != nil {
+		bdn.MovedCallback()
+	}
+	bdn.refreshParentGroup()
+	dw.graphBindersElementChanged(node)
+}
+
 // removeDependenciesInvolvingLink re-creates the diagram's dependencies, omitting any
 // that involve the indicated link. This is a convoluted way of removing any entries
 // involving the link.
@@ -446,30 +655,93 @@ func (dw *DiagramWidget) refreshDependentLinks(de DiagramElement) {
 	for _, pair := range dependencies {
 		pair.link.Refresh()
 	}
+	if node, ok := de.(DiagramNode); ok {
+		// Links to the children of a collapsed group are displayed as connected to the group
+		if group, ok := node.getBaseDiagramNode().typedNode.(DiagramGroup); ok && group.IsCollapsed() {
+			for _, descendant := range group.getBaseDiagramGroup().getDescendants() {
+				for _, pair := range dw.diagramElementLinkDependencies[descendant.GetDiagramElementID()] {
+					pair.link.Refresh()
+				}
+			}
+		}
+	}
+}
+
+// refreshObstacleAvoidingLinks re-routes the links whose path depends on the positions of the nodes. When the
+// moved node is not nil, only the links whose route it may affect are re-routed.
+func (dw *DiagramWidget) refreshObstacleAvoidingLinks(moved DiagramNode) {
+	if dw.obstacleRefreshSuspended > 0 {
+		return
+	}
+	for _, link := range dw.GetDiagramLinks() {
+		bdl := link.getBaseDiagramLink()
+		if _, ok := bdl.router.(*OrthogonalLinkRouter); !ok {
+			continue
+		}
+		if moved != nil && !bdl.orthogonalRoute.mayBeAffectedBy(moved) {
+			continue
+		}
+		link.Refresh()
+	}
 }
 
-// RemoveElement removes the element from the diagram. It also removes any linkss to the element
+// RemoveElement removes the element from the diagram. It also removes any linkss to the element and,
+// if the element is a group, the nodes it contains
 func (dw *DiagramWidget) RemoveElement(elementID string) {
+	removed := dw.removeElement(elementID, nil)
+	if len(removed) > 0 {
+		dw.recordCommand("Delete", &removeElementsCommand{diagram: dw, removed: removed})
+	}
+	dw.drawingArea.Refresh()
+}
+
+// removeElement removes the element and any links to it, appending the removed elements to the
+// supplied slice in the order in which they were removed
+func (dw *DiagramWidget) removeElement(elementID string, removed []removedElement) []removedElement {
 	element := dw.GetDiagramElement(elementID)
 	if element == nil {
-		return
+		return removed
+	}
+	if group, ok := element.(DiagramGroup); ok {
+		for _, child := range group.GetChildren() {
+			removed = dw.removeElement(child.GetDiagramElementID(), removed)
+		}
 	}
 	// We make a copy of the dependencies because the array can get modified during the iteration
 	currentDependencies := append([]linkPadPair(nil), dw.diagramElementLinkDependencies[elementID]...)
 	for _, pair := range currentDependencies {
-		dw.RemoveElement(pair.link.id)
+		removed = dw.removeElement(pair.link.id, removed)
 	}
 	delete(dw.diagramElementLinkDependencies, elementID)
+	index := 0
 	for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
 		diagramElement := listElement.Value.(DiagramElement)
 		if diagramElement.GetDiagramElementID() == elementID {
 			dw.DiagramElements.Remove(listElement)
+			if _, ok := element.(DiagramGroup); ok {
+				dw.groupCount--
+			}
+			removedElement := removedElement{element: element, index: index}
+			if node, ok := element.(DiagramNode); ok {
+				removedElement.group = detachFromGroup(node)
+			}
+			removed = append(removed, removedElement)
+			break
 		}
+		index++
 	}
 	if element.IsLink() {
 		dw.removeDependenciesInvolvingLink(elementID)
 	}
-	dw.drawingArea.Refresh()
+	dw.minimapElementRemoved(element)
+	dw.graphBindersElementRemoved(element)
+	return removed
+}
+
+// resumeObstacleRefresh re-routes the obstacle avoiding links once they are no longer suspended
+func (dw *DiagramWidget) resumeObstacleRefresh() {
+	dw.obstacleRefreshSuspended--
+	dw.refreshObstacleAvoidingLinks(nil)
 }
 
 // SelectDiagramElement clears the selection, makes the indicated element the primary selection, and invokes
@@ -493,26 +765,36 @@ func (dw *DiagramWidget) SelectDiagramElementNoCallback(id string) {
 
 // SendToBack moves the diagram element to the top of the display list (which is the front of the DiagramElements list)
 func (dw *DiagramWidget) SendToBack(elementID string) {
+	oldOrder := dw.GetDiagramElements()
 	for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
 		value := listElement.Value
 		diagramElement := value.(DiagramElement)
 		if diagramElement.GetDiagramElementID() == elementID {
 			dw.DiagramElements.MoveToFront(listElement)
 			dw.drawingArea.Refresh()
+			break
 		}
 	}
+	dw.arrangeGroupOrder()
+	dw.recordZOrderChange(oldOrder)
 }
 
 // SendBackward moves the diagram element on top of the next element of the display list
 func (dw *DiagramWidget) SendBackward(elementID string) {
+	oldOrder := dw.GetDiagramElements()
 	for listElement := dw.DiagramElements.Front(); listElement != nil; listElement = listElement.Next() {
 		value := listElement.Value
 		diagramElement := value.(DiagramElement)
 		if diagramElement.GetDiagramElementID() == elementID {
-			dw.DiagramElements.MoveBefore(listElement, listElement.Prev())
-			dw.drawingArea.Refresh()
+			if listElement.Prev() != nil {
+				dw.DiagramElements.MoveBefore(listElement, listElement.Prev())
+				dw.drawingArea.Refresh()
+			}
+			break
 		}
 	}
+	dw.arrangeGroupOrder()
+	dw.recordZOrderChange(oldOrder)
 }
 
 // showAllPads is a work-around for fyne Issue #3906 in which a child's Hoverable interface
@@ -531,6 +813,12 @@ func (dw *DiagramWidget) showAllPads() {
 func (dw *DiagramWidget) StartNewLinkConnectionTransaction(link DiagramLink) {
 	dw.ConnectionTransaction = NewConnectionTransaction(link.getBaseDiagramLink().linkPoints[0], link, nil, fyne.NewPos(0, 0))
 	dw.showAllPads()
+	dw.highlightPorts()
+}
+
+// suspendObstacleRefresh stops the re-routing of obstacle avoiding links until resumeObstacleRefresh is called
+func (dw *DiagramWidget) suspendObstacleRefresh() {
+	dw.obstacleRefreshSuspended++
 }
 
 // diagramWidgetRenderer
@@ -543,6 +831,7 @@ func (r *diagramWidgetRenderer) Destroy() {
 
 func (r *diagramWidgetRenderer) Layout(size fyne.Size) {
 	r.diagramWidget.scrollingContainer.Resize(r.diagramWidget.Size())
+	r.diagramWidget.refreshMinimapViewports()
 }
 
 func (r *diagramWidgetRenderer) MinSize() fyne.Size {
@@ -564,6 +853,7 @@ func (r *diagramWidgetRenderer) Refresh() {
 type drawingArea struct {
 	widget.BaseWidget
 	diagram *DiagramWidget
+	grid    *canvas.Raster
 }
 
 func newDrawingArea(diagram *DiagramWidget) *drawingArea {
@@ -578,23 +868,55 @@ func newDrawingArea(diagram *DiagramWidget) *drawingArea {
 func (da *drawingArea) CreateRenderer() fyne.WidgetRenderer {
 	dar := &drawingAreaRenderer{}
 	dar.da = da
+	dar.grid = da.diagram.newGridRaster()
+	da.grid = dar.grid
 	return dar
 }
 
-// DragEnd is called when the drag comes to an end. It refreshes the widget
+// refreshGrid redraws the grid, if it is visible, after the drawing area has been resized
+func (da *drawingArea) refreshGrid() {
+	if da.grid != nil && da.diagram.GridVisible {
+		da.grid.Refresh()
+	}
+}
+
+// DragEnd is called when the drag comes to an end. It completes the marquee selection, if any, and refreshes the widget
 func (da *drawingArea) DragEnd() {
+	da.diagram.panInProgress = false
+	da.diagram.backgroundMoveInProgress = false
+	da.diagram.endMarquee()
 	da.Refresh()
 }
 
-// Dragged responds to a drag movement in the background of the diagram. It moves the widget itself.
+// Dragged responds to a drag movement in the background of the diagram. A middle button drag pans
+// the diagram. A drag started with Alt held, or any drag when BackgroundDragMovesElements is true, moves
+// all of the elements. Any other drag drags out a marquee that selects the elements within it.
 func (da *drawingArea) Dragged(event *fyne.DragEvent) {
 	delta := fyne.NewPos(event.Dragged.DX, event.Dragged.DY)
-	da.diagram.moveDiagramElements(delta)
-	da.diagram.adjustBounds()
+	if da.diagram.panInProgress {
+		da.diagram.panBy(delta)
+		return
+	}
+	if da.diagram.marquee == nil && !da.diagram.backgroundMoveInProgress &&
+		(da.diagram.BackgroundDragMovesElements || getCurrentKeyModifiers()&backgroundMoveModifier != 0) {
+		da.diagram.backgroundMoveInProgress = true
+	}
+	if da.diagram.backgroundMoveInProgress {
+		da.diagram.moveDiagramElements(delta)
+		da.diagram.adjustBounds()
+		return
+	}
+	if da.diagram.marquee == nil {
+		da.diagram.startMarquee(event.Position.Subtract(delta))
+	}
+	da.diagram.updateMarquee(event.Position)
 }
 
-// MouseDown responds to MouseDown events. It invokes the callback, if present
+// MouseDown responds to MouseDown events. A middle button press starts panning. It invokes the callback, if present
 func (da *drawingArea) MouseDown(event *desktop.MouseEvent) {
+	if event.Button == desktop.MouseButtonTertiary {
+		da.diagram.panInProgress = true
+	}
 	if da.diagram.MouseDownCallback != nil {
 		da.diagram.MouseDownCallback(event)
 	}
@@ -623,14 +945,33 @@ func (da *drawingArea) MouseOut() {
 
 // MouseUp responds to MouseUp events. It invokes the callback, if present
 func (da *drawingArea) MouseUp(event *desktop.MouseEvent) {
+	if event.Button == desktop.MouseButtonTertiary {
+		da.diagram.panInProgress = false
+	}
 	if da.diagram.MouseUpCallback != nil {
 		da.diagram.MouseUpCallback(event)
 	}
 }
 
+// Scrolled responds to mouse wheel events. With the shortcut modifier (e.g. Ctrl) pressed, it zooms the diagram
+// around the mouse position. Otherwise it scrolls the diagram.
+func (da *drawingArea) Scrolled(event *fyne.ScrollEvent) {
+	if !isZoomModifierPressed() {
+		da.diagram.scrollingContainer.Scrolled(event)
+		return
+	}
+	switch {
+	case event.Scrolled.DY > 0:
+		da.diagram.SetZoomAt(steppedZoom(da.diagram.zoom, 1), event.Position)
+	case event.Scrolled.DY < 0:
+		da.diagram.SetZoomAt(steppedZoom(da.diagram.zoom, -1), event.Position)
+	}
+}
+
 // Tapped  respondss to taps in the diagram background. It removes all diagram elements
-// from the selection
+// from the selection and gives the keyboard focus to the diagram
 func (da *drawingArea) Tapped(event *fyne.PointEvent) {
+	da.diagram.requestFocus()
 	if da.diagram.OnTappedCallback != nil {
 		da.diagram.OnTappedCallback(da.diagram, event)
 	} else {
@@ -639,15 +980,16 @@ func (da *drawingArea) Tapped(event *fyne.PointEvent) {
 }
 
 type drawingAreaRenderer struct {
-	da *drawingArea
+	da   *drawingArea
+	grid *canvas.Raster
 }
 
 func (dar *drawingAreaRenderer) Destroy() {
 
 }
 
-func (dar *drawingAreaRenderer) Layout(fyne.Size) {
-
+func (dar *drawingAreaRenderer) Layout(size fyne.Size) {
+	dar.grid.Resize(size)
 }
 
 func (dar *drawingAreaRenderer) MinSize() fyne.Size {
@@ -656,13 +998,26 @@ func (dar *drawingAreaRenderer) MinSize() fyne.Size {
 
 func (dar *drawingAreaRenderer) Objects() []fyne.CanvasObject {
 	obj := []fyne.CanvasObject{}
+	if dar.da.diagram.GridVisible {
+		obj = append(obj, dar.grid)
+	}
 	for _, n := range dar.da.diagram.GetDiagramElements() {
 		obj = append(obj, n)
 	}
+	for _, guide := range dar.da.diagram.guides {
+		obj = append(obj, guide)
+	}
+	if dar.da.diagram.marquee != nil {
+		obj = append(obj, dar.da.diagram.marquee.rectangle)
+	}
 	return obj
 }
 
 func (dar *drawingAreaRenderer) Refresh() {
+	if dar.da.diagram.GridVisible {
+		dar.grid.Resize(dar.da.Size())
+		dar.grid.Refresh()
+	}
 	for _, obj := range dar.da.diagram.GetDiagramElements() {
 		obj.Refresh()
 	}
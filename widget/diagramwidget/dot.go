@@ -0,0 +1,860 @@
+package diagramwidget
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ImportDOT reads a graph in the Graphviz DOT language and adds its nodes and edges to the diagram. Nodes
+// display their label in a Label inner object and take the shape closest to their DOT shape (ellipse by
+// default, as in Graphviz), with the color and fillcolor attributes as their foreground and background colors.
+// Edges become links with their label as a midpoint AnchoredText, arrows according to the graph type and
+// the dir, arrowhead and arrowtail attributes, and the line style given by the style attribute. In a strict
+// graph, repeated edges between the same nodes are merged into one. Subgraphs are flattened and ports are
+// ignored. The diagram's nodes are then arranged with a HierarchicalLayout in the
+// direction given by the graph's rankdir attribute. The IDs of the new nodes must not already be in use in the
+// diagram. Importing is not an edit that can be undone.
+func ImportDOT(dw *DiagramWidget, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	parser := &dotParser{lexer: newDOTLexer(string(data)), nodeAttributes: map[string]map[string]string{}}
+	if err := parser.parseGraph(); err != nil {
+		return err
+	}
+	return parser.toImportedGraph().addToDiagram(dw)
+}
+
+// ExportDOT writes the diagram's nodes and the links between them as a Graphviz DOT digraph. Nodes and links
+// are written in order of their IDs, and only the attributes that differ from the defaults are written, so
+// that the output of similar diagrams can be compared line by line. Node positions are not written. Groups,
+// and links that are not connected to two nodes, are omitted.
+func ExportDOT(dw *DiagramWidget, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph %s {\n", dotQuote(dw.ID))
+	nodes := dw.GetDiagramNodes()
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].GetDiagramElementID() < nodes[j].GetDiagramElementID()
+	})
+	for _, node := range nodes {
+		if _, ok := node.(DiagramGroup); ok {
+			continue
+		}
+		fmt.Fprintf(bw, "\t%s%s;\n", dotQuote(node.GetDiagramElementID()), dotAttributeList(dotNodeAttributes(dw, node)))
+	}
+	links := dw.GetDiagramLinks()
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].GetDiagramElementID() < links[j].GetDiagramElementID()
+	})
+	for _, link := range links {
+		sourceID, sourceOK := dotLinkEnd(dw, link.GetSourcePad())
+		targetID, targetOK := dotLinkEnd(dw, link.GetTargetPad())
+		if !sourceOK || !targetOK {
+			continue
+		}
+		fmt.Fprintf(bw, "\t%s -> %s%s;\n", dotQuote(sourceID), dotQuote(targetID),
+			dotAttributeList(dotLinkAttributes(dw, link, sourceID, targetID)))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotAttribute is a name-value pair written in an attribute list
+type dotAttribute struct {
+	name  string
+	value string
+}
+
+// dotAttributeList returns the attribute list in DOT syntax, or an empty string if there are no attributes
+func dotAttributeList(attributes []dotAttribute) string {
+	if len(attributes) == 0 {
+		return ""
+	}
+	parts := []string{}
+	for _, attribute := range attributes {
+		parts = append(parts, attribute.name+"="+dotQuote(attribute.value))
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
+
+func dotNodeAttributes(dw *DiagramWidget, node DiagramNode) []dotAttribute {
+	attributes := []dotAttribute{}
+	bdn := node.getBaseDiagramNode()
+	texts := []string{}
+	for _, shape := range appendTextShapes(nil, bdn.innerObject, bdn.Position(), nil, 0) {
+		texts = append(texts, shape.text)
+	}
+	if label := strings.Join(texts, "\n"); label != node.GetDiagramElementID() {
+		attributes = append(attributes, dotAttribute{"label", label})
+	}
+	styles := []string{}
+	switch node.GetShape() {
+	case RectangleShape:
+		attributes = append(attributes, dotAttribute{"shape", "box"})
+	case RoundedRectangleShape:
+		attributes = append(attributes, dotAttribute{"shape", "box"})
+		styles = append(styles, "rounded")
+	case EllipseShape:
+		attributes = append(attributes, dotAttribute{"shape", "ellipse"})
+	case DiamondShape:
+		attributes = append(attributes, dotAttribute{"shape", "diamond"})
+	case ParallelogramShape:
+		attributes = append(attributes, dotAttribute{"shape", "parallelogram"})
+	case CylinderShape:
+		attributes = append(attributes, dotAttribute{"shape", "cylinder"})
+	}
+	properties := node.GetProperties()
+	if !sameColor(properties.ForegroundColor, dw.DefaultDiagramElementProperties.ForegroundColor) {
+		attributes = append(attributes, dotAttribute{"color", dotColor(properties.ForegroundColor)})
+	}
+	if !sameColor(properties.BackgroundColor, dw.DefaultDiagramElementProperties.BackgroundColor) {
+		attributes = append(attributes, dotAttribute{"fillcolor", dotColor(properties.BackgroundColor)})
+		styles = append(styles, "filled")
+	}
+	if len(styles) > 0 {
+		attributes = append(attributes, dotAttribute{"style", strings.Join(styles, ",")})
+	}
+	return attributes
+}
+
+func dotLinkAttributes(dw *DiagramWidget, link DiagramLink, sourceID string, targetID string) []dotAttribute {
+	attributes := []dotAttribute{}
+	bdl := link.getBaseDiagramLink()
+	if link.GetDiagramElementID() != sourceID+"->"+targetID {
+		attributes = append(attributes, dotAttribute{"id", link.GetDiagramElementID()})
+	}
+	texts := []string{}
+	for _, key := range sortedKeys(bdl.midpointAnchoredText) {
+		if text, _ := bdl.midpointAnchoredText[key].GetDisplayedTextBinding().Get(); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) > 0 {
+		attributes = append(attributes, dotAttribute{"label", strings.Join(texts, "\n")})
+	}
+	switch bdl.lineStyle {
+	case LineStyleDashed:
+		attributes = append(attributes, dotAttribute{"style", "dashed"})
+	case LineStyleDotted:
+		attributes = append(attributes, dotAttribute{"style", "dotted"})
+	}
+	properties := link.GetProperties()
+	if !sameColor(properties.ForegroundColor, dw.DefaultDiagramElementProperties.ForegroundColor) {
+		attributes = append(attributes, dotAttribute{"color", dotColor(properties.ForegroundColor)})
+	}
+	if defaultWidth := dw.DefaultDiagramElementProperties.StrokeWidth; properties.StrokeWidth != defaultWidth && defaultWidth > 0 {
+		attributes = append(attributes, dotAttribute{"penwidth", strconv.FormatFloat(float64(properties.StrokeWidth/defaultWidth), 'g', -1, 32)})
+	}
+	sourceArrow, targetArrow := "", ""
+	if len(bdl.SourceDecorations) > 0 {
+		sourceArrow = arrowName(bdl.SourceDecorations[0])
+	}
+	if len(bdl.TargetDecorations) > 0 {
+		targetArrow = arrowName(bdl.TargetDecorations[0])
+	}
+	switch {
+	case sourceArrow == "" && targetArrow == "":
+		attributes = append(attributes, dotAttribute{"dir", "none"})
+	case sourceArrow == "":
+		// forward is the default direction of a digraph edge
+	case targetArrow == "":
+		attributes = append(attributes, dotAttribute{"dir", "back"})
+	default:
+		attributes = append(attributes, dotAttribute{"dir", "both"})
+	}
+	if targetArrow != "" && targetArrow != "normal" {
+		attributes = append(attributes, dotAttribute{"arrowhead", targetArrow})
+	}
+	if sourceArrow != "" && sourceArrow != "normal" {
+		attributes = append(attributes, dotAttribute{"arrowtail", sourceArrow})
+	}
+	return attributes
+}
+
+// dotLinkEnd returns the ID of the node to which a link end is connected, and false if it is not
+// connected to a node that is exported
+func dotLinkEnd(dw *DiagramWidget, pad ConnectionPad) (string, bool) {
+	if pad == nil {
+		return "", false
+	}
+	// The pad owner is the BaseDiagramNode, so the node is looked up to determine whether it is a group
+	node := dw.GetDiagramNode(pad.GetPadOwner().GetDiagramElementID())
+	if _, ok := node.(DiagramGroup); ok || node == nil {
+		return "", false
+	}
+	return node.GetDiagramElementID(), true
+}
+
+// dotQuote returns the string as a quoted DOT ID, escaping quotes, backslashes and newlines
+func dotQuote(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + replacer.Replace(s) + `"`
+}
+
+// dotColor returns the color in the #rrggbb form, or #rrggbbaa if it is not opaque
+func dotColor(c color.Color) string {
+	s := colorToString(c)
+	if strings.HasSuffix(s, "ff") {
+		return s[:7]
+	}
+	return s
+}
+
+// sameColor returns true if both colors are nil or have the same non-premultiplied components
+func sameColor(c1 color.Color, c2 color.Color) bool {
+	return colorToString(c1) == colorToString(c2)
+}
+
+type dotTokenKind int
+
+const (
+	dotEOF dotTokenKind = iota
+	// dotID is an identifier, numeral, quoted string or HTML string
+	dotID
+	// dotPunctuation is one of { } [ ] ; , = :
+	dotPunctuation
+	// dotEdgeOp is -> or --
+	dotEdgeOp
+)
+
+type dotToken struct {
+	kind dotTokenKind
+	text string
+	// quoted is true for quoted and HTML strings, which are never keywords
+	quoted bool
+	line   int
+}
+
+// dotLexer splits DOT text into tokens, skipping comments and preprocessor lines
+type dotLexer struct {
+	input []rune
+	pos   int
+	line  int
+}
+
+func newDOTLexer(input string) *dotLexer {
+	return &dotLexer{input: []rune(input), line: 1}
+}
+
+func (l *dotLexer) peekRune(offset int) rune {
+	if l.pos+offset >= len(l.input) {
+		return 0
+	}
+	return l.input[l.pos+offset]
+}
+
+func (l *dotLexer) advance() rune {
+	r := l.input[l.pos]
+	l.pos++
+	if r == '\n' {
+		l.line++
+	}
+	return r
+}
+
+// skipSpace skips white space and comments. Lines starting with # are preprocessor output and are ignored.
+func (l *dotLexer) skipSpace() {
+	atLineStart := l.pos == 0 || l.input[l.pos-1] == '\n'
+	for l.pos < len(l.input) {
+		r := l.peekRune(0)
+		switch {
+		case r == '\n':
+			l.advance()
+			atLineStart = true
+			continue
+		case unicode.IsSpace(r):
+			l.advance()
+			continue
+		case r == '#' && atLineStart, r == '/' && l.peekRune(1) == '/':
+			for l.pos < len(l.input) && l.peekRune(0) != '\n' {
+				l.advance()
+			}
+			continue
+		case r == '/' && l.peekRune(1) == '*':
+			l.advance()
+			l.advance()
+			for l.pos < len(l.input) && !(l.peekRune(0) == '*' && l.peekRune(1) == '/') {
+				l.advance()
+			}
+			if l.pos < len(l.input) {
+				l.advance()
+				l.advance()
+			}
+			continue
+		}
+		return
+	}
+}
+
+func (l *dotLexer) next() (dotToken, error) {
+	l.skipSpace()
+	token := dotToken{line: l.line}
+	if l.pos >= len(l.input) {
+		return token, nil
+	}
+	r := l.peekRune(0)
+	switch {
+	case strings.ContainsRune("{}[];,=:", r):
+		l.advance()
+		token.kind = dotPunctuation
+		token.text = string(r)
+	case r == '-' && (l.peekRune(1) == '>' || l.peekRune(1) == '-'):
+		token.kind = dotEdgeOp
+		token.text = string([]rune{l.advance(), l.advance()})
+	case r == '"':
+		l.advance()
+		text := []rune{}
+		for {
+			if l.pos >= len(l.input) {
+				return token, fmt.Errorf("line %d: unterminated string", token.line)
+			}
+			c := l.advance()
+			if c == '"' {
+				break
+			}
+			if c == '\\' && l.pos < len(l.input) {
+				next := l.advance()
+				switch next {
+				case '"':
+					text = append(text, '"')
+				case '\n':
+					// line continuation
+				default:
+					text = append(text, c, next)
+				}
+				continue
+			}
+			text = append(text, c)
+		}
+		token.kind = dotID
+		token.text = string(text)
+		token.quoted = true
+	case r == '<':
+		depth := 0
+		start := l.pos
+		for {
+			if l.pos >= len(l.input) {
+				return token, fmt.Errorf("line %d: unterminated HTML string", token.line)
+			}
+			c := l.advance()
+			if c == '<' {
+				depth++
+			} else if c == '>' {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+		token.kind = dotID
+		token.text = htmlLabelText(string(l.input[start+1 : l.pos-1]))
+		token.quoted = true
+	case r == '_' || r == '.' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		start := l.pos
+		l.advance()
+		for l.pos < len(l.input) {
+			c := l.peekRune(0)
+			if c != '_' && c != '.' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+				break
+			}
+			l.advance()
+		}
+		token.kind = dotID
+		token.text = string(l.input[start:l.pos])
+	default:
+		return token, fmt.Errorf("line %d: unexpected character %q", token.line, r)
+	}
+	return token, nil
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+var htmlBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>`)
+
+// htmlLabelText returns the text of an HTML label, with line breaks for <br> elements
+func htmlLabelText(html string) string {
+	text := htmlBreakPattern.ReplaceAllString(html, "\n")
+	text = htmlTagPattern.ReplaceAllString(text, "")
+	replacer := strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&amp;", "&")
+	return strings.TrimSpace(replacer.Replace(text))
+}
+
+// dotScope holds the default attributes set by node and edge statements within a graph or subgraph
+type dotScope struct {
+	nodeDefaults map[string]string
+	edgeDefaults map[string]string
+}
+
+func (s dotScope) copy() dotScope {
+	c := dotScope{nodeDefaults: map[string]string{}, edgeDefaults: map[string]string{}}
+	for name, value := range s.nodeDefaults {
+		c.nodeDefaults[name] = value
+	}
+	for name, value := range s.edgeDefaults {
+		c.edgeDefaults[name] = value
+	}
+	return c
+}
+
+// dotEdge is an edge statement between two nodes with its attributes
+type dotEdge struct {
+	source     string
+	target     string
+	attributes map[string]string
+}
+
+// dotParser is a recursive descent parser of the DOT grammar. It records the nodes, edges and graph
+// attributes, which are converted to an importedGraph once the whole graph has been parsed.
+type dotParser struct {
+	lexer    *dotLexer
+	token    dotToken
+	directed bool
+	// strict graphs have at most one edge between two nodes; the attributes of repeated edges are merged
+	strict         bool
+	graphRankdir   string
+	nodeIDs        []string
+	nodeAttributes map[string]map[string]string
+	edges          []dotEdge
+}
+
+func (p *dotParser) advance() error {
+	token, err := p.lexer.next()
+	p.token = token
+	return err
+}
+
+func (p *dotParser) isKeyword(keyword string) bool {
+	return p.token.kind == dotID && !p.token.quoted && strings.EqualFold(p.token.text, keyword)
+}
+
+func (p *dotParser) isPunctuation(punctuation string) bool {
+	return p.token.kind == dotPunctuation && p.token.text == punctuation
+}
+
+func (p *dotParser) expectPunctuation(punctuation string) error {
+	if !p.isPunctuation(punctuation) {
+		return p.errorf("expected %q", punctuation)
+	}
+	return p.advance()
+}
+
+func (p *dotParser) errorf(format string, args ...any) error {
+	found := p.token.text
+	if p.token.kind == dotEOF {
+		found = "end of input"
+	}
+	return fmt.Errorf("line %d: %s, found %q", p.token.line, fmt.Sprintf(format, args...), found)
+}
+
+// parseGraph parses: [strict] (graph | digraph) [ID] '{' stmt_list '}'
+func (p *dotParser) parseGraph() error {
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if p.isKeyword("strict") {
+		p.strict = true
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case p.isKeyword("digraph"):
+		p.directed = true
+	case p.isKeyword("graph"):
+	default:
+		return p.errorf("expected graph or digraph")
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if p.token.kind == dotID {
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+	if err := p.expectPunctuation("{"); err != nil {
+		return err
+	}
+	scope := dotScope{nodeDefaults: map[string]string{}, edgeDefaults: map[string]string{}}
+	if err := p.parseStatements(scope, nil); err != nil {
+		return err
+	}
+	if err := p.expectPunctuation("}"); err != nil {
+		return err
+	}
+	if p.token.kind != dotEOF {
+		return p.errorf("expected end of input")
+	}
+	return nil
+}
+
+// parseStatements parses the statements up to the closing brace, adding the IDs of the nodes they
+// mention to members
+func (p *dotParser) parseStatements(scope dotScope, members *[]string) error {
+	for p.token.kind != dotEOF && !p.isPunctuation("}") {
+		if err := p.parseStatement(scope, members); err != nil {
+			return err
+		}
+		if p.isPunctuation(";") {
+			if err := p.advance(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *dotParser) parseStatement(scope dotScope, members *[]string) error {
+	switch {
+	case p.isKeyword("graph"), p.isKeyword("node"), p.isKeyword("edge"):
+		keyword := strings.ToLower(p.token.text)
+		if err := p.advance(); err != nil {
+			return err
+		}
+		attributes, err := p.parseAttributeLists()
+		if err != nil {
+			return err
+		}
+		for name, value := range attributes {
+			switch keyword {
+			case "graph":
+				p.setGraphAttribute(name, value)
+			case "node":
+				scope.nodeDefaults[name] = value
+			case "edge":
+				scope.edgeDefaults[name] = value
+			}
+		}
+		return nil
+	case p.isKeyword("subgraph"), p.isPunctuation("{"):
+		operand, err := p.parseSubgraph(scope)
+		if err != nil {
+			return err
+		}
+		addMembers(members, operand)
+		return p.parseEdgeRHS(scope, members, operand)
+	case p.token.kind != dotID:
+		return p.errorf("expected a statement")
+	}
+	id := p.token.text
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if p.isPunctuation("=") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if p.token.kind != dotID {
+			return p.errorf("expected a value for %s", id)
+		}
+		p.setGraphAttribute(id, p.token.text)
+		return p.advance()
+	}
+	if err := p.skipPort(); err != nil {
+		return err
+	}
+	p.addNode(id, scope)
+	addMembers(members, []string{id})
+	if p.token.kind == dotEdgeOp {
+		return p.parseEdgeRHS(scope, members, []string{id})
+	}
+	attributes, err := p.parseAttributeLists()
+	if err != nil {
+		return err
+	}
+	for name, value := range attributes {
+		p.nodeAttributes[id][name] = value
+	}
+	return nil
+}
+
+// parseSubgraph parses: [subgraph [ID]] '{' stmt_list '}' and returns the IDs of the nodes it contains
+func (p *dotParser) parseSubgraph(scope dotScope) ([]string, error) {
+	if p.isKeyword("subgraph") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.token.kind == dotID {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := p.expectPunctuation("{"); err != nil {
+		return nil, err
+	}
+	members := []string{}
+	if err := p.parseStatements(scope.copy(), &members); err != nil {
+		return nil, err
+	}
+	return members, p.expectPunctuation("}")
+}
+
+// parseEdgeRHS parses: (edgeop (node_id | subgraph))* [attr_list], creating an edge from each node of an
+// operand to each node of the next operand
+func (p *dotParser) parseEdgeRHS(scope dotScope, members *[]string, first []string) error {
+	operands := [][]string{first}
+	for p.token.kind == dotEdgeOp {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		var operand []string
+		if p.isKeyword("subgraph") || p.isPunctuation("{") {
+			var err error
+			if operand, err = p.parseSubgraph(scope); err != nil {
+				return err
+			}
+		} else {
+			if p.token.kind != dotID {
+				return p.errorf("expected a node ID")
+			}
+			id := p.token.text
+			if err := p.advance(); err != nil {
+				return err
+			}
+			if err := p.skipPort(); err != nil {
+				return err
+			}
+			p.addNode(id, scope)
+			operand = []string{id}
+		}
+		addMembers(members, operand)
+		operands = append(operands, operand)
+	}
+	attributes, err := p.parseAttributeLists()
+	if err != nil {
+		return err
+	}
+	for i := 1; i < len(operands); i++ {
+		for _, source := range operands[i-1] {
+			for _, target := range operands[i] {
+				edgeAttributes := map[string]string{}
+				for name, value := range scope.edgeDefaults {
+					edgeAttributes[name] = value
+				}
+				for name, value := range attributes {
+					edgeAttributes[name] = value
+				}
+				p.addEdge(source, target, edgeAttributes)
+			}
+		}
+	}
+	return nil
+}
+
+// addEdge records an edge. In a strict graph, an edge between nodes that are already joined by an edge (in the
+// same direction, for a digraph) is merged into the existing edge, its attributes taking precedence.
+func (p *dotParser) addEdge(source string, target string, attributes map[string]string) {
+	if p.strict {
+		for _, edge := range p.edges {
+			sameEnds := edge.source == source && edge.target == target
+			if !p.directed && edge.source == target && edge.target == source {
+				sameEnds = true
+			}
+			if sameEnds {
+				for name, value := range attributes {
+					edge.attributes[name] = value
+				}
+				return
+			}
+		}
+	}
+	p.edges = append(p.edges, dotEdge{source: source, target: target, attributes: attributes})
+}
+
+// parseAttributeLists parses: ('[' [a_list] ']')*
+func (p *dotParser) parseAttributeLists() (map[string]string, error) {
+	attributes := map[string]string{}
+	for p.isPunctuation("[") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.isPunctuation("]") {
+			if p.token.kind != dotID {
+				return nil, p.errorf("expected an attribute name")
+			}
+			name := p.token.text
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			value := "true"
+			if p.isPunctuation("=") {
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+				if p.token.kind != dotID {
+					return nil, p.errorf("expected a value for %s", name)
+				}
+				value = p.token.text
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+			}
+			attributes[name] = value
+			if p.isPunctuation(",") || p.isPunctuation(";") {
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	return attributes, nil
+}
+
+// skipPort skips the optional port and compass point following a node ID
+func (p *dotParser) skipPort() error {
+	for p.isPunctuation(":") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if p.token.kind != dotID {
+			return p.errorf("expected a port")
+		}
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addNode records the node when it is first mentioned, with the node defaults in effect at that point
+func (p *dotParser) addNode(id string, scope dotScope) {
+	if _, ok := p.nodeAttributes[id]; ok {
+		return
+	}
+	attributes := map[string]string{}
+	for name, value := range scope.nodeDefaults {
+		attributes[name] = value
+	}
+	p.nodeIDs = append(p.nodeIDs, id)
+	p.nodeAttributes[id] = attributes
+}
+
+func (p *dotParser) setGraphAttribute(name string, value string) {
+	if name == "rankdir" {
+		p.graphRankdir = value
+	}
+}
+
+// addMembers appends the IDs to the members of the enclosing subgraph, if any
+func addMembers(members *[]string, ids []string) {
+	if members != nil {
+		*members = append(*members, ids...)
+	}
+}
+
+// toImportedGraph interprets the attributes of the parsed nodes and edges
+func (p *dotParser) toImportedGraph() *importedGraph {
+	graph := newImportedGraph()
+	if rankdir := strings.ToUpper(p.graphRankdir); rankdir == "LR" || rankdir == "RL" {
+		graph.direction = LayoutLeftRight
+	}
+	for _, id := range p.nodeIDs {
+		attributes := p.nodeAttributes[id]
+		node := graph.node(id)
+		if label, ok := attributes["label"]; ok {
+			node.label = dotLabelText(label, id)
+		}
+		styles := strings.Split(attributes["style"], ",")
+		node.shape = dotNodeShape(attributes["shape"], containsString(styles, "rounded"))
+		node.foregroundColor = parseImportedColor(attributes["color"])
+		if fillColor, ok := attributes["fillcolor"]; ok {
+			node.backgroundColor = parseImportedColor(fillColor)
+		} else if containsString(styles, "filled") {
+			node.backgroundColor = node.foregroundColor
+		}
+	}
+	for _, parsedEdge := range p.edges {
+		attributes := parsedEdge.attributes
+		edge := &importedEdge{
+			id:              attributes["id"],
+			sourceID:        parsedEdge.source,
+			targetID:        parsedEdge.target,
+			foregroundColor: parseImportedColor(attributes["color"]),
+		}
+		if label, ok := attributes["label"]; ok {
+			edge.label = dotLabelText(label, "")
+		}
+		styles := strings.Split(attributes["style"], ",")
+		switch {
+		case containsString(styles, "dashed"):
+			edge.lineStyle = LineStyleDashed
+		case containsString(styles, "dotted"):
+			edge.lineStyle = LineStyleDotted
+		}
+		if penWidth, err := strconv.ParseFloat(attributes["penwidth"], 32); err == nil && penWidth > 0 {
+			edge.strokeWidthScale = float32(penWidth)
+		} else if containsString(styles, "bold") {
+			edge.strokeWidthScale = 2
+		}
+		dir, ok := attributes["dir"]
+		if !ok {
+			dir = "none"
+			if p.directed {
+				dir = "forward"
+			}
+		}
+		if dir == "forward" || dir == "both" {
+			edge.targetArrow = dotArrow(attributes["arrowhead"])
+		}
+		if dir == "back" || dir == "both" {
+			edge.sourceArrow = dotArrow(attributes["arrowtail"])
+		}
+		graph.addEdge(edge)
+	}
+	return graph
+}
+
+// dotArrow returns the arrow shape, which is normal if it is not specified
+func dotArrow(arrow string) string {
+	if arrow == "" {
+		return "normal"
+	}
+	return arrow
+}
+
+// dotNodeShape returns the NodeShape closest to the DOT shape
+func dotNodeShape(shape string, rounded bool) NodeShape {
+	switch strings.ToLower(shape) {
+	case "box", "rect", "rectangle", "square", "record", "plaintext", "plain", "none", "underline", "note", "tab", "folder", "component":
+		if rounded {
+			return RoundedRectangleShape
+		}
+		return RectangleShape
+	case "mrecord":
+		return RoundedRectangleShape
+	case "diamond", "mdiamond":
+		return DiamondShape
+	case "parallelogram", "trapezium", "invtrapezium":
+		return ParallelogramShape
+	case "cylinder":
+		return CylinderShape
+	}
+	return EllipseShape
+}
+
+// dotLabelText interprets the escape sequences of a DOT label: \n, \l and \r end a line, \N is the node ID
+// and \\ is a backslash
+func dotLabelText(label string, nodeID string) string {
+	replacer := strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\l`, "\n", `\r`, "\n", `\N`, nodeID)
+	return strings.TrimRight(replacer.Replace(label), "\n")
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,456 @@
+package diagramwidget
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/software"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/math/fixed"
+)
+
+// exportMargin is the blank space around the diagram content in exported images
+const exportMargin float32 = 10
+
+type exportShapeKind int
+
+const (
+	exportRectangle exportShapeKind = iota
+	exportPolyline
+	exportPolygon
+	exportText
+)
+
+// exportShape is a display-independent description of one graphic primitive of the diagram.
+// All coordinates are diagram coordinates.
+type exportShape struct {
+	kind        exportShapeKind
+	points      []fyne.Position
+	size        fyne.Size
+	strokeColor color.Color
+	fillColor   color.Color
+	strokeWidth float32
+	lineStyle   LineStyle
+	text        string
+	textSize    float32
+}
+
+// exportableDecoration is implemented by decorations that can describe themselves for export.
+// The origin is the position of the decoration's reference point in diagram coordinates.
+type exportableDecoration interface {
+	getExportShapes(origin fyne.Position) []exportShape
+}
+
+// ExportSVG writes a standalone SVG document depicting the diagram's nodes, links, decorations and
+// anchored texts. Elements are written in z-order with their colors and stroke widths. Node inner
+// objects are represented by the text they contain.
+func ExportSVG(dw *DiagramWidget, w io.Writer) error {
+	shapes := dw.getExportShapes()
+	bounds := exportBounds(shapes)
+	origin := fyne.NewPos(bounds.Min.X-exportMargin, bounds.Min.Y-exportMargin)
+	width := bounds.Max.X - bounds.Min.X + 2*exportMargin
+	height := bounds.Max.Y - bounds.Min.Y + 2*exportMargin
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\">\n",
+		svgNumber(width), svgNumber(height), svgNumber(width), svgNumber(height))
+	fmt.Fprintf(bw, "<rect x=\"0\" y=\"0\" width=\"%s\" height=\"%s\" %s/>\n", svgNumber(width), svgNumber(height),
+		svgPaint("fill", dw.GetBackgroundColor()))
+	for _, shape := range shapes {
+		points := []string{}
+		for _, point := range shape.points {
+			points = append(points, svgNumber(point.X-origin.X)+","+svgNumber(point.Y-origin.Y))
+		}
+		switch shape.kind {
+		case exportRectangle:
+			fmt.Fprintf(bw, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" %s %s stroke-width=\"%s\"/>\n",
+				svgNumber(shape.points[0].X-origin.X), svgNumber(shape.points[0].Y-origin.Y),
+				svgNumber(shape.size.Width), svgNumber(shape.size.Height),
+				svgPaint("fill", shape.fillColor), svgPaint("stroke", shape.strokeColor), svgNumber(shape.strokeWidth))
+		case exportPolyline:
+			fmt.Fprintf(bw, "<polyline points=\"%s\" fill=\"none\" %s stroke-width=\"%s\"%s/>\n",
+				strings.Join(points, " "), svgPaint("stroke", shape.strokeColor), svgNumber(shape.strokeWidth),
+				svgDashArray(shape.lineStyle.getDashPattern(shape.strokeWidth)))
+		case exportPolygon:
+			fmt.Fprintf(bw, "<polygon points=\"%s\" %s %s stroke-width=\"%s\"/>\n",
+				strings.Join(points, " "), svgPaint("fill", shape.fillColor), svgPaint("stroke", shape.strokeColor),
+				svgNumber(shape.strokeWidth))
+		case exportText:
+			fmt.Fprintf(bw, "<text x=\"%s\" y=\"%s\" font-family=\"sans-serif\" font-size=\"%s\" %s>",
+				svgNumber(shape.points[0].X-origin.X), svgNumber(shape.points[0].Y-origin.Y+shape.textSize),
+				svgNumber(shape.textSize), svgPaint("fill", shape.fillColor))
+			if err := xml.EscapeText(bw, []byte(shape.text)); err != nil {
+				return err
+			}
+			fmt.Fprintf(bw, "</text>\n")
+		}
+	}
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+// ExportPNG renders the diagram at the indicated scale and writes it as a PNG image
+func ExportPNG(dw *DiagramWidget, w io.Writer, scale float32) error {
+	return png.Encode(w, RenderImage(dw, scale))
+}
+
+// RenderImage renders the diagram at the indicated scale using the software renderer, so no display
+// is required. The content is the same as that produced by ExportSVG.
+func RenderImage(dw *DiagramWidget, scale float32) image.Image {
+	if scale <= 0 {
+		scale = 1
+	}
+	shapes := dw.getExportShapes()
+	bounds := exportBounds(shapes)
+	origin := fyne.NewPos(bounds.Min.X-exportMargin, bounds.Min.Y-exportMargin)
+	size := fyne.NewSize(bounds.Max.X-bounds.Min.X+2*exportMargin, bounds.Max.Y-bounds.Min.Y+2*exportMargin)
+
+	background := canvas.NewRectangle(dw.GetBackgroundColor())
+	background.Resize(size)
+	objects := []fyne.CanvasObject{background}
+	for _, shape := range shapes {
+		objects = append(objects, shape.canvasObjects(origin, scale)...)
+	}
+	content := container.NewWithoutLayout(objects...)
+
+	c := software.NewTransparentCanvas()
+	c.SetPadded(false)
+	c.SetScale(scale)
+	c.SetContent(content)
+	c.Resize(size)
+	return c.Capture()
+}
+
+// getExportShapes returns the shapes depicting the diagram, in z-order. The shapes are unscaled, so the
+// export does not depend on the current zoom.
+func (dw *DiagramWidget) getExportShapes() []exportShape {
+	shapes := []exportShape{}
+	for _, element := range dw.GetDiagramElements() {
+		if !element.Visible() {
+			continue
+		}
+		switch {
+		case element.IsNode():
+			shapes = append(shapes, element.(DiagramNode).getBaseDiagramNode().getExportShapes()...)
+		case element.IsLink():
+			shapes = append(shapes, element.(DiagramLink).getBaseDiagramLink().getExportShapes()...)
+		}
+	}
+	for i := range shapes {
+		for j, point := range shapes[i].points {
+			shapes[i].points[j] = dw.unscalePosition(point)
+		}
+		shapes[i].size = dw.unscaleSize(shapes[i].size)
+		shapes[i].strokeWidth /= dw.zoom
+	}
+	return shapes
+}
+
+func (bdn *BaseDiagramNode) getExportShapes() []exportShape {
+	shapes := []exportShape{{
+		kind:        exportRectangle,
+		points:      []fyne.Position{bdn.Position()},
+		size:        bdn.Size(),
+		strokeColor: bdn.properties.ForegroundColor,
+		fillColor:   bdn.properties.BackgroundColor,
+		strokeWidth: bdn.diagram.scaled(bdn.properties.StrokeWidth),
+	}}
+	if bdn.shape != RectangleShape {
+		// The outline and details of other shapes are exported as a polygon and polylines
+		outline, details := shapeOutline(bdn.shape, bdn.Size(), bdn.diagram.scaled(roundedCornerRadius))
+		for _, polyline := range append([][]fyne.Position{outline}, details...) {
+			for i, point := range polyline {
+				polyline[i] = bdn.Position().Add(point)
+			}
+		}
+		shapes[0].kind = exportPolygon
+		shapes[0].points = outline
+		for _, detail := range details {
+			shapes = append(shapes, exportShape{
+				kind:        exportPolyline,
+				points:      detail,
+				strokeColor: bdn.properties.ForegroundColor,
+				strokeWidth: shapes[0].strokeWidth,
+			})
+		}
+	}
+	if bdn.innerObject != nil {
+		shapes = appendTextShapes(shapes, bdn.innerObject, bdn.Position(), bdn.properties.ForegroundColor, bdn.properties.TextSize)
+	}
+	return shapes
+}
+
+func (bdl *BaseDiagramLink) getExportShapes() []exportShape {
+	linkPosition := bdl.Position()
+	points := []fyne.Position{}
+	for _, point := range bdl.getRoutePoints() {
+		points = append(points, linkPosition.Add(point))
+	}
+	shapes := []exportShape{{
+		kind:        exportPolyline,
+		points:      points,
+		strokeColor: bdl.properties.ForegroundColor,
+		strokeWidth: bdl.diagram.scaled(bdl.properties.StrokeWidth),
+		lineStyle:   bdl.lineStyle,
+	}}
+	for _, decorations := range [][]Decoration{bdl.SourceDecorations, bdl.MidpointDecorations, bdl.TargetDecorations} {
+		for _, decoration := range decorations {
+			if exportable, ok := decoration.(exportableDecoration); ok {
+				shapes = append(shapes, exportable.getExportShapes(linkPosition.Add(decoration.Position()))...)
+			}
+		}
+	}
+	textOffset := fyne.NewPos(5+theme.InnerPadding(), 5+theme.InnerPadding())
+	for _, anchoredTexts := range []map[string]*AnchoredText{bdl.sourceAnchoredText, bdl.midpointAnchoredText, bdl.targetAnchoredText} {
+		for _, key := range sortedKeys(anchoredTexts) {
+			at := anchoredTexts[key]
+			text, _ := at.displayedTextBinding.Get()
+			if text == "" || !at.Visible() {
+				continue
+			}
+			shapes = append(shapes, exportShape{
+				kind:      exportText,
+				points:    []fyne.Position{linkPosition.Add(at.Position()).Add(textOffset)},
+				fillColor: at.ForegroundColor,
+				text:      text,
+				textSize:  bdl.properties.TextSize,
+			})
+		}
+	}
+	return shapes
+}
+
+func (a *Arrowhead) getExportShapes(origin fyne.Position) []exportShape {
+	return []exportShape{{
+		kind:        exportPolyline,
+		points:      []fyne.Position{origin.Add(a.LeftPoint()), origin, origin.Add(a.RightPoint())},
+		strokeColor: a.StrokeColor,
+		strokeWidth: a.StrokeWidth,
+	}}
+}
+
+func (p *Polygon) getExportShapes(origin fyne.Position) []exportShape {
+	points := []fyne.Position{}
+	for _, point := range p.getRotatedPoints() {
+		points = append(points, origin.Add(point))
+	}
+	if !p.closed {
+		return []exportShape{{kind: exportPolyline, points: points, strokeColor: p.StrokeColor, strokeWidth: p.StrokeWidth}}
+	}
+	fillColor := p.FillColor
+	if p.solid {
+		fillColor = p.StrokeColor
+	}
+	return []exportShape{{kind: exportPolygon, points: points, strokeColor: p.StrokeColor, fillColor: fillColor, strokeWidth: p.StrokeWidth}}
+}
+
+// appendTextShapes adds text shapes for the text displayed by the object and its children
+func appendTextShapes(shapes []exportShape, obj fyne.CanvasObject, parentPosition fyne.Position, c color.Color, textSize float32) []exportShape {
+	if obj == nil || !obj.Visible() {
+		return shapes
+	}
+	position := parentPosition.Add(obj.Position())
+	text := ""
+	inset := theme.InnerPadding()
+	switch o := obj.(type) {
+	case *fyne.Container:
+		for _, child := range o.Objects {
+			shapes = appendTextShapes(shapes, child, position, c, textSize)
+		}
+		return shapes
+	case *canvas.Text:
+		text = o.Text
+		inset = 0
+		if o.TextSize > 0 {
+			textSize = o.TextSize
+		}
+	case *widget.Label:
+		text = o.Text
+	case *widget.Button:
+		text = o.Text
+	case *widget.Entry:
+		text = o.Text
+	case *widget.Hyperlink:
+		text = o.Text
+	case *widget.Check:
+		text = o.Text
+	}
+	if text == "" {
+		return shapes
+	}
+	return append(shapes, exportShape{
+		kind:      exportText,
+		points:    []fyne.Position{position.AddXY(inset, inset)},
+		fillColor: c,
+		text:      text,
+		textSize:  textSize,
+	})
+}
+
+// canvasObjects returns canvas objects for the shape, positioned relative to the origin. Polygons
+// are rasterized at the indicated scale since there is no polygon canvas primitive.
+func (s *exportShape) canvasObjects(origin fyne.Position, scale float32) []fyne.CanvasObject {
+	switch s.kind {
+	case exportRectangle:
+		rect := canvas.NewRectangle(s.fillColor)
+		rect.StrokeColor = s.strokeColor
+		rect.StrokeWidth = s.strokeWidth
+		rect.Move(s.points[0].Subtract(origin))
+		rect.Resize(s.size)
+		return []fyne.CanvasObject{rect}
+	case exportPolyline:
+		lines := []fyne.CanvasObject{}
+		pathLength := float32(0)
+		for i := 0; i < len(s.points)-1; i++ {
+			for _, dash := range splitIntoDashes(s.points[i], s.points[i+1], s.lineStyle.getDashPattern(s.strokeWidth), pathLength) {
+				line := canvas.NewLine(s.strokeColor)
+				line.StrokeWidth = s.strokeWidth
+				line.Position1 = dash[0].Subtract(origin)
+				line.Position2 = dash[1].Subtract(origin)
+				lines = append(lines, line)
+			}
+			pathLength += float32(math.Hypot(float64(s.points[i+1].X-s.points[i].X), float64(s.points[i+1].Y-s.points[i].Y)))
+		}
+		return lines
+	case exportPolygon:
+		return []fyne.CanvasObject{s.rasterizePolygon(origin, scale)}
+	case exportText:
+		text := canvas.NewText(s.text, s.fillColor)
+		text.TextSize = s.textSize
+		text.Move(s.points[0].Subtract(origin))
+		text.Resize(text.MinSize())
+		return []fyne.CanvasObject{text}
+	}
+	return nil
+}
+
+func (s *exportShape) rasterizePolygon(origin fyne.Position, scale float32) fyne.CanvasObject {
+	bounds := exportBounds([]exportShape{*s})
+	topLeft := bounds.Min.AddXY(-s.strokeWidth, -s.strokeWidth)
+	size := fyne.NewSize(bounds.Max.X-bounds.Min.X+2*s.strokeWidth, bounds.Max.Y-bounds.Min.Y+2*s.strokeWidth)
+	width := int(math.Ceil(float64(size.Width * scale)))
+	height := int(math.Ceil(float64(size.Height * scale)))
+	raw := image.NewRGBA(image.Rect(0, 0, width, height))
+	scanner := rasterx.NewScannerGV(width, height, raw, raw.Bounds())
+	toFixed := func(point fyne.Position) fixed.Point26_6 {
+		return rasterx.ToFixedP(float64((point.X-topLeft.X)*scale), float64((point.Y-topLeft.Y)*scale))
+	}
+	if s.fillColor != nil {
+		filler := rasterx.NewFiller(width, height, scanner)
+		filler.SetColor(s.fillColor)
+		for i, point := range s.points {
+			if i == 0 {
+				filler.Start(toFixed(point))
+			} else {
+				filler.Line(toFixed(point))
+			}
+		}
+		filler.Stop(true)
+		filler.Draw()
+	}
+	if s.strokeColor != nil && s.strokeWidth > 0 {
+		dasher := rasterx.NewDasher(width, height, scanner)
+		dasher.SetColor(s.strokeColor)
+		dasher.SetStroke(fixed.Int26_6(float64(s.strokeWidth*scale)*64), 0, nil, nil, nil, 0, nil, 0)
+		for i, point := range s.points {
+			if i == 0 {
+				dasher.Start(toFixed(point))
+			} else {
+				dasher.Line(toFixed(point))
+			}
+		}
+		dasher.Stop(true)
+		dasher.Draw()
+	}
+	img := canvas.NewImageFromImage(raw)
+	img.ScaleMode = canvas.ImageScaleSmooth
+	img.Move(topLeft.Subtract(origin))
+	img.Resize(size)
+	return img
+}
+
+// exportBounds returns the bounding rectangle of the shapes in diagram coordinates. Text extents
+// are estimated from the number of characters.
+type exportRect struct {
+	Min, Max fyne.Position
+}
+
+func exportBounds(shapes []exportShape) exportRect {
+	bounds := exportRect{}
+	first := true
+	include := func(p fyne.Position) {
+		if first {
+			bounds.Min, bounds.Max = p, p
+			first = false
+			return
+		}
+		bounds.Min = fyne.NewPos(float32(math.Min(float64(bounds.Min.X), float64(p.X))), float32(math.Min(float64(bounds.Min.Y), float64(p.Y))))
+		bounds.Max = fyne.NewPos(float32(math.Max(float64(bounds.Max.X), float64(p.X))), float32(math.Max(float64(bounds.Max.Y), float64(p.Y))))
+	}
+	for _, shape := range shapes {
+		switch shape.kind {
+		case exportRectangle:
+			include(shape.points[0])
+			include(shape.points[0].AddXY(shape.size.Width, shape.size.Height))
+		case exportText:
+			include(shape.points[0])
+			include(shape.points[0].AddXY(float32(len([]rune(shape.text)))*shape.textSize*0.6, shape.textSize*1.2))
+		default:
+			for _, point := range shape.points {
+				include(point)
+			}
+		}
+	}
+	return bounds
+}
+
+// svgDashArray returns the stroke-dasharray attribute for the dash pattern, or an empty string for solid lines
+func svgDashArray(pattern []float32) string {
+	if len(pattern) == 0 {
+		return ""
+	}
+	lengths := []string{}
+	for _, length := range pattern {
+		lengths = append(lengths, svgNumber(length))
+	}
+	return " stroke-dasharray=\"" + strings.Join(lengths, " ") + "\""
+}
+
+// svgNumber formats a coordinate with at most two decimal places
+func svgNumber(f float32) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// svgPaint returns the SVG attributes for painting with the color. A nil or fully transparent color is "none".
+func svgPaint(attribute string, c color.Color) string {
+	if c == nil {
+		return attribute + "=\"none\""
+	}
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nrgba.A == 0 {
+		return attribute + "=\"none\""
+	}
+	paint := fmt.Sprintf("%s=\"#%02x%02x%02x\"", attribute, nrgba.R, nrgba.G, nrgba.B)
+	if nrgba.A != 255 {
+		paint += fmt.Sprintf(" %s-opacity=\"%s\"", attribute, svgNumber(float32(nrgba.A)/255))
+	}
+	return paint
+}
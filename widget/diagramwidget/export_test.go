@@ -0,0 +1,61 @@
+package diagramwidget
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportSVG(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, widget.NewLabel("Node <1>"), "Node1")
+	node1.Move(fyne.NewPos(50, 50))
+	node2 := NewDiagramNode(diagram, widget.NewLabel("Node 2"), "Node2")
+	node2.Move(fyne.NewPos(250, 150))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	link.AddTargetDecoration(NewArrowhead())
+	link.AddSourceDecoration(NewPolygon([]fyne.Position{{X: 0, Y: 0}, {X: 8, Y: 4}, {X: 16, Y: 0}, {X: 8, Y: -4}}))
+	link.AddMidpointAnchoredText("name", "Link 1")
+	var buffer bytes.Buffer
+	assert.NoError(t, ExportSVG(diagram, &buffer))
+	svg := buffer.String()
+	assert.True(t, strings.HasPrefix(svg, "<?xml"))
+	assert.Equal(t, 3, strings.Count(svg, "<rect"))
+	assert.Equal(t, 2, strings.Count(svg, "<polyline"))
+	assert.Equal(t, 1, strings.Count(svg, "<polygon"))
+	assert.Contains(t, svg, ">Node &lt;1&gt;</text>")
+	assert.Contains(t, svg, ">Link 1</text>")
+	// Nodes are written before the link because the link is in front of them
+	assert.Less(t, strings.Index(svg, "Node 2"), strings.Index(svg, "<polyline"))
+}
+
+func TestExportPNG(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, widget.NewLabel("Node <1>"), "Node1")
+	node1.Move(fyne.NewPos(50, 50))
+	node2 := NewDiagramNode(diagram, widget.NewLabel("Node 2"), "Node2")
+	node2.Move(fyne.NewPos(250, 150))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	link.AddTargetDecoration(NewArrowhead())
+	link.AddSourceDecoration(NewPolygon([]fyne.Position{{X: 0, Y: 0}, {X: 8, Y: 4}, {X: 16, Y: 0}, {X: 8, Y: -4}}))
+	link.AddMidpointAnchoredText("name", "Link 1")
+	var buffer bytes.Buffer
+	assert.NoError(t, ExportPNG(diagram, &buffer, 2))
+	img, err := png.Decode(&buffer)
+	assert.NoError(t, err)
+	bounds := exportBounds(diagram.getExportShapes())
+	expectedWidth := int((bounds.Max.X - bounds.Min.X + 2*exportMargin) * 2)
+	assert.InDelta(t, expectedWidth, img.Bounds().Dx(), 2)
+}
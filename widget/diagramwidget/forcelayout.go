@@ -0,0 +1,571 @@
+package diagramwidget
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// forceLayoutProgressInterval is the minimum time between the progress reports of a background layout
+var forceLayoutProgressInterval = 50 * time.Millisecond
+
+// ForceLayoutOptions control the behavior of a ForceLayout. Distances are specified at zoom 1.
+type ForceLayoutOptions struct {
+	// LinkLength is the preferred distance between the centers of linked nodes
+	LinkLength float64
+	// Theta is the Barnes-Hut approximation threshold: a group of distant nodes is treated as a single body
+	// when the size of the region containing it divided by its distance is less than Theta. Zero computes the
+	// repulsion between every pair of nodes exactly.
+	Theta float64
+	// Gravity pulls the nodes toward their centroid so that the disconnected parts of the graph stay together
+	Gravity float64
+	// InitialTemperature is the greatest distance that a node can move in the first step
+	InitialTemperature float64
+	// Cooling is the factor (between 0 and 1) by which the temperature is reduced after a step that does not
+	// reduce the energy of the layout sufficiently
+	Cooling float64
+	// Tolerance determines convergence: the layout stops when no node moves further than Tolerance times
+	// the LinkLength in a step
+	Tolerance float64
+	// MaxIterations is the maximum number of steps taken by Run and RunInBackground
+	MaxIterations int
+}
+
+// NewForceLayoutOptions returns the default options for a ForceLayout
+func NewForceLayoutOptions() ForceLayoutOptions {
+	return ForceLayoutOptions{
+		LinkLength:         150,
+		Theta:              0.9,
+		Gravity:            0.05,
+		InitialTemperature: 150,
+		Cooling:            0.9,
+		Tolerance:          0.01,
+		MaxIterations:      1000,
+	}
+}
+
+// ForceLayoutProgress reports the state of a ForceLayout
+type ForceLayoutProgress struct {
+	// Iteration is the number of steps taken so far
+	Iteration int
+	// Energy is the sum of the squared forces on the nodes in the last step
+	Energy float64
+	// Temperature is the greatest distance that a node can move in the next step
+	Temperature float64
+	// Converged is true once no node moved further than the tolerance in a step
+	Converged bool
+	// Done is true in the last report, once the layout has converged, reached the maximum number of
+	// iterations or been stopped
+	Done bool
+}
+
+// ForceLayout arranges the diagram's nodes by simulating forces: linked nodes attract each other like springs,
+// all nodes repel each other and a weak gravity holds the graph together (Fruchterman-Reingold). The repulsion
+// is approximated with a Barnes-Hut quadtree so that a step takes O(n log n) time rather than O(n²). The
+// temperature limiting the movement of the nodes cools as the layout proceeds, and is adapted to the change in
+// the energy of the layout, until the nodes stop moving. Pinned nodes (see SetPinned and the diagram's
+// PinDraggedNodes) are not moved, but still act on the others. Groups are not moved by the layout.
+//
+// The nodes and links are those in the diagram when the ForceLayout is created. A ForceLayout is used either
+// step by step, with Step or Run, or in the background with RunInBackground, but not both at once.
+type ForceLayout struct {
+	diagram *DiagramWidget
+	options ForceLayoutOptions
+	nodes   []DiagramNode
+	// edges are pairs of indices into nodes
+	edges [][2]int
+	// positions are the node centers at zoom 1, and pinned marks the nodes that are not moved
+	positions   []fyne.Position
+	pinned      []bool
+	temperature float64
+	energy      float64
+	// progressCount counts the consecutive steps that have reduced the energy
+	progressCount int
+	// progress is written by the goroutine running the layout while holding the lock
+	progress ForceLayoutProgress
+	// The remaining fields are used by RunInBackground. The lock protects progress, stopRequested and
+	// externalMoves.
+	lock          sync.Mutex
+	running       bool
+	stopRequested bool
+	// externalMoves are the nodes that have been moved or pinned outside of the layout while it runs in the
+	// background, with their new centers
+	externalMoves map[int]fyne.Position
+	// startPositions and appliedPositions are the unscaled node positions when the background run started
+	// and after the layout last moved them. They are relative to the diagram's bounds when the run started
+	// (startBoundsShift), since the diagram may shift all of its elements while the layout runs.
+	startPositions   []fyne.Position
+	appliedPositions []fyne.Position
+	startBoundsShift fyne.Position
+	done             chan struct{}
+}
+
+// NewForceLayout creates a ForceLayout for the diagram's nodes and the links connecting them
+func NewForceLayout(dw *DiagramWidget, options ForceLayoutOptions) *ForceLayout {
+	fl := &ForceLayout{
+		diagram:     dw,
+		options:     options,
+		temperature: options.InitialTemperature,
+	}
+	index := map[string]int{}
+	for _, node := range dw.GetDiagramNodes() {
+		if _, ok := node.(DiagramGroup); ok {
+			continue
+		}
+		index[node.GetDiagramElementID()] = len(fl.nodes)
+		fl.nodes = append(fl.nodes, node)
+	}
+	for _, link := range dw.GetDiagramLinks() {
+		sourcePad := link.GetSourcePad()
+		targetPad := link.GetTargetPad()
+		if sourcePad == nil || targetPad == nil {
+			continue
+		}
+		source, sourceOK := index[sourcePad.GetPadOwner().GetDiagramElementID()]
+		target, targetOK := index[targetPad.GetPadOwner().GetDiagramElementID()]
+		if sourceOK && targetOK && source != target {
+			fl.edges = append(fl.edges, [2]int{source, target})
+		}
+	}
+	fl.positions = make([]fyne.Position, len(fl.nodes))
+	fl.pinned = make([]bool, len(fl.nodes))
+	fl.readDiagram()
+	return fl
+}
+
+// GetProgress returns the state of the layout after the last step. It may be called while the layout runs
+// in the background.
+func (fl *ForceLayout) GetProgress() ForceLayoutProgress {
+	fl.lock.Lock()
+	defer fl.lock.Unlock()
+	return fl.progress
+}
+
+// Run takes steps until the layout converges or the maximum number of iterations is reached, and then moves
+// the nodes to their final positions as a single undo entry. The layout starts again from the initial
+// temperature, so a layout can be run again after the nodes have been moved. It returns the final progress.
+func (fl *ForceLayout) Run() ForceLayoutProgress {
+	fl.lock.Lock()
+	fl.restart()
+	fl.lock.Unlock()
+	fl.readDiagram()
+	for !fl.progress.Converged && fl.progress.Iteration < fl.options.MaxIterations {
+		fl.step()
+	}
+	fl.setDone()
+	fl.applyPositions()
+	return fl.GetProgress()
+}
+
+// RunInBackground runs the layout on a separate goroutine and returns immediately. The nodes are moved
+// on the main goroutine each time that progress is reported, and the callback, if not nil, is called after
+// each move; the last report has Done set. Nodes that are pinned or moved (e.g. dragged) while the layout
+// runs are held where they are. The whole layout is a single undo entry. As with Run, the layout starts again
+// from the initial temperature. Calling RunInBackground while the layout is already running has no effect.
+func (fl *ForceLayout) RunInBackground(progressCallback func(ForceLayoutProgress)) {
+	fl.lock.Lock()
+	if fl.running {
+		fl.lock.Unlock()
+		return
+	}
+	fl.running = true
+	fl.restart()
+	fl.stopRequested = false
+	fl.externalMoves = map[int]fyne.Position{}
+	fl.done = make(chan struct{})
+	fl.lock.Unlock()
+	fl.readDiagram()
+	fl.startPositions = make([]fyne.Position, len(fl.nodes))
+	for i, node := range fl.nodes {
+		fl.startPositions[i] = fl.diagram.unscalePosition(node.Position())
+	}
+	fl.appliedPositions = append([]fyne.Position(nil), fl.startPositions...)
+	fl.startBoundsShift = fl.diagram.boundsShift
+	go fl.runInBackground(progressCallback)
+}
+
+// Stop requests a layout running in the background to stop. The nodes are left where the layout last moved
+// them, and the final progress is reported.
+func (fl *ForceLayout) Stop() {
+	fl.lock.Lock()
+	defer fl.lock.Unlock()
+	fl.stopRequested = true
+}
+
+// Step takes a single step of the layout and moves the nodes accordingly, as a single undo entry. The
+// positions of the nodes are read from the diagram first, so they may have been moved since the previous
+// step. It returns true if the layout has converged.
+func (fl *ForceLayout) Step() bool {
+	fl.readDiagram()
+	fl.step()
+	fl.applyPositions()
+	return fl.progress.Converged
+}
+
+// Wait blocks until a layout running in the background has finished, i.e. after the last progress report
+// has been queued. It returns immediately if the layout is not running.
+func (fl *ForceLayout) Wait() {
+	fl.lock.Lock()
+	done := fl.done
+	fl.lock.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// applyPositions moves the nodes to the computed positions, as a batch and as a single undo entry
+func (fl *ForceLayout) applyPositions() {
+	dw := fl.diagram
+	dw.StartUndoGroup("Layout")
+	moved := []DiagramNode{}
+	targets := []fyne.Position{}
+	for i, node := range fl.nodes {
+		if fl.pinned[i] {
+			continue
+		}
+		target := dw.scalePosition(fl.topLeft(i))
+		if delta := target.Subtract(node.Position()); !delta.IsZero() {
+			dw.recordCommand("Layout", newDisplaceNodeCommand(dw, node, delta))
+			moved = append(moved, node)
+			targets = append(targets, target)
+		}
+	}
+	dw.moveNodes(moved, targets)
+	dw.EndUndoGroup()
+	dw.adjustBounds()
+}
+
+// applyBackgroundProgress is called on the main goroutine to move the nodes to the positions computed in
+// the background. Nodes that have been moved or pinned since the last update are reported to the layout
+// and left in place. When the layout is done, the movement of the nodes is recorded for undo.
+func (fl *ForceLayout) applyBackgroundProgress(positions []fyne.Position, progress ForceLayoutProgress, progressCallback func(ForceLayoutProgress)) {
+	dw := fl.diagram
+	dw.suspendUndoRecording()
+	inDiagram := map[DiagramNode]bool{}
+	for _, node := range dw.GetDiagramNodes() {
+		inDiagram[node] = true
+	}
+	fl.lock.Lock()
+	// The background positions are relative to the bounds of the diagram when the run started
+	offset := dw.boundsShift.Subtract(fl.startBoundsShift)
+	laidOut := []DiagramNode{}
+	targets := []fyne.Position{}
+	for i, node := range fl.nodes {
+		if !inDiagram[node] {
+			// The node has been removed from the diagram
+			continue
+		}
+		current := dw.unscalePosition(node.Position()).Subtract(offset)
+		_, moved := fl.externalMoves[i]
+		if changed := !samePosition(current, fl.appliedPositions[i]); moved || changed || node.IsPinned() {
+			if changed {
+				// Only the layout's own displacement of the node is recorded for undo
+				fl.startPositions[i] = fl.startPositions[i].Add(current.Subtract(fl.appliedPositions[i]))
+			}
+			fl.externalMoves[i] = fl.center(node, current)
+			fl.appliedPositions[i] = current
+			continue
+		}
+		target := positions[i].Subtract(fl.halfSize(node))
+		if !samePosition(target, current) {
+			laidOut = append(laidOut, node)
+			targets = append(targets, dw.scalePosition(target.Add(offset)))
+		}
+		fl.appliedPositions[i] = target
+	}
+	fl.lock.Unlock()
+	dw.moveNodes(laidOut, targets)
+	dw.resumeUndoRecording()
+	dw.adjustBounds()
+	if progress.Done {
+		dw.StartUndoGroup("Layout")
+		for i, node := range fl.nodes {
+			if delta := fl.appliedPositions[i].Subtract(fl.startPositions[i]); !samePosition(delta, fyne.Position{}) {
+				dw.recordCommand("Layout", newDisplaceNodeCommand(dw, node, dw.scalePosition(delta)))
+			}
+		}
+		dw.EndUndoGroup()
+	}
+	if progressCallback != nil {
+		progressCallback(progress)
+	}
+}
+
+// center returns the center at zoom 1 of the node whose top left corner at zoom 1 is the position
+func (fl *ForceLayout) center(node DiagramNode, position fyne.Position) fyne.Position {
+	return position.Add(fl.halfSize(node))
+}
+
+// halfSize returns half of the size of the node at zoom 1
+func (fl *ForceLayout) halfSize(node DiagramNode) fyne.Position {
+	size := fl.diagram.unscaleSize(node.Size())
+	return fyne.NewPos(size.Width/2, size.Height/2)
+}
+
+// readDiagram reads the positions and the pinned state of the nodes from the diagram
+func (fl *ForceLayout) readDiagram() {
+	for i, node := range fl.nodes {
+		fl.positions[i] = fl.center(node, fl.diagram.unscalePosition(node.Position()))
+		fl.pinned[i] = node.IsPinned()
+	}
+}
+
+// runInBackground takes steps until the layout is done, reporting progress on the main goroutine
+func (fl *ForceLayout) runInBackground(progressCallback func(ForceLayoutProgress)) {
+	lastReport := time.Now()
+	for {
+		fl.lock.Lock()
+		stop := fl.stopRequested
+		for i, position := range fl.externalMoves {
+			fl.positions[i] = position
+			fl.pinned[i] = true
+		}
+		fl.lock.Unlock()
+		if !stop && !fl.progress.Converged && fl.progress.Iteration < fl.options.MaxIterations {
+			fl.step()
+		} else {
+			fl.setDone()
+		}
+		if fl.progress.Done || time.Since(lastReport) >= forceLayoutProgressInterval {
+			lastReport = time.Now()
+			positions := append([]fyne.Position(nil), fl.positions...)
+			progress := fl.progress
+			fyne.Do(func() {
+				fl.applyBackgroundProgress(positions, progress, progressCallback)
+			})
+		}
+		if fl.progress.Done {
+			break
+		}
+	}
+	fl.lock.Lock()
+	fl.running = false
+	close(fl.done)
+	fl.done = nil
+	fl.lock.Unlock()
+}
+
+// step computes one step of the layout, updating the positions, the temperature and the progress
+func (fl *ForceLayout) step() {
+	count := len(fl.nodes)
+	forces := make([]fyne.Position, count)
+	if count == 0 {
+		fl.lock.Lock()
+		fl.progress.Converged = true
+		fl.lock.Unlock()
+		return
+	}
+	k := fl.options.LinkLength
+	// Repulsion between all the nodes, approximated with a quadtree
+	tree := newQuadTree(fl.positions)
+	var centroid fyne.Position
+	for i, position := range fl.positions {
+		fx, fy := tree.repulsion(i, position, k*k, fl.options.Theta)
+		forces[i] = fyne.NewPos(float32(fx), float32(fy))
+		centroid = centroid.Add(position)
+	}
+	centroid = fyne.NewPos(centroid.X/float32(count), centroid.Y/float32(count))
+	// Attraction along the links
+	for _, edge := range fl.edges {
+		dx := float64(fl.positions[edge[1]].X - fl.positions[edge[0]].X)
+		dy := float64(fl.positions[edge[1]].Y - fl.positions[edge[0]].Y)
+		distance := math.Hypot(dx, dy)
+		if distance == 0 {
+			continue
+		}
+		// The magnitude is distance² / k, in the direction of the link
+		f := fyne.NewPos(float32(dx*distance/k), float32(dy*distance/k))
+		forces[edge[0]] = forces[edge[0]].Add(f)
+		forces[edge[1]] = forces[edge[1]].Subtract(f)
+	}
+	// Gravity toward the centroid, and the displacement limited by the temperature
+	energy := 0.0
+	maxDisplacement := 0.0
+	for i := range fl.positions {
+		if fl.pinned[i] {
+			continue
+		}
+		pull := centroid.Subtract(fl.positions[i])
+		gravity := float32(fl.options.Gravity)
+		force := forces[i].Add(fyne.NewPos(pull.X*gravity, pull.Y*gravity))
+		magnitude := math.Hypot(float64(force.X), float64(force.Y))
+		energy += magnitude * magnitude
+		if magnitude == 0 {
+			continue
+		}
+		displacement := math.Min(magnitude, fl.temperature)
+		factor := float32(displacement / magnitude)
+		fl.positions[i] = fl.positions[i].Add(fyne.NewPos(force.X*factor, force.Y*factor))
+		maxDisplacement = math.Max(maxDisplacement, displacement)
+	}
+	fl.updateTemperature(energy)
+	fl.lock.Lock()
+	fl.progress.Iteration++
+	fl.progress.Energy = energy
+	fl.progress.Temperature = fl.temperature
+	fl.progress.Converged = maxDisplacement < fl.options.Tolerance*k
+	fl.lock.Unlock()
+}
+
+// restart resets the progress and the temperature so that the layout starts again. The caller holds the lock.
+func (fl *ForceLayout) restart() {
+	fl.progress = ForceLayoutProgress{}
+	fl.temperature = fl.options.InitialTemperature
+	fl.energy = 0
+	fl.progressCount = 0
+}
+
+// setDone marks the progress as the last one of a run
+func (fl *ForceLayout) setDone() {
+	fl.lock.Lock()
+	defer fl.lock.Unlock()
+	fl.progress.Done = true
+}
+
+// topLeft returns the position at zoom 1 of the top left corner of the node at the computed center
+func (fl *ForceLayout) topLeft(i int) fyne.Position {
+	return fl.positions[i].Subtract(fl.halfSize(fl.nodes[i]))
+}
+
+// updateTemperature adapts the temperature to the change in energy (Hu's adaptive cooling): the layout is
+// cooled when the energy does not decrease, and heated again after several steps in which it decreases.
+func (fl *ForceLayout) updateTemperature(energy float64) {
+	if fl.progress.Iteration > 0 && energy < fl.energy {
+		fl.progressCount++
+		if fl.progressCount >= 5 {
+			fl.progressCount = 0
+			fl.temperature = math.Min(fl.temperature/fl.options.Cooling, fl.options.InitialTemperature)
+		}
+	} else {
+		fl.progressCount = 0
+		fl.temperature *= fl.options.Cooling
+	}
+	fl.energy = energy
+}
+
+// IsPinned returns true if force layouts leave the node in place
+func (bdn *BaseDiagramNode) IsPinned() bool {
+	return bdn.pinned
+}
+
+// SetPinned determines whether force layouts leave the node in place
+func (bdn *BaseDiagramNode) SetPinned(pinned bool) {
+	bdn.pinned = pinned
+}
+
+// samePosition returns true if the positions differ by less than half a unit in each direction
+func samePosition(p1 fyne.Position, p2 fyne.Position) bool {
+	return math.Abs(float64(p1.X-p2.X)) < 0.5 && math.Abs(float64(p1.Y-p2.Y)) < 0.5
+}
+
+// quadTreeMaxDepth bounds the depth of the quadtree so that coincident nodes share a leaf
+const quadTreeMaxDepth = 32
+
+// quadTree is a node of the Barnes-Hut quadtree. Each node covers a square region and records the number
+// of bodies within it and their center of mass. A leaf holds the indices of its bodies.
+type quadTree struct {
+	x, y, size   float64
+	mass         float64
+	massX, massY float64
+	bodies       []int
+	children     *[4]quadTree
+}
+
+// newQuadTree builds the quadtree of the positions
+func newQuadTree(positions []fyne.Position) *quadTree {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range positions {
+		minX = math.Min(minX, float64(p.X))
+		minY = math.Min(minY, float64(p.Y))
+		maxX = math.Max(maxX, float64(p.X))
+		maxY = math.Max(maxY, float64(p.Y))
+	}
+	tree := &quadTree{x: minX, y: minY, size: math.Max(math.Max(maxX-minX, maxY-minY), 1)}
+	for i, p := range positions {
+		tree.insert(i, float64(p.X), float64(p.Y), positions, 0)
+	}
+	return tree
+}
+
+func (qt *quadTree) insert(body int, x, y float64, positions []fyne.Position, depth int) {
+	qt.massX = (qt.massX*qt.mass + x) / (qt.mass + 1)
+	qt.massY = (qt.massY*qt.mass + y) / (qt.mass + 1)
+	qt.mass++
+	if qt.children == nil {
+		qt.bodies = append(qt.bodies, body)
+		if len(qt.bodies) == 1 || depth >= quadTreeMaxDepth {
+			return
+		}
+		// Split the leaf and move its bodies to the children
+		half := qt.size / 2
+		qt.children = &[4]quadTree{
+			{x: qt.x, y: qt.y, size: half},
+			{x: qt.x + half, y: qt.y, size: half},
+			{x: qt.x, y: qt.y + half, size: half},
+			{x: qt.x + half, y: qt.y + half, size: half},
+		}
+		bodies := qt.bodies
+		qt.bodies = nil
+		for _, b := range bodies {
+			p := positions[b]
+			qt.child(float64(p.X), float64(p.Y)).insert(b, float64(p.X), float64(p.Y), positions, depth+1)
+		}
+		return
+	}
+	qt.child(x, y).insert(body, x, y, positions, depth+1)
+}
+
+// child returns the quadrant containing the point
+func (qt *quadTree) child(x, y float64) *quadTree {
+	index := 0
+	if x >= qt.x+qt.size/2 {
+		index++
+	}
+	if y >= qt.y+qt.size/2 {
+		index += 2
+	}
+	return &qt.children[index]
+}
+
+// repulsion returns the repulsive force k²/d exerted on the body at the position by the other bodies,
+// treating distant regions as single bodies at their centers of mass
+func (qt *quadTree) repulsion(body int, position fyne.Position, k2 float64, theta float64) (float64, float64) {
+	if qt.mass == 0 {
+		return 0, 0
+	}
+	x, y := float64(position.X), float64(position.Y)
+	if qt.children == nil {
+		fx, fy := 0.0, 0.0
+		for _, other := range qt.bodies {
+			if other == body {
+				continue
+			}
+			dx, dy := x-qt.massX, y-qt.massY
+			if dx == 0 && dy == 0 {
+				// Coincident bodies are pushed apart in a direction depending on their indices
+				angle := float64(body-other) * 2.399963
+				dx, dy = math.Cos(angle)*0.01, math.Sin(angle)*0.01
+			}
+			d2 := dx*dx + dy*dy
+			fx += dx * k2 / d2
+			fy += dy * k2 / d2
+		}
+		return fx, fy
+	}
+	dx, dy := x-qt.massX, y-qt.massY
+	d2 := dx*dx + dy*dy
+	if d2 > 0 && qt.size*qt.size < theta*theta*d2 {
+		// The region is far enough away to be treated as a single body
+		return dx * k2 * qt.mass / d2, dy * k2 * qt.mass / d2
+	}
+	fx, fy := 0.0, 0.0
+	for i := range qt.children {
+		cfx, cfy := qt.children[i].repulsion(body, position, k2, theta)
+		fx += cfx
+		fy += cfy
+	}
+	return fx, fy
+}
@@ -0,0 +1,247 @@
+package diagramwidget
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForceLayoutRun(t *testing.T) {
+	test.NewApp()
+	// A ring of nodes spread along a diagonal line
+	diagram := NewDiagramWidget("Diagram1")
+	nodes := []DiagramNode{}
+	for i := 0; i < 8; i++ {
+		node := NewDiagramNode(diagram, nil, fmt.Sprintf("Node%d", i))
+		node.Move(fyne.NewPos(float32(500+10*i), float32(500+7*i)))
+		nodes = append(nodes, node)
+	}
+	for i := range nodes {
+		link := NewDiagramLink(diagram, fmt.Sprintf("Link%d", i))
+		link.SetSourcePad(nodes[i].GetEdgePad())
+		link.SetTargetPad(nodes[(i+1)%len(nodes)].GetEdgePad())
+	}
+	diagram.ClearUndoHistory()
+	nodes[0].SetPinned(true)
+	progress := NewForceLayout(diagram, NewForceLayoutOptions()).Run()
+	assert.True(t, progress.Converged)
+	assert.True(t, progress.Done)
+	assert.Less(t, progress.Iteration, NewForceLayoutOptions().MaxIterations)
+	assert.Equal(t, fyne.NewPos(500, 500), nodes[0].Position())
+
+	// Linked nodes are roughly the link length apart, and no nodes overlap
+	for i := range nodes {
+		d := distance(nodes[i], nodes[(i+1)%len(nodes)])
+		assert.Greater(t, d, 50.0)
+		assert.Less(t, d, 300.0)
+		for j := i + 1; j < len(nodes); j++ {
+			assert.Greater(t, distance(nodes[i], nodes[j]), 30.0)
+		}
+	}
+
+	assert.Equal(t, 1, len(diagram.commands.undoStack))
+	assert.Equal(t, "Layout", diagram.GetUndoName())
+	diagram.Undo()
+	for i, node := range nodes {
+		assert.Equal(t, fyne.NewPos(float32(500+10*i), float32(500+7*i)), node.Position())
+	}
+}
+
+func TestForceLayoutBarnesHut(t *testing.T) {
+	test.NewApp()
+	positions := []fyne.Position{}
+	for i := 0; i < 200; i++ {
+		positions = append(positions, fyne.NewPos(float32(10*i+(i%13)*40), float32(7*i+(i%7)*50)))
+	}
+	tree := newQuadTree(positions)
+	for _, i := range []int{0, 57, 199} {
+		exactX, exactY := tree.repulsion(i, positions[i], 100, 0)
+		approximateX, approximateY := tree.repulsion(i, positions[i], 100, 0.5)
+		magnitude := math.Hypot(exactX, exactY)
+		assert.Less(t, math.Hypot(approximateX-exactX, approximateY-exactY), 0.05*magnitude)
+	}
+}
+
+func TestForceLayoutInBackground(t *testing.T) {
+	test.NewApp()
+	interval := forceLayoutProgressInterval
+	forceLayoutProgressInterval = 0
+	defer func() { forceLayoutProgressInterval = interval }()
+	diagram := NewDiagramWidget("Diagram1")
+	nodes := []DiagramNode{}
+	for i := 0; i < 6; i++ {
+		node := NewDiagramNode(diagram, nil, fmt.Sprintf("Node%d", i))
+		node.Move(fyne.NewPos(float32(500+10*i), float32(500+7*i)))
+		nodes = append(nodes, node)
+	}
+	for i := range nodes {
+		link := NewDiagramLink(diagram, fmt.Sprintf("Link%d", i))
+		link.SetSourcePad(nodes[i].GetEdgePad())
+		link.SetTargetPad(nodes[(i+1)%len(nodes)].GetEdgePad())
+	}
+	diagram.ClearUndoHistory()
+	diagram.PinDraggedNodes = true
+	layout := NewForceLayout(diagram, NewForceLayoutOptions())
+	reports := []ForceLayoutProgress{}
+	var dropped fyne.Position
+	layout.RunInBackground(func(progress ForceLayoutProgress) {
+		reports = append(reports, progress)
+		if len(reports) == 1 {
+			// A node dragged while the layout runs stays where it is dropped
+			diagram.DiagramNodeDragged(nodes[1].getBaseDiagramNode(), &fyne.DragEvent{Dragged: fyne.NewDelta(-300, 0)})
+			diagram.diagramNodeDragEnd()
+			dropped = nodes[1].Position()
+		}
+	})
+	layout.Wait()
+	assert.Greater(t, len(reports), 2)
+	assert.Equal(t, 1, reports[0].Iteration)
+	assert.True(t, reports[len(reports)-1].Done)
+	assert.True(t, reports[len(reports)-1].Converged)
+	assert.True(t, nodes[1].IsPinned())
+	assert.False(t, nodes[2].IsPinned())
+	assert.Equal(t, dropped, nodes[1].Position())
+
+	// The drag and the layout are separate undo entries
+	assert.Equal(t, "Layout", diagram.GetUndoName())
+	diagram.Undo()
+	assert.Equal(t, fyne.NewPos(210, 507), nodes[1].Position())
+	assert.Equal(t, fyne.NewPos(520, 514), nodes[2].Position())
+	diagram.Undo()
+	assert.Equal(t, fyne.NewPos(510, 507), nodes[1].Position())
+}
+
+func TestForceLayoutStop(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	nodes := []DiagramNode{}
+	for i := 0; i < 6; i++ {
+		node := NewDiagramNode(diagram, nil, fmt.Sprintf("Node%d", i))
+		node.Move(fyne.NewPos(float32(500+10*i), float32(500+7*i)))
+		nodes = append(nodes, node)
+	}
+	for i := range nodes {
+		link := NewDiagramLink(diagram, fmt.Sprintf("Link%d", i))
+		link.SetSourcePad(nodes[i].GetEdgePad())
+		link.SetTargetPad(nodes[(i+1)%len(nodes)].GetEdgePad())
+	}
+	diagram.ClearUndoHistory()
+	options := NewForceLayoutOptions()
+	// The layout never converges
+	options.Tolerance = 0
+	options.MaxIterations = math.MaxInt32
+	layout := NewForceLayout(diagram, options)
+	var last ForceLayoutProgress
+	layout.RunInBackground(func(progress ForceLayoutProgress) {
+		last = progress
+	})
+	layout.Stop()
+	layout.Wait()
+	assert.True(t, last.Done)
+	assert.False(t, last.Converged)
+}
+
+func TestForceLayoutRunsAgain(t *testing.T) {
+	test.NewApp()
+	interval := forceLayoutProgressInterval
+	forceLayoutProgressInterval = 0
+	defer func() { forceLayoutProgressInterval = interval }()
+	diagram := NewDiagramWidget("Diagram1")
+	nodes := []DiagramNode{}
+	for i := 0; i < 6; i++ {
+		node := NewDiagramNode(diagram, nil, fmt.Sprintf("Node%d", i))
+		node.Move(fyne.NewPos(float32(500+10*i), float32(500+7*i)))
+		nodes = append(nodes, node)
+	}
+	for i := range nodes {
+		link := NewDiagramLink(diagram, fmt.Sprintf("Link%d", i))
+		link.SetSourcePad(nodes[i].GetEdgePad())
+		link.SetTargetPad(nodes[(i+1)%len(nodes)].GetEdgePad())
+	}
+	diagram.ClearUndoHistory()
+	layout := NewForceLayout(diagram, NewForceLayoutOptions())
+	layout.RunInBackground(nil)
+	// The progress can be read while the layout runs
+	for layout.GetProgress().Iteration == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	layout.Wait()
+	first := layout.GetProgress()
+	assert.True(t, first.Converged)
+
+	// A second run starts from the beginning rather than ending immediately
+	nodes[3].Move(nodes[3].Position().AddXY(600, 0))
+	reports := []ForceLayoutProgress{}
+	layout.RunInBackground(func(progress ForceLayoutProgress) {
+		reports = append(reports, progress)
+	})
+	layout.Wait()
+	assert.Equal(t, 1, reports[0].Iteration)
+	assert.True(t, reports[len(reports)-1].Done)
+	assert.True(t, reports[len(reports)-1].Converged)
+	assert.Less(t, distance(nodes[3], nodes[2]), 2*NewForceLayoutOptions().LinkLength)
+
+	nodes[3].Move(nodes[3].Position().AddXY(600, 0))
+	progress := layout.Run()
+	assert.True(t, progress.Converged)
+	assert.Equal(t, progress, layout.GetProgress())
+	assert.Less(t, distance(nodes[3], nodes[2]), 2*NewForceLayoutOptions().LinkLength)
+}
+
+func TestForceLayoutStep(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	nodes := []DiagramNode{}
+	for i := 0; i < 4; i++ {
+		node := NewDiagramNode(diagram, nil, fmt.Sprintf("Node%d", i))
+		node.Move(fyne.NewPos(float32(500+10*i), float32(500+7*i)))
+		nodes = append(nodes, node)
+	}
+	for i := range nodes {
+		link := NewDiagramLink(diagram, fmt.Sprintf("Link%d", i))
+		link.SetSourcePad(nodes[i].GetEdgePad())
+		link.SetTargetPad(nodes[(i+1)%len(nodes)].GetEdgePad())
+	}
+	diagram.ClearUndoHistory()
+	layout := NewForceLayout(diagram, NewForceLayoutOptions())
+	start := nodes[3].Position()
+	assert.False(t, layout.Step())
+	assert.NotEqual(t, start, nodes[3].Position())
+	assert.Equal(t, 1, layout.GetProgress().Iteration)
+	assert.Equal(t, 1, len(diagram.commands.undoStack))
+	diagram.Undo()
+	assert.Equal(t, start, nodes[3].Position())
+}
+
+// distance returns the distance between the centers of the nodes
+func distance(node1 DiagramNode, node2 DiagramNode) float64 {
+	return node1.R2Center().Add(node2.R2Center().Scale(-1)).Length()
+}
+
+// BenchmarkForceLayoutStep measures a step of a large layout, most of which is spent moving the nodes and
+// refreshing their links
+func BenchmarkForceLayoutStep(b *testing.B) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	nodes := []DiagramNode{}
+	for i := 0; i < 4000; i++ {
+		node := NewDiagramNode(diagram, nil, fmt.Sprintf("Node%d", i))
+		node.Move(fyne.NewPos(float32(40*(i%64)), float32(40*(i/64))))
+		nodes = append(nodes, node)
+	}
+	for i := 1; i < len(nodes); i++ {
+		link := NewDiagramLink(diagram, fmt.Sprintf("Link%d", i))
+		link.SetSourcePad(nodes[(i-1)/2].GetEdgePad())
+		link.SetTargetPad(nodes[i].GetEdgePad())
+	}
+	layout := NewForceLayout(diagram, NewForceLayoutOptions())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		layout.Step()
+	}
+}
@@ -0,0 +1,87 @@
+package r2
+
+import "math"
+
+// Ellipse describes an axis-aligned ellipse in R2
+type Ellipse struct {
+	// C defines the center of the ellipse
+	C Vec2
+
+	// R defines the horizontal (X) and vertical (Y) radii of the ellipse
+	R Vec2
+}
+
+// MakeEllipse creates an r2 Ellipse
+func MakeEllipse(c, r Vec2) Ellipse {
+	return Ellipse{
+		C: c,
+		R: r,
+	}
+}
+
+// MakeEllipseInBox creates the r2 Ellipse inscribed in the box
+func MakeEllipseInBox(b Box) Ellipse {
+	return MakeEllipse(b.Center(), b.S.Scale(0.5))
+}
+
+// Contains returns true if the point v is within the ellipse e.
+func (e Ellipse) Contains(v Vec2) bool {
+	if e.R.X == 0 || e.R.Y == 0 {
+		return false
+	}
+	dx := (v.X - e.C.X) / e.R.X
+	dy := (v.Y - e.C.Y) / e.R.Y
+	return dx*dx+dy*dy <= 1
+}
+
+// Intersect returns the intersection of the ellipse's perimeter and the line, and a Boolean indicating
+// if they intersect. If the line crosses the perimeter twice, the intersection nearest the first endpoint
+// of the line is returned. If they do not intersect, the zero vector is returned.
+func (e Ellipse) Intersect(l Line) (Vec2, bool) {
+	if e.R.X == 0 || e.R.Y == 0 {
+		return V2(0, 0), false
+	}
+	// Scaling the coordinates by the radii turns the ellipse into a unit circle centered on
+	// the origin. The intersections are the solutions of |a + t s| = 1 with t in [0, 1].
+	a := V2((l.A.X-e.C.X)/e.R.X, (l.A.Y-e.C.Y)/e.R.Y)
+	s := V2(l.S.X/e.R.X, l.S.Y/e.R.Y)
+	qa := s.Dot(s)
+	qb := 2 * a.Dot(s)
+	qc := a.Dot(a) - 1
+	if qa == 0 {
+		return V2(0, 0), false
+	}
+	discriminant := qb*qb - 4*qa*qc
+	if discriminant < 0 {
+		return V2(0, 0), false
+	}
+	root := math.Sqrt(discriminant)
+	for _, t := range []float64{(-qb - root) / (2 * qa), (-qb + root) / (2 * qa)} {
+		if t >= 0 && t <= 1 {
+			return l.A.Add(l.S.Scale(t)), true
+		}
+	}
+	return V2(0, 0), false
+}
+
+// Points returns n points evenly spaced by angle around the perimeter of the ellipse, starting at
+// the rightmost point and proceeding clockwise (in window coordinates, where Y increases downwards).
+func (e Ellipse) Points(n int) []Vec2 {
+	points := make([]Vec2, n)
+	for i := range points {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		points[i] = V2(e.C.X+e.R.X*math.Cos(angle), e.C.Y+e.R.Y*math.Sin(angle))
+	}
+	return points
+}
+
+// ProjectToPerimeter returns the point at which the ray from the center of the ellipse through v crosses
+// the perimeter. If v is the center, the rightmost point of the ellipse is returned.
+func (e Ellipse) ProjectToPerimeter(v Vec2) Vec2 {
+	d := v.Add(e.C.Scale(-1))
+	if d.Length() == 0 || e.R.X == 0 || e.R.Y == 0 {
+		return e.C.Add(V2(e.R.X, 0))
+	}
+	scale := 1 / math.Sqrt((d.X*d.X)/(e.R.X*e.R.X)+(d.Y*d.Y)/(e.R.Y*e.R.Y))
+	return e.C.Add(d.Scale(scale))
+}
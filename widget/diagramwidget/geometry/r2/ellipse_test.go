@@ -0,0 +1,42 @@
+package r2
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEllipseIntersect(t *testing.T) {
+	tolerance := 0.000001
+	ellipse := MakeEllipseInBox(MakeBox(V2(100, 100), V2(200, 100)))
+	if ellipse.C != V2(200, 150) || ellipse.R != V2(100, 50) {
+		t.Errorf("MakeEllipseInBox failed. Got center %v, radii %v", ellipse.C, ellipse.R)
+	}
+	if !ellipse.Contains(V2(290, 150)) || ellipse.Contains(V2(295, 190)) {
+		t.Errorf("Contains failed")
+	}
+	// A line from the center to the right
+	point, ok := ellipse.Intersect(MakeLineFromEndpoints(V2(200, 150), V2(400, 150)))
+	if !ok || point.Add(V2(-300, -150)).Length() > tolerance {
+		t.Errorf("Intersect to the right failed. Got %v", point)
+	}
+	// A diagonal line from the center
+	point, ok = ellipse.Intersect(MakeLineFromEndpoints(V2(200, 150), V2(400, 350)))
+	expected := 100 * 50 / math.Sqrt(100*100+50*50)
+	if !ok || point.Add(V2(-200-expected, -150-expected)).Length() > tolerance {
+		t.Errorf("Diagonal Intersect failed. Got %v", point)
+	}
+	// A line crossing the ellipse twice returns the crossing nearest its first endpoint
+	point, ok = ellipse.Intersect(MakeLineFromEndpoints(V2(0, 150), V2(400, 150)))
+	if !ok || point.Add(V2(-100, -150)).Length() > tolerance {
+		t.Errorf("Intersect of crossing line failed. Got %v", point)
+	}
+	// A line that misses the ellipse
+	_, ok = ellipse.Intersect(MakeLineFromEndpoints(V2(0, 0), V2(400, 0)))
+	if ok {
+		t.Errorf("Intersect of missing line failed")
+	}
+	point = ellipse.ProjectToPerimeter(V2(200, 160))
+	if point.Add(V2(-200, -200)).Length() > tolerance {
+		t.Errorf("ProjectToPerimeter failed. Got %v", point)
+	}
+}
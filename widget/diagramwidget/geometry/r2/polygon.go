@@ -0,0 +1,96 @@
+package r2
+
+import "math"
+
+// Polygon describes a closed polygon in R2. The last point is implicitly connected to the first.
+type Polygon struct {
+	// Points defines the vertices of the polygon
+	Points []Vec2
+}
+
+// MakePolygon creates an r2 Polygon
+func MakePolygon(points []Vec2) Polygon {
+	return Polygon{
+		Points: points,
+	}
+}
+
+// Edges returns the edges of the polygon, including the one from the last point back to the first.
+func (p Polygon) Edges() []Line {
+	edges := []Line{}
+	if len(p.Points) < 2 {
+		return edges
+	}
+	for i, point := range p.Points {
+		edges = append(edges, MakeLineFromEndpoints(point, p.Points[(i+1)%len(p.Points)]))
+	}
+	return edges
+}
+
+// Contains returns true if the point v is within the polygon p. It uses the even-odd rule.
+func (p Polygon) Contains(v Vec2) bool {
+	inside := false
+	for _, edge := range p.Edges() {
+		a := edge.Endpoint1()
+		b := edge.Endpoint2()
+		if (a.Y > v.Y) != (b.Y > v.Y) {
+			crossingX := a.X + (v.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+			if v.X < crossingX {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// FindPerimeterPointNearestPoint returns the point on the perimeter of the polygon closest to v.
+// If the polygon has no edges, it returns a (0,0) vector
+func (p Polygon) FindPerimeterPointNearestPoint(v Vec2) Vec2 {
+	nearest := V2(0, 0)
+	shortestDistance := -1.0
+	for _, edge := range p.Edges() {
+		point := edge.A
+		if length := edge.S.Dot(edge.S); length > 0 {
+			t := math.Max(0, math.Min(1, v.Add(edge.A.Scale(-1)).Dot(edge.S)/length))
+			point = edge.A.Add(edge.S.Scale(t))
+		}
+		distance := v.Add(point.Scale(-1)).Length()
+		if shortestDistance < 0 || distance < shortestDistance {
+			shortestDistance = distance
+			nearest = point
+		}
+	}
+	return nearest
+}
+
+// Intersect returns the intersection of the polygon's perimeter and the line, and a Boolean indicating
+// if they intersect. If the line crosses the perimeter more than once, the intersection nearest the first
+// endpoint of the line is returned. If they do not intersect, the zero vector is returned.
+func (p Polygon) Intersect(l Line) (Vec2, bool) {
+	best := -1.0
+	for _, edge := range p.Edges() {
+		// Solve l.A + t l.S = edge.A + u edge.S for t and u
+		denominator := cross(l.S, edge.S)
+		if denominator == 0 {
+			continue
+		}
+		offset := edge.A.Add(l.A.Scale(-1))
+		t := cross(offset, edge.S) / denominator
+		u := cross(offset, l.S) / denominator
+		if t < 0 || t > 1 || u < 0 || u > 1 {
+			continue
+		}
+		if best < 0 || t < best {
+			best = t
+		}
+	}
+	if best < 0 {
+		return V2(0, 0), false
+	}
+	return l.A.Add(l.S.Scale(best)), true
+}
+
+// cross returns the Z component of the cross product of the vectors
+func cross(v, u Vec2) float64 {
+	return v.X*u.Y - v.Y*u.X
+}
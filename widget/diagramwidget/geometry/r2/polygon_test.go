@@ -0,0 +1,38 @@
+package r2
+
+import (
+	"testing"
+)
+
+func TestPolygonIntersect(t *testing.T) {
+	tolerance := 0.000001
+	// A diamond centered on 100,100
+	diamond := MakePolygon([]Vec2{V2(100, 50), V2(200, 100), V2(100, 150), V2(0, 100)})
+	if len(diamond.Edges()) != 4 {
+		t.Errorf("Edges failed. Got %d edges", len(diamond.Edges()))
+	}
+	if !diamond.Contains(V2(100, 100)) || !diamond.Contains(V2(150, 90)) || diamond.Contains(V2(180, 60)) {
+		t.Errorf("Contains failed")
+	}
+	point, ok := diamond.Intersect(MakeLineFromEndpoints(V2(100, 100), V2(300, 100)))
+	if !ok || point.Add(V2(-200, -100)).Length() > tolerance {
+		t.Errorf("Intersect to the right failed. Got %v", point)
+	}
+	point, ok = diamond.Intersect(MakeLineFromEndpoints(V2(100, 100), V2(300, 300)))
+	if !ok || point.Add(V2(-100-100.0/3, -100-100.0/3)).Length() > tolerance {
+		t.Errorf("Diagonal Intersect failed. Got %v", point)
+	}
+	// A line crossing the polygon twice returns the crossing nearest its first endpoint
+	point, ok = diamond.Intersect(MakeLineFromEndpoints(V2(-100, 100), V2(300, 100)))
+	if !ok || point.Add(V2(0, -100)).Length() > tolerance {
+		t.Errorf("Intersect of crossing line failed. Got %v", point)
+	}
+	_, ok = diamond.Intersect(MakeLineFromEndpoints(V2(0, 0), V2(300, 0)))
+	if ok {
+		t.Errorf("Intersect of missing line failed")
+	}
+	point = diamond.FindPerimeterPointNearestPoint(V2(140, 80))
+	if point.Add(V2(-144, -72)).Length() > tolerance {
+		t.Errorf("FindPerimeterPointNearestPoint failed. Got %v", point)
+	}
+}
@@ -0,0 +1,276 @@
+package diagramwidget
+
+import (
+	"fyne.io/fyne/v2/widget"
+)
+
+// GraphBinder keeps a DiagramWidget synchronized with a GraphModel in both directions. Changes to the model
+// are applied to the diagram: nodes and links are created, moved, connected and removed to match. Changes
+// made in the diagram are written back to the model: moving a node updates its position, and connecting a
+// link, e.g. with a ConnectionTransaction, adds or updates the link in the model once both of its ends are
+// connected. Disconnecting an end in the diagram removes the link from the model. Groups are not represented
+// in the model. A link end that the model connects to a PortPad in a way that the port rules do not allow is
+// left disconnected in the diagram.
+//
+// The changes made to the diagram by the binder are not recorded for Undo. Undoing a change made in the diagram
+// writes the restored state back to the model. As with the other diagram operations, the model must only be
+// changed on the main goroutine (e.g. within fyne.Do) while it is bound.
+type GraphBinder struct {
+	diagram  *DiagramWidget
+	model    GraphModel
+	listener GraphModelListener
+	// CreateNodeCallback creates the DiagramNode displaying a model node. It must be set before Bind is
+	// called. If it is nil, a node containing a label displaying the node's Label is created.
+	CreateNodeCallback func(diagram *DiagramWidget, nodeID string, node GraphNode) DiagramNode
+	// CreateLinkCallback creates the DiagramLink displaying a model link. It must be set before Bind is
+	// called. If it is nil, a BaseDiagramLink is created.
+	CreateLinkCallback func(diagram *DiagramWidget, linkID string, link GraphLink) DiagramLink
+	// updatingDiagram is true while model changes are being applied to the diagram, and updatingModel
+	// while diagram changes are being written to the model. They prevent the changes from being echoed.
+	updatingDiagram bool
+	updatingModel   bool
+}
+
+// NewGraphBinder creates a GraphBinder for the diagram and the model. The binder has no effect until Bind is
+// called.
+func NewGraphBinder(diagram *DiagramWidget, model GraphModel) *GraphBinder {
+	return &GraphBinder{
+		diagram: diagram,
+		model:   model,
+	}
+}
+
+// Bind synchronizes the diagram with the model and starts keeping them synchronized. The model's nodes and
+// links are created in, or applied to, the diagram. The diagram's nodes and connected links that are not in
+// the model are then added to it.
+func (gb *GraphBinder) Bind() {
+	if gb.listener != nil {
+		return
+	}
+	gb.listener = NewGraphModelListener(gb.modelChanged)
+	gb.model.AddListener(gb.listener)
+	gb.diagram.graphBinders = append(gb.diagram.graphBinders, gb)
+	gb.updateDiagram(func() {
+		for _, nodeID := range gb.model.GetNodeIDs() {
+			gb.syncNode(nodeID)
+		}
+		for _, linkID := range gb.model.GetLinkIDs() {
+			gb.syncLink(linkID)
+		}
+	})
+	for _, node := range gb.diagram.GetDiagramNodes() {
+		gb.elementChanged(node)
+	}
+	for _, link := range gb.diagram.GetDiagramLinks() {
+		gb.elementChanged(link)
+	}
+}
+
+// GetDiagram returns the diagram kept synchronized with the model
+func (gb *GraphBinder) GetDiagram() *DiagramWidget {
+	return gb.diagram
+}
+
+// GetModel returns the model kept synchronized with the diagram
+func (gb *GraphBinder) GetModel() GraphModel {
+	return gb.model
+}
+
+// Unbind stops keeping the diagram and the model synchronized. Both retain their present contents.
+func (gb *GraphBinder) Unbind() {
+	if gb.listener == nil {
+		return
+	}
+	gb.model.RemoveListener(gb.listener)
+	gb.listener = nil
+	for i, binder := range gb.diagram.graphBinders {
+		if binder == gb {
+			gb.diagram.graphBinders = append(gb.diagram.graphBinders[:i:i], gb.diagram.graphBinders[i+1:]...)
+			break
+		}
+	}
+}
+
+// elementChanged writes the state of a node or link of the diagram to the model
+func (gb *GraphBinder) elementChanged(de DiagramElement) {
+	if gb.updatingDiagram {
+		return
+	}
+	id := de.GetDiagramElementID()
+	switch element := de.(type) {
+	case DiagramGroup:
+		return
+	case DiagramNode:
+		node, _ := gb.model.GetNode(id)
+		bdn := element.getBaseDiagramNode()
+		if label, ok := bdn.innerObject.(*widget.Label); ok {
+			node.Label = label.Text
+		}
+		node.Position = gb.diagram.unscalePosition(bdn.Position())
+		gb.updateModel(func() {
+			gb.model.SetNode(id, node)
+		})
+	case DiagramLink:
+		sourcePad := element.GetSourcePad()
+		targetPad := element.GetTargetPad()
+		modelLink, inModel := gb.model.GetLink(id)
+		if inModel && sourcePad == gb.findPad(modelLink.SourceID, modelLink.SourcePad) &&
+			targetPad == gb.findPad(modelLink.TargetID, modelLink.TargetPad) {
+			// The link is as the model describes it, possibly awaiting the creation of its ends
+			return
+		}
+		gb.updateModel(func() {
+			switch {
+			case sourcePad != nil && targetPad != nil:
+				gb.model.SetLink(id, GraphLink{
+					SourceID:  sourcePad.GetPadOwner().GetDiagramElementID(),
+					SourcePad: graphPadKey(sourcePad),
+					TargetID:  targetPad.GetPadOwner().GetDiagramElementID(),
+					TargetPad: graphPadKey(targetPad),
+				})
+			case inModel:
+				gb.model.RemoveLink(id)
+			}
+		})
+	}
+}
+
+// elementRemoved removes a node or link that has been removed from the diagram from the model
+func (gb *GraphBinder) elementRemoved(de DiagramElement) {
+	if gb.updatingDiagram {
+		return
+	}
+	id := de.GetDiagramElementID()
+	gb.updateModel(func() {
+		if de.IsLink() {
+			gb.model.RemoveLink(id)
+		} else {
+			gb.model.RemoveNode(id)
+		}
+	})
+}
+
+// modelChanged applies a change of the model to the diagram
+func (gb *GraphBinder) modelChanged(event GraphModelEvent) {
+	if gb.updatingModel {
+		return
+	}
+	gb.updateDiagram(func() {
+		switch event.Type {
+		case GraphNodeAdded, GraphNodeUpdated, GraphNodeRemoved:
+			gb.syncNode(event.ID)
+		case GraphLinkAdded, GraphLinkUpdated, GraphLinkRemoved:
+			gb.syncLink(event.ID)
+		}
+	})
+}
+
+// syncLink makes the diagram link match the model link, creating or removing it as necessary
+func (gb *GraphBinder) syncLink(linkID string) {
+	modelLink, ok := gb.model.GetLink(linkID)
+	link := gb.diagram.GetDiagramLink(linkID)
+	if !ok {
+		if link != nil {
+			gb.diagram.RemoveElement(linkID)
+		}
+		return
+	}
+	if link == nil {
+		if gb.CreateLinkCallback != nil {
+			link = gb.CreateLinkCallback(gb.diagram, linkID, modelLink)
+		} else {
+			link = NewDiagramLink(gb.diagram, linkID)
+		}
+	}
+	link.SetSourcePad(gb.findPad(modelLink.SourceID, modelLink.SourcePad))
+	link.SetTargetPad(gb.findPad(modelLink.TargetID, modelLink.TargetPad))
+}
+
+// syncNode makes the diagram node match the model node, creating or removing it as necessary. The model
+// links connected to the node are then synchronized, since their ends may not have been found before.
+func (gb *GraphBinder) syncNode(nodeID string) {
+	modelNode, ok := gb.model.GetNode(nodeID)
+	node := gb.diagram.GetDiagramNode(nodeID)
+	if !ok {
+		if node != nil {
+			gb.diagram.RemoveElement(nodeID)
+		}
+		return
+	}
+	if node == nil {
+		if gb.CreateNodeCallback != nil {
+			node = gb.CreateNodeCallback(gb.diagram, nodeID, modelNode)
+		} else {
+			node = NewDiagramNode(gb.diagram, widget.NewLabel(modelNode.Label), nodeID)
+		}
+	} else if label, ok := node.getBaseDiagramNode().innerObject.(*widget.Label); ok && label.Text != modelNode.Label {
+		label.SetText(modelNode.Label)
+		node.Refresh()
+	}
+	position := gb.diagram.scalePosition(modelNode.Position)
+	if node.Position() != position {
+		node.Move(position)
+		gb.diagram.adjustBounds()
+	}
+	for _, linkID := range gb.model.GetLinkIDs() {
+		if modelLink, _ := gb.model.GetLink(linkID); modelLink.SourceID == nodeID || modelLink.TargetID == nodeID {
+			gb.syncLink(linkID)
+		}
+	}
+}
+
+// findPad returns the pad of the diagram node with the key, or nil if there is no such pad
+func (gb *GraphBinder) findPad(nodeID string, padKey string) ConnectionPad {
+	node := gb.diagram.GetDiagramNode(nodeID)
+	if node == nil {
+		return nil
+	}
+	if padKey == "" {
+		padKey = "default"
+	}
+	return node.GetConnectionPads()[padKey]
+}
+
+// updateDiagram applies changes to the diagram without writing them back to the model or recording them
+// for Undo
+func (gb *GraphBinder) updateDiagram(update func()) {
+	gb.updatingDiagram = true
+	gb.diagram.suspendUndoRecording()
+	defer func() {
+		gb.diagram.resumeUndoRecording()
+		gb.updatingDiagram = false
+	}()
+	update()
+}
+
+// updateModel writes changes to the model without applying them back to the diagram
+func (gb *GraphBinder) updateModel(update func()) {
+	gb.updatingModel = true
+	defer func() {
+		gb.updatingModel = false
+	}()
+	update()
+}
+
+// graphPadKey returns the key of the pad as stored in a GraphLink: empty for the default pad
+func graphPadKey(pad ConnectionPad) string {
+	key := getPadKey(pad)
+	if key == "default" {
+		return ""
+	}
+	return key
+}
+
+// graphBindersElementChanged writes the state of the element to the models of the diagram's GraphBinders
+func (dw *DiagramWidget) graphBindersElementChanged(de DiagramElement) {
+	for _, gb := range dw.graphBinders {
+		gb.elementChanged(de)
+	}
+}
+
+// graphBindersElementRemoved removes the element from the models of the diagram's GraphBinders
+func (dw *DiagramWidget) graphBindersElementRemoved(de DiagramElement) {
+	for _, gb := range dw.graphBinders {
+		gb.elementRemoved(de)
+	}
+}
@@ -0,0 +1,239 @@
+package diagramwidget
+
+import (
+	"sort"
+	"sync"
+
+	"fyne.io/fyne/v2"
+)
+
+// GraphModelEventType identifies the kind of change reported by a GraphModelEvent
+type GraphModelEventType int
+
+const (
+	// GraphNodeAdded indicates that a node has been added to the model
+	GraphNodeAdded GraphModelEventType = iota
+	// GraphNodeUpdated indicates that the data of a node has changed
+	GraphNodeUpdated
+	// GraphNodeRemoved indicates that a node has been removed from the model
+	GraphNodeRemoved
+	// GraphLinkAdded indicates that a link has been added to the model
+	GraphLinkAdded
+	// GraphLinkUpdated indicates that the data of a link has changed
+	GraphLinkUpdated
+	// GraphLinkRemoved indicates that a link has been removed from the model
+	GraphLinkRemoved
+)
+
+// GraphModelEvent describes a change to a GraphModel
+type GraphModelEvent struct {
+	Type GraphModelEventType
+	// ID is the ID of the node or link that changed
+	ID string
+}
+
+// GraphModelListener is notified of the changes to a GraphModel
+type GraphModelListener interface {
+	GraphModelChanged(event GraphModelEvent)
+}
+
+// NewGraphModelListener creates a GraphModelListener that calls the function for each change
+func NewGraphModelListener(fn func(event GraphModelEvent)) GraphModelListener {
+	return &graphModelListener{fn: fn}
+}
+
+type graphModelListener struct {
+	fn func(event GraphModelEvent)
+}
+
+func (gml *graphModelListener) GraphModelChanged(event GraphModelEvent) {
+	gml.fn(event)
+}
+
+// GraphNode is the model data of a node
+type GraphNode struct {
+	// Label is displayed by the nodes that a GraphBinder creates by default
+	Label string
+	// Position is the position of the node in the diagram at zoom 1
+	Position fyne.Position
+}
+
+// GraphLink is the model data of a link
+type GraphLink struct {
+	// SourceID is the ID of the node to which the source end of the link is connected
+	SourceID string
+	// SourcePad is the key of the connection pad to which the source end is connected. An empty key
+	// designates the node's default (edge) pad.
+	SourcePad string
+	// TargetID is the ID of the node to which the target end of the link is connected
+	TargetID string
+	// TargetPad is the key of the connection pad to which the target end is connected. An empty key
+	// designates the node's default (edge) pad.
+	TargetPad string
+}
+
+// GraphModel is an application-side graph of nodes and links that can be displayed and edited in a
+// DiagramWidget by means of a GraphBinder. Listeners are notified of each change after it has been made.
+// The IDs of the nodes and links are shared with the corresponding DiagramElements, so they must be unique
+// across both nodes and links. Removing a node is expected to also remove the links connected to it.
+type GraphModel interface {
+	// AddListener registers a listener to be notified of the changes to the model
+	AddListener(listener GraphModelListener)
+	// RemoveListener unregisters a listener
+	RemoveListener(listener GraphModelListener)
+	// GetNodeIDs returns the IDs of the nodes in the model
+	GetNodeIDs() []string
+	// GetNode returns the data of the node and whether the node is present in the model
+	GetNode(id string) (GraphNode, bool)
+	// SetNode adds the node to the model or updates its data
+	SetNode(id string, node GraphNode)
+	// RemoveNode removes the node from the model
+	RemoveNode(id string)
+	// GetLinkIDs returns the IDs of the links in the model
+	GetLinkIDs() []string
+	// GetLink returns the data of the link and whether the link is present in the model
+	GetLink(id string) (GraphLink, bool)
+	// SetLink adds the link to the model or updates its data
+	SetLink(id string, link GraphLink)
+	// RemoveLink removes the link from the model
+	RemoveLink(id string)
+}
+
+// Validate that graphModel implements GraphModel
+var _ GraphModel = (*graphModel)(nil)
+
+// graphModel is the in-memory GraphModel returned by NewGraphModel
+type graphModel struct {
+	lock      sync.RWMutex
+	nodes     map[string]GraphNode
+	links     map[string]GraphLink
+	listeners []GraphModelListener
+}
+
+// NewGraphModel creates an empty in-memory GraphModel. Its listeners are notified synchronously, on the
+// goroutine that made the change. Removing a node also removes the links connected to it, each removal
+// being notified before that of the node.
+func NewGraphModel() GraphModel {
+	return &graphModel{
+		nodes: map[string]GraphNode{},
+		links: map[string]GraphLink{},
+	}
+}
+
+func (gm *graphModel) AddListener(listener GraphModelListener) {
+	gm.lock.Lock()
+	defer gm.lock.Unlock()
+	gm.listeners = append(gm.listeners, listener)
+}
+
+func (gm *graphModel) RemoveListener(listener GraphModelListener) {
+	gm.lock.Lock()
+	defer gm.lock.Unlock()
+	for i, l := range gm.listeners {
+		if l == listener {
+			gm.listeners = append(gm.listeners[:i:i], gm.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+func (gm *graphModel) GetNodeIDs() []string {
+	gm.lock.RLock()
+	defer gm.lock.RUnlock()
+	return sortedKeys(gm.nodes)
+}
+
+func (gm *graphModel) GetNode(id string) (GraphNode, bool) {
+	gm.lock.RLock()
+	defer gm.lock.RUnlock()
+	node, ok := gm.nodes[id]
+	return node, ok
+}
+
+func (gm *graphModel) SetNode(id string, node GraphNode) {
+	gm.lock.Lock()
+	oldNode, ok := gm.nodes[id]
+	if ok && oldNode == node {
+		gm.lock.Unlock()
+		return
+	}
+	gm.nodes[id] = node
+	gm.lock.Unlock()
+	if ok {
+		gm.notify(GraphModelEvent{Type: GraphNodeUpdated, ID: id})
+	} else {
+		gm.notify(GraphModelEvent{Type: GraphNodeAdded, ID: id})
+	}
+}
+
+func (gm *graphModel) RemoveNode(id string) {
+	gm.lock.RLock()
+	_, ok := gm.nodes[id]
+	linkIDs := []string{}
+	for linkID, link := range gm.links {
+		if link.SourceID == id || link.TargetID == id {
+			linkIDs = append(linkIDs, linkID)
+		}
+	}
+	gm.lock.RUnlock()
+	if !ok {
+		return
+	}
+	sort.Strings(linkIDs)
+	for _, linkID := range linkIDs {
+		gm.RemoveLink(linkID)
+	}
+	gm.lock.Lock()
+	delete(gm.nodes, id)
+	gm.lock.Unlock()
+	gm.notify(GraphModelEvent{Type: GraphNodeRemoved, ID: id})
+}
+
+func (gm *graphModel) GetLinkIDs() []string {
+	gm.lock.RLock()
+	defer gm.lock.RUnlock()
+	return sortedKeys(gm.links)
+}
+
+func (gm *graphModel) GetLink(id string) (GraphLink, bool) {
+	gm.lock.RLock()
+	defer gm.lock.RUnlock()
+	link, ok := gm.links[id]
+	return link, ok
+}
+
+func (gm *graphModel) SetLink(id string, link GraphLink) {
+	gm.lock.Lock()
+	oldLink, ok := gm.links[id]
+	if ok && oldLink == link {
+		gm.lock.Unlock()
+		return
+	}
+	gm.links[id] = link
+	gm.lock.Unlock()
+	if ok {
+		gm.notify(GraphModelEvent{Type: GraphLinkUpdated, ID: id})
+	} else {
+		gm.notify(GraphModelEvent{Type: GraphLinkAdded, ID: id})
+	}
+}
+
+func (gm *graphModel) RemoveLink(id string) {
+	gm.lock.Lock()
+	_, ok := gm.links[id]
+	delete(gm.links, id)
+	gm.lock.Unlock()
+	if ok {
+		gm.notify(GraphModelEvent{Type: GraphLinkRemoved, ID: id})
+	}
+}
+
+// notify calls the listeners without holding the lock so that they can query and modify the model
+func (gm *graphModel) notify(event GraphModelEvent) {
+	gm.lock.RLock()
+	listeners := append([]GraphModelListener(nil), gm.listeners...)
+	gm.lock.RUnlock()
+	for _, listener := range listeners {
+		listener.GraphModelChanged(event)
+	}
+}
@@ -0,0 +1,124 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphModel(t *testing.T) {
+	model := NewGraphModel()
+	events := []GraphModelEvent{}
+	model.AddListener(NewGraphModelListener(func(event GraphModelEvent) {
+		events = append(events, event)
+	}))
+	model.SetNode("Node1", GraphNode{Label: "One"})
+	model.SetNode("Node2", GraphNode{Label: "Two"})
+	model.SetLink("Link1", GraphLink{SourceID: "Node1", TargetID: "Node2"})
+	model.SetNode("Node1", GraphNode{Label: "One", Position: fyne.NewPos(10, 20)})
+	// Setting the same data again is not a change
+	model.SetNode("Node1", GraphNode{Label: "One", Position: fyne.NewPos(10, 20)})
+	model.RemoveNode("Node2")
+	assert.Equal(t, []GraphModelEvent{
+		{Type: GraphNodeAdded, ID: "Node1"},
+		{Type: GraphNodeAdded, ID: "Node2"},
+		{Type: GraphLinkAdded, ID: "Link1"},
+		{Type: GraphNodeUpdated, ID: "Node1"},
+		{Type: GraphLinkRemoved, ID: "Link1"},
+		{Type: GraphNodeRemoved, ID: "Node2"},
+	}, events)
+	assert.Equal(t, []string{"Node1"}, model.GetNodeIDs())
+	assert.Equal(t, 0, len(model.GetLinkIDs()))
+}
+
+func TestGraphBinderModelToDiagram(t *testing.T) {
+	test.NewApp()
+	model := NewGraphModel()
+	// The link is added before the nodes it connects
+	model.SetLink("Link1", GraphLink{SourceID: "Node1", TargetID: "Node2"})
+	model.SetNode("Node1", GraphNode{Label: "One", Position: fyne.NewPos(20, 30)})
+	diagram := NewDiagramWidget("Diagram1")
+	diagram.SetZoom(2)
+	binder := NewGraphBinder(diagram, model)
+	binder.Bind()
+
+	node1 := diagram.GetDiagramNode("Node1")
+	assert.NotNil(t, node1)
+	assert.Equal(t, "One", node1.getBaseDiagramNode().innerObject.(*widget.Label).Text)
+	assert.Equal(t, fyne.NewPos(40, 60), node1.Position())
+	link := diagram.GetDiagramLink("Link1")
+	assert.NotNil(t, link)
+	assert.Equal(t, node1.GetEdgePad(), link.GetSourcePad())
+	assert.Nil(t, link.GetTargetPad())
+
+	model.SetNode("Node2", GraphNode{Label: "Two", Position: fyne.NewPos(200, 30)})
+	node2 := diagram.GetDiagramNode("Node2")
+	assert.NotNil(t, node2)
+	assert.Equal(t, node2.GetEdgePad(), link.GetTargetPad())
+
+	model.SetNode("Node1", GraphNode{Label: "First", Position: fyne.NewPos(50, 30)})
+	assert.Equal(t, "First", node1.getBaseDiagramNode().innerObject.(*widget.Label).Text)
+	assert.Equal(t, fyne.NewPos(100, 60), node1.Position())
+
+	model.RemoveNode("Node2")
+	assert.Nil(t, diagram.GetDiagramNode("Node2"))
+	assert.Nil(t, diagram.GetDiagramLink("Link1"))
+	// The binder's changes are not recorded for Undo
+	assert.False(t, diagram.CanUndo())
+
+	binder.Unbind()
+	model.RemoveNode("Node1")
+	assert.NotNil(t, diagram.GetDiagramNode("Node1"))
+}
+
+func TestGraphBinderDiagramToModel(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	NewDiagramNode(diagram, widget.NewLabel("One"), "Node1")
+	model := NewGraphModel()
+	model.SetNode("Node2", GraphNode{Label: "Two", Position: fyne.NewPos(200, 0)})
+	events := []GraphModelEvent{}
+	model.AddListener(NewGraphModelListener(func(event GraphModelEvent) {
+		events = append(events, event)
+	}))
+	NewGraphBinder(diagram, model).Bind()
+	// The node already in the diagram is added to the model
+	node, ok := model.GetNode("Node1")
+	assert.True(t, ok)
+	assert.Equal(t, "One", node.Label)
+	// Node2 is created in the diagram without being written back to the model
+	assert.Equal(t, []GraphModelEvent{{Type: GraphNodeAdded, ID: "Node1"}}, events)
+
+	diagram.DisplaceNode(diagram.GetDiagramNode("Node1"), fyne.NewPos(10, 15))
+	node, _ = model.GetNode("Node1")
+	assert.Equal(t, fyne.NewPos(10, 15), node.Position)
+
+	// Connecting a new link with a ConnectionTransaction adds it to the model once both ends are connected
+	link := NewDiagramLink(diagram, "Link1")
+	_, ok = model.GetLink("Link1")
+	assert.False(t, ok)
+	diagram.StartNewLinkConnectionTransaction(link)
+	diagram.GetDiagramNode("Node1").GetEdgePad().(*RectanglePad).MouseDown(&desktop.MouseEvent{})
+	_, ok = model.GetLink("Link1")
+	assert.False(t, ok)
+	link.SetTargetPad(diagram.GetDiagramNode("Node2").GetEdgePad())
+	modelLink, ok := model.GetLink("Link1")
+	assert.True(t, ok)
+	assert.Equal(t, GraphLink{SourceID: "Node1", TargetID: "Node2"}, modelLink)
+
+	// Removing a node from the diagram removes it and its link from the model, and Undo restores them
+	diagram.RemoveElement("Node2")
+	_, ok = model.GetNode("Node2")
+	assert.False(t, ok)
+	_, ok = model.GetLink("Link1")
+	assert.False(t, ok)
+	diagram.Undo()
+	_, ok = model.GetNode("Node2")
+	assert.True(t, ok)
+	modelLink, _ = model.GetLink("Link1")
+	assert.Equal(t, GraphLink{SourceID: "Node1", TargetID: "Node2"}, modelLink)
+}
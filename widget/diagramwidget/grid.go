@@ -0,0 +1,168 @@
+package diagramwidget
+
+import (
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+)
+
+const (
+	// defaultGridSpacing is the default distance between grid lines at zoom 1
+	defaultGridSpacing float32 = 20
+	// minGridLineSpacing is the minimum distance in pixels between the displayed grid lines. When the zoom
+	// makes the grid finer than this, only every second (fourth, ...) line is drawn.
+	minGridLineSpacing = 6
+	// guideTolerance is the distance within which a dragged node snaps to an alignment guide
+	guideTolerance float32 = 5
+)
+
+// newGridRaster creates the raster that draws the background grid of the diagram
+func (dw *DiagramWidget) newGridRaster() *canvas.Raster {
+	var raster *canvas.Raster
+	raster = canvas.NewRasterWithPixels(func(x, y, w, h int) color.Color {
+		size := raster.Size()
+		if size.Width <= 0 {
+			return color.Transparent
+		}
+		spacing := float64(dw.scaled(dw.GridSpacing)) * float64(w) / float64(size.Width)
+		if spacing <= 0 {
+			return color.Transparent
+		}
+		for spacing < minGridLineSpacing {
+			spacing *= 2
+		}
+		if math.Mod(float64(x), spacing) < 1 || math.Mod(float64(y), spacing) < 1 {
+			return dw.GridColor
+		}
+		return color.Transparent
+	})
+	return raster
+}
+
+// snapToGrid returns the grid coordinate nearest to the value if SnapToGrid is set. Otherwise, it returns the value.
+func (dw *DiagramWidget) snapToGrid(value float32) float32 {
+	spacing := dw.scaled(dw.GridSpacing)
+	if !dw.SnapToGrid || spacing <= 0 {
+		return value
+	}
+	return float32(math.Round(float64(value/spacing))) * spacing
+}
+
+// snapDrag applies a drag delta to a point that is being snapped. The difference between the
+// unsnapped and the snapped point is carried over to the next drag event of the gesture so that
+// slow drags are not lost. It returns the snapped displacement of the point.
+func (dw *DiagramWidget) snapDrag(point fyne.Position, delta fyne.Position, snapX bool, snapY bool) fyne.Position {
+	unsnapped := point.Add(dw.dragResidual).Add(delta)
+	snapped := unsnapped
+	if snapX {
+		snapped.X = dw.snapToGrid(unsnapped.X)
+	}
+	if snapY {
+		snapped.Y = dw.snapToGrid(unsnapped.Y)
+	}
+	dw.dragResidual = unsnapped.Subtract(snapped)
+	return snapped.Subtract(point)
+}
+
+// snapNodeDrag returns the displacement of a dragged node, taking the grid and the alignment guides into account
+func (dw *DiagramWidget) snapNodeDrag(node DiagramNode, delta fyne.Position) fyne.Position {
+	position := node.Position()
+	unsnapped := position.Add(dw.dragResidual).Add(delta)
+	target := fyne.NewPos(dw.snapToGrid(unsnapped.X), dw.snapToGrid(unsnapped.Y))
+	dw.guides = nil
+	if dw.AlignmentGuidesEnabled {
+		size := node.Size()
+		if offset, guide, ok := dw.findAlignmentGuide(node, unsnapped.X, size.Width, true); ok {
+			target.X = unsnapped.X + offset
+			dw.guides = append(dw.guides, guide)
+		}
+		if offset, guide, ok := dw.findAlignmentGuide(node, unsnapped.Y, size.Height, false); ok {
+			target.Y = unsnapped.Y + offset
+			dw.guides = append(dw.guides, guide)
+		}
+	}
+	dw.dragResidual = unsnapped.Subtract(target)
+	return target.Subtract(position)
+}
+
+// findAlignmentGuide looks for another node whose start, center or end lines up (within the guide tolerance)
+// with the start, center or end of a node at the indicated position along the X (vertical guide) or Y axis.
+// It returns the offset needed to align the node and the guide line to display.
+func (dw *DiagramWidget) findAlignmentGuide(node DiagramNode, start float32, length float32, vertical bool) (float32, *canvas.Line, bool) {
+	found := false
+	bestOffset := float32(0)
+	var bestOther DiagramNode
+	for _, other := range dw.GetDiagramNodes() {
+		if other == node || !other.Visible() || areGroupRelatives(node, other) {
+			continue
+		}
+		otherStart, otherLength := other.Position().Y, other.Size().Height
+		if vertical {
+			otherStart, otherLength = other.Position().X, other.Size().Width
+		}
+		for _, edge := range []float32{0, length / 2, length} {
+			for _, otherEdge := range []float32{otherStart, otherStart + otherLength/2, otherStart + otherLength} {
+				offset := otherEdge - (start + edge)
+				if abs32(offset) <= guideTolerance && (!found || abs32(offset) < abs32(bestOffset)) {
+					found = true
+					bestOffset = offset
+					bestOther = other
+				}
+			}
+		}
+	}
+	if !found {
+		return 0, nil, false
+	}
+	// The guide line spans both nodes
+	line := canvas.NewLine(theme.Color(theme.ColorNamePrimary))
+	line.StrokeWidth = 1
+	nodeBox, otherBox := node.getBaseDiagramNode().R2Box(), bestOther.getBaseDiagramNode().R2Box()
+	for _, edge := range []float32{0, length / 2, length} {
+		coordinate := start + bestOffset + edge
+		if vertical {
+			if !isAlignedWith(coordinate, float32(otherBox.A.X), float32(otherBox.S.X)) {
+				continue
+			}
+			top := math.Min(float64(nodeBox.A.Y), otherBox.A.Y)
+			bottom := math.Max(float64(nodeBox.A.Y+nodeBox.S.Y), otherBox.A.Y+otherBox.S.Y)
+			line.Position1 = fyne.NewPos(coordinate, float32(top))
+			line.Position2 = fyne.NewPos(coordinate, float32(bottom))
+		} else {
+			if !isAlignedWith(coordinate, float32(otherBox.A.Y), float32(otherBox.S.Y)) {
+				continue
+			}
+			left := math.Min(float64(nodeBox.A.X), otherBox.A.X)
+			right := math.Max(float64(nodeBox.A.X+nodeBox.S.X), otherBox.A.X+otherBox.S.X)
+			line.Position1 = fyne.NewPos(float32(left), coordinate)
+			line.Position2 = fyne.NewPos(float32(right), coordinate)
+		}
+		break
+	}
+	return bestOffset, line, true
+}
+
+// isAlignedWith returns true if the coordinate coincides with the start, center or end of the span
+func isAlignedWith(coordinate float32, start float32, length float32) bool {
+	for _, edge := range []float32{start, start + length/2, start + length} {
+		if abs32(coordinate-edge) < 0.5 {
+			return true
+		}
+	}
+	return false
+}
+
+// clearAlignmentGuides removes any alignment guides from the display
+func (dw *DiagramWidget) clearAlignmentGuides() {
+	if len(dw.guides) > 0 {
+		dw.guides = nil
+		dw.drawingArea.Refresh()
+	}
+}
+
+func abs32(value float32) float32 {
+	return float32(math.Abs(float64(value)))
+}
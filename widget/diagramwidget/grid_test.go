@@ -0,0 +1,54 @@
+package diagramwidget
+
+import (
+	"math"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapToGrid(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	diagram.SnapToGrid = true
+	node1 := NewDiagramNode(diagram, widget.NewLabel("Node1"), "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	bdn := node1.getBaseDiagramNode()
+
+	for i := 0; i < 3; i++ {
+		bdn.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(7, 2)})
+	}
+	bdn.DragEnd()
+	assert.Equal(t, fyne.NewPos(120, 100), node1.Position())
+
+	// Resizing snaps the edge being dragged
+	bdn.handles["lowerRight"].Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(13, 0)})
+	bdn.handles["lowerRight"].DragEnd()
+	right := float64(node1.Position().X + node1.Size().Width)
+	assert.Equal(t, 0.0, math.Mod(right, float64(diagram.GridSpacing)))
+
+	// The grid is zoom-aware
+	diagram.SetZoomAt(2, fyne.NewPos(0, 0))
+	assert.Equal(t, float32(40), diagram.snapToGrid(45))
+}
+
+func TestAlignmentGuides(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	diagram.AlignmentGuidesEnabled = true
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 150))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(300, 100))
+	bdn := node1.getBaseDiagramNode()
+
+	bdn.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(0, -47)})
+	assert.Equal(t, fyne.NewPos(100, 100), node1.Position())
+	assert.Equal(t, 1, len(diagram.guides))
+	assert.Equal(t, float32(100), diagram.guides[0].Position1.Y)
+	bdn.DragEnd()
+	assert.Equal(t, 0, len(diagram.guides))
+}
@@ -0,0 +1,496 @@
+package diagramwidget
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Validate that BaseDiagramGroup implements DiagramGroup and DoubleTappable
+var _ DiagramGroup = (*BaseDiagramGroup)(nil)
+var _ fyne.DoubleTappable = (*BaseDiagramGroup)(nil)
+
+// groupMargin is the space (at zoom 1) between the children of an expanded group and its border
+const groupMargin float32 = 10
+
+// DiagramGroup is a DiagramNode that contains other DiagramNodes, which may themselves be groups
+type DiagramGroup interface {
+	DiagramNode
+	getBaseDiagramGroup() *BaseDiagramGroup
+	// AddChild makes the node a child of the group, removing it from any group it currently belongs to
+	AddChild(DiagramNode)
+	// GetChildren returns the nodes directly contained in the group
+	GetChildren() []DiagramNode
+	// IsCollapsed returns true if the group is displayed as a single node that hides its children
+	IsCollapsed() bool
+	// RemoveChild removes the node from the group. The node remains in the diagram.
+	RemoveChild(DiagramNode)
+	// SetCollapsed collapses or expands the group
+	SetCollapsed(bool)
+}
+
+// BaseDiagramGroup is a node that owns child nodes. When it is expanded, it is drawn as a box enclosing its
+// children with its title at the top, and it is resized automatically to fit them. Displacing the group (see
+// DiagramWidget.DisplaceNode) moves its children along with it. When it is collapsed, it is drawn as an
+// ordinary node, its children and the links between them are hidden, and links to the hidden children are
+// displayed as connected to the group's edge pad. Double-tapping a group collapses or expands it.
+type BaseDiagramGroup struct {
+	BaseDiagramNode
+	title     *widget.Label
+	children  []DiagramNode
+	collapsed bool
+	// collapsedInnerSize is the unscaled inner size of the group while it is collapsed
+	collapsedInnerSize fyne.Size
+}
+
+// NewDiagramGroup creates a DiagramGroup with the indicated title and adds it to the DiagramWidget. The
+// groupID must be unique across all of the DiagramElements in the diagram.
+func NewDiagramGroup(diagram *DiagramWidget, title string, groupID string) DiagramGroup {
+	group := &BaseDiagramGroup{}
+	InitializeBaseDiagramGroup(group, diagram, title, groupID)
+	return group
+}
+
+// InitializeBaseDiagramGroup is used to initialize the BaseDiagramGroup. It must be called by any extensions
+// to the BaseDiagramGroup
+func InitializeBaseDiagramGroup(group DiagramGroup, diagram *DiagramWidget, title string, groupID string) {
+	bdg := group.getBaseDiagramGroup()
+	bdg.title = widget.NewLabel(title)
+	bdg.children = []DiagramNode{}
+	InitializeBaseDiagramNode(group, diagram, bdg.title, groupID)
+	bdg.collapsedInnerSize = diagram.unscaleSize(bdg.InnerSize)
+}
+
+// AddChild makes the node a child of the group, removing it from any group it currently belongs to. The
+// group is resized to fit the node. A node cannot be added to itself or to one of its own descendants.
+func (bdg *BaseDiagramGroup) AddChild(node DiagramNode) {
+	bdn := node.getBaseDiagramNode()
+	if bdn.diagram != bdg.diagram || bdn.parent == bdg.typedGroup() || isAncestorOf(node, bdg.typedGroup()) {
+		return
+	}
+	bdg.diagram.setParentGroup(node, bdg.typedGroup())
+}
+
+// DoubleTapped collapses or expands the group
+func (bdg *BaseDiagramGroup) DoubleTapped(event *fyne.PointEvent) {
+	bdg.SetCollapsed(!bdg.collapsed)
+}
+
+// fitToChildren sets the geometry of an expanded group so that it encloses its children, leaving room for the
+// title. The geometry of a collapsed group is not affected by its children.
+func (bdg *BaseDiagramGroup) fitToChildren() {
+	if bdg.collapsed || len(bdg.children) == 0 {
+		return
+	}
+	left, top := math.Inf(1), math.Inf(1)
+	right, bottom := math.Inf(-1), math.Inf(-1)
+	for _, child := range bdg.children {
+		box := child.getBaseDiagramNode().R2Box()
+		left = math.Min(left, box.A.X)
+		top = math.Min(top, box.A.Y)
+		right = math.Max(right, box.A.X+box.S.X)
+		bottom = math.Max(bottom, box.A.Y+box.S.Y)
+	}
+	margin := float64(bdg.diagram.scaled(groupMargin))
+	padding := float64(bdg.scaledPadding())
+	titleHeight := float64(bdg.title.MinSize().Height)
+	position := fyne.NewPos(float32(left-margin-padding), float32(top-margin-titleHeight-padding))
+	bdg.InnerSize = fyne.NewSize(float32(right-left+2*margin), float32(bottom-top+2*margin+titleHeight))
+	if position != bdg.Position() {
+		bdg.BaseDiagramNode.Move(position)
+	} else {
+		bdg.refreshParentGroup()
+	}
+	bdg.Refresh()
+}
+
+func (bdg *BaseDiagramGroup) getBaseDiagramGroup() *BaseDiagramGroup {
+	return bdg
+}
+
+// GetChildren returns the nodes directly contained in the group
+func (bdg *BaseDiagramGroup) GetChildren() []DiagramNode {
+	return append([]DiagramNode(nil), bdg.children...)
+}
+
+// getDescendants returns all of the nodes contained in the group, directly or indirectly
+func (bdg *BaseDiagramGroup) getDescendants() []DiagramNode {
+	descendants := []DiagramNode{}
+	for _, child := range bdg.children {
+		descendants = append(descendants, child)
+		if group, ok := child.(DiagramGroup); ok {
+			descendants = append(descendants, group.getBaseDiagramGroup().getDescendants()...)
+		}
+	}
+	return descendants
+}
+
+// GetTitle returns the title of the group
+func (bdg *BaseDiagramGroup) GetTitle() string {
+	return bdg.title.Text
+}
+
+// IsCollapsed returns true if the group is collapsed
+func (bdg *BaseDiagramGroup) IsCollapsed() bool {
+	return bdg.collapsed
+}
+
+// RemoveChild removes the node from the group. The node remains in the diagram as a node that does not
+// belong to any group.
+func (bdg *BaseDiagramGroup) RemoveChild(node DiagramNode) {
+	if node.getBaseDiagramNode().parent != bdg.typedGroup() {
+		return
+	}
+	bdg.diagram.setParentGroup(node, nil)
+}
+
+// SetCollapsed collapses or expands the group. While the group is collapsed, its children and the links
+// between them are hidden, and links to the children are displayed as connected to the group's edge pad.
+func (bdg *BaseDiagramGroup) SetCollapsed(collapsed bool) {
+	if bdg.collapsed == collapsed {
+		return
+	}
+	name := "Collapse"
+	if !collapsed {
+		name = "Expand"
+	}
+	bdg.diagram.recordCommand(name, &collapseCommand{group: bdg.typedGroup(), collapsed: collapsed})
+	bdg.collapsed = collapsed
+	if collapsed {
+		// The group keeps its position and takes the size it had when it was last collapsed
+		bdg.InnerSize = bdg.diagram.scaleSize(bdg.collapsedInnerSize)
+		bdg.Refresh()
+		bdg.refreshParentGroup()
+	} else {
+		bdg.collapsedInnerSize = bdg.diagram.unscaleSize(bdg.InnerSize)
+		bdg.fitToChildren()
+	}
+	bdg.diagram.updateGroupVisibility()
+	bdg.diagram.refreshDependentLinks(bdg)
+	bdg.diagram.adjustBounds()
+}
+
+// SetTitle sets the title of the group
+func (bdg *BaseDiagramGroup) SetTitle(title string) {
+	bdg.title.SetText(title)
+	bdg.fitToChildren()
+}
+
+// typedGroup returns the group as the DiagramGroup that was initialized, which may be an extension of
+// the BaseDiagramGroup
+func (bdg *BaseDiagramGroup) typedGroup() DiagramGroup {
+	return bdg.typedNode.(DiagramGroup)
+}
+
+// getOutermostCollapsedGroup returns the outermost collapsed group containing the node, or nil if none of
+// the groups containing the node is collapsed
+func getOutermostCollapsedGroup(node DiagramNode) DiagramGroup {
+	var collapsedGroup DiagramGroup
+	for group := node.GetParentGroup(); group != nil; group = group.GetParentGroup() {
+		if group.IsCollapsed() {
+			collapsedGroup = group
+		}
+	}
+	return collapsedGroup
+}
+
+// getDisplayedPad returns the pad at which a link end connected to the pad is displayed: the edge pad of
+// the outermost collapsed group containing the pad's owner or, if there is none, the pad itself
+func getDisplayedPad(pad ConnectionPad) ConnectionPad {
+	if pad == nil {
+		return nil
+	}
+	if node, ok := pad.GetPadOwner().(DiagramNode); ok {
+		if group := getOutermostCollapsedGroup(node); group != nil {
+			return group.GetEdgePad()
+		}
+	}
+	return pad
+}
+
+// isAncestorOf returns true if the node is the group or contains it, directly or indirectly
+func isAncestorOf(node DiagramNode, group DiagramGroup) bool {
+	for ancestor := DiagramNode(group); ancestor != nil; {
+		if ancestor.getBaseDiagramNode() == node.getBaseDiagramNode() {
+			return true
+		}
+		parent := ancestor.GetParentGroup()
+		if parent == nil {
+			return false
+		}
+		ancestor = parent
+	}
+	return false
+}
+
+// arrangeGroupOrder reorders the display list so that each group is drawn behind its children and behind
+// the links within it, preserving the relative order of the elements otherwise. It does nothing when the
+// diagram contains no groups.
+func (dw *DiagramWidget) arrangeGroupOrder() {
+	if dw.groupCount == 0 {
+		return
+	}
+	elements := dw.GetDiagramElements()
+	members := map[string][]DiagramElement{}
+	grouped := false
+	for _, element := range elements {
+		parentID := ""
+		if group := dw.getDisplayGroup(element); group != nil {
+			parentID = group.GetDiagramElementID()
+			grouped = true
+		}
+		members[parentID] = append(members[parentID], element)
+	}
+	if !grouped {
+		return
+	}
+	ordered := make([]DiagramElement, 0, len(elements))
+	var appendMembers func(parentID string)
+	appendMembers = func(parentID string) {
+		for _, element := range members[parentID] {
+			ordered = append(ordered, element)
+			if _, ok := element.(DiagramGroup); ok {
+				appendMembers(element.GetDiagramElementID())
+			}
+		}
+	}
+	appendMembers("")
+	if len(ordered) != len(elements) {
+		// an element belongs to a group that is not in the diagram
+		return
+	}
+	for i := range ordered {
+		if ordered[i] != elements[i] {
+			dw.setDisplayOrder(ordered)
+			return
+		}
+	}
+}
+
+// getDisplayGroup returns the innermost group in front of which the element must be drawn: for a node, the
+// group it belongs to; for a link, the innermost group that contains (or is) the owners of both of its ends.
+func (dw *DiagramWidget) getDisplayGroup(element DiagramElement) DiagramGroup {
+	if node, ok := element.(DiagramNode); ok {
+		return node.GetParentGroup()
+	}
+	link, ok := element.(DiagramLink)
+	if !ok {
+		return nil
+	}
+	// enclosingGroups lists the groups in front of which a link end must be drawn, innermost first
+	enclosingGroups := func(pad ConnectionPad) []DiagramGroup {
+		groups := []DiagramGroup{}
+		if pad == nil {
+			return groups
+		}
+		node, ok := pad.GetPadOwner().(DiagramNode)
+		if !ok {
+			return groups
+		}
+		if group, ok := node.getBaseDiagramNode().typedNode.(DiagramGroup); ok {
+			groups = append(groups, group)
+		}
+		for group := node.GetParentGroup(); group != nil; group = group.GetParentGroup() {
+			groups = append(groups, group)
+		}
+		return groups
+	}
+	bdl := link.getBaseDiagramLink()
+	sourceGroups := enclosingGroups(bdl.sourcePad)
+	targetGroups := enclosingGroups(bdl.targetPad)
+	switch {
+	case bdl.sourcePad == nil && len(targetGroups) > 0:
+		return targetGroups[0]
+	case bdl.targetPad == nil && len(sourceGroups) > 0:
+		return sourceGroups[0]
+	}
+	for _, sourceGroup := range sourceGroups {
+		for _, targetGroup := range targetGroups {
+			if sourceGroup == targetGroup {
+				return sourceGroup
+			}
+		}
+	}
+	return nil
+}
+
+// isLinkHiddenByGroup returns true if the link lies entirely within a collapsed group or is connected to a
+// link that is hidden
+func (dw *DiagramWidget) isLinkHiddenByGroup(link DiagramLink) bool {
+	bdl := link.getBaseDiagramLink()
+	for _, pad := range []ConnectionPad{bdl.sourcePad, bdl.targetPad} {
+		if pad != nil && pad.GetPadOwner().IsLink() && !pad.GetPadOwner().Visible() {
+			return true
+		}
+	}
+	displayedSource := getDisplayedPad(bdl.sourcePad)
+	displayedTarget := getDisplayedPad(bdl.targetPad)
+	return displayedSource != nil && displayedSource == displayedTarget &&
+		(displayedSource != bdl.sourcePad || displayedTarget != bdl.targetPad)
+}
+
+// areGroupRelatives returns true if either node contains the other, directly or indirectly
+func areGroupRelatives(node1 DiagramNode, node2 DiagramNode) bool {
+	contains := func(outer DiagramNode, inner DiagramNode) bool {
+		for group := inner.GetParentGroup(); group != nil; group = group.GetParentGroup() {
+			if group.getBaseDiagramNode() == outer.getBaseDiagramNode() {
+				return true
+			}
+		}
+		return false
+	}
+	return contains(node1, node2) || contains(node2, node1)
+}
+
+// isInSelectedGroup returns true if any of the groups containing the node is in the selection
+func isInSelectedGroup(node DiagramNode, selection map[string]DiagramElement) bool {
+	for group := node.GetParentGroup(); group != nil; group = group.GetParentGroup() {
+		if selection[group.GetDiagramElementID()] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// removeGroupRelativesFromSelection removes from the selection the groups containing the element and, if the
+// element is a group, the nodes it contains, so that a group and its contents are never selected together
+func (dw *DiagramWidget) removeGroupRelativesFromSelection(de DiagramElement) {
+	node, ok := de.(DiagramNode)
+	if !ok {
+		return
+	}
+	for group := node.GetParentGroup(); group != nil; group = group.GetParentGroup() {
+		dw.removeElementFromSelection(group)
+	}
+	if group, ok := node.getBaseDiagramNode().typedNode.(DiagramGroup); ok {
+		for _, descendant := range group.getBaseDiagramGroup().getDescendants() {
+			dw.removeElementFromSelection(descendant)
+		}
+	}
+}
+
+// setParentGroup moves the node into the indicated group, or out of any group if the group is nil
+func (dw *DiagramWidget) setParentGroup(node DiagramNode, group DiagramGroup) {
+	oldGroup := node.GetParentGroup()
+	if oldGroup == group {
+		return
+	}
+	dw.recordCommand("Group", &parentGroupCommand{node: node, oldGroup: oldGroup, newGroup: group})
+	detachFromGroup(node)
+	if group != nil {
+		attachToGroup(node, group)
+		if dw.IsSelected(group) {
+			dw.removeElementFromSelection(node)
+		}
+	}
+	dw.arrangeGroupOrder()
+	dw.updateGroupVisibility()
+	dw.adjustBounds()
+}
+
+// attachToGroup adds the node to the children of the group and resizes the group to fit it
+func attachToGroup(node DiagramNode, group DiagramGroup) {
+	bdg := group.getBaseDiagramGroup()
+	node.getBaseDiagramNode().parent = group
+	bdg.children = append(bdg.children, node)
+	bdg.fitToChildren()
+}
+
+// detachFromGroup removes the node from the group to which it belongs, if any, and resizes the group to
+// fit its remaining children. It returns the group.
+func detachFromGroup(node DiagramNode) DiagramGroup {
+	bdn := node.getBaseDiagramNode()
+	group := bdn.parent
+	if group == nil {
+		return nil
+	}
+	bdg := group.getBaseDiagramGroup()
+	for i, child := range bdg.children {
+		if child.getBaseDiagramNode() == bdn {
+			bdg.children = append(bdg.children[:i], bdg.children[i+1:]...)
+			break
+		}
+	}
+	bdn.parent = nil
+	bdg.fitToChildren()
+	return group
+}
+
+// updateGroupVisibility hides the nodes within collapsed groups and the links that lie entirely within
+// collapsed groups, and shows the grouped nodes and links that are no longer hidden. Hidden elements are
+// removed from the selection.
+func (dw *DiagramWidget) updateGroupVisibility() {
+	affectedLinks := []DiagramLink{}
+	for _, link := range dw.GetDiagramLinks() {
+		bdl := link.getBaseDiagramLink()
+		for _, pad := range []ConnectionPad{bdl.sourcePad, bdl.targetPad} {
+			if pad == nil {
+				continue
+			}
+			if node, ok := pad.GetPadOwner().(DiagramNode); (ok && node.GetParentGroup() != nil) || pad.GetPadOwner().IsLink() {
+				affectedLinks = append(affectedLinks, link)
+				break
+			}
+		}
+	}
+	setVisible := func(element DiagramElement, visible bool) bool {
+		if element.Visible() == visible {
+			return false
+		}
+		if visible {
+			element.Show()
+		} else {
+			element.Hide()
+			dw.removeElementFromSelection(element)
+		}
+		return true
+	}
+	for _, node := range dw.GetDiagramNodes() {
+		if node.GetParentGroup() != nil {
+			setVisible(node, getOutermostCollapsedGroup(node) == nil)
+		}
+	}
+	// Links may connect to other links, so we repeat until no more visibility changes occur
+	for changed := true; changed; {
+		changed = false
+		for _, link := range affectedLinks {
+			if setVisible(link, !dw.isLinkHiddenByGroup(link)) {
+				changed = true
+			}
+		}
+	}
+	for _, link := range affectedLinks {
+		link.Refresh()
+	}
+	dw.drawingArea.Refresh()
+}
+
+// collapseCommand records the collapsing or expanding of a group
+type collapseCommand struct {
+	group     DiagramGroup
+	collapsed bool
+}
+
+func (c *collapseCommand) undo() {
+	c.group.SetCollapsed(!c.collapsed)
+}
+
+func (c *collapseCommand) redo() {
+	c.group.SetCollapsed(c.collapsed)
+}
+
+// parentGroupCommand records a change in the group to which a node belongs
+type parentGroupCommand struct {
+	node     DiagramNode
+	oldGroup DiagramGroup
+	newGroup DiagramGroup
+}
+
+func (c *parentGroupCommand) undo() {
+	c.node.getBaseDiagramNode().diagram.setParentGroup(c.node, c.oldGroup)
+}
+
+func (c *parentGroupCommand) redo() {
+	c.node.getBaseDiagramNode().diagram.setParentGroup(c.node, c.newGroup)
+}
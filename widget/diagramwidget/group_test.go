@@ -0,0 +1,145 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupEnclosesAndMovesChildren(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(200, 150))
+	outside := NewDiagramNode(diagram, nil, "Outside")
+	outside.Move(fyne.NewPos(400, 100))
+	group := NewDiagramGroup(diagram, "Group", "Group1")
+	group.AddChild(node1)
+	group.AddChild(node2)
+	link1 := NewDiagramLink(diagram, "Link1")
+	link1.SetSourcePad(node1.GetEdgePad())
+	link1.SetTargetPad(node2.GetEdgePad())
+	link2 := NewDiagramLink(diagram, "Link2")
+	link2.SetSourcePad(node2.GetEdgePad())
+	link2.SetTargetPad(outside.GetEdgePad())
+	diagram.ClearUndoHistory()
+
+	assert.Equal(t, DiagramGroup(group), node1.GetParentGroup())
+	assert.Equal(t, 2, len(group.GetChildren()))
+	groupBox := group.getBaseDiagramNode().R2Box()
+	for _, child := range []DiagramNode{node1, node2} {
+		childBox := child.getBaseDiagramNode().R2Box()
+		assert.True(t, groupBox.A.X < childBox.A.X && groupBox.A.Y < childBox.A.Y)
+		assert.True(t, groupBox.A.X+groupBox.S.X > childBox.A.X+childBox.S.X)
+		assert.True(t, groupBox.A.Y+groupBox.S.Y > childBox.A.Y+childBox.S.Y)
+	}
+	// The group is drawn behind its children and the link between them
+	elements := diagram.GetDiagramElements()
+	indexOf := func(id string) int {
+		for i, element := range elements {
+			if element.GetDiagramElementID() == id {
+				return i
+			}
+		}
+		return -1
+	}
+	assert.Less(t, indexOf("Group1"), indexOf("Node1"))
+	assert.Less(t, indexOf("Group1"), indexOf("Link1"))
+
+	// Moving the group moves its children
+	groupPosition := group.Position()
+	diagram.DisplaceNode(group, fyne.NewPos(30, 40))
+	assert.Equal(t, fyne.NewPos(130, 140), node1.Position())
+	assert.Equal(t, fyne.NewPos(230, 190), node2.Position())
+	assert.Equal(t, groupPosition.AddXY(30, 40), group.Position())
+	diagram.Undo()
+	assert.Equal(t, fyne.NewPos(100, 100), node1.Position())
+	assert.Equal(t, groupPosition, group.Position())
+
+	// Moving a child resizes the group
+	groupSize := group.Size()
+	diagram.DisplaceNode(node2, fyne.NewPos(50, 0))
+	assert.Equal(t, groupSize.Width+50, group.Size().Width)
+
+	// A group and its contents are not selected together
+	diagram.SelectDiagramElement(node1)
+	diagram.ElementTappedExtendsSelection = true
+	diagram.DiagramElementTapped(group)
+	assert.True(t, diagram.IsSelected(group))
+	assert.False(t, diagram.IsSelected(node1))
+}
+
+func TestCollapseGroup(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(200, 150))
+	outside := NewDiagramNode(diagram, nil, "Outside")
+	outside.Move(fyne.NewPos(400, 100))
+	group := NewDiagramGroup(diagram, "Group", "Group1")
+	group.AddChild(node1)
+	group.AddChild(node2)
+	link1 := NewDiagramLink(diagram, "Link1")
+	link1.SetSourcePad(node1.GetEdgePad())
+	link1.SetTargetPad(node2.GetEdgePad())
+	link2 := NewDiagramLink(diagram, "Link2")
+	link2.SetSourcePad(node2.GetEdgePad())
+	link2.SetTargetPad(outside.GetEdgePad())
+	diagram.ClearUndoHistory()
+	position := group.Position()
+
+	group.SetCollapsed(true)
+	assert.True(t, group.IsCollapsed())
+	assert.Equal(t, position, group.Position())
+	assert.False(t, node1.Visible())
+	assert.False(t, node2.Visible())
+	assert.False(t, link1.Visible())
+	assert.True(t, link2.Visible())
+	// The link to the hidden child is displayed as connected to the group
+	sourcePoint := link2.getBaseDiagramLink().getSourcePosition().Add(link2.Position())
+	groupBox := group.getBaseDiagramNode().R2Box()
+	assert.InDelta(t, groupBox.A.X+groupBox.S.X, float64(sourcePoint.X), 1)
+	assert.Equal(t, node2.GetEdgePad(), link2.GetSourcePad())
+
+	// The hidden children move with the collapsed group
+	diagram.DisplaceNode(group, fyne.NewPos(0, 100))
+	assert.Equal(t, fyne.NewPos(100, 200), node1.Position())
+
+	diagram.Undo()
+	diagram.Undo()
+	assert.False(t, group.IsCollapsed())
+	assert.True(t, node1.Visible())
+	assert.True(t, link1.Visible())
+
+	// Removing the group removes its contents, and undo restores the hierarchy
+	diagram.RemoveElement("Group1")
+	assert.Nil(t, diagram.GetDiagramElement("Node1"))
+	assert.Nil(t, diagram.GetDiagramElement("Link2"))
+	assert.NotNil(t, diagram.GetDiagramElement(outside.GetDiagramElementID()))
+	assert.Equal(t, 0, diagram.groupCount)
+	diagram.Undo()
+	assert.Equal(t, 6, len(diagram.GetDiagramElements()))
+	assert.Equal(t, 1, diagram.groupCount)
+	assert.Equal(t, DiagramGroup(group), node2.GetParentGroup())
+	assert.Equal(t, 2, len(group.GetChildren()))
+
+	// The hierarchy and collapsed state survive serialization
+	group.SetCollapsed(true)
+	data, err := Marshal(diagram)
+	assert.NoError(t, err)
+	restored := NewDiagramWidget("Diagram2")
+	assert.NoError(t, Unmarshal(data, restored))
+	restoredGroup, ok := restored.GetDiagramNode("Group1").(DiagramGroup)
+	assert.True(t, ok)
+	assert.True(t, restoredGroup.IsCollapsed())
+	assert.Equal(t, "Group", restoredGroup.getBaseDiagramGroup().GetTitle())
+	assert.Equal(t, restoredGroup, restored.GetDiagramNode("Node1").GetParentGroup())
+	assert.False(t, restored.GetDiagramLink("Link1").Visible())
+	assert.Equal(t, group.Position(), restoredGroup.Position())
+}
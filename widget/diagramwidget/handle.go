@@ -8,8 +8,9 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
-// Validate implementation of Draggable
+// Validate implementation of Draggable and SecondaryTappable
 var _ fyne.Draggable = (*Handle)(nil)
+var _ fyne.SecondaryTappable = (*Handle)(nil)
 
 var defaultHandleSize float32 = 10.0
 
@@ -52,6 +53,13 @@ func (h *Handle) DragEnd() {
 	h.de.handleDragEnd(h)
 }
 
+// TappedSecondary passes the event on to the owning DiagramElement if it responds to it, e.g. with a context menu
+func (h *Handle) TappedSecondary(event *fyne.PointEvent) {
+	if responder, ok := h.de.(secondaryTapHandleResponder); ok {
+		responder.handleTappedSecondary(h, event)
+	}
+}
+
 func (h *Handle) getStrokeColor() color.Color {
 	return h.de.GetDiagram().GetForegroundColor()
 }
@@ -66,6 +74,11 @@ func (h *Handle) Move(position fyne.Position) {
 	h.BaseWidget.Move(position.Add(delta))
 }
 
+// secondaryTapHandleResponder is implemented by the DiagramElements that respond to secondary taps on their handles
+type secondaryTapHandleResponder interface {
+	handleTappedSecondary(handle *Handle, event *fyne.PointEvent)
+}
+
 // handleRenderer
 type handleRenderer struct {
 	handle *Handle
@@ -0,0 +1,435 @@
+package diagramwidget
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// LayoutDirection indicates the direction in which the layers of a hierarchical layout are arranged
+type LayoutDirection int
+
+const (
+	// LayoutTopDown places the first layer at the top and the links flow downward
+	LayoutTopDown LayoutDirection = iota
+	// LayoutLeftRight places the first layer at the left and the links flow to the right
+	LayoutLeftRight
+)
+
+// HierarchicalLayoutOptions control the behavior of HierarchicalLayout
+type HierarchicalLayoutOptions struct {
+	// Direction determines whether the layers are stacked vertically or horizontally
+	Direction LayoutDirection
+	// LayerSpacing is the distance between adjacent layers
+	LayerSpacing float32
+	// NodeSpacing is the minimum distance between adjacent nodes in the same layer
+	NodeSpacing float32
+	// CrossingMinimizationPasses is the number of down and up sweeps used to reduce link crossings
+	CrossingMinimizationPasses int
+	// Animate moves the nodes smoothly to their new positions over the AnimationDuration
+	Animate bool
+	// AnimationDuration is the duration of the animation when Animate is true
+	AnimationDuration time.Duration
+}
+
+// NewHierarchicalLayoutOptions returns the default options for a top-down hierarchical layout
+func NewHierarchicalLayoutOptions() HierarchicalLayoutOptions {
+	return HierarchicalLayoutOptions{
+		Direction:                  LayoutTopDown,
+		LayerSpacing:               60,
+		NodeSpacing:                30,
+		CrossingMinimizationPasses: 8,
+		AnimationDuration:          500 * time.Millisecond,
+	}
+}
+
+// layoutVertex is a node in the layered graph. Dummy vertices (with a nil node) are inserted where a
+// link spans more than one layer so that the crossing minimization takes such links into account.
+type layoutVertex struct {
+	node  DiagramNode
+	layer int
+	order int
+	// position is the coordinate of the vertex center across the layer direction
+	position float64
+	// breadth is the extent of the vertex across the layer direction, depth is its extent along it
+	breadth float64
+	depth   float64
+	up      []*layoutVertex
+	down    []*layoutVertex
+}
+
+// HierarchicalLayout arranges the diagram's nodes in layers (a Sugiyama-style layout) so that links
+// between nodes flow in the indicated direction. Cycles are broken by reversing links, nodes are assigned
+// to layers by longest path, the order within each layer is chosen to reduce link crossings, and the nodes
+// are then positioned close to the nodes they are linked with. Only links whose ends are both connected
+// to nodes affect the layout. Groups are not laid out: they fit themselves to their children. The result is a
+// single undo entry.
+func HierarchicalLayout(dw *DiagramWidget, options HierarchicalLayoutOptions) {
+	nodes := []DiagramNode{}
+	for _, node := range dw.GetDiagramNodes() {
+		if _, ok := node.(DiagramGroup); ok {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		return
+	}
+	layers := buildLayers(dw, nodes, options.Direction)
+	minimizeCrossings(layers, options.CrossingMinimizationPasses)
+	// The spacing is specified at zoom 1
+	nodeSpacing := float64(dw.scaled(options.NodeSpacing))
+	layerSpacing := float64(dw.scaled(options.LayerSpacing))
+	assignLayerPositions(layers, nodeSpacing)
+
+	// Compute the position along the layer direction
+	margin := nodeSpacing
+	targets := map[DiagramNode]fyne.Position{}
+	layerStart := margin
+	for _, layer := range layers {
+		layerDepth := 0.0
+		for _, v := range layer {
+			layerDepth = math.Max(layerDepth, v.depth)
+		}
+		for _, v := range layer {
+			if v.node == nil {
+				continue
+			}
+			along := layerStart + (layerDepth-v.depth)/2
+			across := v.position - v.breadth/2
+			if options.Direction == LayoutLeftRight {
+				targets[v.node] = fyne.NewPos(float32(along), float32(across))
+			} else {
+				targets[v.node] = fyne.NewPos(float32(across), float32(along))
+			}
+		}
+		layerStart += layerDepth + layerSpacing
+	}
+	applyLayoutTargets(dw, nodes, targets, options.Animate, options.AnimationDuration)
+}
+
+// applyLayoutTargets moves the nodes to the target positions as a single undo entry, optionally animating the movement
+func applyLayoutTargets(dw *DiagramWidget, nodes []DiagramNode, targets map[DiagramNode]fyne.Position, animate bool, duration time.Duration) {
+	if !animate {
+		dw.StartUndoGroup("Layout")
+		for _, node := range nodes {
+			dw.DisplaceNode(node, targets[node].Subtract(node.Position()))
+		}
+		dw.EndUndoGroup()
+		return
+	}
+	starts := map[DiagramNode]fyne.Position{}
+	dw.StartUndoGroup("Layout")
+	for _, node := range nodes {
+		starts[node] = node.Position()
+		dw.recordCommand("Layout", newDisplaceNodeCommand(dw, node, targets[node].Subtract(node.Position())))
+	}
+	dw.EndUndoGroup()
+	animation := fyne.NewAnimation(duration, func(progress float32) {
+		dw.suspendUndoRecording()
+		defer dw.resumeUndoRecording()
+		for _, node := range nodes {
+			start := starts[node]
+			delta := targets[node].Subtract(start)
+			desired := start.AddXY(delta.X*progress, delta.Y*progress)
+			dw.DisplaceNode(node, desired.Subtract(node.Position()))
+		}
+	})
+	animation.Curve = fyne.AnimationEaseInOut
+	animation.Start()
+}
+
+// buildLayers breaks cycles, assigns the nodes to layers and inserts dummy vertices for long links
+func buildLayers(dw *DiagramWidget, nodes []DiagramNode, direction LayoutDirection) [][]*layoutVertex {
+	vertices := []*layoutVertex{}
+	vertexIndex := map[DiagramElement]int{}
+	for i, node := range nodes {
+		size := node.Size()
+		if size.IsZero() {
+			size = node.MinSize()
+		}
+		vertex := &layoutVertex{node: node, breadth: float64(size.Width), depth: float64(size.Height)}
+		if direction == LayoutLeftRight {
+			vertex.breadth, vertex.depth = vertex.depth, vertex.breadth
+		}
+		vertices = append(vertices, vertex)
+		vertexIndex[node] = i
+	}
+	edges := layoutEdges(dw, vertexIndex)
+	edges = breakCycles(len(vertices), edges)
+
+	// Longest path layering in topological order
+	successors := make([][]int, len(vertices))
+	inDegree := make([]int, len(vertices))
+	for _, edge := range edges {
+		successors[edge[0]] = append(successors[edge[0]], edge[1])
+		inDegree[edge[1]]++
+	}
+	queue := []int{}
+	for i := range vertices {
+		if inDegree[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	maxLayer := 0
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, v := range successors[u] {
+			if vertices[u].layer+1 > vertices[v].layer {
+				vertices[v].layer = vertices[u].layer + 1
+			}
+			inDegree[v]--
+			if inDegree[v] == 0 {
+				queue = append(queue, v)
+			}
+		}
+		if vertices[u].layer > maxLayer {
+			maxLayer = vertices[u].layer
+		}
+	}
+
+	layers := make([][]*layoutVertex, maxLayer+1)
+	for _, vertex := range vertices {
+		layers[vertex.layer] = append(layers[vertex.layer], vertex)
+	}
+	for _, edge := range edges {
+		from := vertices[edge[0]]
+		to := vertices[edge[1]]
+		previous := from
+		for layer := from.layer + 1; layer < to.layer; layer++ {
+			dummy := &layoutVertex{layer: layer}
+			layers[layer] = append(layers[layer], dummy)
+			previous.down = append(previous.down, dummy)
+			dummy.up = append(dummy.up, previous)
+			previous = dummy
+		}
+		previous.down = append(previous.down, to)
+		to.up = append(to.up, previous)
+	}
+	for _, layer := range layers {
+		for i, vertex := range layer {
+			vertex.order = i
+		}
+	}
+	return layers
+}
+
+// layoutEdges returns the distinct node-to-node connections as pairs of vertex indices
+func layoutEdges(dw *DiagramWidget, vertexIndex map[DiagramElement]int) [][2]int {
+	edges := [][2]int{}
+	seen := map[[2]int]bool{}
+	for _, link := range dw.GetDiagramLinks() {
+		sourcePad := link.GetSourcePad()
+		targetPad := link.GetTargetPad()
+		if sourcePad == nil || targetPad == nil {
+			continue
+		}
+		from, ok1 := vertexIndex[sourcePad.GetPadOwner()]
+		to, ok2 := vertexIndex[targetPad.GetPadOwner()]
+		if !ok1 || !ok2 || from == to {
+			continue
+		}
+		edge := [2]int{from, to}
+		if !seen[edge] {
+			seen[edge] = true
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// breakCycles reverses the edges that close a cycle in a depth-first traversal so that the graph is acyclic
+func breakCycles(vertexCount int, edges [][2]int) [][2]int {
+	successors := make([][]int, vertexCount)
+	for i, edge := range edges {
+		successors[edge[0]] = append(successors[edge[0]], i)
+	}
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make([]int, vertexCount)
+	reversed := make([]bool, len(edges))
+	var visit func(u int)
+	visit = func(u int) {
+		state[u] = onStack
+		for _, edgeIndex := range successors[u] {
+			v := edges[edgeIndex][1]
+			switch state[v] {
+			case onStack:
+				reversed[edgeIndex] = true
+			case unvisited:
+				visit(v)
+			}
+		}
+		state[u] = done
+	}
+	for u := 0; u < vertexCount; u++ {
+		if state[u] == unvisited {
+			visit(u)
+		}
+	}
+	result := [][2]int{}
+	seen := map[[2]int]bool{}
+	for i, edge := range edges {
+		if reversed[i] {
+			edge = [2]int{edge[1], edge[0]}
+		}
+		if !seen[edge] {
+			seen[edge] = true
+			result = append(result, edge)
+		}
+	}
+	return result
+}
+
+// minimizeCrossings reorders the vertices within each layer using the barycenter heuristic, sweeping down
+// and up the layers, and keeps the ordering with the fewest crossings
+func minimizeCrossings(layers [][]*layoutVertex, passes int) {
+	best := snapshotOrder(layers)
+	bestCrossings := countCrossings(layers)
+	for pass := 0; pass < passes && bestCrossings > 0; pass++ {
+		for i := 1; i < len(layers); i++ {
+			orderByBarycenter(layers[i], func(v *layoutVertex) []*layoutVertex { return v.up })
+		}
+		for i := len(layers) - 2; i >= 0; i-- {
+			orderByBarycenter(layers[i], func(v *layoutVertex) []*layoutVertex { return v.down })
+		}
+		if crossings := countCrossings(layers); crossings < bestCrossings {
+			bestCrossings = crossings
+			best = snapshotOrder(layers)
+		}
+	}
+	for i := range layers {
+		layers[i] = best[i]
+		for j, vertex := range layers[i] {
+			vertex.order = j
+		}
+	}
+}
+
+func orderByBarycenter(layer []*layoutVertex, neighbors func(*layoutVertex) []*layoutVertex) {
+	barycenters := map[*layoutVertex]float64{}
+	for _, vertex := range layer {
+		adjacent := neighbors(vertex)
+		if len(adjacent) == 0 {
+			barycenters[vertex] = float64(vertex.order)
+			continue
+		}
+		sum := 0.0
+		for _, neighbor := range adjacent {
+			sum += float64(neighbor.order)
+		}
+		barycenters[vertex] = sum / float64(len(adjacent))
+	}
+	sort.SliceStable(layer, func(i, j int) bool { return barycenters[layer[i]] < barycenters[layer[j]] })
+	for i, vertex := range layer {
+		vertex.order = i
+	}
+}
+
+func snapshotOrder(layers [][]*layoutVertex) [][]*layoutVertex {
+	snapshot := make([][]*layoutVertex, len(layers))
+	for i, layer := range layers {
+		snapshot[i] = append([]*layoutVertex(nil), layer...)
+	}
+	return snapshot
+}
+
+// countCrossings returns the number of crossings between the edges of adjacent layers
+func countCrossings(layers [][]*layoutVertex) int {
+	crossings := 0
+	for i := 0; i < len(layers)-1; i++ {
+		edges := [][2]int{}
+		for _, vertex := range layers[i] {
+			for _, neighbor := range vertex.down {
+				edges = append(edges, [2]int{vertex.order, neighbor.order})
+			}
+		}
+		for a := 0; a < len(edges); a++ {
+			for b := a + 1; b < len(edges); b++ {
+				if (edges[a][0]-edges[b][0])*(edges[a][1]-edges[b][1]) < 0 {
+					crossings++
+				}
+			}
+		}
+	}
+	return crossings
+}
+
+// assignLayerPositions computes the position of each vertex across the layer direction. The vertices are
+// first packed in order and then repeatedly pulled towards the average position of their neighbors while
+// keeping their order and the minimum spacing.
+func assignLayerPositions(layers [][]*layoutVertex, spacing float64) {
+	for _, layer := range layers {
+		position := 0.0
+		for i, vertex := range layer {
+			if i > 0 {
+				position += layer[i-1].breadth/2 + spacing + vertex.breadth/2
+			}
+			vertex.position = position
+		}
+	}
+	for iteration := 0; iteration < 8; iteration++ {
+		if iteration%2 == 0 {
+			for i := 1; i < len(layers); i++ {
+				alignLayer(layers[i], func(v *layoutVertex) []*layoutVertex { return v.up }, spacing)
+			}
+		} else {
+			for i := len(layers) - 2; i >= 0; i-- {
+				alignLayer(layers[i], func(v *layoutVertex) []*layoutVertex { return v.down }, spacing)
+			}
+		}
+	}
+	// Shift everything so that the left-most edge is at the spacing distance from the origin
+	minimum := math.Inf(1)
+	for _, layer := range layers {
+		for _, vertex := range layer {
+			minimum = math.Min(minimum, vertex.position-vertex.breadth/2)
+		}
+	}
+	for _, layer := range layers {
+		for _, vertex := range layer {
+			vertex.position += spacing - minimum
+		}
+	}
+}
+
+func alignLayer(layer []*layoutVertex, neighbors func(*layoutVertex) []*layoutVertex, spacing float64) {
+	if len(layer) == 0 {
+		return
+	}
+	desired := make([]float64, len(layer))
+	for i, vertex := range layer {
+		desired[i] = vertex.position
+		if adjacent := neighbors(vertex); len(adjacent) > 0 {
+			sum := 0.0
+			for _, neighbor := range adjacent {
+				sum += neighbor.position
+			}
+			desired[i] = sum / float64(len(adjacent))
+		}
+	}
+	// Resolve overlaps by pushing to the right, then shift the layer so that on average
+	// the vertices are as close as possible to their desired positions
+	positions := make([]float64, len(layer))
+	for i := range layer {
+		positions[i] = desired[i]
+		if i > 0 {
+			minimum := positions[i-1] + layer[i-1].breadth/2 + spacing + layer[i].breadth/2
+			positions[i] = math.Max(positions[i], minimum)
+		}
+	}
+	shift := 0.0
+	for i := range layer {
+		shift += desired[i] - positions[i]
+	}
+	shift /= float64(len(layer))
+	for i, vertex := range layer {
+		vertex.position = positions[i] + shift
+	}
+}
@@ -0,0 +1,117 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHierarchicalLayoutTopDown(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	nodes := []DiagramNode{}
+	for _, id := range []string{"A", "B", "C", "D"} {
+		node := NewDiagramNode(diagram, nil, id)
+		node.Move(fyne.NewPos(200, 200))
+		nodes = append(nodes, node)
+	}
+	connect := func(id string, source, target DiagramNode) {
+		link := NewDiagramLink(diagram, id)
+		link.SetSourcePad(source.GetEdgePad())
+		link.SetTargetPad(target.GetEdgePad())
+	}
+	connect("AB", nodes[0], nodes[1])
+	connect("AC", nodes[0], nodes[2])
+	connect("BD", nodes[1], nodes[3])
+	connect("CD", nodes[2], nodes[3])
+	// The cycle is broken by the layout
+	connect("DA", nodes[3], nodes[0])
+	diagram.ClearUndoHistory()
+	HierarchicalLayout(diagram, NewHierarchicalLayoutOptions())
+	a, b, c, d := nodes[0], nodes[1], nodes[2], nodes[3]
+	assert.Less(t, a.Position().Y, b.Position().Y)
+	assert.Equal(t, b.Position().Y, c.Position().Y)
+	assert.Less(t, b.Position().Y, d.Position().Y)
+	assert.NotEqual(t, b.Position().X, c.Position().X)
+
+	assert.Equal(t, 1, len(diagram.commands.undoStack))
+	assert.Equal(t, "Layout", diagram.GetUndoName())
+	diagram.Undo()
+	for _, node := range nodes {
+		assert.Equal(t, fyne.NewPos(200, 200), node.Position())
+	}
+}
+
+func TestHierarchicalLayoutLeftRight(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	nodes := []DiagramNode{}
+	for _, id := range []string{"A", "B", "C", "D"} {
+		node := NewDiagramNode(diagram, nil, id)
+		node.Move(fyne.NewPos(200, 200))
+		nodes = append(nodes, node)
+	}
+	connect := func(id string, source, target DiagramNode) {
+		link := NewDiagramLink(diagram, id)
+		link.SetSourcePad(source.GetEdgePad())
+		link.SetTargetPad(target.GetEdgePad())
+	}
+	connect("AB", nodes[0], nodes[1])
+	connect("AC", nodes[0], nodes[2])
+	connect("BD", nodes[1], nodes[3])
+	connect("CD", nodes[2], nodes[3])
+	// The cycle is broken by the layout
+	connect("DA", nodes[3], nodes[0])
+	diagram.ClearUndoHistory()
+	options := NewHierarchicalLayoutOptions()
+	options.Direction = LayoutLeftRight
+	HierarchicalLayout(diagram, options)
+	a, b, c, d := nodes[0], nodes[1], nodes[2], nodes[3]
+	assert.Less(t, a.Position().X, b.Position().X)
+	assert.Equal(t, b.Position().X, c.Position().X)
+	assert.Less(t, c.Position().X, d.Position().X)
+	assert.NotEqual(t, b.Position().Y, c.Position().Y)
+}
+
+func TestHierarchicalLayoutAnimatedWithGroup(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	nodes := []DiagramNode{}
+	for i, id := range []string{"A", "B", "C"} {
+		node := NewDiagramNode(diagram, nil, id)
+		node.Move(fyne.NewPos(float32(100+150*i), 100))
+		nodes = append(nodes, node)
+	}
+	group := NewDiagramGroup(diagram, "Group", "Group1")
+	group.AddChild(nodes[1])
+	group.AddChild(nodes[2])
+	for _, pair := range [][2]int{{0, 1}, {1, 2}} {
+		link := NewDiagramLink(diagram, nodes[pair[0]].GetDiagramElementID()+nodes[pair[1]].GetDiagramElementID())
+		link.SetSourcePad(nodes[pair[0]].GetEdgePad())
+		link.SetTargetPad(nodes[pair[1]].GetEdgePad())
+	}
+	diagram.ClearUndoHistory()
+	starts := []fyne.Position{}
+	for _, node := range nodes {
+		starts = append(starts, node.Position())
+	}
+	options := NewHierarchicalLayoutOptions()
+	options.Animate = true
+	HierarchicalLayout(diagram, options)
+	a, b, c := nodes[0], nodes[1], nodes[2]
+	assert.Less(t, a.Position().Y, b.Position().Y)
+	assert.Less(t, b.Position().Y, c.Position().Y)
+	// The group encloses its laid out children
+	groupBox := group.getBaseDiagramNode().R2Box()
+	assert.True(t, groupBox.Contains(b.getBaseDiagramNode().R2Box().Center()))
+	assert.True(t, groupBox.Contains(c.getBaseDiagramNode().R2Box().Center()))
+
+	// Undo returns the grouped nodes to where they started
+	assert.Equal(t, 1, len(diagram.commands.undoStack))
+	diagram.Undo()
+	for i, node := range nodes {
+		assert.Equal(t, starts[i], node.Position())
+	}
+}
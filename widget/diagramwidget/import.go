@@ -0,0 +1,224 @@
+package diagramwidget
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/widget"
+	"golang.org/x/image/colornames"
+)
+
+// importedGraph is the description of a graph read by one of the importers. The diagram elements are
+// created from it once the whole input has been parsed.
+type importedGraph struct {
+	nodes     []*importedNode
+	nodeIndex map[string]*importedNode
+	edges     []*importedEdge
+	direction LayoutDirection
+}
+
+// importedNode describes a node to be created with a label as its inner object
+type importedNode struct {
+	id              string
+	label           string
+	shape           NodeShape
+	foregroundColor color.Color
+	backgroundColor color.Color
+}
+
+// importedEdge describes a link between two imported nodes. The decorations are named after the
+// Graphviz arrow shapes (see newArrowDecoration), an empty name meaning no decoration.
+type importedEdge struct {
+	id               string
+	sourceID         string
+	targetID         string
+	label            string
+	lineStyle        LineStyle
+	sourceArrow      string
+	targetArrow      string
+	foregroundColor  color.Color
+	strokeWidthScale float32
+}
+
+func newImportedGraph() *importedGraph {
+	return &importedGraph{nodeIndex: map[string]*importedNode{}}
+}
+
+// node returns the node with the ID, creating it with the ID as its label if it does not exist yet
+func (g *importedGraph) node(id string) *importedNode {
+	node, ok := g.nodeIndex[id]
+	if !ok {
+		node = &importedNode{id: id, label: id}
+		g.nodeIndex[id] = node
+		g.nodes = append(g.nodes, node)
+	}
+	return node
+}
+
+// addEdge adds an edge between the nodes, creating them if necessary
+func (g *importedGraph) addEdge(edge *importedEdge) {
+	g.node(edge.sourceID)
+	g.node(edge.targetID)
+	g.edges = append(g.edges, edge)
+}
+
+// addToDiagram creates the nodes and links in the diagram and arranges the diagram's nodes with a
+// HierarchicalLayout in the graph's direction. Links without an explicit ID are identified by their
+// source and target IDs. Importing is not an edit that can be undone.
+func (g *importedGraph) addToDiagram(dw *DiagramWidget) error {
+	for _, node := range g.nodes {
+		if dw.GetDiagramElement(node.id) != nil {
+			return fmt.Errorf("diagram element %s already exists", node.id)
+		}
+	}
+	assigned := map[string]bool{}
+	for id := range g.nodeIndex {
+		assigned[id] = true
+	}
+	for _, edge := range g.edges {
+		if edge.id == "" {
+			edge.id = uniqueImportedID(dw, edge.sourceID+"->"+edge.targetID, assigned)
+		} else if dw.GetDiagramElement(edge.id) != nil || assigned[edge.id] {
+			return fmt.Errorf("diagram element %s already exists", edge.id)
+		}
+		assigned[edge.id] = true
+	}
+	dw.suspendUndoRecording()
+	defer dw.resumeUndoRecording()
+	for _, importedNode := range g.nodes {
+		node := NewDiagramNode(dw, widget.NewLabel(importedNode.label), importedNode.id)
+		if importedNode.shape != RectangleShape {
+			node.SetShape(importedNode.shape)
+		}
+		if importedNode.foregroundColor != nil {
+			node.SetForegroundColor(importedNode.foregroundColor)
+		}
+		if importedNode.backgroundColor != nil {
+			node.SetBackgroundColor(importedNode.backgroundColor)
+		}
+	}
+	for _, edge := range g.edges {
+		link := NewDiagramLink(dw, edge.id)
+		if edge.foregroundColor != nil {
+			link.SetForegroundColor(edge.foregroundColor)
+		}
+		if edge.strokeWidthScale != 0 {
+			properties := link.GetProperties()
+			properties.StrokeWidth *= edge.strokeWidthScale
+			link.SetProperties(properties)
+		}
+		link.SetLineStyle(edge.lineStyle)
+		if decoration := newArrowDecoration(edge.sourceArrow); decoration != nil {
+			link.AddSourceDecoration(decoration)
+		}
+		if decoration := newArrowDecoration(edge.targetArrow); decoration != nil {
+			link.AddTargetDecoration(decoration)
+		}
+		if edge.label != "" {
+			link.AddMidpointAnchoredText("label", edge.label)
+		}
+		link.SetSourcePad(dw.GetDiagramNode(edge.sourceID).GetEdgePad())
+		link.SetTargetPad(dw.GetDiagramNode(edge.targetID).GetEdgePad())
+	}
+	options := NewHierarchicalLayoutOptions()
+	options.Direction = g.direction
+	HierarchicalLayout(dw, options)
+	dw.adjustBounds()
+	dw.Refresh()
+	return nil
+}
+
+// uniqueImportedID returns the ID, with a numeric suffix if the ID is already in use
+func uniqueImportedID(dw *DiagramWidget, id string, assigned map[string]bool) string {
+	candidate := id
+	for i := 1; dw.GetDiagramElement(candidate) != nil || assigned[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", id, i)
+	}
+	return candidate
+}
+
+// newArrowDecoration returns the decoration closest to the Graphviz arrow shape, or nil for none
+func newArrowDecoration(arrow string) Decoration {
+	switch arrow {
+	case "normal":
+		return NewArrowhead()
+	case "vee", "open":
+		return NewOpenTriangle()
+	case "empty", "onormal":
+		return NewClosedTriangle()
+	case "diamond":
+		return NewFilledDiamond()
+	case "ediamond", "odiamond":
+		return NewDiamond()
+	case "dot", "odot", "invdot", "invodot":
+		return NewCircle()
+	case "tee":
+		return NewBar()
+	case "crow":
+		return NewCrowsFoot()
+	}
+	return nil
+}
+
+// arrowName returns the Graphviz arrow shape that newArrowDecoration maps to the decoration
+func arrowName(decoration Decoration) string {
+	switch d := decoration.(type) {
+	case *Arrowhead:
+		return "normal"
+	case *Polygon:
+		for _, candidate := range []string{"vee", "empty", "diamond", "odiamond", "odot", "tee", "crow"} {
+			if p, ok := newArrowDecoration(candidate).(*Polygon); ok && samePolygon(p, d) {
+				return candidate
+			}
+		}
+	}
+	return "normal"
+}
+
+// samePolygon returns true if the polygons have the same definition
+func samePolygon(p1 *Polygon, p2 *Polygon) bool {
+	if len(p1.definingPoints) != len(p2.definingPoints) || p1.closed != p2.closed || p1.solid != p2.solid {
+		return false
+	}
+	for i, point := range p1.definingPoints {
+		if p2.definingPoints[i] != point {
+			return false
+		}
+	}
+	return true
+}
+
+// parseImportedColor returns the color described by a hexadecimal color or a color name, or nil if it is
+// not understood. Only the first color of a DOT color list is used.
+func parseImportedColor(value string) color.Color {
+	value = strings.TrimSpace(strings.SplitN(value, ":", 2)[0])
+	value = strings.SplitN(value, ";", 2)[0]
+	if strings.HasPrefix(value, "#") {
+		return parseHexColor(value)
+	}
+	if c, ok := colornames.Map[strings.ToLower(value)]; ok {
+		return c
+	}
+	return nil
+}
+
+// parseHexColor parses a #rgb, #rrggbb or #rrggbbaa color, returning nil if it is invalid
+func parseHexColor(value string) color.Color {
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return nil
+	}
+	components, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil
+	}
+	return color.NRGBA{R: uint8(components >> 24), G: uint8(components >> 16), B: uint8(components >> 8), A: uint8(components)}
+}
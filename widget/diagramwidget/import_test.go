@@ -0,0 +1,142 @@
+package diagramwidget
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportDOT(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	dot := `// The build pipeline
+digraph pipeline {
+	rankdir=LR
+	node [shape=box]
+	fetch [label="Fetch\nsources", shape=cylinder, fillcolor="#ff0000", style=filled]
+	build; test /* a comment */
+	fetch -> build [label="ok", style=dashed, color=blue]
+	build -> test -> fetch [dir=none]
+	subgraph cluster_release { node [shape=diamond]; release }
+	test -> release [arrowhead=empty]
+}`
+	assert.NoError(t, ImportDOT(diagram, strings.NewReader(dot)))
+	assert.Equal(t, 4, len(diagram.GetDiagramNodes()))
+	assert.Equal(t, 4, len(diagram.GetDiagramLinks()))
+
+	fetch := diagram.GetDiagramNode("fetch")
+	assert.Equal(t, "Fetch\nsources", fetch.getBaseDiagramNode().innerObject.(*widget.Label).Text)
+	assert.Equal(t, CylinderShape, fetch.GetShape())
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, fetch.GetProperties().BackgroundColor)
+	assert.Equal(t, RectangleShape, diagram.GetDiagramNode("build").GetShape())
+	assert.Equal(t, DiamondShape, diagram.GetDiagramNode("release").GetShape())
+
+	link := diagram.GetDiagramLink("fetch->build")
+	assert.Equal(t, "ok", midpointLabel(link))
+	assert.Equal(t, LineStyleDashed, link.getBaseDiagramLink().GetLineStyle())
+	assert.Equal(t, 1, len(link.getBaseDiagramLink().TargetDecorations))
+	assert.Equal(t, 0, len(diagram.GetDiagramLink("build->test").getBaseDiagramLink().TargetDecorations))
+	assert.IsType(t, &Polygon{}, diagram.GetDiagramLink("test->release").getBaseDiagramLink().TargetDecorations[0])
+	assert.Equal(t, diagram.GetDiagramNode("build").GetEdgePad(), link.GetTargetPad())
+
+	// The layout flows left to right, and importing is not undoable
+	assert.Less(t, fetch.Position().X, diagram.GetDiagramNode("build").Position().X)
+	assert.False(t, diagram.CanUndo())
+
+	// The IDs are checked before anything is created
+	assert.Error(t, ImportDOT(diagram, strings.NewReader(`digraph { fetch -> other }`)))
+	assert.Nil(t, diagram.GetDiagramNode("other"))
+	assert.Error(t, ImportDOT(NewDiagramWidget("Diagram2"), strings.NewReader(`digraph { a -> }`)))
+
+	// A strict graph merges the repeated edges, in either direction for an undirected graph
+	strict := NewDiagramWidget("Diagram3")
+	assert.NoError(t, ImportDOT(strict, strings.NewReader(`strict graph { a -- b; b -- a [label="both"]; a -- c; a -- c }`)))
+	assert.Equal(t, 2, len(strict.GetDiagramLinks()))
+	assert.Equal(t, "both", midpointLabel(strict.GetDiagramLink("a->b")))
+	strict = NewDiagramWidget("Diagram4")
+	assert.NoError(t, ImportDOT(strict, strings.NewReader(`strict digraph { a -> b; b -> a; a -> b [color="#ff0000"] }`)))
+	assert.Equal(t, 2, len(strict.GetDiagramLinks()))
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, strict.GetDiagramLink("a->b").GetProperties().ForegroundColor)
+	notStrict := NewDiagramWidget("Diagram5")
+	assert.NoError(t, ImportDOT(notStrict, strings.NewReader(`digraph { a -> b; a -> b }`)))
+	assert.Equal(t, 2, len(notStrict.GetDiagramLinks()))
+}
+
+func TestImportMermaid(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	mermaid := `flowchart TD
+	%% A decision
+	A[Start] --> B{Is it working?}
+	B -- yes --> C([Done]); B -.->|no| D((Retry)) & E[("Log<br>store")]
+	D ==> A
+	style A fill:#f9f,stroke:#333
+`
+	assert.NoError(t, ImportMermaid(diagram, strings.NewReader(mermaid)))
+	assert.Equal(t, 5, len(diagram.GetDiagramNodes()))
+	assert.Equal(t, 5, len(diagram.GetDiagramLinks()))
+	assert.Equal(t, DiamondShape, diagram.GetDiagramNode("B").GetShape())
+	assert.Equal(t, RoundedRectangleShape, diagram.GetDiagramNode("C").GetShape())
+	assert.Equal(t, EllipseShape, diagram.GetDiagramNode("D").GetShape())
+	assert.Equal(t, CylinderShape, diagram.GetDiagramNode("E").GetShape())
+	assert.Equal(t, "Log\nstore", diagram.GetDiagramNode("E").getBaseDiagramNode().innerObject.(*widget.Label).Text)
+	assert.Equal(t, color.NRGBA{R: 0xff, G: 0x99, B: 0xff, A: 0xff}, diagram.GetDiagramNode("A").GetProperties().BackgroundColor)
+
+	yes := diagram.GetDiagramLink("B->C")
+	assert.Equal(t, "yes", midpointLabel(yes))
+	no := diagram.GetDiagramLink("B->E")
+	assert.Equal(t, "no", midpointLabel(no))
+	assert.Equal(t, LineStyleDotted, no.getBaseDiagramLink().GetLineStyle())
+	assert.Equal(t, 2*diagram.DefaultDiagramElementProperties.StrokeWidth, diagram.GetDiagramLink("D->A").GetProperties().StrokeWidth)
+	// The layout flows top down
+	assert.Less(t, diagram.GetDiagramNode("A").Position().Y, diagram.GetDiagramNode("B").Position().Y)
+
+	assert.Error(t, ImportMermaid(NewDiagramWidget("Diagram2"), strings.NewReader("A --> B")))
+
+	// The class shorthand is ignored
+	classes := NewDiagramWidget("Diagram3")
+	assert.NoError(t, ImportMermaid(classes, strings.NewReader("graph LR\n\tA:::warn --> B[Next]:::ok & C\n\tclassDef ok fill:#0f0")))
+	assert.Equal(t, 3, len(classes.GetDiagramNodes()))
+	assert.Equal(t, 2, len(classes.GetDiagramLinks()))
+	assert.Equal(t, "Next", classes.GetDiagramNode("B").getBaseDiagramNode().innerObject.(*widget.Label).Text)
+}
+
+func TestExportDOT(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, widget.NewLabel("First"), "Node1")
+	node1.SetShape(EllipseShape)
+	NewDiagramNode(diagram, widget.NewLabel("Node2"), "Node2")
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(diagram.GetDiagramNode("Node2").GetEdgePad())
+	link.AddTargetDecoration(NewClosedTriangle())
+	link.AddMidpointAnchoredText("label", `say "hi"`)
+	link.SetLineStyle(LineStyleDotted)
+	var buffer bytes.Buffer
+	assert.NoError(t, ExportDOT(diagram, &buffer))
+	assert.Equal(t, `digraph "Diagram1" {
+	"Node1" [label="First", shape="ellipse"];
+	"Node2" [shape="box"];
+	"Node1" -> "Node2" [id="Link1", label="say \"hi\"", style="dotted", arrowhead="empty"];
+}
+`, buffer.String())
+
+	// Importing the export and exporting it again gives the same result
+	restored := NewDiagramWidget("Diagram1")
+	assert.NoError(t, ImportDOT(restored, bytes.NewReader(buffer.Bytes())))
+	var restoredBuffer bytes.Buffer
+	assert.NoError(t, ExportDOT(restored, &restoredBuffer))
+	assert.Equal(t, buffer.String(), restoredBuffer.String())
+}
+
+// midpointLabel returns the text of the link's midpoint anchored text created by the importers
+func midpointLabel(link DiagramLink) string {
+	text, _ := link.getBaseDiagramLink().GetMidpointAnchoredText("label").GetDisplayedTextBinding().Get()
+	return text
+}
@@ -0,0 +1,241 @@
+package diagramwidget
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// Verify that interfaces are fully implemented
+var _ fyne.Focusable = (*DiagramWidget)(nil)
+var _ fyne.Tabbable = (*DiagramWidget)(nil)
+var _ desktop.Keyable = (*DiagramWidget)(nil)
+
+const (
+	// keyboardStep is the distance (at zoom 1) by which an arrow key moves the selected nodes when
+	// SnapToGrid is not set. When it is set, the nodes move by the GridSpacing.
+	keyboardStep float32 = 5
+	// keyboardLargeStepFactor multiplies the step when Shift is held
+	keyboardLargeStepFactor float32 = 10
+)
+
+// AcceptsTab returns true so that Tab and Shift+Tab step through the diagram elements rather than
+// moving the focus to the next widget. Once they step past the last (or first) element, the focus moves on.
+func (dw *DiagramWidget) AcceptsTab() bool {
+	return true
+}
+
+// DeleteSelection removes the selected elements (and the links connected to them) from the diagram.
+// The removal is a single undo entry.
+func (dw *DiagramWidget) DeleteSelection() {
+	dw.removeSelection("Delete")
+}
+
+// EditPrimaryAnchoredText moves the keyboard focus to the text entry of the primary anchored text of the
+// primary selection, if the primary selection is a link that has anchored text. The midpoint texts take
+// precedence over the source texts, which take precedence over the target texts.
+func (dw *DiagramWidget) EditPrimaryAnchoredText() {
+	link, ok := dw.primarySelection.(DiagramLink)
+	if !ok {
+		return
+	}
+	at := link.getBaseDiagramLink().getPrimaryAnchoredText()
+	if at == nil {
+		return
+	}
+	if c := dw.getCanvas(); c != nil {
+		c.Focus(at.textEntry)
+	}
+}
+
+// FocusGained is called when the diagram receives the keyboard focus. It presently is a noop
+func (dw *DiagramWidget) FocusGained() {
+}
+
+// FocusLost is called when the diagram loses the keyboard focus
+func (dw *DiagramWidget) FocusLost() {
+	dw.shiftPressed = false
+}
+
+// KeyDown keeps track of the Shift key so that it can modify the navigation keys
+func (dw *DiagramWidget) KeyDown(event *fyne.KeyEvent) {
+	if event.Name == desktop.KeyShiftLeft || event.Name == desktop.KeyShiftRight {
+		dw.shiftPressed = true
+	}
+}
+
+// KeyUp keeps track of the Shift key so that it can modify the navigation keys
+func (dw *DiagramWidget) KeyUp(event *fyne.KeyEvent) {
+	if event.Name == desktop.KeyShiftLeft || event.Name == desktop.KeyShiftRight {
+		dw.shiftPressed = false
+	}
+}
+
+// TypedKey responds to the keys used to operate the diagram without a mouse. Tab and Shift+Tab select
+// the next and previous element in z-order, and move the focus to the next or previous widget from the last
+// or first element. Escape also moves the focus to the next widget. The arrow keys move the selected nodes
+// (by a larger step with Shift), Delete and Backspace remove the selection, and Enter starts editing the
+// primary anchored text.
+func (dw *DiagramWidget) TypedKey(event *fyne.KeyEvent) {
+	step := keyboardStep
+	if dw.SnapToGrid && dw.GridSpacing > 0 {
+		step = dw.GridSpacing
+	}
+	if dw.isShiftPressed() {
+		step *= keyboardLargeStepFactor
+	}
+	step = dw.scaled(step)
+	switch event.Name {
+	case fyne.KeyTab:
+		forward := !dw.isShiftPressed()
+		if !dw.selectAdjacentElement(forward) {
+			dw.releaseFocus(forward)
+		}
+	case fyne.KeyEscape:
+		dw.releaseFocus(true)
+	case fyne.KeyLeft:
+		dw.displaceSelectedNodes(fyne.NewPos(-step, 0))
+	case fyne.KeyRight:
+		dw.displaceSelectedNodes(fyne.NewPos(step, 0))
+	case fyne.KeyUp:
+		dw.displaceSelectedNodes(fyne.NewPos(0, -step))
+	case fyne.KeyDown:
+		dw.displaceSelectedNodes(fyne.NewPos(0, step))
+	case fyne.KeyDelete, fyne.KeyBackspace:
+		dw.DeleteSelection()
+	case fyne.KeyReturn, fyne.KeyEnter:
+		dw.EditPrimaryAnchoredText()
+	}
+}
+
+// TypedRune is required by fyne.Focusable. Characters are ignored by the diagram.
+func (dw *DiagramWidget) TypedRune(r rune) {
+}
+
+// displaceSelectedNodes moves the selected nodes as a single undo entry
+func (dw *DiagramWidget) displaceSelectedNodes(delta fyne.Position) {
+	nodes := dw.getSelectedNodes()
+	if len(nodes) == 0 {
+		return
+	}
+	dw.StartUndoGroup("Move")
+	for _, node := range nodes {
+		dw.DisplaceNode(node, delta)
+	}
+	dw.EndUndoGroup()
+	dw.scrollToElement(nodes[0])
+}
+
+// getCanvas returns the canvas on which the diagram is displayed, or nil if there is none
+func (dw *DiagramWidget) getCanvas() fyne.Canvas {
+	driver := fyne.CurrentApp().Driver()
+	if len(driver.AllWindows()) == 0 {
+		return nil
+	}
+	return driver.CanvasForObject(dw)
+}
+
+// isShiftPressed returns true if a Shift key is held down
+func (dw *DiagramWidget) isShiftPressed() bool {
+	return dw.shiftPressed || getCurrentKeyModifiers()&fyne.KeyModifierShift != 0
+}
+
+// releaseFocus clears the selection and moves the keyboard focus to the next or previous widget in the window
+func (dw *DiagramWidget) releaseFocus(forward bool) {
+	dw.ClearSelection()
+	c := dw.getCanvas()
+	if c == nil {
+		return
+	}
+	if forward {
+		c.FocusNext()
+	} else {
+		c.FocusPrevious()
+	}
+}
+
+// removeSelection removes the selected elements from the diagram as a single undo entry with the indicated name
+func (dw *DiagramWidget) removeSelection(undoName string) {
+	selected := []string{}
+	for _, element := range dw.GetDiagramElements() {
+		if dw.IsSelected(element) {
+			selected = append(selected, element.GetDiagramElementID())
+		}
+	}
+	dw.ClearSelection()
+	dw.StartUndoGroup(undoName)
+	for _, id := range selected {
+		dw.RemoveElement(id)
+	}
+	dw.EndUndoGroup()
+}
+
+// requestFocus gives the keyboard focus to the diagram
+func (dw *DiagramWidget) requestFocus() {
+	if c := dw.getCanvas(); c != nil && c.Focused() != dw {
+		c.Focus(dw)
+	}
+}
+
+// scrollToElement scrolls the diagram, if necessary, so that the element is visible
+func (dw *DiagramWidget) scrollToElement(element DiagramElement) {
+	offset := dw.scrollingContainer.Offset
+	viewport := dw.scrollingContainer.Size()
+	position := element.Position()
+	size := element.Size()
+	switch {
+	case position.X < offset.X:
+		offset.X = position.X
+	case position.X+size.Width > offset.X+viewport.Width:
+		offset.X = position.X + size.Width - viewport.Width
+	}
+	switch {
+	case position.Y < offset.Y:
+		offset.Y = position.Y
+	case position.Y+size.Height > offset.Y+viewport.Height:
+		offset.Y = position.Y + size.Height - viewport.Height
+	}
+	if offset != dw.scrollingContainer.Offset {
+		dw.scrollTo(offset)
+	}
+}
+
+// selectAdjacentElement makes the next (or previous) visible element in z-order after the primary selection the
+// only selected element. The element is not brought to the front, so that repeated use steps through all of
+// the elements. It returns false, leaving the selection unchanged, if there is no such element.
+func (dw *DiagramWidget) selectAdjacentElement(forward bool) bool {
+	elements := []DiagramElement{}
+	current := -1
+	for _, element := range dw.GetDiagramElements() {
+		if !element.Visible() {
+			continue
+		}
+		if dw.primarySelection != nil && element.GetDiagramElementID() == dw.primarySelection.GetDiagramElementID() {
+			current = len(elements)
+		}
+		elements = append(elements, element)
+	}
+	var next int
+	switch {
+	case current == -1 && forward:
+		next = 0
+	case current == -1:
+		next = len(elements) - 1
+	case forward:
+		next = current + 1
+	default:
+		next = current - 1
+	}
+	if next < 0 || next >= len(elements) {
+		return false
+	}
+	element := elements[next]
+	dw.ClearSelectionNoCallback()
+	dw.primarySelection = element
+	dw.selection[element.GetDiagramElementID()] = element
+	element.ShowHandles()
+	if dw.PrimaryDiagramElementSelectionChangedCallback != nil {
+		dw.PrimaryDiagramElementSelectionChangedCallback(element.GetDiagramElementID())
+	}
+	dw.scrollToElement(element)
+	return true
+}
@@ -0,0 +1,133 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyboardNavigation(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(300, 100))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	diagram.ClearUndoHistory()
+	window := test.NewWindow(diagram)
+	defer window.Close()
+	window.Canvas().Focus(diagram)
+	assert.Equal(t, fyne.Focusable(diagram), window.Canvas().Focused())
+
+	// Tab and Shift+Tab cycle through the elements in z-order
+	test.FocusNext(window.Canvas())
+	assert.Equal(t, fyne.Focusable(diagram), window.Canvas().Focused())
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Equal(t, DiagramElement(node1), diagram.GetPrimarySelection())
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Equal(t, DiagramElement(link), diagram.GetPrimarySelection())
+	diagram.KeyDown(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Equal(t, DiagramElement(node2), diagram.GetPrimarySelection())
+	diagram.KeyUp(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+
+	// The arrow keys move the selected nodes, by a larger step with Shift
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Nil(t, diagram.GetPrimarySelection())
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Equal(t, DiagramElement(node1), diagram.GetPrimarySelection())
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyRight})
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyDown})
+	assert.Equal(t, fyne.NewPos(105, 105), node1.Position())
+	diagram.KeyDown(&fyne.KeyEvent{Name: desktop.KeyShiftRight})
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyLeft})
+	diagram.KeyUp(&fyne.KeyEvent{Name: desktop.KeyShiftRight})
+	assert.Equal(t, fyne.NewPos(55, 105), node1.Position())
+	diagram.Undo()
+	assert.Equal(t, fyne.NewPos(105, 105), node1.Position())
+
+	// Delete removes the selection
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyDelete})
+	assert.Nil(t, diagram.GetDiagramElement("Node1"))
+	assert.Nil(t, diagram.GetDiagramElement("Link1"))
+	assert.Equal(t, "Delete", diagram.GetUndoName())
+	diagram.Undo()
+	assert.NotNil(t, diagram.GetDiagramElement("Link1"))
+}
+
+func TestKeyboardEditsAnchoredText(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(200, 0))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	link.AddSourceAnchoredText("role", "Role")
+	name := link.AddMidpointAnchoredText("name", "Name")
+	window := test.NewWindow(diagram)
+	defer window.Close()
+	// The text entry can only be focused once it has been rendered
+	window.Canvas().Capture()
+
+	// Tapping an element gives the keyboard focus to the diagram
+	diagram.DiagramElementTapped(link)
+	assert.Equal(t, fyne.Focusable(diagram), window.Canvas().Focused())
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	assert.Equal(t, fyne.Focusable(name.GetTextEntry()), window.Canvas().Focused())
+	test.Type(name.GetTextEntry(), "d ")
+	text, _ := name.GetDisplayedTextBinding().Get()
+	assert.Equal(t, "d Name", text)
+}
+
+func TestKeyboardReleasesFocus(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(200, 0))
+	before := widget.NewEntry()
+	after := widget.NewEntry()
+	window := test.NewWindow(container.NewVBox(before, diagram, after))
+	defer window.Close()
+	focused := func() fyne.Focusable {
+		return window.Canvas().Focused()
+	}
+
+	// Tab from the last element moves the focus to the next widget
+	window.Canvas().Focus(diagram)
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Equal(t, DiagramElement(node2), diagram.GetPrimarySelection())
+	assert.Equal(t, fyne.Focusable(diagram), focused())
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Equal(t, fyne.Focusable(after), focused())
+	assert.Nil(t, diagram.GetPrimarySelection())
+
+	// Shift+Tab from the first element moves the focus to the previous widget
+	window.Canvas().Focus(diagram)
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	assert.Equal(t, DiagramElement(node1), diagram.GetPrimarySelection())
+	diagram.KeyDown(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	diagram.KeyUp(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	assert.Equal(t, fyne.Focusable(before), focused())
+
+	// Escape moves the focus to the next widget
+	window.Canvas().Focus(diagram)
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyTab})
+	diagram.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEscape})
+	assert.Equal(t, fyne.Focusable(after), focused())
+	assert.Nil(t, diagram.GetPrimarySelection())
+}
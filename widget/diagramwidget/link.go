@@ -45,17 +45,25 @@ type DiagramLink interface {
 	GetSourceHandle() *Handle
 	GetTargetPad() ConnectionPad
 	GetTargetHandle() *Handle
+	GetWaypoints() []fyne.Position
+	InsertWaypoint(int, fyne.Position)
 	isConnectionAllowed(*LinkPoint, ConnectionPad) bool
+	RemoveWaypoint(int)
 	SetSourcePad(ConnectionPad)
 	SetTargetPad(ConnectionPad)
+	SetWaypoints([]fyne.Position)
 }
 
 // BaseDiagramLink is a directed graphic connection between two DiagramElements that are referred to as the Source
 // and Target. The link consists of one or more line segments. By default a single line segment connects the
 // Source and Target. The Link connects to ConnectionPads on the DiagramElements.
+// Waypoints between the Source and Target bend the link: its path passes through them in order. They are set with
+// SetWaypoints, or by the user double-clicking a segment to insert one, dragging its handle to move it, and removing
+// it from the handle's context menu.
+// The path followed by the link is determined by its LinkRouter: by default the line segments are straight, but
+// orthogonal and curved routing are also available (see SetRouter).
 // There are three key points on a Link: the Source connection point, the Target connection point, and a MidPoint.
-// For a single segment, the MidPoint is the middle of the segment. When there are two or more segments, the
-// MidPoint is the source end of the next-to-last segment.
+// The MidPoint is the point halfway along the path of the link.
 // Graphic Decoration widgets may be added at each of these points. Multiple decorations may be added at each point
 // Multiple decorations are "stacked" along the line in the order added. These graphic decorations rotate with their
 // associated line segments to maintain their orientation with respect to the line segment.
@@ -78,6 +86,14 @@ type BaseDiagramLink struct {
 	targetAnchoredText   map[string]*AnchoredText
 	MidpointDecorations  []Decoration
 	midpointAnchoredText map[string]*AnchoredText
+	router               LinkRouter
+	lineStyle            LineStyle
+	// routePoints is the path determined by the router, in link coordinates
+	routePoints []fyne.Position
+	// orthogonalRoute is the route last computed by an OrthogonalLinkRouter, and is nil otherwise
+	orthogonalRoute *orthogonalRoute
+	// waypointDragStart holds the unscaled waypoints at the start of a waypoint drag, and is nil otherwise
+	waypointDragStart []fyne.Position
 	// We keep the typed link so that when extensions are created the callbacks are called with the correct type
 	typedLink DiagramLink
 }
@@ -226,14 +242,33 @@ func (bdl *BaseDiagramLink) GetMidPad() ConnectionPad {
 	return bdl.pads["default"]
 }
 
+// getMidPosition returns the point halfway along the path of the link
 func (bdl *BaseDiagramLink) getMidPosition() fyne.Position {
-	// TODO update when additional points are introduced
-	sourcePoint := bdl.linkPoints[0].Position()
-	targetPoint := bdl.linkPoints[len(bdl.linkPoints)-1].Position()
-	midPoint := fyne.NewPos((sourcePoint.X+targetPoint.X)/2, (sourcePoint.Y+targetPoint.Y)/2)
+	midPoint, _ := bdl.getMidPositionAndAngle()
 	return midPoint
 }
 
+// getMidPositionAndAngle returns the point halfway along the path of the link and the angle of the
+// path at that point
+func (bdl *BaseDiagramLink) getMidPositionAndAngle() (fyne.Position, float64) {
+	points := bdl.getRoutePoints()
+	length := 0.0
+	for i := 0; i < len(points)-1; i++ {
+		length += toR2(points[i+1].Subtract(points[i])).Length()
+	}
+	remaining := length / 2
+	for i := 0; i < len(points)-1; i++ {
+		segmentLength := toR2(points[i+1].Subtract(points[i])).Length()
+		if segmentLength > 0 && (remaining <= segmentLength || i == len(points)-2) {
+			fraction := float32(remaining / segmentLength)
+			delta := points[i+1].Subtract(points[i])
+			return points[i].AddXY(delta.X*fraction, delta.Y*fraction), getSegmentAngle(points[i], points[i+1])
+		}
+		remaining -= segmentLength
+	}
+	return points[0], 0
+}
+
 // GetMidpointAnchoredText returns the midpoint anchored text indexed under the supplied key
 func (bdl *BaseDiagramLink) GetMidpointAnchoredText(key string) *AnchoredText {
 	return bdl.midpointAnchoredText[key]
@@ -249,6 +284,42 @@ func (bdl *BaseDiagramLink) GetTargetAnchoredText(key string) *AnchoredText {
 	return bdl.targetAnchoredText[key]
 }
 
+// getPrimaryAnchoredText returns the anchored text that is edited from the keyboard: the first (by key) of the
+// midpoint texts or, if there are none, of the source texts or, failing those, of the target texts
+func (bdl *BaseDiagramLink) getPrimaryAnchoredText() *AnchoredText {
+	for _, anchoredTexts := range []map[string]*AnchoredText{bdl.midpointAnchoredText, bdl.sourceAnchoredText, bdl.targetAnchoredText} {
+		if keys := sortedKeys(anchoredTexts); len(keys) > 0 {
+			return anchoredTexts[keys[0]]
+		}
+	}
+	return nil
+}
+
+// GetLineStyle returns the style with which the link's segments are drawn
+func (bdl *BaseDiagramLink) GetLineStyle() LineStyle {
+	return bdl.lineStyle
+}
+
+// GetRouter returns the LinkRouter that determines the path of the link
+func (bdl *BaseDiagramLink) GetRouter() LinkRouter {
+	if bdl.router == nil {
+		return NewStraightLinkRouter()
+	}
+	return bdl.router
+}
+
+// getRoutePoints returns the path of the link in link coordinates
+func (bdl *BaseDiagramLink) getRoutePoints() []fyne.Position {
+	if len(bdl.routePoints) >= 2 {
+		return bdl.routePoints
+	}
+	points := []fyne.Position{}
+	for _, linkPoint := range bdl.linkPoints {
+		points = append(points, linkPoint.Position())
+	}
+	return points
+}
+
 // GetSourceHandle returns the handle associated with the source end
 func (bdl *BaseDiagramLink) GetSourceHandle() *Handle {
 	return bdl.handles[SOURCE.ToString()]
@@ -279,6 +350,10 @@ func (bdl *BaseDiagramLink) getTargetPosition() fyne.Position {
 
 func (bdl *BaseDiagramLink) handleDragged(handle *Handle, event *fyne.DragEvent) {
 	handleKey := bdl.getHandleKey(handle)
+	if index, ok := parseWaypointHandleKey(handleKey); ok {
+		bdl.waypointDragged(index, event)
+		return
+	}
 	var linkPoint *LinkPoint
 	var pad ConnectionPad
 	switch handleKey {
@@ -298,22 +373,28 @@ func (bdl *BaseDiagramLink) handleDragged(handle *Handle, event *fyne.DragEvent)
 	if connTrans == nil {
 		connTrans = NewConnectionTransaction(linkPoint, bdl, pad, linkPoint.Position())
 		bdl.diagram.ConnectionTransaction = connTrans
+		bdl.diagram.dragResidual = fyne.NewPos(0, 0)
 		// TODO remove this after fyne Issue #3906 has been resolved
 		bdl.diagram.showAllPads()
+		bdl.diagram.highlightPorts()
 
 	} else if connTrans.LinkPoint != linkPoint {
 		// The existing transaction is for a different linkPoint
 		return
 	}
 	currentPosition := linkPoint.Position()
-	newPosition := fyne.NewPos(currentPosition.X+event.Dragged.DX, currentPosition.Y+event.Dragged.DY)
-	linkPoint.Move(newPosition)
+	delta := bdl.diagram.snapDrag(bdl.Position().Add(currentPosition), fyne.NewPos(event.Dragged.DX, event.Dragged.DY), true, true)
+	linkPoint.Move(currentPosition.Add(delta))
 	bdl.Refresh()
 }
 
 func (bdl *BaseDiagramLink) handleDragEnd(handle *Handle) {
 	connTrans := bdl.diagram.ConnectionTransaction
 	handleKey := bdl.getHandleKey(handle)
+	if _, ok := parseWaypointHandleKey(handleKey); ok {
+		bdl.waypointDragEnd()
+		return
+	}
 	if connTrans != nil {
 		if connTrans.PendingPad != nil {
 			// We have a new pad for connection
@@ -333,6 +414,18 @@ func (bdl *BaseDiagramLink) handleDragEnd(handle *Handle) {
 			case TARGET.ToString():
 				bdl.targetPad = connTrans.PendingPad
 			}
+			if connTrans.PendingPad != connTrans.InitialPad {
+				linkEnd := SOURCE
+				if handleKey == TARGET.ToString() {
+					linkEnd = TARGET
+				}
+				bdl.diagram.recordCommand("Connect", &padChangeCommand{
+					link:    bdl.typedLink,
+					linkEnd: linkEnd,
+					oldPad:  connTrans.InitialPad,
+					newPad:  connTrans.PendingPad,
+				})
+			}
 			if bdl.diagram.LinkConnectionChangedCallback != nil {
 				bdl.diagram.LinkConnectionChangedCallback(bdl.typedLink, handleKey, connTrans.InitialPad, connTrans.PendingPad)
 			}
@@ -353,6 +446,7 @@ func (bdl *BaseDiagramLink) handleDragEnd(handle *Handle) {
 		}
 		bdl.diagram.ConnectionTransaction = nil
 		bdl.diagram.hideAllPads()
+		bdl.diagram.clearPortHighlights()
 		bdl.diagram.SelectDiagramElement(bdl)
 		bdl.Refresh()
 	}
@@ -373,13 +467,17 @@ func (bdl *BaseDiagramLink) isConnectionAllowed(linkPoint *LinkPoint, pad Connec
 		// the point is not the source or target point
 		return false
 	}
+	var linkEnd LinkEnd
+	if pointIndex == 0 {
+		linkEnd = SOURCE
+	} else if pointIndex == len(bdl.linkPoints)-1 {
+		linkEnd = TARGET
+	}
+	// The port rules are applied before any application-specific rules
+	if !bdl.diagram.isPortConnectionAllowed(bdl, linkEnd, pad) {
+		return false
+	}
 	if bdl.diagram.IsConnectionAllowedCallback != nil {
-		var linkEnd LinkEnd
-		if pointIndex == 0 {
-			linkEnd = SOURCE
-		} else if pointIndex == len(bdl.linkPoints)-1 {
-			linkEnd = TARGET
-		}
 		return bdl.diagram.IsConnectionAllowedCallback(bdl, linkEnd, pad)
 	}
 	// By default, we accept any connection
@@ -408,35 +506,66 @@ func (bdl *BaseDiagramLink) MouseMoved(event *desktop.MouseEvent) {
 func (bdl *BaseDiagramLink) MouseOut() {
 }
 
-// SetSourcePad sets the source pad (belonging to another DiagramElement) and adds the link dependency to the diagram
+// SetLineStyle sets the style with which the link's segments are drawn: solid, dashed or dotted
+func (bdl *BaseDiagramLink) SetLineStyle(style LineStyle) {
+	bdl.lineStyle = style
+	bdl.Refresh()
+}
+
+// SetRouter sets the LinkRouter that determines the path of the link. A nil router restores the default
+// straight line routing.
+func (bdl *BaseDiagramLink) SetRouter(router LinkRouter) {
+	bdl.router = router
+	bdl.Refresh()
+}
+
+// SetSourcePad sets the source pad (belonging to another DiagramElement) and adds the link dependency to the diagram.
+// A nil pad disconnects the source end, leaving it at its present position. A connection to a PortPad that the
+// port rules do not allow is refused, leaving the source end unchanged.
 func (bdl *BaseDiagramLink) SetSourcePad(pad ConnectionPad) {
 	oldPad := bdl.sourcePad
+	if pad != nil && !bdl.diagram.isPortConnectionAllowed(bdl, SOURCE, pad) {
+		return
+	}
 	if oldPad != pad {
 		if oldPad != nil {
 			bdl.diagram.removeLinkDependency(oldPad.GetPadOwner(), bdl, oldPad)
 		}
 		bdl.sourcePad = pad
-		bdl.diagram.addLinkDependency(bdl.sourcePad.GetPadOwner(), bdl, bdl.sourcePad)
+		if pad != nil {
+			bdl.diagram.addLinkDependency(pad.GetPadOwner(), bdl, pad)
+		}
+		bdl.diagram.recordCommand("Connect", &padChangeCommand{link: bdl.typedLink, linkEnd: SOURCE, oldPad: oldPad, newPad: pad})
 		if bdl.diagram.LinkConnectionChangedCallback != nil {
 			bdl.diagram.LinkConnectionChangedCallback(bdl.typedLink, SOURCE.ToString(), oldPad, pad)
 		}
 		bdl.Refresh()
+		bdl.diagram.graphBindersElementChanged(bdl.typedLink)
 	}
 }
 
-// SetTargetPad sets the target pad (belonging to another DiagramElement) and adds the link dependency to the diagram
+// SetTargetPad sets the target pad (belonging to another DiagramElement) and adds the link dependency to the diagram.
+// A nil pad disconnects the target end, leaving it at its present position. A connection to a PortPad that the
+// port rules do not allow is refused, leaving the target end unchanged.
 func (bdl *BaseDiagramLink) SetTargetPad(pad ConnectionPad) {
 	oldPad := bdl.targetPad
+	if pad != nil && !bdl.diagram.isPortConnectionAllowed(bdl, TARGET, pad) {
+		return
+	}
 	if oldPad != pad {
 		if oldPad != nil {
 			bdl.diagram.removeLinkDependency(oldPad.GetPadOwner(), bdl, oldPad)
 		}
 		bdl.targetPad = pad
-		bdl.diagram.addLinkDependency(bdl.targetPad.GetPadOwner(), bdl, bdl.targetPad)
+		if pad != nil {
+			bdl.diagram.addLinkDependency(pad.GetPadOwner(), bdl, pad)
+		}
+		bdl.diagram.recordCommand("Connect", &padChangeCommand{link: bdl.typedLink, linkEnd: TARGET, oldPad: oldPad, newPad: pad})
 		if bdl.diagram.LinkConnectionChangedCallback != nil {
 			bdl.diagram.LinkConnectionChangedCallback(bdl.typedLink, TARGET.ToString(), oldPad, pad)
 		}
 		bdl.Refresh()
+		bdl.diagram.graphBindersElementChanged(bdl.typedLink)
 	}
 }
 
@@ -454,17 +583,17 @@ func (dlr *diagramLinkRenderer) Destroy() {
 
 func (dlr *diagramLinkRenderer) MinSize() fyne.Size {
 	var xMin, xMax, yMin, yMax float32
-	for i, point := range dlr.link.linkPoints {
+	for i, point := range dlr.link.getRoutePoints() {
 		if i == 0 {
-			xMin = point.Position().X
-			xMax = point.Position().X
-			yMin = point.Position().Y
-			yMax = point.Position().Y
+			xMin = point.X
+			xMax = point.X
+			yMin = point.Y
+			yMax = point.Y
 		} else {
-			xMin = float32(math.Min(float64(xMin), float64(point.Position().X)))
-			xMax = float32(math.Max(float64(xMax), float64(point.Position().X)))
-			yMin = float32(math.Min(float64(yMin), float64(point.Position().Y)))
-			yMax = float32(math.Max(float64(yMax), float64(point.Position().Y)))
+			xMin = float32(math.Min(float64(xMin), float64(point.X)))
+			xMax = float32(math.Max(float64(xMax), float64(point.X)))
+			yMin = float32(math.Min(float64(yMin), float64(point.Y)))
+			yMax = float32(math.Max(float64(yMax), float64(point.Y)))
 		}
 	}
 	return fyne.Size{Width: float32(math.Abs(float64(xMax - xMin))), Height: float32(math.Abs(float64(yMax - yMin)))}
@@ -521,80 +650,118 @@ func (dlr *diagramLinkRenderer) Refresh() {
 	var targetDiagramCoordinatePosition fyne.Position
 	currentSourceDiagramCoordinatePosition := dlr.link.getSourcePosition().Add(dlr.link.Position())
 	currentTargetDiagramCoordinatePosition := dlr.link.getTargetPosition().Add(dlr.link.Position())
-	if dlr.link.sourcePad != nil {
-		sourceDiagramCoordinateReferencePoint = dlr.link.sourcePad.GetCenterInDiagramCoordinates()
+	// Ends connected to the children of collapsed groups are displayed as connected to the groups
+	sourcePad := getDisplayedPad(dlr.link.sourcePad)
+	targetPad := getDisplayedPad(dlr.link.targetPad)
+	if sourcePad != nil {
+		sourceDiagramCoordinateReferencePoint = sourcePad.GetCenterInDiagramCoordinates()
 	} else {
 		// we have to translate the source position back to diagram coordinates
 		sourceDiagramCoordinateReferencePoint = currentSourceDiagramCoordinatePosition
 	}
-	if dlr.link.targetPad != nil {
-		targetDiagramCoordinateReferencePoint = dlr.link.targetPad.GetCenterInDiagramCoordinates()
+	if targetPad != nil {
+		targetDiagramCoordinateReferencePoint = targetPad.GetCenterInDiagramCoordinates()
 	} else {
 		// we have to translate the target position back to diagram coordinates
 		targetDiagramCoordinateReferencePoint = currentTargetDiagramCoordinatePosition
 	}
-	if dlr.link.sourcePad != nil {
-		sourceDiagramCoordinatePosition = dlr.link.sourcePad.getConnectionPointInDiagramCoordinates(targetDiagramCoordinateReferencePoint)
+	if sourcePad != nil {
+		sourceDiagramCoordinatePosition = sourcePad.getConnectionPointInDiagramCoordinates(targetDiagramCoordinateReferencePoint)
 	} else {
 		sourceDiagramCoordinatePosition = currentSourceDiagramCoordinatePosition
 	}
-	if dlr.link.targetPad != nil {
-		targetDiagramCoordinatePosition = dlr.link.targetPad.getConnectionPointInDiagramCoordinates(sourceDiagramCoordinateReferencePoint)
+	if targetPad != nil {
+		targetDiagramCoordinatePosition = targetPad.getConnectionPointInDiagramCoordinates(sourceDiagramCoordinateReferencePoint)
 	} else {
 		targetDiagramCoordinatePosition = currentTargetDiagramCoordinatePosition
 	}
-	// The Position of the link is the upper left hand corner of a bounding box surrounding the source and target positions
-	linkPosition := fyne.NewPos(float32(math.Min(float64(sourceDiagramCoordinatePosition.X), float64(targetDiagramCoordinatePosition.X))),
-		float32(math.Min(float64(sourceDiagramCoordinatePosition.Y), float64(targetDiagramCoordinatePosition.Y))))
+	// The router determines the path through the control points. The control points and the path are in diagram coordinates.
+	currentLinkPosition := dlr.link.Position()
+	controlPoints := []fyne.Position{sourceDiagramCoordinatePosition}
+	for _, linkPoint := range dlr.link.linkPoints[1 : len(dlr.link.linkPoints)-1] {
+		controlPoints = append(controlPoints, linkPoint.Position().Add(currentLinkPosition))
+	}
+	controlPoints = append(controlPoints, targetDiagramCoordinatePosition)
+	route := dlr.link.GetRouter().Route(dlr.link.typedLink, controlPoints)
+	if len(route) < 2 {
+		route = controlPoints
+	}
+
+	// The Position of the link is the upper left hand corner of a bounding box surrounding the path
+	linkPosition := route[0]
+	for _, point := range route {
+		linkPosition = fyne.NewPos(float32(math.Min(float64(linkPosition.X), float64(point.X))),
+			float32(math.Min(float64(linkPosition.Y), float64(point.Y))))
+	}
 	dlr.link.Move(linkPosition)
 
-	// Now we put the source and target positions back into link coordinates by subtracting the linkPosition
-	dlr.link.linkPoints[0].Move(sourceDiagramCoordinatePosition.Subtract(linkPosition))
-	dlr.link.linkPoints[len(dlr.link.linkPoints)-1].Move(targetDiagramCoordinatePosition.Subtract(linkPosition))
-	// TODO adjust the position of the other points based on the change in link position
-	// Now resize the link - note that MinSize is derived from the point positions
+	// Now we put the path and the link points back into link coordinates by subtracting the linkPosition
+	dlr.link.routePoints = make([]fyne.Position, len(route))
+	for i, point := range route {
+		dlr.link.routePoints[i] = point.Subtract(linkPosition)
+	}
+	for i, linkPoint := range dlr.link.linkPoints {
+		linkPoint.Move(controlPoints[i].Subtract(linkPosition))
+	}
+	// Now resize the link - note that MinSize is derived from the path
 	dlr.link.Resize(dlr.MinSize())
 
 	// Position segments only after all points have been positioned
-	for i := 0; i < len(dlr.link.linkPoints)-1; i++ {
-		linkSegment := dlr.link.linkSegments[i]
-		linkSegment.SetPoints(dlr.link.linkPoints[i].Position(), dlr.link.linkPoints[i+1].Position())
-	}
-
-	// Have to change the sign of Y since the window inverts the Y axis
-	lineVector := r2.Vec2{X: float64(dlr.link.linkPoints[1].Position().X - dlr.link.linkPoints[0].Position().X),
-		Y: -float64(dlr.link.linkPoints[1].Position().Y - dlr.link.linkPoints[0].Position().Y)}
-	var sourceAngle float64
-	if lineVector.Length() == 0 {
-		sourceAngle = 0
-	} else {
-		sourceAngle = lineVector.Angle()
+	routePoints := dlr.link.routePoints
+	for len(dlr.link.linkSegments) < len(routePoints)-1 {
+		dlr.link.linkSegments = append(dlr.link.linkSegments, NewLinkSegment(dlr.link, fyne.Position{}, fyne.Position{}))
+	}
+	dlr.link.linkSegments = dlr.link.linkSegments[:len(routePoints)-1]
+	// The dash pattern continues from one segment to the next
+	pathLength := float32(0)
+	for i, linkSegment := range dlr.link.linkSegments {
+		linkSegment.dashOffset = pathLength
+		linkSegment.SetPoints(routePoints[i], routePoints[i+1])
+		pathLength += float32(math.Hypot(float64(routePoints[i+1].X-routePoints[i].X), float64(routePoints[i+1].Y-routePoints[i].Y)))
+	}
+
+	// The decorations are oriented along the first and last non-degenerate segments of the path
+	sourcePoint := routePoints[0]
+	sourceAngle := 0.0
+	for _, point := range routePoints[1:] {
+		if point != sourcePoint {
+			sourceAngle = getSegmentAngle(sourcePoint, point)
+			break
+		}
 	}
 	sourceOffset := 0.0
 	for _, decoration := range dlr.link.SourceDecorations {
 		decorationReferencePoint := fyne.Position{
-			X: float32(float64(dlr.link.linkPoints[0].Position().X) + math.Cos(sourceAngle)*sourceOffset),
-			Y: float32(float64(dlr.link.linkPoints[0].Position().Y) - math.Sin(sourceAngle)*sourceOffset),
+			X: float32(float64(sourcePoint.X) + math.Cos(sourceAngle)*sourceOffset),
+			Y: float32(float64(sourcePoint.Y) - math.Sin(sourceAngle)*sourceOffset),
 		}
 		decoration.Move(decorationReferencePoint)
 		decoration.setBaseAngle(sourceAngle)
 		sourceOffset = sourceOffset + float64(decoration.GetReferenceLength())
 	}
 
-	// TODO Update target angle for multiple segments
+	targetPoint := routePoints[len(routePoints)-1]
 	targetAngle := r2.AddAngles(sourceAngle, math.Pi)
+	for i := len(routePoints) - 2; i >= 0; i-- {
+		if routePoints[i] != targetPoint {
+			targetAngle = getSegmentAngle(targetPoint, routePoints[i])
+			break
+		}
+	}
 
+	midPosition, midAngle := dlr.link.getMidPositionAndAngle()
+	midStackAngle := r2.AddAngles(midAngle, math.Pi)
 	midOffset := 0.0
 	for _, decoration := range dlr.link.MidpointDecorations {
 		decorationReferencePoint := fyne.Position{
-			X: float32(float64(dlr.link.getMidPosition().X) + math.Cos(targetAngle)*midOffset),
-			Y: float32(float64(dlr.link.getMidPosition().Y) - math.Sin(targetAngle)*midOffset),
+			X: float32(float64(midPosition.X) + math.Cos(midStackAngle)*midOffset),
+			Y: float32(float64(midPosition.Y) - math.Sin(midStackAngle)*midOffset),
 		}
 		decoration.Move(decorationReferencePoint)
-		decoration.setBaseAngle(sourceAngle)
+		decoration.setBaseAngle(midAngle)
 		midOffset = midOffset + float64(decoration.GetReferenceLength())
 	}
-	defaultPadPosition := dlr.link.getMidPosition().AddXY(-pointPadSize/2, -pointPadSize/2)
+	defaultPadPosition := midPosition.AddXY(-pointPadSize/2, -pointPadSize/2)
 	dlr.link.pads["default"].Move(defaultPadPosition)
 	dlr.link.pads["default"].Resize(fyne.NewSize(pointPadSize, pointPadSize))
 	dlr.link.pads["default"].Refresh()
@@ -602,8 +769,8 @@ func (dlr *diagramLinkRenderer) Refresh() {
 	targetOffset := 0.0
 	for _, decoration := range dlr.link.TargetDecorations {
 		decorationReferencePoint := fyne.Position{
-			X: float32(float64(dlr.link.linkPoints[len(dlr.link.linkPoints)-1].Position().X) + math.Cos(targetAngle)*targetOffset),
-			Y: float32(float64(dlr.link.linkPoints[len(dlr.link.linkPoints)-1].Position().Y) - math.Sin(targetAngle)*targetOffset),
+			X: float32(float64(targetPoint.X) + math.Cos(targetAngle)*targetOffset),
+			Y: float32(float64(targetPoint.Y) - math.Sin(targetAngle)*targetOffset),
 		}
 		decoration.Move(decorationReferencePoint)
 		decoration.setBaseAngle(targetAngle)
@@ -613,7 +780,7 @@ func (dlr *diagramLinkRenderer) Refresh() {
 		anchoredText.SetReferencePosition(dlr.link.getSourcePosition())
 	}
 	for _, anchoredText := range dlr.link.midpointAnchoredText {
-		anchoredText.SetReferencePosition(dlr.link.getMidPosition())
+		anchoredText.SetReferencePosition(midPosition)
 	}
 	for _, anchoredText := range dlr.link.targetAnchoredText {
 		anchoredText.SetReferencePosition(dlr.link.getTargetPosition())
@@ -623,21 +790,23 @@ func (dlr *diagramLinkRenderer) Refresh() {
 	for _, linkSegment := range dlr.link.linkSegments {
 		linkSegment.Refresh()
 	}
+	strokeColor := dlr.link.diagram.getHighlightedForegroundColor(dlr.link.id, dlr.link.properties.ForegroundColor)
+	strokeWidth := dlr.link.diagram.getHighlightedStrokeWidth(dlr.link.id, dlr.link.diagram.scaled(dlr.link.properties.StrokeWidth))
 	for _, decoration := range dlr.link.SourceDecorations {
-		decoration.SetStrokeColor(dlr.link.properties.ForegroundColor)
-		decoration.SetStrokeWidth(dlr.link.properties.StrokeWidth)
+		decoration.SetStrokeColor(strokeColor)
+		decoration.SetStrokeWidth(strokeWidth)
 		decoration.SetFillColor(dlr.link.diagram.GetBackgroundColor())
 		decoration.Refresh()
 	}
 	for _, decoration := range dlr.link.MidpointDecorations {
-		decoration.SetStrokeColor(dlr.link.properties.ForegroundColor)
-		decoration.SetStrokeWidth(dlr.link.properties.StrokeWidth)
+		decoration.SetStrokeColor(strokeColor)
+		decoration.SetStrokeWidth(strokeWidth)
 		decoration.SetFillColor(dlr.link.diagram.GetBackgroundColor())
 		decoration.Refresh()
 	}
 	for _, decoration := range dlr.link.TargetDecorations {
-		decoration.SetStrokeColor(dlr.link.properties.ForegroundColor)
-		decoration.SetStrokeWidth(dlr.link.properties.StrokeWidth)
+		decoration.SetStrokeColor(strokeColor)
+		decoration.SetStrokeWidth(strokeWidth)
 		decoration.SetFillColor(dlr.link.diagram.GetBackgroundColor())
 		decoration.Refresh()
 	}
@@ -649,12 +818,27 @@ func (dlr *diagramLinkRenderer) Refresh() {
 			handle.Move(dlr.link.linkPoints[0].Position())
 		case TARGET.ToString():
 			handle.Move(dlr.link.linkPoints[len(dlr.link.linkPoints)-1].Position())
+		default:
+			if index, ok := parseWaypointHandleKey(key); ok && index+1 < len(dlr.link.linkPoints)-1 {
+				handle.Move(dlr.link.linkPoints[index+1].Position())
+			}
 		}
 		handle.Resize(fyne.NewSize(handle.handleSize, handle.handleSize))
 		handle.Refresh()
 	}
 
 	dlr.link.diagram.refreshDependentLinks(dlr.link)
+	dlr.link.diagram.minimapElementChanged(dlr.link.typedLink)
+}
+
+// getSegmentAngle returns the angle of the segment from p1 to p2, or zero if the points coincide.
+// The sign of Y is changed since the window inverts the Y axis.
+func getSegmentAngle(p1 fyne.Position, p2 fyne.Position) float64 {
+	lineVector := r2.Vec2{X: float64(p2.X - p1.X), Y: -float64(p2.Y - p1.Y)}
+	if lineVector.Length() == 0 {
+		return 0
+	}
+	return lineVector.Angle()
 }
 
 // ConnectionTransaction holds transient data during the creation of a link. It is public for testing purposes only
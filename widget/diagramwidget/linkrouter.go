@@ -0,0 +1,614 @@
+package diagramwidget
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	"fyne.io/fyne/v2"
+
+	"fyne.io/x/fyne/widget/diagramwidget/geometry/r2"
+)
+
+// LinkRouter determines the path followed by a DiagramLink. The link supplies its control points: the
+// source connection point, any intermediate link points, and the target connection point, all in diagram
+// coordinates. The router returns the path in diagram coordinates, which must begin at the first control
+// point and end at the last. The link draws one LinkSegment for each pair of consecutive path points and
+// orients its decorations and midpoint AnchoredTexts along the path.
+type LinkRouter interface {
+	Route(link DiagramLink, controlPoints []fyne.Position) []fyne.Position
+}
+
+// Validate that the routers implement LinkRouter
+var _ LinkRouter = (*StraightLinkRouter)(nil)
+var _ LinkRouter = (*OrthogonalLinkRouter)(nil)
+var _ LinkRouter = (*SplineLinkRouter)(nil)
+
+/******************************
+	StraightLinkRouter
+*******************************/
+
+// StraightLinkRouter connects the control points with straight line segments. It is the default router.
+type StraightLinkRouter struct{}
+
+// NewStraightLinkRouter returns a StraightLinkRouter
+func NewStraightLinkRouter() *StraightLinkRouter {
+	return &StraightLinkRouter{}
+}
+
+// Route returns the control points
+func (r *StraightLinkRouter) Route(link DiagramLink, controlPoints []fyne.Position) []fyne.Position {
+	return append([]fyne.Position(nil), controlPoints...)
+}
+
+/******************************
+	OrthogonalLinkRouter
+*******************************/
+
+// OrthogonalLinkRouter connects the control points with horizontal and vertical line segments that avoid
+// the bounding boxes of the diagram's nodes. Where a link end is connected to a node, the path leaves the
+// node perpendicular to the side on which the connection point lies.
+type OrthogonalLinkRouter struct {
+	// Margin is the clearance between the path and the bounding boxes of the nodes at zoom 1
+	Margin float32
+	// BendPenalty is the path length equivalent (at zoom 1) of a bend. Larger values produce paths with fewer bends.
+	BendPenalty float32
+}
+
+// NewOrthogonalLinkRouter returns an OrthogonalLinkRouter with the default margin and bend penalty
+func NewOrthogonalLinkRouter() *OrthogonalLinkRouter {
+	return &OrthogonalLinkRouter{
+		Margin:      15,
+		BendPenalty: 40,
+	}
+}
+
+// Route returns an orthogonal path through the control points. Only the nodes in the region around the control
+// points are considered, and the route is reused until the control points or the nodes in the region change.
+func (r *OrthogonalLinkRouter) Route(link DiagramLink, controlPoints []fyne.Position) []fyne.Position {
+	if len(controlPoints) < 2 {
+		return append([]fyne.Position(nil), controlPoints...)
+	}
+	diagram := link.GetDiagram()
+	margin := float64(diagram.scaled(r.Margin))
+	bendPenalty := float64(diagram.scaled(r.BendPenalty))
+
+	// The stubs are the points, just outside of the nodes, through which the path leaves and arrives
+	last := len(controlPoints) - 2
+	type span struct {
+		start, startStub, startDirection, end, endStub, endDirection r2.Vec2
+	}
+	spans := make([]span, last+1)
+	regionPoints := []r2.Vec2{}
+	for i := 0; i <= last; i++ {
+		start := toR2(controlPoints[i])
+		end := toR2(controlPoints[i+1])
+		var startDirection, endDirection r2.Vec2
+		var startClearance, endClearance float64
+		if i == 0 {
+			startDirection, startClearance = getPadExit(getDisplayedPad(link.GetSourcePad()), start)
+		}
+		if i == last {
+			endDirection, endClearance = getPadExit(getDisplayedPad(link.GetTargetPad()), end)
+		}
+		startStub := start.Add(startDirection.Scale(startClearance + margin))
+		endStub := end.Add(endDirection.Scale(endClearance + margin))
+		spans[i] = span{start, startStub, startDirection, end, endStub, endDirection}
+		regionPoints = append(regionPoints, start, startStub, end, endStub)
+	}
+	region, obstacles := getRouteRegion(regionPoints, margin, getRouteObstacles(diagram, margin))
+
+	bdl := link.getBaseDiagramLink()
+	if cached := bdl.orthogonalRoute; cached != nil && cached.matches(r, margin, bendPenalty, controlPoints, obstacles) {
+		return append([]fyne.Position(nil), cached.route...)
+	}
+	boxes := make([]r2.Box, len(obstacles))
+	for i, obstacle := range obstacles {
+		boxes[i] = obstacle.box
+	}
+	path := []r2.Vec2{toR2(controlPoints[0])}
+	for _, s := range spans {
+		route := r.routeSpan(s.start, s.startStub, s.startDirection, s.end, s.endStub, s.endDirection, region, boxes, bendPenalty)
+		path = append(path, route[1:]...)
+	}
+	route := fromR2(simplifyPath(path))
+	bdl.orthogonalRoute = &orthogonalRoute{
+		router:        r,
+		margin:        margin,
+		bendPenalty:   bendPenalty,
+		controlPoints: append([]fyne.Position(nil), controlPoints...),
+		region:        region,
+		obstacles:     obstacles,
+		route:         route,
+	}
+	return append([]fyne.Position(nil), route...)
+}
+
+// routeSpan finds the shortest orthogonal path (counting bends) between the start and end points on a grid
+// formed by the edges of the obstacles within the region. The path leaves the start point through the start
+// stub and arrives at the end point through the end stub. A non-zero direction forces the path to leave (or
+// arrive) along it.
+func (r *OrthogonalLinkRouter) routeSpan(start, startStub, startDirection, end, endStub, endDirection r2.Vec2, region r2.Box, obstacles []r2.Box, bendPenalty float64) []r2.Vec2 {
+	fallback := []r2.Vec2{start, startStub, r2.V2((startStub.X+endStub.X)/2, startStub.Y),
+		r2.V2((startStub.X+endStub.X)/2, endStub.Y), endStub, end}
+	if insideAny(startStub, obstacles) || insideAny(endStub, obstacles) {
+		return fallback
+	}
+
+	xs := []float64{startStub.X, endStub.X, (startStub.X + endStub.X) / 2, region.A.X, region.A.X + region.S.X}
+	ys := []float64{startStub.Y, endStub.Y, (startStub.Y + endStub.Y) / 2, region.A.Y, region.A.Y + region.S.Y}
+	for _, box := range obstacles {
+		xs = append(xs, box.A.X, box.A.X+box.S.X)
+		ys = append(ys, box.A.Y, box.A.Y+box.S.Y)
+	}
+	// The path stays within the region, outside of which the obstacles are not known
+	xs = uniqueSortedWithin(xs, region.A.X, region.A.X+region.S.X)
+	ys = uniqueSortedWithin(ys, region.A.Y, region.A.Y+region.S.Y)
+	index := func(coordinates []float64, value float64) int {
+		return sort.SearchFloat64s(coordinates, value)
+	}
+	grid := newOrthogonalGrid(xs, ys, obstacles)
+	startX, startY := index(xs, startStub.X), index(ys, startStub.Y)
+	endX, endY := index(xs, endStub.X), index(ys, endStub.Y)
+
+	// Dijkstra over (grid point, arrival direction) states. The arrival direction noDirection is only
+	// used for the start state when the start direction is free.
+	startState := grid.state(startX, startY, directionIndex(startDirection))
+	arrivalDirection := directionIndex(endDirection.Scale(-1))
+	stateCount := len(xs) * len(ys) * 5
+	cost := make([]float64, stateCount)
+	previous := make([]int, stateCount)
+	for i := range cost {
+		cost[i] = math.Inf(1)
+		previous[i] = -1
+	}
+	cost[startState] = 0
+	queue := &routeQueue{{state: startState}}
+	bestEnd := -1
+	bestCost := math.Inf(1)
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(routeQueueItem)
+		if item.cost > cost[item.state] || item.cost >= bestCost {
+			continue
+		}
+		x, y, direction := grid.coordinates(item.state)
+		if x == endX && y == endY {
+			total := item.cost
+			if arrivalDirection != noDirection && direction != arrivalDirection {
+				total += bendPenalty
+			}
+			if total < bestCost {
+				bestCost = total
+				bestEnd = item.state
+			}
+			continue
+		}
+		for newDirection, step := range directionSteps {
+			nx, ny := x+step[0], y+step[1]
+			if !grid.isOpen(x, y, nx, ny) {
+				continue
+			}
+			newCost := item.cost + math.Abs(xs[nx]-xs[x]) + math.Abs(ys[ny]-ys[y])
+			if direction != noDirection && direction != newDirection {
+				newCost += bendPenalty
+			}
+			newState := grid.state(nx, ny, newDirection)
+			if newCost < cost[newState] {
+				cost[newState] = newCost
+				previous[newState] = item.state
+				heap.Push(queue, routeQueueItem{state: newState, cost: newCost})
+			}
+		}
+	}
+	if bestEnd == -1 {
+		return fallback
+	}
+	gridPath := []r2.Vec2{}
+	for state := bestEnd; state != -1; state = previous[state] {
+		x, y, _ := grid.coordinates(state)
+		gridPath = append(gridPath, r2.V2(xs[x], ys[y]))
+	}
+	path := []r2.Vec2{start}
+	for i := len(gridPath) - 1; i >= 0; i-- {
+		path = append(path, gridPath[i])
+	}
+	return append(path, end)
+}
+
+// maxRouteRegionPasses is the number of times that the region searched for a route is grown to enclose the
+// obstacles overlapping it
+const maxRouteRegionPasses = 3
+
+// routeObstacle is the bounding box of a node, including the margin, that a route must avoid
+type routeObstacle struct {
+	node DiagramNode
+	box  r2.Box
+}
+
+// orthogonalRoute is the route last computed for a link by an OrthogonalLinkRouter, together with what it was
+// computed from, so that the link is only re-routed when these change
+type orthogonalRoute struct {
+	router        *OrthogonalLinkRouter
+	margin        float64
+	bendPenalty   float64
+	controlPoints []fyne.Position
+	// region is the area within which the route was searched, and obstacles are the nodes overlapping it
+	region    r2.Box
+	obstacles []routeObstacle
+	route     []fyne.Position
+}
+
+// matches returns true if the route was computed by the router from the same control points and obstacles
+func (or *orthogonalRoute) matches(router *OrthogonalLinkRouter, margin, bendPenalty float64, controlPoints []fyne.Position, obstacles []routeObstacle) bool {
+	if or.router != router || or.margin != margin || or.bendPenalty != bendPenalty ||
+		len(or.controlPoints) != len(controlPoints) || len(or.obstacles) != len(obstacles) {
+		return false
+	}
+	for i, point := range controlPoints {
+		if or.controlPoints[i] != point {
+			return false
+		}
+	}
+	for i, obstacle := range obstacles {
+		if or.obstacles[i] != obstacle {
+			return false
+		}
+	}
+	return true
+}
+
+// mayBeAffectedBy returns false if the route cannot depend on the node, as the node was not among its obstacles
+// and does not overlap its region
+func (or *orthogonalRoute) mayBeAffectedBy(node DiagramNode) bool {
+	if or == nil {
+		return true
+	}
+	for _, obstacle := range or.obstacles {
+		if obstacle.node == node {
+			return true
+		}
+	}
+	return boxesOverlap(growBox(node.getBaseDiagramNode().R2Box(), or.margin), or.region)
+}
+
+// getRouteObstacles returns the bounding boxes, grown by the margin, of the nodes that routes must avoid
+func getRouteObstacles(diagram *DiagramWidget, margin float64) []routeObstacle {
+	obstacles := []routeObstacle{}
+	for _, node := range diagram.GetDiagramNodes() {
+		if !node.Visible() {
+			continue
+		}
+		if group, ok := node.(DiagramGroup); ok && !group.IsCollapsed() {
+			// Links may cross the area of an expanded group to reach its children
+			continue
+		}
+		obstacles = append(obstacles, routeObstacle{node: node, box: growBox(node.getBaseDiagramNode().R2Box(), margin)})
+	}
+	return obstacles
+}
+
+// getRouteRegion returns the area within which a route through the points is searched, and the obstacles
+// overlapping it. The area encloses the points and grows to enclose the obstacles in the way, with room to
+// go around them.
+func getRouteRegion(points []r2.Vec2, margin float64, obstacles []routeObstacle) (r2.Box, []routeObstacle) {
+	region := r2.MakeBox(points[0], r2.V2(0, 0))
+	for _, point := range points[1:] {
+		region = unionBox(region, r2.MakeBox(point, r2.V2(0, 0)))
+	}
+	region = growBox(region, margin)
+	for pass := 0; pass < maxRouteRegionPasses; pass++ {
+		grown := region
+		for _, obstacle := range obstacles {
+			if boxesOverlap(obstacle.box, region) {
+				grown = unionBox(grown, growBox(obstacle.box, margin))
+			}
+		}
+		if grown == region {
+			break
+		}
+		region = grown
+	}
+	inRegion := []routeObstacle{}
+	for _, obstacle := range obstacles {
+		if boxesOverlap(obstacle.box, region) {
+			inRegion = append(inRegion, obstacle)
+		}
+	}
+	return region, inRegion
+}
+
+const noDirection = 4
+
+// directionSteps are the grid steps for right, down, left and up
+var directionSteps = [4][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+
+func directionIndex(direction r2.Vec2) int {
+	switch {
+	case direction.X > 0:
+		return 0
+	case direction.Y > 0:
+		return 1
+	case direction.X < 0:
+		return 2
+	case direction.Y < 0:
+		return 3
+	}
+	return noDirection
+}
+
+// orthogonalGrid is the grid searched for a route. The grid points and steps inside the obstacles are blocked.
+type orthogonalGrid struct {
+	xs []float64
+	ys []float64
+	// blockedPoints, blockedHorizontal and blockedVertical are indexed by y*len(xs)+x, the steps by the index of
+	// the grid point with the lower coordinates
+	blockedPoints     []bool
+	blockedHorizontal []bool
+	blockedVertical   []bool
+}
+
+// newOrthogonalGrid creates the grid and marks the grid points and steps inside the obstacles. Since the grid
+// includes all obstacle edges, a step is inside an obstacle if its midpoint is.
+func newOrthogonalGrid(xs, ys []float64, obstacles []r2.Box) *orthogonalGrid {
+	g := &orthogonalGrid{
+		xs:                xs,
+		ys:                ys,
+		blockedPoints:     make([]bool, len(xs)*len(ys)),
+		blockedHorizontal: make([]bool, len(xs)*len(ys)),
+		blockedVertical:   make([]bool, len(xs)*len(ys)),
+	}
+	for _, box := range obstacles {
+		boxes := []r2.Box{box}
+		// Only the grid lines from the edges of the box inwards are inside it
+		firstX, lastX := sort.SearchFloat64s(xs, box.A.X), sort.SearchFloat64s(xs, box.A.X+box.S.X)
+		firstY, lastY := sort.SearchFloat64s(ys, box.A.Y), sort.SearchFloat64s(ys, box.A.Y+box.S.Y)
+		for y := firstY; y <= lastY && y < len(ys); y++ {
+			for x := firstX; x <= lastX && x < len(xs); x++ {
+				i := y*len(xs) + x
+				if insideAny(r2.V2(xs[x], ys[y]), boxes) {
+					g.blockedPoints[i] = true
+				}
+				if x+1 < len(xs) && insideAny(r2.V2((xs[x]+xs[x+1])/2, ys[y]), boxes) {
+					g.blockedHorizontal[i] = true
+				}
+				if y+1 < len(ys) && insideAny(r2.V2(xs[x], (ys[y]+ys[y+1])/2), boxes) {
+					g.blockedVertical[i] = true
+				}
+			}
+		}
+	}
+	return g
+}
+
+func (g *orthogonalGrid) state(x, y, direction int) int {
+	return (y*len(g.xs)+x)*5 + direction
+}
+
+func (g *orthogonalGrid) coordinates(state int) (int, int, int) {
+	point := state / 5
+	return point % len(g.xs), point / len(g.xs), state % 5
+}
+
+// isOpen returns true if the step from (x, y) to (nx, ny) stays on the grid and outside of the obstacles
+func (g *orthogonalGrid) isOpen(x, y, nx, ny int) bool {
+	if nx < 0 || ny < 0 || nx >= len(g.xs) || ny >= len(g.ys) {
+		return false
+	}
+	if g.blockedPoints[ny*len(g.xs)+nx] {
+		return false
+	}
+	if ny == y {
+		return !g.blockedHorizontal[y*len(g.xs)+int(math.Min(float64(x), float64(nx)))]
+	}
+	return !g.blockedVertical[int(math.Min(float64(y), float64(ny)))*len(g.xs)+x]
+}
+
+type routeQueueItem struct {
+	state int
+	cost  float64
+}
+
+// routeQueue is a priority queue ordered by cost
+type routeQueue []routeQueueItem
+
+func (q routeQueue) Len() int            { return len(q) }
+func (q routeQueue) Less(i, j int) bool  { return q[i].cost < q[j].cost }
+func (q routeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *routeQueue) Push(x interface{}) { *q = append(*q, x.(routeQueueItem)) }
+func (q *routeQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+/******************************
+	SplineLinkRouter
+*******************************/
+
+// SplineLinkRouter connects the control points with smooth cubic Bézier curves. Where a link end is
+// connected to a node, the curve leaves the node perpendicular to the side on which the connection
+// point lies. The curves are approximated by straight line segments.
+type SplineLinkRouter struct {
+	// Curvature is the length of the Bézier handles relative to the distance between control points
+	Curvature float32
+	// SegmentsPerSpan is the number of line segments used to approximate the curve between two control points
+	SegmentsPerSpan int
+}
+
+// NewSplineLinkRouter returns a SplineLinkRouter with the default curvature and number of segments
+func NewSplineLinkRouter() *SplineLinkRouter {
+	return &SplineLinkRouter{
+		Curvature:       0.4,
+		SegmentsPerSpan: 16,
+	}
+}
+
+// Route returns a curved path through the control points
+func (r *SplineLinkRouter) Route(link DiagramLink, controlPoints []fyne.Position) []fyne.Position {
+	if len(controlPoints) < 2 {
+		return append([]fyne.Position(nil), controlPoints...)
+	}
+	points := []r2.Vec2{}
+	for _, controlPoint := range controlPoints {
+		points = append(points, toR2(controlPoint))
+	}
+	// The tangents are unit vectors pointing along the direction of travel
+	last := len(points) - 1
+	tangents := make([]r2.Vec2, len(points))
+	for i := range points {
+		switch i {
+		case 0:
+			tangents[i] = getPadExitDirection(getDisplayedPad(link.GetSourcePad()), points[0])
+			if tangents[i].Length() == 0 {
+				tangents[i] = unitOrZero(points[1].Add(points[0].Scale(-1)))
+			}
+		case last:
+			tangents[i] = getPadExitDirection(getDisplayedPad(link.GetTargetPad()), points[last]).Scale(-1)
+			if tangents[i].Length() == 0 {
+				tangents[i] = unitOrZero(points[last].Add(points[last-1].Scale(-1)))
+			}
+		default:
+			tangents[i] = unitOrZero(points[i+1].Add(points[i-1].Scale(-1)))
+		}
+	}
+	segments := r.SegmentsPerSpan
+	if segments < 1 {
+		segments = 1
+	}
+	path := []r2.Vec2{points[0]}
+	for i := 0; i < last; i++ {
+		handleLength := float64(r.Curvature) * points[i+1].Add(points[i].Scale(-1)).Length()
+		p0 := points[i]
+		p1 := p0.Add(tangents[i].Scale(handleLength))
+		p3 := points[i+1]
+		p2 := p3.Add(tangents[i+1].Scale(-handleLength))
+		for s := 1; s <= segments; s++ {
+			t := float64(s) / float64(segments)
+			u := 1 - t
+			point := p0.Scale(u * u * u).Add(p1.Scale(3 * u * u * t)).Add(p2.Scale(3 * u * t * t)).Add(p3.Scale(t * t * t))
+			path = append(path, point)
+		}
+	}
+	return fromR2(path)
+}
+
+/******************************
+	Helpers
+*******************************/
+
+// getPadExitDirection returns the outward unit normal of the side of the pad owner's bounding box nearest to the
+// point when the pad belongs to a node. Otherwise it returns a zero vector, indicating that any direction is acceptable.
+func getPadExitDirection(pad ConnectionPad, point r2.Vec2) r2.Vec2 {
+	direction, _ := getPadExit(pad, point)
+	return direction
+}
+
+// getPadExit returns the exit direction (see getPadExitDirection) and the distance from the point to the
+// nearest side of the pad owner's bounding box, measured along the exit direction
+func getPadExit(pad ConnectionPad, point r2.Vec2) (r2.Vec2, float64) {
+	if pad == nil || !pad.GetPadOwner().IsNode() {
+		return r2.V2(0, 0), 0
+	}
+	box := pad.GetPadOwner().(DiagramNode).getBaseDiagramNode().R2Box()
+	distances := []float64{
+		box.A.X + box.S.X - point.X,
+		box.A.Y + box.S.Y - point.Y,
+		point.X - box.A.X,
+		point.Y - box.A.Y,
+	}
+	nearest := 0
+	for i, distance := range distances {
+		if math.Abs(distance) < math.Abs(distances[nearest]) {
+			nearest = i
+		}
+	}
+	step := directionSteps[nearest]
+	return r2.V2(float64(step[0]), float64(step[1])), distances[nearest]
+}
+
+// insideAny returns true if the point is strictly inside any of the boxes
+func insideAny(point r2.Vec2, boxes []r2.Box) bool {
+	const epsilon = 0.001
+	for _, box := range boxes {
+		if point.X > box.A.X+epsilon && point.X < box.A.X+box.S.X-epsilon &&
+			point.Y > box.A.Y+epsilon && point.Y < box.A.Y+box.S.Y-epsilon {
+			return true
+		}
+	}
+	return false
+}
+
+// simplifyPath removes repeated points and points in the middle of straight runs
+func simplifyPath(path []r2.Vec2) []r2.Vec2 {
+	const epsilon = 0.001
+	result := []r2.Vec2{}
+	for _, point := range path {
+		if len(result) > 0 && point.Add(result[len(result)-1].Scale(-1)).Length() < epsilon {
+			result[len(result)-1] = point
+			continue
+		}
+		if len(result) >= 2 {
+			a := result[len(result)-2]
+			b := result[len(result)-1]
+			cross := (b.X-a.X)*(point.Y-b.Y) - (b.Y-a.Y)*(point.X-b.X)
+			if math.Abs(cross) < epsilon && (b.X-a.X)*(point.X-b.X)+(b.Y-a.Y)*(point.Y-b.Y) >= 0 {
+				result[len(result)-1] = point
+				continue
+			}
+		}
+		result = append(result, point)
+	}
+	return result
+}
+
+// uniqueSortedWithin returns the distinct values between min and max, in increasing order
+func uniqueSortedWithin(values []float64, min, max float64) []float64 {
+	sort.Float64s(values)
+	result := []float64{}
+	for _, value := range values {
+		if value < min || value > max {
+			continue
+		}
+		if len(result) == 0 || value != result[len(result)-1] {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// boxesOverlap returns true if the boxes overlap or touch
+func boxesOverlap(a, b r2.Box) bool {
+	return a.A.X <= b.A.X+b.S.X && b.A.X <= a.A.X+a.S.X && a.A.Y <= b.A.Y+b.S.Y && b.A.Y <= a.A.Y+a.S.Y
+}
+
+// growBox returns the box grown by the margin on every side
+func growBox(box r2.Box, margin float64) r2.Box {
+	return r2.MakeBox(box.A.Add(r2.V2(-margin, -margin)), box.S.Add(r2.V2(2*margin, 2*margin)))
+}
+
+// unionBox returns the smallest box enclosing both boxes
+func unionBox(a, b r2.Box) r2.Box {
+	minX, minY := math.Min(a.A.X, b.A.X), math.Min(a.A.Y, b.A.Y)
+	maxX, maxY := math.Max(a.A.X+a.S.X, b.A.X+b.S.X), math.Max(a.A.Y+a.S.Y, b.A.Y+b.S.Y)
+	return r2.MakeBox(r2.V2(minX, minY), r2.V2(maxX-minX, maxY-minY))
+}
+
+func unitOrZero(v r2.Vec2) r2.Vec2 {
+	if v.Length() == 0 {
+		return v
+	}
+	return v.Unit()
+}
+
+func toR2(p fyne.Position) r2.Vec2 {
+	return r2.V2(float64(p.X), float64(p.Y))
+}
+
+func fromR2(points []r2.Vec2) []fyne.Position {
+	positions := []fyne.Position{}
+	for _, point := range points {
+		positions = append(positions, fyne.NewPos(float32(point.X), float32(point.Y)))
+	}
+	return positions
+}
@@ -0,0 +1,140 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+
+	"fyne.io/x/fyne/widget/diagramwidget/geometry/r2"
+)
+
+func getDiagramRoute(link *BaseDiagramLink) []fyne.Position {
+	route := []fyne.Position{}
+	for _, point := range link.getRoutePoints() {
+		route = append(route, link.Position().Add(point))
+	}
+	return route
+}
+
+func TestOrthogonalLinkRouterAvoidsNodes(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	source := NewDiagramNode(diagram, nil, "Source")
+	source.Move(fyne.NewPos(50, 100))
+	obstacle := NewDiagramNode(diagram, nil, "Obstacle")
+	obstacle.Move(fyne.NewPos(250, 100))
+	target := NewDiagramNode(diagram, nil, "Target")
+	target.Move(fyne.NewPos(450, 100))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(source.GetEdgePad())
+	link.SetTargetPad(target.GetEdgePad())
+	link.AddTargetDecoration(NewArrowhead())
+	link.AddMidpointAnchoredText("name", "Link 1")
+	// The straight path passes through the obstacle
+	assert.Equal(t, 2, len(link.getRoutePoints()))
+
+	link.SetRouter(NewOrthogonalLinkRouter())
+	route := getDiagramRoute(link)
+	assert.Greater(t, len(route), 2)
+	assert.Equal(t, len(route)-1, len(link.linkSegments))
+	box := obstacle.getBaseDiagramNode().R2Box()
+	for i := 0; i < len(route)-1; i++ {
+		p1, p2 := route[i], route[i+1]
+		assert.True(t, p1.X == p2.X || p1.Y == p2.Y, "segment %d is not orthogonal", i)
+		midpoint := toR2(fyne.NewPos((p1.X+p2.X)/2, (p1.Y+p2.Y)/2))
+		assert.False(t, insideAny(midpoint, []r2.Box{box}), "segment %d crosses the obstacle", i)
+	}
+
+	// The arrowhead is aligned with the last segment and the midpoint text follows the path
+	last := len(route) - 1
+	assert.InDelta(t, getSegmentAngle(route[last], route[last-1]), link.TargetDecorations[0].(*Arrowhead).baseAngle, 1e-6)
+	assert.Equal(t, link.getMidPosition(), link.GetMidpointAnchoredText("name").referencePosition)
+}
+
+func TestSplineLinkRouter(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	source := NewDiagramNode(diagram, nil, "Source")
+	source.Move(fyne.NewPos(50, 100))
+	obstacle := NewDiagramNode(diagram, nil, "Obstacle")
+	obstacle.Move(fyne.NewPos(250, 100))
+	target := NewDiagramNode(diagram, nil, "Target")
+	target.Move(fyne.NewPos(450, 100))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(source.GetEdgePad())
+	link.SetTargetPad(target.GetEdgePad())
+	link.AddTargetDecoration(NewArrowhead())
+	link.AddMidpointAnchoredText("name", "Link 1")
+	link.SetRouter(NewSplineLinkRouter())
+	route := getDiagramRoute(link)
+	assert.Equal(t, 17, len(route))
+	assert.Equal(t, link.GetSourcePad().getConnectionPointInDiagramCoordinates(link.GetTargetPad().GetCenterInDiagramCoordinates()), route[0])
+
+	link.SetRouter(nil)
+	assert.Equal(t, 2, len(link.getRoutePoints()))
+	assert.Equal(t, 1, len(link.linkSegments))
+}
+
+func TestOrthogonalLinkRouterFollowsNodeMoves(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	source := NewDiagramNode(diagram, nil, "Source")
+	source.Move(fyne.NewPos(50, 100))
+	obstacle := NewDiagramNode(diagram, nil, "Obstacle")
+	obstacle.Move(fyne.NewPos(250, 100))
+	target := NewDiagramNode(diagram, nil, "Target")
+	target.Move(fyne.NewPos(450, 100))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(source.GetEdgePad())
+	link.SetTargetPad(target.GetEdgePad())
+	link.AddTargetDecoration(NewArrowhead())
+	link.AddMidpointAnchoredText("name", "Link 1")
+	link.SetRouter(NewOrthogonalLinkRouter())
+	assert.Greater(t, len(link.getRoutePoints()), 2)
+	obstacle.Move(obstacle.Position().AddXY(0, 200))
+	assert.Equal(t, 2, len(link.getRoutePoints()))
+}
+
+func TestOrthogonalLinkRouterOnlyReroutesAffectedLinks(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	source := NewDiagramNode(diagram, nil, "Source")
+	source.Move(fyne.NewPos(50, 100))
+	obstacle := NewDiagramNode(diagram, nil, "Obstacle")
+	obstacle.Move(fyne.NewPos(250, 100))
+	target := NewDiagramNode(diagram, nil, "Target")
+	target.Move(fyne.NewPos(450, 100))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(source.GetEdgePad())
+	link.SetTargetPad(target.GetEdgePad())
+	link.AddTargetDecoration(NewArrowhead())
+	link.AddMidpointAnchoredText("name", "Link 1")
+	obstacle.Move(obstacle.Position().AddXY(0, 200))
+	far := NewDiagramNode(diagram, nil, "Far")
+	far.Move(fyne.NewPos(250, 800))
+	for i := 0; i < 100; i++ {
+		NewDiagramNode(diagram, nil, "Other").Move(fyne.NewPos(float32(i%10)*100, 1000+float32(i/10)*100))
+	}
+	link.SetRouter(NewOrthogonalLinkRouter())
+	assert.Equal(t, 2, len(link.getRoutePoints()))
+	// Only the nodes near the link are considered when routing
+	route := link.orthogonalRoute
+	assert.Equal(t, 2, len(route.obstacles))
+
+	// Moving a node outside of the region of the link does not re-route it
+	far.Move(far.Position().AddXY(50, 0))
+	assert.Same(t, route, link.orthogonalRoute)
+
+	// Moving a node into the region re-routes the link around it
+	far.Move(fyne.NewPos(250, 100))
+	assert.NotSame(t, route, link.orthogonalRoute)
+	assert.Greater(t, len(link.getRoutePoints()), 2)
+	route = link.orthogonalRoute
+	assert.Equal(t, 3, len(route.obstacles))
+
+	// Moving it back out of the way restores the straight path
+	far.Move(fyne.NewPos(250, 800))
+	assert.Equal(t, 2, len(link.getRoutePoints()))
+}
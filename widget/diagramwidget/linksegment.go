@@ -11,13 +11,62 @@ import (
 	"github.com/twpayne/go-geom/xy"
 )
 
+// LineStyle determines how the segments of a link are drawn
+type LineStyle int
+
+const (
+	// LineStyleSolid draws continuous lines. This is the default.
+	LineStyleSolid LineStyle = iota
+	// LineStyleDashed draws dashed lines
+	LineStyleDashed
+	// LineStyleDotted draws dotted lines
+	LineStyleDotted
+)
+
+// getDashPattern returns the alternating lengths of the dashes and gaps of the line style for lines of the
+// indicated width. The pattern is empty for solid lines.
+func (style LineStyle) getDashPattern(strokeWidth float32) []float32 {
+	switch style {
+	case LineStyleDashed:
+		return []float32{6 * strokeWidth, 4 * strokeWidth}
+	case LineStyleDotted:
+		return []float32{strokeWidth, 3 * strokeWidth}
+	}
+	return nil
+}
+
+// splitIntoDashes returns the endpoints of the dashes of a line from p1 to p2 drawn with the dash pattern.
+// The offset is the length of the path preceding the line, so that the pattern continues from the previous
+// line of a path; a path starts with a dash. If the pattern is empty, the whole line is returned.
+func splitIntoDashes(p1 fyne.Position, p2 fyne.Position, pattern []float32, offset float32) [][2]fyne.Position {
+	length := float32(math.Hypot(float64(p2.X-p1.X), float64(p2.Y-p1.Y)))
+	if len(pattern) < 2 || length == 0 {
+		return [][2]fyne.Position{{p1, p2}}
+	}
+	pointAt := func(distance float32) fyne.Position {
+		return fyne.NewPos(p1.X+(p2.X-p1.X)*distance/length, p1.Y+(p2.Y-p1.Y)*distance/length)
+	}
+	period := pattern[0] + pattern[1]
+	dashes := [][2]fyne.Position{}
+	for start := -float32(math.Mod(float64(offset), float64(period))); start < length; start += period {
+		dashStart := float32(math.Max(float64(start), 0))
+		dashEnd := float32(math.Min(float64(start+pattern[0]), float64(length)))
+		if dashEnd > dashStart {
+			dashes = append(dashes, [2]fyne.Position{pointAt(dashStart), pointAt(dashEnd)})
+		}
+	}
+	return dashes
+}
+
 // LinkSegment is a widget representing a single line segment belonging to a link
 type LinkSegment struct {
 	widget.BaseWidget
 	link *BaseDiagramLink
 	// p1 and p2 are coordinates in the link's coordinate space
-	p1                fyne.Position
-	p2                fyne.Position
+	p1 fyne.Position
+	p2 fyne.Position
+	// dashOffset is the length of the link's path before the segment, at which its dash pattern starts
+	dashOffset        float32
 	mouseDownPosition fyne.Position
 }
 
@@ -42,6 +91,11 @@ func (ls *LinkSegment) CreateRenderer() fyne.WidgetRenderer {
 	return lsr
 }
 
+// DoubleTapped inserts a waypoint into the link at the tapped point of the segment
+func (ls *LinkSegment) DoubleTapped(event *fyne.PointEvent) {
+	ls.link.insertWaypointOnSegment(ls, ls.Position().Add(event.Position))
+}
+
 // MouseDown behavior depends upon the mouse event. If it is the primary button, it records the locateion of the
 // MouseDown in preparation for a MouseUp at the same location, which will trigger Tapped() behavior. Otherwise, if
 // it is the seconday button and a callback is present, it will invoke the callback
@@ -60,7 +114,7 @@ func (ls *LinkSegment) MouseUp(event *desktop.MouseEvent) {
 		clickPoint := geom.Coord{float64(event.Position.X), float64(event.Position.Y)}
 		p1 := geom.Coord{float64(ls.p1.X), float64(ls.p1.Y)}
 		p2 := geom.Coord{float64(ls.p2.X), float64(ls.p2.Y)}
-		if xy.DistanceFromPointToLine(clickPoint, p1, p2) <= float64(ls.link.properties.StrokeWidth/2)+3 {
+		if xy.DistanceFromPointToLine(clickPoint, p1, p2) <= float64(ls.link.diagram.scaled(ls.link.properties.StrokeWidth)/2)+3 {
 			ls.link.diagram.DiagramElementTapped(ls.link)
 		}
 	} else if ls.link.diagram.LinkSegmentMouseUpCallback != nil {
@@ -79,6 +133,8 @@ func (ls *LinkSegment) SetPoints(p1 fyne.Position, p2 fyne.Position) {
 type linkSegmentRenderer struct {
 	ls   *LinkSegment
 	line *canvas.Line
+	// dashes are displayed instead of the line when the link's line style is not solid
+	dashes []*canvas.Line
 }
 
 func (lsr *linkSegmentRenderer) Destroy() {
@@ -93,6 +149,13 @@ func (lsr *linkSegmentRenderer) MinSize() fyne.Size {
 }
 
 func (lsr *linkSegmentRenderer) Objects() []fyne.CanvasObject {
+	if lsr.ls.link.lineStyle != LineStyleSolid {
+		obj := []fyne.CanvasObject{}
+		for _, dash := range lsr.dashes {
+			obj = append(obj, dash)
+		}
+		return obj
+	}
 	obj := []fyne.CanvasObject{
 		lsr.line,
 	}
@@ -107,7 +170,28 @@ func (lsr *linkSegmentRenderer) Refresh() {
 	lsr.ls.Resize(lsr.MinSize())
 	lsr.line.Position1 = lsr.ls.p1.AddXY(-widgetPosition.X, -widgetPosition.Y)
 	lsr.line.Position2 = lsr.ls.p2.AddXY(-widgetPosition.X, -widgetPosition.Y)
-	lsr.line.StrokeColor = lsr.ls.link.properties.ForegroundColor
-	lsr.line.StrokeWidth = lsr.ls.link.properties.StrokeWidth
+	lsr.line.StrokeColor = lsr.ls.link.diagram.getHighlightedForegroundColor(lsr.ls.link.id, lsr.ls.link.properties.ForegroundColor)
+	lsr.line.StrokeWidth = lsr.ls.link.diagram.getHighlightedStrokeWidth(lsr.ls.link.id, lsr.ls.link.diagram.scaled(lsr.ls.link.properties.StrokeWidth))
 	lsr.line.Refresh()
+	lsr.refreshDashes()
+}
+
+// refreshDashes updates the dashes used to draw lines that are not solid
+func (lsr *linkSegmentRenderer) refreshDashes() {
+	if lsr.ls.link.lineStyle == LineStyleSolid {
+		lsr.dashes = nil
+		return
+	}
+	dashes := splitIntoDashes(lsr.line.Position1, lsr.line.Position2, lsr.ls.link.lineStyle.getDashPattern(lsr.line.StrokeWidth), lsr.ls.dashOffset)
+	for len(lsr.dashes) < len(dashes) {
+		lsr.dashes = append(lsr.dashes, canvas.NewLine(lsr.line.StrokeColor))
+	}
+	lsr.dashes = lsr.dashes[:len(dashes)]
+	for i, dash := range lsr.dashes {
+		dash.Position1 = dashes[i][0]
+		dash.Position2 = dashes[i][1]
+		dash.StrokeColor = lsr.line.StrokeColor
+		dash.StrokeWidth = lsr.line.StrokeWidth
+		dash.Refresh()
+	}
 }
@@ -0,0 +1,198 @@
+package diagramwidget
+
+import (
+	"image/color"
+	"math"
+
+	"fyne.io/x/fyne/widget/diagramwidget/geometry/r2"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+)
+
+// MarqueeSelectionMode determines which elements are selected by a marquee (rubber-band) selection
+type MarqueeSelectionMode int
+
+const (
+	// MarqueeSelectContained selects the elements that lie entirely within the marquee
+	MarqueeSelectContained MarqueeSelectionMode = iota
+	// MarqueeSelectIntersecting selects the elements that touch the marquee
+	MarqueeSelectIntersecting
+)
+
+// marqueeExtendModifier is the modifier that adds the elements in the marquee to the existing selection
+const marqueeExtendModifier = fyne.KeyModifierShift
+
+// backgroundMoveModifier is the modifier that makes a background drag move all of the elements instead of
+// dragging out a marquee
+const backgroundMoveModifier = fyne.KeyModifierAlt
+
+// marquee holds the transient state of a marquee selection gesture
+type marquee struct {
+	start     fyne.Position
+	end       fyne.Position
+	rectangle *canvas.Rectangle
+	// initialSelection is the selection that the marquee extends. It is empty unless the extend modifier
+	// was pressed when the gesture started.
+	initialSelection map[string]DiagramElement
+	initialPrimary   DiagramElement
+}
+
+// box returns the area covered by the marquee
+func (m *marquee) box() r2.Box {
+	left := math.Min(float64(m.start.X), float64(m.end.X))
+	top := math.Min(float64(m.start.Y), float64(m.end.Y))
+	return r2.MakeBox(r2.V2(left, top), r2.V2(math.Abs(float64(m.end.X-m.start.X)), math.Abs(float64(m.end.Y-m.start.Y))))
+}
+
+// startMarquee begins a marquee selection at the indicated drawing area position
+func (dw *DiagramWidget) startMarquee(start fyne.Position) {
+	dw.marquee = &marquee{
+		start:            start,
+		end:              start,
+		initialSelection: map[string]DiagramElement{},
+	}
+	if getCurrentKeyModifiers()&marqueeExtendModifier != 0 {
+		for id, element := range dw.selection {
+			dw.marquee.initialSelection[id] = element
+		}
+		dw.marquee.initialPrimary = dw.primarySelection
+	}
+	strokeColor := theme.Color(theme.ColorNamePrimary)
+	r, g, b, _ := strokeColor.RGBA()
+	dw.marquee.rectangle = canvas.NewRectangle(color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0x30})
+	dw.marquee.rectangle.StrokeColor = strokeColor
+	dw.marquee.rectangle.StrokeWidth = 1
+}
+
+// updateMarquee moves the free corner of the marquee and shows the handles of the elements that would
+// be selected. The selection callbacks are not invoked until the gesture ends.
+func (dw *DiagramWidget) updateMarquee(end fyne.Position) {
+	m := dw.marquee
+	m.end = end
+	box := m.box()
+	m.rectangle.Move(fyne.NewPos(float32(box.A.X), float32(box.A.Y)))
+	m.rectangle.Resize(fyne.NewSize(float32(box.S.X), float32(box.S.Y)))
+	m.rectangle.Refresh()
+
+	newSelection := map[string]DiagramElement{}
+	for id, element := range m.initialSelection {
+		newSelection[id] = element
+	}
+	for _, element := range dw.GetDiagramElements() {
+		if element.Visible() && dw.isInMarquee(element, box) {
+			newSelection[element.GetDiagramElementID()] = element
+		}
+	}
+	// A selected group stands for the nodes it contains
+	for id, element := range newSelection {
+		if node, ok := element.(DiagramNode); ok && isInSelectedGroup(node, newSelection) {
+			delete(newSelection, id)
+		}
+	}
+	for id, element := range dw.selection {
+		if newSelection[id] == nil {
+			element.HideHandles()
+		}
+	}
+	for id, element := range newSelection {
+		if dw.selection[id] == nil {
+			element.ShowHandles()
+		}
+	}
+	dw.selection = newSelection
+	dw.drawingArea.Refresh()
+}
+
+// endMarquee completes the marquee selection and invokes the PrimaryDiagramElementSelectionChangedCallback
+// if the primary selection has changed
+func (dw *DiagramWidget) endMarquee() {
+	if dw.marquee == nil {
+		return
+	}
+	oldPrimary := dw.primarySelection
+	newPrimary := dw.marquee.initialPrimary
+	if newPrimary == nil || !dw.IsSelected(newPrimary) {
+		newPrimary = nil
+		// The topmost selected element becomes the primary selection
+		elements := dw.GetDiagramElements()
+		for i := len(elements) - 1; i >= 0; i-- {
+			if dw.IsSelected(elements[i]) {
+				newPrimary = elements[i]
+				break
+			}
+		}
+	}
+	dw.primarySelection = newPrimary
+	dw.marquee = nil
+	dw.drawingArea.Refresh()
+	if newPrimary != oldPrimary && dw.PrimaryDiagramElementSelectionChangedCallback != nil {
+		id := ""
+		if newPrimary != nil {
+			id = newPrimary.GetDiagramElementID()
+		}
+		dw.PrimaryDiagramElementSelectionChangedCallback(id)
+	}
+}
+
+// isInMarquee determines whether the element is selected by the marquee box, taking the MarqueeSelectionMode into account.
+// Links are tested against their path rather than their bounding box.
+func (dw *DiagramWidget) isInMarquee(element DiagramElement, box r2.Box) bool {
+	if element.IsLink() {
+		bdl := element.(DiagramLink).getBaseDiagramLink()
+		points := []r2.Vec2{}
+		for _, point := range bdl.getRoutePoints() {
+			points = append(points, toR2(bdl.Position().Add(point)))
+		}
+		if dw.MarqueeSelectionMode == MarqueeSelectIntersecting {
+			for i := 0; i < len(points)-1; i++ {
+				if segmentIntersectsBox(points[i], points[i+1], box) {
+					return true
+				}
+			}
+			return false
+		}
+		for _, point := range points {
+			if !box.Contains(point) {
+				return false
+			}
+		}
+		return len(points) > 0
+	}
+	position := element.Position()
+	size := element.Size()
+	elementBox := r2.MakeBox(toR2(position), r2.V2(float64(size.Width), float64(size.Height)))
+	if dw.MarqueeSelectionMode == MarqueeSelectIntersecting {
+		return elementBox.A.X <= box.A.X+box.S.X && box.A.X <= elementBox.A.X+elementBox.S.X &&
+			elementBox.A.Y <= box.A.Y+box.S.Y && box.A.Y <= elementBox.A.Y+elementBox.S.Y
+	}
+	return box.Contains(elementBox.A) && box.Contains(elementBox.A.Add(elementBox.S))
+}
+
+// segmentIntersectsBox determines whether the line segment from p1 to p2 touches the box. It clips the
+// segment against the box (Liang-Barsky).
+func segmentIntersectsBox(p1, p2 r2.Vec2, box r2.Box) bool {
+	d := p2.Add(p1.Scale(-1))
+	t0, t1 := 0.0, 1.0
+	clip := func(p, q float64) bool {
+		if p == 0 {
+			return q >= 0
+		}
+		t := q / p
+		if p < 0 {
+			if t > t1 {
+				return false
+			}
+			t0 = math.Max(t0, t)
+		} else {
+			if t < t0 {
+				return false
+			}
+			t1 = math.Min(t1, t)
+		}
+		return true
+	}
+	return clip(-d.X, p1.X-box.A.X) && clip(d.X, box.A.X+box.S.X-p1.X) &&
+		clip(-d.Y, p1.Y-box.A.Y) && clip(d.Y, box.A.Y+box.S.Y-p1.Y)
+}
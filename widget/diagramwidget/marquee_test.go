@@ -0,0 +1,92 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/x/fyne/widget/diagramwidget/geometry/r2"
+	"github.com/stretchr/testify/assert"
+)
+
+func dragMarquee(diagram *DiagramWidget, start, end fyne.Position) {
+	middle := fyne.NewPos((start.X+end.X)/2, (start.Y+end.Y)/2)
+	diagram.drawingArea.Dragged(&fyne.DragEvent{
+		PointEvent: fyne.PointEvent{Position: middle},
+		Dragged:    fyne.NewDelta(middle.X-start.X, middle.Y-start.Y),
+	})
+	diagram.drawingArea.Dragged(&fyne.DragEvent{
+		PointEvent: fyne.PointEvent{Position: end},
+		Dragged:    fyne.NewDelta(end.X-middle.X, end.Y-middle.Y),
+	})
+	diagram.drawingArea.DragEnd()
+}
+
+func TestMarqueeSelection(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(300, 100))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+
+	callbackIDs := []string{}
+	diagram.PrimaryDiagramElementSelectionChangedCallback = func(id string) {
+		callbackIDs = append(callbackIDs, id)
+	}
+
+	// Fully contained: only node1 lies within the marquee
+	dragMarquee(diagram, fyne.NewPos(90, 90), fyne.NewPos(250, 250))
+	assert.True(t, diagram.IsSelected(node1))
+	assert.False(t, diagram.IsSelected(node2))
+	assert.False(t, diagram.IsSelected(link))
+	assert.Equal(t, DiagramElement(node1), diagram.GetPrimarySelection())
+	assert.Equal(t, []string{"Node1"}, callbackIDs)
+	assert.Nil(t, diagram.marquee)
+
+	// Intersecting: the marquee touches node1 and the link
+	diagram.MarqueeSelectionMode = MarqueeSelectIntersecting
+	callbackIDs = []string{}
+	dragMarquee(diagram, fyne.NewPos(90, 90), fyne.NewPos(250, 250))
+	assert.True(t, diagram.IsSelected(node1))
+	assert.False(t, diagram.IsSelected(node2))
+	assert.True(t, diagram.IsSelected(link))
+	assert.Equal(t, DiagramElement(link), diagram.GetPrimarySelection())
+	assert.Equal(t, []string{"Link1"}, callbackIDs)
+
+	// An empty marquee clears the selection
+	callbackIDs = []string{}
+	dragMarquee(diagram, fyne.NewPos(0, 0), fyne.NewPos(10, 10))
+	assert.Equal(t, 0, len(diagram.selection))
+	assert.Nil(t, diagram.GetPrimarySelection())
+	assert.Equal(t, []string{""}, callbackIDs)
+}
+
+func TestBackgroundDragMovesElements(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node := NewDiagramNode(diagram, nil, "Node1")
+	node.Move(fyne.NewPos(100, 100))
+
+	diagram.BackgroundDragMovesElements = true
+	dragMarquee(diagram, fyne.NewPos(300, 300), fyne.NewPos(340, 320))
+	assert.Equal(t, fyne.NewPos(140, 120), node.Position())
+	assert.Nil(t, diagram.marquee)
+	assert.Equal(t, 0, len(diagram.selection))
+	assert.False(t, diagram.backgroundMoveInProgress)
+
+	diagram.BackgroundDragMovesElements = false
+	dragMarquee(diagram, fyne.NewPos(300, 300), fyne.NewPos(340, 320))
+	assert.Equal(t, fyne.NewPos(140, 120), node.Position())
+}
+
+func TestSegmentIntersectsBox(t *testing.T) {
+	box := r2.MakeBox(r2.V2(10, 10), r2.V2(10, 10))
+	assert.True(t, segmentIntersectsBox(r2.V2(0, 15), r2.V2(30, 15), box))
+	assert.True(t, segmentIntersectsBox(r2.V2(12, 12), r2.V2(14, 14), box))
+	assert.False(t, segmentIntersectsBox(r2.V2(0, 0), r2.V2(30, 5), box))
+	assert.False(t, segmentIntersectsBox(r2.V2(0, 25), r2.V2(25, 40), box))
+}
@@ -0,0 +1,299 @@
+package diagramwidget
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ImportMermaid reads a Mermaid flowchart (graph or flowchart syntax) and adds its nodes and links to the
+// diagram. Nodes display their text in a Label inner object and take the shape closest to their Mermaid
+// shape: [rectangle], (rounded), ([stadium]), ((circle)), {rhombus}, [(database)] and [/parallelogram/].
+// Links become links with their text as a midpoint AnchoredText; -->, ---, -.-> (dotted) and ==> (thick)
+// links are supported, with their text either inline (-- text -->) or between bars (-->|text|), as are
+// chains (A --> B --> C) and node lists (A & B --> C). The fill and stroke colors of style statements are
+// applied to the nodes. Subgraphs are flattened, and class, click and linkStyle statements, as well as the
+// class shorthand (A:::someclass), are ignored.
+// The diagram's nodes are then arranged with a HierarchicalLayout in the direction given by the header.
+// The IDs of the new nodes must not already be in use in the diagram. Importing is not an edit that can
+// be undone.
+func ImportMermaid(dw *DiagramWidget, r io.Reader) error {
+	parser := &mermaidParser{graph: newImportedGraph()}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if comment := strings.Index(text, "%%"); comment >= 0 {
+			text = text[:comment]
+		}
+		for _, statement := range splitMermaidStatements(text) {
+			if err := parser.parseStatement(strings.TrimSpace(statement)); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if !parser.headerFound {
+		return fmt.Errorf("missing graph or flowchart header")
+	}
+	return parser.graph.addToDiagram(dw)
+}
+
+// mermaidShape associates the delimiters of a node's text with the corresponding NodeShape
+type mermaidShape struct {
+	open  string
+	close []string
+	shape NodeShape
+}
+
+// mermaidShapes are ordered so that the longer opening delimiters are tried first
+var mermaidShapes = []mermaidShape{
+	{"(((", []string{")))"}, EllipseShape},
+	{"([", []string{"])"}, RoundedRectangleShape},
+	{"[[", []string{"]]"}, RectangleShape},
+	{"[(", []string{")]"}, CylinderShape},
+	{"((", []string{"))"}, EllipseShape},
+	{"{{", []string{"}}"}, RectangleShape},
+	{"[/", []string{"/]", `\]`}, ParallelogramShape},
+	{`[\`, []string{`\]`, "/]"}, ParallelogramShape},
+	{">", []string{"]"}, RectangleShape},
+	{"[", []string{"]"}, RectangleShape},
+	{"(", []string{")"}, RoundedRectangleShape},
+	{"{", []string{"}"}, DiamondShape},
+}
+
+var (
+	mermaidHeaderPattern = regexp.MustCompile(`^(graph|flowchart)(\s+(\w+))?$`)
+	mermaidIDPattern     = regexp.MustCompile(`^[\p{L}\p{N}_]+`)
+	// The link patterns capture the start arrow, the line, the text of the inline forms and the end arrow
+	mermaidInlineTextLinkPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^(<?)(--)\s+(.*?)\s+-{2,}([>ox]?)`),
+		regexp.MustCompile(`^(<?)(-\.)\s*(.*?)\s*\.-+([>ox]?)`),
+		regexp.MustCompile(`^(<?)(==)\s*(.*?)\s*={2,}([>ox]?)`),
+	}
+	mermaidLinkPattern     = regexp.MustCompile(`^(<?)(-{2,}|={2,}|-\.+-)()([>ox]?)`)
+	mermaidLinkTextPattern = regexp.MustCompile(`^\|([^|]*)\|`)
+	mermaidBreakPattern    = regexp.MustCompile(`(?i)<br\s*/?>`)
+	// mermaidClassPattern matches the class shorthand that may follow a node, e.g. A:::someclass
+	mermaidClassPattern = regexp.MustCompile(`^:::[\p{L}\p{N}_-]+`)
+)
+
+// mermaidParser adds the nodes and links of the statements to an importedGraph
+type mermaidParser struct {
+	graph       *importedGraph
+	headerFound bool
+}
+
+func (p *mermaidParser) parseStatement(statement string) error {
+	if statement == "" {
+		return nil
+	}
+	if !p.headerFound {
+		match := mermaidHeaderPattern.FindStringSubmatch(statement)
+		if match == nil {
+			return fmt.Errorf("expected a graph or flowchart header, found %q", statement)
+		}
+		if direction := strings.ToUpper(match[3]); direction == "LR" || direction == "RL" {
+			p.graph.direction = LayoutLeftRight
+		}
+		p.headerFound = true
+		return nil
+	}
+	keyword := strings.Fields(statement)[0]
+	switch keyword {
+	case "subgraph", "end", "direction", "classDef", "class", "click", "linkStyle":
+		return nil
+	case "style":
+		return p.parseStyle(statement)
+	}
+	return p.parseChain(statement)
+}
+
+// parseStyle applies the fill and stroke colors of a statement such as: style A fill:#f9f,stroke:#333
+func (p *mermaidParser) parseStyle(statement string) error {
+	fields := strings.Fields(statement)
+	if len(fields) < 3 {
+		return fmt.Errorf("incomplete style statement %q", statement)
+	}
+	node := p.graph.node(fields[1])
+	for _, property := range strings.Split(strings.Join(fields[2:], ""), ",") {
+		name, value, found := strings.Cut(property, ":")
+		if !found {
+			continue
+		}
+		switch name {
+		case "fill":
+			node.backgroundColor = parseImportedColor(value)
+		case "stroke":
+			node.foregroundColor = parseImportedColor(value)
+		}
+	}
+	return nil
+}
+
+// parseChain parses node lists separated by links, e.g. A[Start] --> B & C -- yes --> D
+func (p *mermaidParser) parseChain(statement string) error {
+	rest := statement
+	sources, rest, err := p.parseNodeList(rest)
+	if err != nil {
+		return err
+	}
+	for rest != "" {
+		edge := &importedEdge{}
+		if rest, err = parseMermaidLink(rest, edge); err != nil {
+			return err
+		}
+		var targets []string
+		if targets, rest, err = p.parseNodeList(rest); err != nil {
+			return err
+		}
+		for _, source := range sources {
+			for _, target := range targets {
+				link := *edge
+				link.sourceID = source
+				link.targetID = target
+				p.graph.addEdge(&link)
+			}
+		}
+		sources = targets
+	}
+	return nil
+}
+
+// parseNodeList parses one or more nodes separated by &, returning their IDs and the remaining text
+func (p *mermaidParser) parseNodeList(text string) ([]string, string, error) {
+	ids := []string{}
+	for {
+		id, rest, err := p.parseNode(strings.TrimSpace(text))
+		if err != nil {
+			return nil, "", err
+		}
+		ids = append(ids, id)
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, "&") {
+			return ids, rest, nil
+		}
+		text = rest[1:]
+	}
+}
+
+// parseNode parses a node ID followed by its optional shape and text, returning the ID and the remaining text
+func (p *mermaidParser) parseNode(text string) (string, string, error) {
+	id := mermaidIDPattern.FindString(text)
+	if id == "" {
+		return "", "", fmt.Errorf("expected a node ID, found %q", text)
+	}
+	node := p.graph.node(id)
+	rest := text[len(id):]
+	for _, shape := range mermaidShapes {
+		if !strings.HasPrefix(rest, shape.open) {
+			continue
+		}
+		label, remainder, err := parseMermaidText(rest[len(shape.open):], shape.close)
+		if err != nil {
+			return "", "", fmt.Errorf("node %s: %w", id, err)
+		}
+		node.label = label
+		node.shape = shape.shape
+		return id, skipMermaidClass(remainder), nil
+	}
+	return id, skipMermaidClass(rest), nil
+}
+
+// skipMermaidClass returns the text following the class shorthand at its start, if any. Classes are ignored
+// like the class statements.
+func skipMermaidClass(text string) string {
+	return text[len(mermaidClassPattern.FindString(text)):]
+}
+
+// parseMermaidText returns the text up to the first of the closing delimiters, and the text following it.
+// Quoted text may contain the delimiters.
+func parseMermaidText(text string, closers []string) (string, string, error) {
+	if strings.HasPrefix(text, `"`) {
+		end := strings.Index(text[1:], `"`)
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string")
+		}
+		label := text[1 : end+1]
+		for _, closer := range closers {
+			if strings.HasPrefix(text[end+2:], closer) {
+				return mermaidLabelText(label), text[end+2+len(closer):], nil
+			}
+		}
+		return "", "", fmt.Errorf("expected %q after %q", closers[0], label)
+	}
+	end := -1
+	closerLength := 0
+	for _, closer := range closers {
+		if i := strings.Index(text, closer); i >= 0 && (end < 0 || i < end) {
+			end = i
+			closerLength = len(closer)
+		}
+	}
+	if end < 0 {
+		return "", "", fmt.Errorf("expected %q", closers[0])
+	}
+	return mermaidLabelText(strings.TrimSpace(text[:end])), text[end+closerLength:], nil
+}
+
+// parseMermaidLink parses a link operator and its optional text into the edge, returning the remaining text
+func parseMermaidLink(text string, edge *importedEdge) (string, error) {
+	var match []string
+	for _, pattern := range mermaidInlineTextLinkPatterns {
+		if match = pattern.FindStringSubmatch(text); match != nil {
+			break
+		}
+	}
+	if match == nil {
+		if match = mermaidLinkPattern.FindStringSubmatch(text); match == nil {
+			return "", fmt.Errorf("expected a link, found %q", text)
+		}
+	}
+	rest := strings.TrimSpace(text[len(match[0]):])
+	edge.label = mermaidLabelText(strings.Trim(match[3], `"`))
+	if textMatch := mermaidLinkTextPattern.FindStringSubmatch(rest); textMatch != nil {
+		edge.label = mermaidLabelText(strings.Trim(strings.TrimSpace(textMatch[1]), `"`))
+		rest = rest[len(textMatch[0]):]
+	}
+	switch {
+	case strings.HasPrefix(match[2], "="):
+		edge.strokeWidthScale = 2
+	case strings.Contains(match[2], "."):
+		edge.lineStyle = LineStyleDotted
+	}
+	if match[1] == "<" {
+		edge.sourceArrow = "normal"
+	}
+	switch match[4] {
+	case ">":
+		edge.targetArrow = "normal"
+	case "o":
+		edge.targetArrow = "odot"
+	}
+	return rest, nil
+}
+
+// mermaidLabelText replaces the <br> elements of a label with line breaks
+func mermaidLabelText(label string) string {
+	return mermaidBreakPattern.ReplaceAllString(label, "\n")
+}
+
+// splitMermaidStatements splits a line at the semicolons that are not within quotes
+func splitMermaidStatements(line string) []string {
+	statements := []string{}
+	inQuotes := false
+	start := 0
+	for i, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == ';' && !inQuotes:
+			statements = append(statements, line[start:i])
+			start = i + 1
+		}
+	}
+	return append(statements, line[start:])
+}
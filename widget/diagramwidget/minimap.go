@@ -0,0 +1,317 @@
+package diagramwidget
+
+import (
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Verify that interfaces are fully implemented
+var _ fyne.Draggable = (*Minimap)(nil)
+var _ fyne.Tappable = (*Minimap)(nil)
+
+// minimapMinSize is the minimum size of a Minimap
+var minimapMinSize = fyne.NewSize(200, 150)
+
+// Minimap is a companion widget that displays a scaled-down overview of a DiagramWidget: the outlines of its
+// nodes and links, and a rectangle showing the part of the diagram that is currently visible. Dragging the
+// rectangle scrolls the diagram, and tapping the minimap centers the visible area on the tapped point.
+//
+// The minimap is updated element by element as the diagram changes: moving a node only updates the outlines
+// of the node and its links. Everything is laid out again only when the scale of the minimap changes, e.g. when
+// the diagram grows or is zoomed.
+type Minimap struct {
+	widget.BaseWidget
+	diagram *DiagramWidget
+	// NodeColor is the color of the node outlines. Defaults to the diagram's foreground color
+	NodeColor color.Color
+	// LinkColor is the color of the link outlines. Defaults to the diagram's foreground color
+	LinkColor color.Color
+	// ViewportColor is the color of the rectangle showing the visible area. Defaults to the theme's primary color
+	ViewportColor color.Color
+	background    *canvas.Rectangle
+	viewport      *canvas.Rectangle
+	nodeOutlines  map[string]*canvas.Rectangle
+	linkOutlines  map[string][]*canvas.Line
+	// objects caches the renderer objects. It is set to nil when outlines are added or removed
+	objects []fyne.CanvasObject
+	// scale and origin map drawing area coordinates to minimap coordinates. A zero scale means that
+	// there is nothing to display
+	scale  float32
+	origin fyne.Position
+	// contentSize and minimapSize are the sizes for which scale and origin were computed
+	contentSize fyne.Size
+	minimapSize fyne.Size
+	// dragInProgress is true between the first Dragged event and the DragEnd
+	dragInProgress bool
+}
+
+// NewMinimap creates a Minimap displaying the overview of the diagram
+func NewMinimap(diagram *DiagramWidget) *Minimap {
+	m := &Minimap{
+		diagram:       diagram,
+		NodeColor:     diagram.GetForegroundColor(),
+		LinkColor:     diagram.GetForegroundColor(),
+		ViewportColor: theme.Color(theme.ColorNamePrimary),
+		background:    canvas.NewRectangle(diagram.GetBackgroundColor()),
+		viewport:      canvas.NewRectangle(color.Transparent),
+		nodeOutlines:  map[string]*canvas.Rectangle{},
+		linkOutlines:  map[string][]*canvas.Line{},
+	}
+	m.viewport.StrokeWidth = 1
+	diagram.minimaps = append(diagram.minimaps, m)
+	m.ExtendBaseWidget(m)
+	return m
+}
+
+// CreateRenderer is the required method for widget extensions
+func (m *Minimap) CreateRenderer() fyne.WidgetRenderer {
+	return &minimapRenderer{minimap: m}
+}
+
+// DragEnd is called when the drag comes to an end
+func (m *Minimap) DragEnd() {
+	m.dragInProgress = false
+}
+
+// Dragged scrolls the diagram by the equivalent of the drag. A drag that starts outside the visible area
+// rectangle first centers the rectangle on the starting point.
+func (m *Minimap) Dragged(event *fyne.DragEvent) {
+	if m.scale == 0 {
+		return
+	}
+	if !m.dragInProgress {
+		m.dragInProgress = true
+		start := event.Position.SubtractXY(event.Dragged.DX, event.Dragged.DY)
+		position := m.viewport.Position()
+		size := m.viewport.Size()
+		if start.X < position.X || start.Y < position.Y || start.X > position.X+size.Width || start.Y > position.Y+size.Height {
+			m.centerViewportAt(start)
+		}
+	}
+	offset := m.diagram.scrollingContainer.Offset.AddXY(event.Dragged.DX/m.scale, event.Dragged.DY/m.scale)
+	m.scrollDiagramTo(offset)
+}
+
+// Tapped centers the visible area of the diagram on the tapped point
+func (m *Minimap) Tapped(event *fyne.PointEvent) {
+	if m.scale == 0 {
+		return
+	}
+	m.centerViewportAt(event.Position)
+}
+
+// centerViewportAt scrolls the diagram so that the visible area is centered on the point in minimap coordinates
+func (m *Minimap) centerViewportAt(position fyne.Position) {
+	viewport := m.diagram.scrollingContainer.Size()
+	center := m.toDiagramPosition(position)
+	m.scrollDiagramTo(center.SubtractXY(viewport.Width/2, viewport.Height/2))
+}
+
+// elementChanged updates the outline of the element
+func (m *Minimap) elementChanged(de DiagramElement) {
+	if m.scale == 0 {
+		return
+	}
+	if de.IsNode() {
+		m.updateNodeOutline(de.(DiagramNode))
+	} else {
+		m.updateLinkOutline(de.(DiagramLink))
+	}
+}
+
+// elementRemoved discards the outline of the element
+func (m *Minimap) elementRemoved(de DiagramElement) {
+	delete(m.nodeOutlines, de.GetDiagramElementID())
+	delete(m.linkOutlines, de.GetDiagramElementID())
+	m.objects = nil
+	m.Refresh()
+}
+
+// layoutAll updates the background, the outlines of all of the elements, and the visible area rectangle
+func (m *Minimap) layoutAll() {
+	m.background.Resize(m.Size())
+	m.background.FillColor = m.diagram.GetBackgroundColor()
+	m.background.Refresh()
+	if m.scale != 0 {
+		for _, element := range m.diagram.GetDiagramElements() {
+			m.elementChanged(element)
+		}
+	}
+	m.updateViewport()
+}
+
+// toDiagramPosition converts a position in minimap coordinates to drawing area coordinates
+func (m *Minimap) toDiagramPosition(position fyne.Position) fyne.Position {
+	position = position.Subtract(m.origin)
+	return fyne.NewPos(position.X/m.scale, position.Y/m.scale)
+}
+
+// toMinimapPosition converts a position in drawing area coordinates to minimap coordinates
+func (m *Minimap) toMinimapPosition(position fyne.Position) fyne.Position {
+	return m.origin.AddXY(position.X*m.scale, position.Y*m.scale)
+}
+
+// scrollDiagramTo scrolls the diagram to the offset, keeping the visible area within the drawing area
+func (m *Minimap) scrollDiagramTo(offset fyne.Position) {
+	content := m.diagram.drawingArea.Size()
+	viewport := m.diagram.scrollingContainer.Size()
+	offset.X = float32(math.Max(0, math.Min(float64(offset.X), float64(content.Width-viewport.Width))))
+	offset.Y = float32(math.Max(0, math.Min(float64(offset.Y), float64(content.Height-viewport.Height))))
+	m.diagram.scrollTo(offset)
+}
+
+// updateLinkOutline positions the lines that follow the path of the link
+func (m *Minimap) updateLinkOutline(link DiagramLink) {
+	id := link.GetDiagramElementID()
+	bdl := link.getBaseDiagramLink()
+	points := bdl.getRoutePoints()
+	segmentCount := int(math.Max(0, float64(len(points)-1)))
+	lines := m.linkOutlines[id]
+	if len(lines) != segmentCount {
+		for len(lines) < segmentCount {
+			line := canvas.NewLine(m.LinkColor)
+			line.StrokeWidth = 1
+			lines = append(lines, line)
+		}
+		lines = lines[:segmentCount]
+		m.linkOutlines[id] = lines
+		m.objects = nil
+		m.Refresh()
+	}
+	linkPosition := link.Position()
+	for i, line := range lines {
+		line.Position1 = m.toMinimapPosition(points[i].Add(linkPosition))
+		line.Position2 = m.toMinimapPosition(points[i+1].Add(linkPosition))
+		line.StrokeColor = m.LinkColor
+		line.Hidden = !link.Visible()
+		line.Refresh()
+	}
+}
+
+// updateNodeOutline positions the rectangle that outlines the node
+func (m *Minimap) updateNodeOutline(node DiagramNode) {
+	id := node.GetDiagramElementID()
+	outline, ok := m.nodeOutlines[id]
+	if !ok {
+		outline = canvas.NewRectangle(color.Transparent)
+		outline.StrokeWidth = 1
+		m.nodeOutlines[id] = outline
+		m.objects = nil
+		m.Refresh()
+	}
+	size := node.Size()
+	outline.Move(m.toMinimapPosition(node.Position()))
+	outline.Resize(fyne.NewSize(size.Width*m.scale, size.Height*m.scale))
+	outline.StrokeColor = m.NodeColor
+	outline.Hidden = !node.Visible()
+	outline.Refresh()
+}
+
+// updateScale computes the scale and origin that fit the drawing area into the minimap. It returns true
+// if either has changed.
+func (m *Minimap) updateScale() bool {
+	content := m.diagram.drawingArea.Size()
+	size := m.Size()
+	if content == m.contentSize && size == m.minimapSize {
+		return false
+	}
+	m.contentSize = content
+	m.minimapSize = size
+	if content.Width <= 0 || content.Height <= 0 || size.Width <= 0 || size.Height <= 0 {
+		m.scale = 0
+		return true
+	}
+	m.scale = float32(math.Min(float64(size.Width/content.Width), float64(size.Height/content.Height)))
+	m.origin = fyne.NewPos((size.Width-content.Width*m.scale)/2, (size.Height-content.Height*m.scale)/2)
+	return true
+}
+
+// updateViewport positions the rectangle that shows the visible area of the diagram
+func (m *Minimap) updateViewport() {
+	m.viewport.Hidden = m.scale == 0
+	if m.scale == 0 {
+		return
+	}
+	content := m.diagram.drawingArea.Size()
+	size := m.diagram.scrollingContainer.Size()
+	size.Width = float32(math.Min(float64(size.Width), float64(content.Width)))
+	size.Height = float32(math.Min(float64(size.Height), float64(content.Height)))
+	m.viewport.Move(m.toMinimapPosition(m.diagram.scrollingContainer.Offset))
+	m.viewport.Resize(fyne.NewSize(size.Width*m.scale, size.Height*m.scale))
+	m.viewport.StrokeColor = m.ViewportColor
+	m.viewport.Refresh()
+}
+
+// viewportChanged responds to changes in the scroll position, the size of the visible area, or the size of
+// the drawing area. The outlines are only laid out again if the scale has changed.
+func (m *Minimap) viewportChanged() {
+	if m.updateScale() {
+		m.layoutAll()
+	} else {
+		m.updateViewport()
+	}
+}
+
+type minimapRenderer struct {
+	minimap *Minimap
+}
+
+func (mr *minimapRenderer) Destroy() {
+}
+
+func (mr *minimapRenderer) Layout(size fyne.Size) {
+	mr.minimap.updateScale()
+	mr.minimap.layoutAll()
+}
+
+func (mr *minimapRenderer) MinSize() fyne.Size {
+	return minimapMinSize
+}
+
+func (mr *minimapRenderer) Objects() []fyne.CanvasObject {
+	m := mr.minimap
+	if m.objects == nil {
+		m.objects = []fyne.CanvasObject{m.background}
+		for _, element := range m.diagram.GetDiagramElements() {
+			if outline, ok := m.nodeOutlines[element.GetDiagramElementID()]; ok {
+				m.objects = append(m.objects, outline)
+			}
+			for _, line := range m.linkOutlines[element.GetDiagramElementID()] {
+				m.objects = append(m.objects, line)
+			}
+		}
+		m.objects = append(m.objects, m.viewport)
+	}
+	return m.objects
+}
+
+func (mr *minimapRenderer) Refresh() {
+	mr.minimap.viewportChanged()
+}
+
+// minimapElementChanged updates the outline of the element in the diagram's minimaps
+func (dw *DiagramWidget) minimapElementChanged(de DiagramElement) {
+	for _, m := range dw.minimaps {
+		m.elementChanged(de)
+	}
+}
+
+// minimapElementRemoved removes the outline of the element from the diagram's minimaps
+func (dw *DiagramWidget) minimapElementRemoved(de DiagramElement) {
+	for _, m := range dw.minimaps {
+		m.elementRemoved(de)
+	}
+}
+
+// refreshMinimapViewports updates the diagram's minimaps after the visible area or the size of the drawing
+// area has changed
+func (dw *DiagramWidget) refreshMinimapViewports() {
+	for _, m := range dw.minimaps {
+		m.viewportChanged()
+	}
+}
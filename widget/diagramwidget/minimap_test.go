@@ -0,0 +1,62 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinimap(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(400, 300))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	diagram.Resize(fyne.NewSize(400, 300))
+	minimap := NewMinimap(diagram)
+	minimap.Resize(fyne.NewSize(200, 150))
+
+	// The 800 x 600 drawing area is displayed at a quarter of its size
+	assert.Equal(t, float32(0.25), minimap.scale)
+	outline := minimap.nodeOutlines["Node1"]
+	assert.Equal(t, fyne.NewPos(25, 25), outline.Position())
+	assert.Equal(t, fyne.NewSize(node1.Size().Width/4, node1.Size().Height/4), outline.Size())
+	assert.Equal(t, 1, len(minimap.linkOutlines["Link1"]))
+	assert.Equal(t, fyne.NewPos(0, 0), minimap.viewport.Position())
+	assert.Equal(t, fyne.NewSize(100, 75), minimap.viewport.Size())
+
+	// Moving a node updates its outline and that of its link
+	line := minimap.linkOutlines["Link1"][0]
+	lineStart := line.Position1
+	diagram.DisplaceNode(node1, fyne.NewPos(40, 0))
+	assert.Equal(t, fyne.NewPos(35, 25), outline.Position())
+	assert.NotEqual(t, lineStart, line.Position1)
+
+	// Dragging the viewport rectangle scrolls the diagram
+	minimap.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(35, 35)}, Dragged: fyne.NewDelta(25, 25)})
+	minimap.DragEnd()
+	assert.Equal(t, fyne.NewPos(100, 100), diagram.scrollingContainer.Offset)
+	assert.Equal(t, fyne.NewPos(25, 25), minimap.viewport.Position())
+	// The visible area stays within the drawing area
+	minimap.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(150, 150)}, Dragged: fyne.NewDelta(100, 100)})
+	minimap.DragEnd()
+	assert.Equal(t, fyne.NewPos(400, 300), diagram.scrollingContainer.Offset)
+
+	// Tapping centers the visible area on the tapped point
+	minimap.Tapped(&fyne.PointEvent{Position: fyne.NewPos(50, 50)})
+	assert.Equal(t, fyne.NewPos(0, 50), diagram.scrollingContainer.Offset)
+	assert.Equal(t, fyne.NewPos(0, 12.5), minimap.viewport.Position())
+
+	// Removing an element removes its outline
+	diagram.RemoveElement("Node2")
+	assert.Nil(t, minimap.nodeOutlines["Node2"])
+	assert.Nil(t, minimap.linkOutlines["Link1"])
+	renderer := test.TempWidgetRenderer(t, minimap)
+	assert.Equal(t, 3, len(renderer.Objects()))
+}
@@ -1,6 +1,7 @@
 package diagramwidget
 
 import (
+	"image"
 	"image/color"
 
 	"fyne.io/x/fyne/widget/diagramwidget/geometry/r2"
@@ -15,8 +16,18 @@ type DiagramNode interface {
 	DiagramElement
 	getBaseDiagramNode() *BaseDiagramNode
 	GetEdgePad() ConnectionPad
+	// GetParentGroup returns the group to which the node belongs, or nil if it does not belong to a group
+	GetParentGroup() DiagramGroup
+	// GetShape returns the outline drawn around the node's inner object
+	GetShape() NodeShape
+	// IsPinned returns true if force layouts leave the node in place
+	IsPinned() bool
 	R2Center() r2.Vec2
 	SetInnerObject(fyne.CanvasObject)
+	// SetPinned determines whether force layouts leave the node in place
+	SetPinned(bool)
+	// SetShape sets the outline drawn around the node's inner object and replaces its edge pad
+	SetShape(NodeShape)
 }
 
 // Validate that BaseDiagramNode implements DiagramElement and Tappable
@@ -45,6 +56,19 @@ type BaseDiagramNode struct {
 	innerObject fyne.CanvasObject
 	// MovedCallback, if present, is invoked when the node is moved
 	MovedCallback func()
+	// parent is the group to which the node belongs, if any
+	parent DiagramGroup
+	// We keep the typed node so that extensions (e.g. groups) can be recognized
+	typedNode DiagramNode
+	// resizeInProgress is true while a handle is being dragged. The geometry at the start of the
+	// drag is retained so that the resize can be undone.
+	resizeInProgress     bool
+	resizeStartPosition  fyne.Position
+	resizeStartInnerSize fyne.Size
+	// shape is the outline drawn around the inner object
+	shape NodeShape
+	// pinned nodes are not moved by force layouts
+	pinned bool
 }
 
 // NewDiagramNode creates a DiagramNode widget and adds it to the DiagramWidget. The user-supplied
@@ -60,8 +84,9 @@ func NewDiagramNode(diagram *DiagramWidget, obj fyne.CanvasObject, nodeID string
 // InitializeBaseDiagramNode is used to initailize the BaseDiagramNode. It must be called by any extensions to the BaseDiagramNode
 func InitializeBaseDiagramNode(diagramNode DiagramNode, diagram *DiagramWidget, obj fyne.CanvasObject, nodeID string) {
 	bdn := diagramNode.getBaseDiagramNode()
-	bdn.InnerSize = fyne.Size{Width: defaultWidth, Height: defaultHeight}
+	bdn.InnerSize = diagram.scaleSize(fyne.Size{Width: defaultWidth, Height: defaultHeight})
 	bdn.innerObject = obj
+	bdn.typedNode = diagramNode
 	bdn.diagramElement.initialize(diagram, nodeID)
 	bdn.pads["default"] = NewRectanglePad(bdn)
 	bdn.pads["default"].Hide()
@@ -81,8 +106,10 @@ func (bdn *BaseDiagramNode) CreateRenderer() fyne.WidgetRenderer {
 		node: bdn,
 		box:  canvas.NewRectangle(bdn.diagram.GetForegroundColor()),
 	}
+	dnr.outline = canvas.NewRaster(dnr.drawOutline)
+	dnr.dimmer = canvas.NewRectangle(color.Transparent)
 
-	dnr.box.StrokeWidth = bdn.properties.StrokeWidth
+	dnr.box.StrokeWidth = bdn.diagram.scaled(bdn.properties.StrokeWidth)
 	dnr.box.FillColor = bdn.diagram.GetBackgroundColor()
 
 	(&dnr).Refresh()
@@ -100,8 +127,9 @@ func (bdn *BaseDiagramNode) Cursor() desktop.Cursor {
 	return desktop.DefaultCursor
 }
 
-// DragEnd is presently a no-op
+// DragEnd completes the drag gesture, making it a single undo entry
 func (bdn *BaseDiagramNode) DragEnd() {
+	bdn.diagram.diagramNodeDragEnd()
 }
 
 // Dragged passes the DragEvent to the diagram for processing
@@ -139,10 +167,26 @@ func (bdn *BaseDiagramNode) GetEdgePad() ConnectionPad {
 	return bdn.pads["default"]
 }
 
+// GetParentGroup returns the group to which the node belongs, or nil if it does not belong to a group
+func (bdn *BaseDiagramNode) GetParentGroup() DiagramGroup {
+	return bdn.parent
+}
+
 func (bdn *BaseDiagramNode) handleDragged(handle *Handle, event *fyne.DragEvent) {
+	if group, ok := bdn.typedNode.(DiagramGroup); ok && !group.IsCollapsed() {
+		// The size of an expanded group is determined by its children
+		return
+	}
+	if !bdn.resizeInProgress {
+		bdn.resizeInProgress = true
+		bdn.resizeStartPosition = bdn.Position()
+		bdn.resizeStartInnerSize = bdn.InnerSize
+		bdn.diagram.dragResidual = fyne.NewPos(0, 0)
+	}
 	// determine which handle it is
 	currentInnerSize := bdn.effectiveInnerSize()
 	handleKey := bdn.findKeyForHandle(handle)
+	event = bdn.snapHandleDrag(handleKey, event)
 	positionChange := fyne.Position{X: 0, Y: 0}
 	sizeChange := fyne.Size{Height: 0, Width: 0}
 	switch handleKey {
@@ -173,8 +217,11 @@ func (bdn *BaseDiagramNode) handleDragged(handle *Handle, event *fyne.DragEvent)
 		sizeChange.Height = event.Dragged.DY
 		sizeChange.Width = event.Dragged.DX
 	}
-	trialInnerSize := bdn.InnerSize.Add(sizeChange)
-	bdn.InnerSize = bdn.innerObject.MinSize().Max(trialInnerSize)
+	trialInnerSize := currentInnerSize.Add(sizeChange)
+	bdn.InnerSize = trialInnerSize
+	if bdn.innerObject != nil {
+		bdn.InnerSize = bdn.innerObject.MinSize().Max(trialInnerSize)
+	}
 	if trialInnerSize.Height < bdn.InnerSize.Height {
 		sizeChange.Height = bdn.InnerSize.Height - currentInnerSize.Height
 		if positionChange.Y != 0 {
@@ -187,19 +234,66 @@ func (bdn *BaseDiagramNode) handleDragged(handle *Handle, event *fyne.DragEvent)
 			positionChange.X = -sizeChange.Width
 		}
 	}
-	bdn.Resize(bdn.Size().Add(sizeChange))
+	// The margins around the inner object of shapes other than rectangles grow with the inner object
+	newSize := bdn.shapeSize()
+	if positionChange.X != 0 {
+		positionChange.X = bdn.Size().Width - newSize.Width
+	}
+	if positionChange.Y != 0 {
+		positionChange.Y = bdn.Size().Height - newSize.Height
+	}
+	bdn.Resize(newSize)
 	bdn.Move(bdn.Position().Add(positionChange))
 	bdn.Refresh()
 }
 
-func (bdn *BaseDiagramNode) handleDragEnd(handle *Handle) {
+// snapHandleDrag adjusts the drag event of a handle so that the edges moved by the handle snap to the grid
+func (bdn *BaseDiagramNode) snapHandleDrag(handleKey string, event *fyne.DragEvent) *fyne.DragEvent {
+	if !bdn.diagram.SnapToGrid {
+		return event
+	}
+	// The point being dragged is the corner or edge of the node on which the handle lies
+	edge := bdn.Position()
+	size := bdn.Size()
+	snapX, snapY := false, false
+	switch handleKey {
+	case "upperLeft", "leftMiddle", "lowerLeft":
+		snapX = true
+	case "upperRight", "rightMiddle", "lowerRight":
+		snapX = true
+		edge.X += size.Width
+	}
+	switch handleKey {
+	case "upperLeft", "upperMiddle", "upperRight":
+		snapY = true
+	case "lowerLeft", "lowerMiddle", "lowerRight":
+		snapY = true
+		edge.Y += size.Height
+	}
+	delta := bdn.diagram.snapDrag(edge, fyne.NewPos(event.Dragged.DX, event.Dragged.DY), snapX, snapY)
+	return &fyne.DragEvent{PointEvent: event.PointEvent, Dragged: fyne.NewDelta(delta.X, delta.Y)}
+}
 
+func (bdn *BaseDiagramNode) handleDragEnd(handle *Handle) {
+	if !bdn.resizeInProgress {
+		return
+	}
+	bdn.resizeInProgress = false
+	bdn.diagram.recordCommand("Resize", &nodeGeometryCommand{
+		node:         bdn,
+		oldPosition:  bdn.diagram.unscalePosition(bdn.resizeStartPosition),
+		oldInnerSize: bdn.diagram.unscaleSize(bdn.resizeStartInnerSize),
+		newPosition:  bdn.diagram.unscalePosition(bdn.Position()),
+		newInnerSize: bdn.diagram.unscaleSize(bdn.InnerSize),
+	})
+	bdn.diagram.adjustBounds()
 }
 
 func (bdn *BaseDiagramNode) innerPos() fyne.Position {
+	left, top, _, _ := bdn.shapeMargins()
 	return fyne.Position{
-		X: float32(bdn.properties.Padding),
-		Y: float32(bdn.properties.Padding),
+		X: left + bdn.scaledPadding(),
+		Y: top + bdn.scaledPadding(),
 	}
 }
 
@@ -213,21 +307,24 @@ func (bdn *BaseDiagramNode) IsNode() bool {
 	return true
 }
 
-// Move moves the node and invokes the callback if present.
+// Move moves the node and invokes the callback if present. Links that avoid nodes are re-routed.
 func (bdn *BaseDiagramNode) Move(position fyne.Position) {
 	bdn.BaseWidget.Move(position)
 	if bdn.MovedCallback != nil {
 		bdn.MovedCallback()
 	}
 	bdn.Refresh()
+	bdn.refreshParentGroup()
+	bdn.diagram.refreshObstacleAvoidingLinks(bdn.typedNode)
+	bdn.diagram.graphBindersElementChanged(bdn.typedNode)
 }
 
 // R2Box returns the bounding box in r2 coordinates
 func (bdn *BaseDiagramNode) R2Box() r2.Box {
-	inner := bdn.effectiveInnerSize()
+	size := bdn.shapeSize()
 	s := r2.V2(
-		float64(inner.Width+float32(2*bdn.properties.Padding)),
-		float64(inner.Height+float32(2*bdn.properties.Padding)),
+		float64(size.Width),
+		float64(size.Height),
 	)
 
 	return r2.MakeBox(bdn.R2Position(), s)
@@ -243,10 +340,23 @@ func (bdn *BaseDiagramNode) R2Position() r2.Vec2 {
 	return r2.V2(float64(bdn.Position().X), float64(bdn.Position().Y))
 }
 
+// refreshParentGroup resizes the group to which the node belongs, if any, to fit its children
+func (bdn *BaseDiagramNode) refreshParentGroup() {
+	if bdn.parent != nil {
+		bdn.parent.getBaseDiagramGroup().fitToChildren()
+	}
+}
+
+// scaledPadding returns the padding adjusted for the diagram's zoom
+func (bdn *BaseDiagramNode) scaledPadding() float32 {
+	return bdn.diagram.scaled(bdn.properties.Padding)
+}
+
 // SetInnerObject makes the skupplied canvas object the center of the node
 func (bdn *BaseDiagramNode) SetInnerObject(obj fyne.CanvasObject) {
 	bdn.innerObject = obj
 	bdn.Refresh()
+	bdn.refreshParentGroup()
 	bdn.diagram.refreshDependentLinks(bdn)
 }
 
@@ -259,6 +369,10 @@ func (bdn *BaseDiagramNode) Tapped(event *fyne.PointEvent) {
 type diagramNodeRenderer struct {
 	node *BaseDiagramNode
 	box  *canvas.Rectangle
+	// outline is displayed instead of the box for shapes other than rectangles
+	outline *canvas.Raster
+	// dimmer covers the node when it is dimmed by a search
+	dimmer *canvas.Rectangle
 }
 
 func (dnr *diagramNodeRenderer) ApplyTheme(size fyne.Size) {
@@ -272,12 +386,33 @@ func (dnr *diagramNodeRenderer) Destroy() {
 }
 
 func (dnr *diagramNodeRenderer) MinSize() fyne.Size {
-	// space for the inner widget, plus padding on all sides.
-	inner := dnr.node.effectiveInnerSize()
-	return fyne.Size{
-		Width:  inner.Width + float32(2*dnr.node.properties.Padding),
-		Height: inner.Height + float32(2*dnr.node.properties.Padding),
+	// space for the inner widget, plus padding on all sides, plus the margins required by the shape.
+	return dnr.node.shapeSize()
+}
+
+// drawOutline rasterizes the node's shape at the resolution of the raster, inset so that the stroke
+// is not clipped
+func (dnr *diagramNodeRenderer) drawOutline(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	size := dnr.node.Size()
+	if size.Width <= 0 || size.Height <= 0 {
+		return img
+	}
+	strokeWidth := dnr.node.diagram.getHighlightedStrokeWidth(dnr.node.id, dnr.node.diagram.scaled(dnr.node.properties.StrokeWidth))
+	outline, details := shapeOutline(dnr.node.shape, size.SubtractWidthHeight(strokeWidth, strokeWidth),
+		dnr.node.diagram.scaled(roundedCornerRadius))
+	rasterizeOutline(img, float32(width)/size.Width, fyne.NewPos(strokeWidth/2, strokeWidth/2), outline, details,
+		dnr.node.properties.BackgroundColor, dnr.getStrokeColor(), strokeWidth)
+	return img
+}
+
+// getStrokeColor returns the color of the node's outline, which is the HighlightColor while the node is
+// highlighted by a search. A dimmed node is faded by the dimmer instead.
+func (dnr *diagramNodeRenderer) getStrokeColor() color.Color {
+	if dnr.node.diagram.isHighlighted(dnr.node.id) {
+		return dnr.node.diagram.HighlightColor
 	}
+	return dnr.node.properties.ForegroundColor
 }
 
 func (dnr *diagramNodeRenderer) Layout(size fyne.Size) {
@@ -285,10 +420,21 @@ func (dnr *diagramNodeRenderer) Layout(size fyne.Size) {
 
 func (dnr *diagramNodeRenderer) Objects() []fyne.CanvasObject {
 	obj := make([]fyne.CanvasObject, 0)
-	obj = append(obj, dnr.box)
+	if dnr.node.shape == RectangleShape {
+		obj = append(obj, dnr.box)
+	} else {
+		obj = append(obj, dnr.outline)
+	}
 	obj = append(obj, dnr.node.innerObject)
-	for _, pad := range dnr.node.pads {
-		obj = append(obj, pad)
+	if dnr.node.diagram.isDimmed(dnr.node.id) {
+		obj = append(obj, dnr.dimmer)
+	}
+	// The edge pad is placed below the other pads (e.g. ports) so that it does not mask them
+	obj = append(obj, dnr.node.pads["default"])
+	for _, key := range sortedKeys(dnr.node.pads) {
+		if key != "default" {
+			obj = append(obj, dnr.node.pads[key])
+		}
 	}
 	for _, handle := range dnr.node.handles {
 		obj = append(obj, handle)
@@ -302,6 +448,11 @@ func (dnr *diagramNodeRenderer) Refresh() {
 	dnr.node.pads["default"].Resize(nodeSize)
 	dnr.node.pads["default"].Move(fyne.NewPos(0, 0))
 	dnr.node.pads["default"].Refresh()
+	for _, pad := range dnr.node.pads {
+		if port, ok := pad.(*PortPad); ok {
+			port.place(nodeSize)
+		}
+	}
 
 	if dnr.node.innerObject != nil {
 		dnr.node.innerObject.Move(dnr.node.innerPos())
@@ -336,13 +487,21 @@ func (dnr *diagramNodeRenderer) Refresh() {
 		handle.Refresh()
 	}
 
-	dnr.box.StrokeWidth = dnr.node.properties.StrokeWidth
+	dnr.box.StrokeWidth = dnr.node.diagram.getHighlightedStrokeWidth(dnr.node.id, dnr.node.diagram.scaled(dnr.node.properties.StrokeWidth))
 	dnr.box.FillColor = dnr.node.properties.BackgroundColor
-	dnr.box.StrokeColor = dnr.node.properties.ForegroundColor
+	dnr.box.StrokeColor = dnr.getStrokeColor()
 	dnr.box.Refresh()
+	dnr.outline.Resize(nodeSize)
+	dnr.outline.Refresh()
+	if dimmer := dnr.node.diagram.getDimmer(dnr.node.id); dimmer != nil {
+		dnr.dimmer.FillColor = dimmer
+		dnr.dimmer.Resize(nodeSize)
+		dnr.dimmer.Refresh()
+	}
 
 	for _, pad := range dnr.node.pads {
 		pad.Refresh()
 	}
 	dnr.node.diagram.refreshDependentLinks(dnr.node)
+	dnr.node.diagram.minimapElementChanged(dnr.node.typedNode)
 }
@@ -78,7 +78,7 @@ func (p *Polygon) GetReferenceLength() float32 {
 		xMin = math.Min(xMin, float64(point.X))
 		xMax = math.Max(xMax, float64(point.X))
 	}
-	return float32(math.Abs(xMax-xMin)) + p.StrokeWidth
+	return float32(math.Abs(xMax-xMin)*getDecorationZoom(p.link)) + p.StrokeWidth
 }
 
 // getRenderingData returns the defining points rotated to the correct orientation and
@@ -120,14 +120,15 @@ func (p *Polygon) getRenderingData() ([]fyne.Position, fyne.Position) {
 	return renderingPoints, offsetVector
 }
 
-// getRotatedPoints returns the points after the nominal points
-// have been rotated by the reference angle
+// getRotatedPoints returns the points after the nominal points have been
+// scaled by the diagram's zoom and rotated by the reference angle
 func (p *Polygon) getRotatedPoints() []fyne.Position {
 	rotatedPoints := []fyne.Position{}
 	var rotX float32
 	var rotY float32
+	zoom := getDecorationZoom(p.link)
 	for _, point := range p.definingPoints {
-		v2Point := r2.V2(float64(point.X), float64(point.Y))
+		v2Point := r2.V2(float64(point.X), float64(point.Y)).Scale(zoom)
 		len := v2Point.Length()
 		if len == 0 {
 			rotX = 0
@@ -149,9 +150,9 @@ func (p *Polygon) getRotatedPoints() []fyne.Position {
 
 // MinSize returns the minimum size based on nominal polygon points, base angle, and stroke width
 func (p *Polygon) MinSize() fyne.Size {
-	// // The origin is always one of the points regardless of whether the polygon uses that point
-	// points := []r2.Vec2{{X: 0.0, Y: 0.0}}
-	points := []r2.Vec2{}
+	// The origin is always one of the points regardless of whether the polygon uses that point,
+	// as it is in getRenderingData
+	points := []r2.Vec2{{X: 0.0, Y: 0.0}}
 	for _, point := range p.getRotatedPoints() {
 		points = append(points, r2.Vec2{X: float64(point.X), Y: float64(point.Y)})
 	}
@@ -266,7 +267,7 @@ func (pr *polygonRenderer) Refresh() {
 				dasher.Line(rasterx.ToFixedP(float64(point.X), float64(point.Y)))
 			}
 		}
-		dasher.Stop(true)
+		dasher.Stop(pr.polygon.closed)
 		dasher.Draw()
 	}
 
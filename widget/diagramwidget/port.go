@@ -0,0 +1,359 @@
+package diagramwidget
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// portPadSize is the diameter of a PortPad at zoom 1
+	portPadSize float32 = 10
+)
+
+// PortDirection indicates whether a PortPad accepts the source or the target of a link
+type PortDirection int
+
+const (
+	// PortInput ports accept link targets
+	PortInput PortDirection = iota
+	// PortOutput ports accept link sources
+	PortOutput
+	// PortInOut ports accept both link sources and targets
+	PortInOut
+)
+
+// PortSide is the edge of the node on which a PortPad is placed
+type PortSide int
+
+const (
+	// PortSideLeft places the port on the left edge of the node
+	PortSideLeft PortSide = iota
+	// PortSideRight places the port on the right edge of the node
+	PortSideRight
+	// PortSideTop places the port on the top edge of the node
+	PortSideTop
+	// PortSideBottom places the port on the bottom edge of the node
+	PortSideBottom
+)
+
+// Validate that PortPad implements ConnectionPad
+var _ ConnectionPad = (*PortPad)(nil)
+
+// PortPad is a named ConnectionPad at a fixed location on the edge of a node, as used in dataflow editors.
+// A port has a data type, a direction, and a maximum number of connections. When a link end is connected
+// interactively, the diagram only allows connections that satisfy these constraints:
+//   - a link's source can only be connected to an output (or in/out) port and its target to an input
+//     (or in/out) port
+//   - a port cannot have more than MaxConnections links connected to it
+//   - when both ends of a link are ports, their data types must be compatible as determined by
+//     the DiagramWidget's PortTypesCompatibleCallback
+//
+// Unlike other pads, ports are always visible. While a ConnectionTransaction is in progress the ports
+// are highlighted to indicate whether the link end being connected can be connected to them.
+type PortPad struct {
+	widget.BaseWidget
+	connectionPad
+	name string
+	// DataType is the type of the data flowing through the port. An empty DataType is compatible with any type
+	DataType string
+	// Direction determines which link ends can be connected to the port
+	Direction PortDirection
+	// MaxConnections is the maximum number of links that can be connected to the port. Zero means no limit
+	MaxConnections int
+	side           PortSide
+	// offset is the location of the port along its side, from 0 (left or top) to 1 (right or bottom)
+	offset float32
+	// autoPlaced ports are spread evenly along their side
+	autoPlaced bool
+	// highlightColor is the fill color used during a ConnectionTransaction, or nil when not highlighted
+	highlightColor color.Color
+}
+
+// NewPortPad creates a PortPad and adds it to the node's connection pads, using the name as its key.
+// Input ports are placed on the left edge of the node and other ports on the right edge, spread evenly
+// along the edge. SetPlacement can be used to place the port elsewhere.
+func NewPortPad(node DiagramNode, name string, direction PortDirection, dataType string) *PortPad {
+	port := &PortPad{
+		name:       name,
+		DataType:   dataType,
+		Direction:  direction,
+		side:       PortSideRight,
+		autoPlaced: true,
+	}
+	if direction == PortInput {
+		port.side = PortSideLeft
+	}
+	port.connectionPad.padOwner = node
+	port.lineWidth = node.GetProperties().StrokeWidth
+	port.padColor = node.GetProperties().PadColor
+	port.BaseWidget.ExtendBaseWidget(port)
+	bdn := node.getBaseDiagramNode()
+	bdn.pads[name] = port
+	bdn.spreadPorts(port.side)
+	node.Refresh()
+	return port
+}
+
+// CreateRenderer creates the WidgetRenderer for the PortPad
+func (port *PortPad) CreateRenderer() fyne.WidgetRenderer {
+	ppr := &portPadRenderer{
+		port:   port,
+		circle: canvas.NewCircle(color.Transparent),
+	}
+	ppr.Refresh()
+	return ppr
+}
+
+// GetCenterInDiagramCoordinates returns the center of the port in the diagram's coordinate system
+func (port *PortPad) GetCenterInDiagramCoordinates() fyne.Position {
+	size := port.Size()
+	return port.padOwner.Position().Add(port.Position()).AddXY(size.Width/2, size.Height/2)
+}
+
+// GetName returns the name of the port, which is also its key in the node's connection pads
+func (port *PortPad) GetName() string {
+	return port.name
+}
+
+// GetPlacement returns the side of the node on which the port is placed and the location of the port
+// along that side, from 0 (left or top) to 1 (right or bottom)
+func (port *PortPad) GetPlacement() (PortSide, float32) {
+	return port.side, port.offset
+}
+
+// getConnectionPointInDiagramCoordinates returns the point on the pad to which a connection will be made from
+// the referencePoint. For a port, this is always the center.
+func (port *PortPad) getConnectionPointInDiagramCoordinates(referencePoint fyne.Position) fyne.Position {
+	return port.GetCenterInDiagramCoordinates()
+}
+
+// MouseDown responds to mouse down events. When a new link is being created, it connects the link's source to the port
+func (port *PortPad) MouseDown(event *desktop.MouseEvent) {
+	diagram := port.padOwner.GetDiagram()
+	connectionTransaction := diagram.ConnectionTransaction
+	if connectionTransaction == nil {
+		return
+	}
+	link := connectionTransaction.Link
+	if !link.isConnectionAllowed(connectionTransaction.LinkPoint, port) {
+		return
+	}
+	center := port.GetCenterInDiagramCoordinates()
+	pseudoEvent := &fyne.DragEvent{
+		Dragged: fyne.NewDelta(center.X, center.Y),
+	}
+	// the link point has to be changed before the handle is dragged
+	connectionTransaction.LinkPoint = link.GetLinkPoints()[1]
+	link.GetHandle(TARGET.ToString()).Dragged(pseudoEvent)
+	link.SetSourcePad(port)
+	diagram.SelectDiagramElement(link)
+	link.ShowHandles()
+	// The ports that can accept the target differ from those that could accept the source
+	diagram.highlightPorts()
+}
+
+// MouseIn responds to the mouse entering the port. If the port can accept the link end being connected, it
+// becomes the pending pad of the ConnectionTransaction
+func (port *PortPad) MouseIn(event *desktop.MouseEvent) {
+	conTrans := port.padOwner.GetDiagram().ConnectionTransaction
+	if conTrans != nil && conTrans.Link.isConnectionAllowed(conTrans.LinkPoint, port) {
+		conTrans.PendingPad = port
+		port.lineWidth = 2 * port.padOwner.GetProperties().StrokeWidth
+	}
+	port.Refresh()
+}
+
+// MouseMoved responds to mouse movements within the port
+func (port *PortPad) MouseMoved(event *desktop.MouseEvent) {
+}
+
+// MouseOut responds to the mouse leaving the port
+func (port *PortPad) MouseOut() {
+	conTrans := port.padOwner.GetDiagram().ConnectionTransaction
+	if conTrans != nil && conTrans.PendingPad == port {
+		conTrans.PendingPad = nil
+	}
+	port.lineWidth = port.padOwner.GetProperties().StrokeWidth
+	port.Refresh()
+}
+
+// MouseUp responds to mouse up events
+func (port *PortPad) MouseUp(event *desktop.MouseEvent) {
+}
+
+// SetPadColor sets the color used to highlight the port when it can accept the link end being connected
+func (port *PortPad) SetPadColor(c color.Color) {
+	port.padColor = c
+	port.Refresh()
+}
+
+// SetPlacement places the port on the indicated side of the node. The offset is the location of the port
+// along that side, from 0 (left or top) to 1 (right or bottom)
+func (port *PortPad) SetPlacement(side PortSide, offset float32) {
+	oldSide := port.side
+	port.side = side
+	port.offset = offset
+	port.autoPlaced = false
+	bdn := port.padOwner.(DiagramNode).getBaseDiagramNode()
+	bdn.spreadPorts(oldSide)
+	bdn.Refresh()
+}
+
+// connectionCount returns the number of links other than the indicated one connected to the port
+func (port *PortPad) connectionCount(excludedLink *BaseDiagramLink) int {
+	count := 0
+	for _, pair := range port.padOwner.GetDiagram().diagramElementLinkDependencies[port.padOwner.GetDiagramElementID()] {
+		if pair.pad == port && pair.link != excludedLink {
+			count++
+		}
+	}
+	return count
+}
+
+// place moves the port to its location on the edge of a node of the indicated size
+func (port *PortPad) place(nodeSize fyne.Size) {
+	size := port.padOwner.GetDiagram().scaled(portPadSize)
+	var center fyne.Position
+	switch port.side {
+	case PortSideLeft:
+		center = fyne.NewPos(0, nodeSize.Height*port.offset)
+	case PortSideRight:
+		center = fyne.NewPos(nodeSize.Width, nodeSize.Height*port.offset)
+	case PortSideTop:
+		center = fyne.NewPos(nodeSize.Width*port.offset, 0)
+	case PortSideBottom:
+		center = fyne.NewPos(nodeSize.Width*port.offset, nodeSize.Height)
+	}
+	port.Resize(fyne.NewSize(size, size))
+	port.Move(center.SubtractXY(size/2, size/2))
+}
+
+// portPadRenderer
+type portPadRenderer struct {
+	port   *PortPad
+	circle *canvas.Circle
+}
+
+func (ppr *portPadRenderer) Destroy() {
+}
+
+func (ppr *portPadRenderer) Layout(size fyne.Size) {
+	ppr.circle.Resize(size)
+}
+
+func (ppr *portPadRenderer) MinSize() fyne.Size {
+	size := ppr.port.padOwner.GetDiagram().scaled(portPadSize)
+	return fyne.NewSize(size, size)
+}
+
+func (ppr *portPadRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{ppr.circle}
+}
+
+func (ppr *portPadRenderer) Refresh() {
+	properties := ppr.port.padOwner.GetProperties()
+	ppr.circle.StrokeColor = properties.ForegroundColor
+	ppr.circle.StrokeWidth = ppr.port.padOwner.GetDiagram().scaled(ppr.port.lineWidth)
+	ppr.circle.FillColor = properties.BackgroundColor
+	if ppr.port.highlightColor != nil {
+		ppr.circle.FillColor = ppr.port.highlightColor
+	}
+	ppr.circle.Resize(ppr.port.Size())
+	ppr.circle.Refresh()
+}
+
+// spreadPorts spreads the automatically placed ports on the indicated side evenly along that side
+func (bdn *BaseDiagramNode) spreadPorts(side PortSide) {
+	ports := []*PortPad{}
+	for _, key := range sortedKeys(bdn.pads) {
+		if port, ok := bdn.pads[key].(*PortPad); ok && port.autoPlaced && port.side == side {
+			ports = append(ports, port)
+		}
+	}
+	for i, port := range ports {
+		port.offset = float32(i+1) / float32(len(ports)+1)
+	}
+}
+
+// arePortTypesCompatible returns true if data of the output type can flow into a port of the input type
+func (dw *DiagramWidget) arePortTypesCompatible(outputType string, inputType string) bool {
+	if dw.PortTypesCompatibleCallback != nil {
+		return dw.PortTypesCompatibleCallback(outputType, inputType)
+	}
+	return outputType == "" || inputType == "" || outputType == inputType
+}
+
+// clearPortHighlights removes the highlighting of the ports at the end of a ConnectionTransaction
+func (dw *DiagramWidget) clearPortHighlights() {
+	for _, node := range dw.GetDiagramNodes() {
+		for _, pad := range node.GetConnectionPads() {
+			if port, ok := pad.(*PortPad); ok && port.highlightColor != nil {
+				port.highlightColor = nil
+				port.Refresh()
+			}
+		}
+	}
+}
+
+// highlightPorts colors each port according to whether the link end of the ConnectionTransaction can be
+// connected to it
+func (dw *DiagramWidget) highlightPorts() {
+	connTrans := dw.ConnectionTransaction
+	if connTrans == nil {
+		return
+	}
+	for _, node := range dw.GetDiagramNodes() {
+		for _, pad := range node.GetConnectionPads() {
+			port, ok := pad.(*PortPad)
+			if !ok {
+				continue
+			}
+			if connTrans.Link.isConnectionAllowed(connTrans.LinkPoint, port) {
+				port.highlightColor = port.padColor
+			} else {
+				port.highlightColor = dw.InvalidPortColor
+			}
+			port.Refresh()
+		}
+	}
+}
+
+// isPortConnectionAllowed applies the port rules to the connection of the link end to the pad. Connections
+// to pads other than ports are always allowed.
+func (dw *DiagramWidget) isPortConnectionAllowed(bdl *BaseDiagramLink, linkEnd LinkEnd, pad ConnectionPad) bool {
+	port, ok := pad.(*PortPad)
+	if !ok {
+		return true
+	}
+	var otherPad ConnectionPad
+	switch linkEnd {
+	case SOURCE:
+		if port.Direction == PortInput {
+			return false
+		}
+		otherPad = bdl.targetPad
+	case TARGET:
+		if port.Direction == PortOutput {
+			return false
+		}
+		otherPad = bdl.sourcePad
+	}
+	if port.MaxConnections > 0 && port.connectionCount(bdl) >= port.MaxConnections {
+		return false
+	}
+	otherPort, ok := otherPad.(*PortPad)
+	if !ok {
+		return true
+	}
+	if otherPort == port {
+		return false
+	}
+	if linkEnd == SOURCE {
+		return dw.arePortTypesCompatible(port.DataType, otherPort.DataType)
+	}
+	return dw.arePortTypesCompatible(otherPort.DataType, port.DataType)
+}
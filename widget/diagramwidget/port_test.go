@@ -0,0 +1,157 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortPlacement(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node := NewDiagramNode(diagram, nil, "Node1")
+	in1 := NewPortPad(node, "in1", PortInput, "int")
+	in2 := NewPortPad(node, "in2", PortInput, "int")
+	out := NewPortPad(node, "out", PortOutput, "int")
+	assert.Equal(t, ConnectionPad(in1), node.GetConnectionPads()["in1"])
+
+	// The inputs are spread along the left edge, the output is centered on the right edge
+	size := node.Size()
+	assert.Equal(t, fyne.NewPos(0, size.Height/3), in1.GetCenterInDiagramCoordinates())
+	assert.Equal(t, fyne.NewPos(0, 2*size.Height/3), in2.GetCenterInDiagramCoordinates())
+	assert.Equal(t, fyne.NewPos(size.Width, size.Height/2), out.GetCenterInDiagramCoordinates())
+	in2.SetPlacement(PortSideBottom, 0.25)
+	assert.Equal(t, fyne.NewPos(0, size.Height/2), in1.GetCenterInDiagramCoordinates())
+	assert.Equal(t, fyne.NewPos(size.Width/4, size.Height), in2.GetCenterInDiagramCoordinates())
+
+	// The ports survive serialization
+	data, err := Marshal(diagram)
+	assert.NoError(t, err)
+	restored := NewDiagramWidget("Diagram2")
+	assert.NoError(t, Unmarshal(data, restored))
+	restoredPort, ok := restored.GetDiagramNode("Node1").GetConnectionPads()["in2"].(*PortPad)
+	assert.True(t, ok)
+	assert.Equal(t, PortInput, restoredPort.Direction)
+	assert.Equal(t, "int", restoredPort.DataType)
+	side, offset := restoredPort.GetPlacement()
+	assert.Equal(t, PortSideBottom, side)
+	assert.Equal(t, float32(0.25), offset)
+}
+
+func TestPortConnectionRules(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	producer := NewDiagramNode(diagram, nil, "Producer")
+	out := NewPortPad(producer, "out", PortOutput, "int")
+	consumer := NewDiagramNode(diagram, nil, "Consumer")
+	consumer.Move(fyne.NewPos(200, 0))
+	value := NewPortPad(consumer, "value", PortInput, "int")
+	value.MaxConnections = 1
+	name := NewPortPad(consumer, "name", PortInput, "string")
+	other := NewDiagramNode(diagram, nil, "Other")
+	other.Move(fyne.NewPos(200, 200))
+	untyped := NewPortPad(other, "any", PortInput, "")
+
+	link1 := NewDiagramLink(diagram, "Link1")
+	link1.SetSourcePad(out)
+	link1.SetTargetPad(value)
+	link2 := NewDiagramLink(diagram, "Link2")
+	link2.SetSourcePad(out)
+	link2.SetTargetPad(untyped)
+	sourcePoint := link2.GetLinkPoints()[0]
+	targetPoint := link2.GetLinkPoints()[1]
+
+	// Direction: a source cannot be connected to an input port
+	assert.False(t, link2.isConnectionAllowed(sourcePoint, name))
+	// Data type: an int output cannot feed a string input, but can feed an untyped one
+	assert.False(t, link2.isConnectionAllowed(targetPoint, name))
+	assert.True(t, link2.isConnectionAllowed(targetPoint, untyped))
+	// Maximum connections: the value port already has a link
+	assert.False(t, link2.isConnectionAllowed(targetPoint, value))
+	assert.True(t, link1.isConnectionAllowed(link1.GetLinkPoints()[1], value))
+	// Connections to other pads are unaffected
+	assert.True(t, link2.isConnectionAllowed(targetPoint, consumer.GetEdgePad()))
+	// The application can define the compatible types
+	diagram.PortTypesCompatibleCallback = func(outputType string, inputType string) bool {
+		return outputType == "int" && inputType == "string"
+	}
+	assert.True(t, link2.isConnectionAllowed(targetPoint, name))
+	diagram.PortTypesCompatibleCallback = nil
+
+	// While the target of link2 is being connected, the ports are highlighted
+	link2.GetHandle(TARGET.ToString()).Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(10, 10)})
+	assert.Equal(t, out.padColor, untyped.highlightColor)
+	assert.Equal(t, diagram.InvalidPortColor, name.highlightColor)
+	assert.Equal(t, diagram.InvalidPortColor, value.highlightColor)
+	assert.Equal(t, diagram.InvalidPortColor, out.highlightColor)
+	assert.True(t, name.Visible())
+	link2.GetHandle(TARGET.ToString()).DragEnd()
+	assert.Nil(t, untyped.highlightColor)
+	assert.Nil(t, name.highlightColor)
+	assert.True(t, name.Visible())
+	assert.Equal(t, ConnectionPad(untyped), link2.GetTargetPad())
+}
+
+func TestPortConnectionRulesAppliedProgrammatically(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	producer := NewDiagramNode(diagram, nil, "Producer")
+	out := NewPortPad(producer, "out", PortOutput, "int")
+	consumer := NewDiagramNode(diagram, nil, "Consumer")
+	consumer.Move(fyne.NewPos(200, 0))
+	value := NewPortPad(consumer, "value", PortInput, "int")
+	value.MaxConnections = 1
+	name := NewPortPad(consumer, "name", PortInput, "string")
+
+	link1 := NewDiagramLink(diagram, "Link1")
+	link1.SetSourcePad(out)
+	link1.SetTargetPad(value)
+	assert.Equal(t, ConnectionPad(value), link1.GetTargetPad())
+
+	// Direction: the source of a link cannot be an input port
+	link2 := NewDiagramLink(diagram, "Link2")
+	link2.SetSourcePad(name)
+	assert.Nil(t, link2.GetSourcePad())
+	// Maximum connections: the value port already has a link
+	link2.SetSourcePad(out)
+	link2.SetTargetPad(value)
+	assert.Nil(t, link2.GetTargetPad())
+	// Data type: an int output cannot feed a string input
+	link2.SetTargetPad(name)
+	assert.Nil(t, link2.GetTargetPad())
+	// A refused connection is not recorded for undo
+	diagram.Undo()
+	assert.Nil(t, link2.GetSourcePad())
+
+	// A saved diagram that breaks the port rules cannot be loaded
+	model, err := NewDiagramModel(diagram)
+	assert.NoError(t, err)
+	for i := range model.Links {
+		if model.Links[i].ID == "Link1" {
+			model.Links[i].Target.PadKey = "name"
+		}
+	}
+	restored := NewDiagramWidget("Diagram2")
+	assert.Error(t, model.AddToDiagram(restored))
+
+	// A GraphBinder leaves the end of a model link that breaks the port rules disconnected
+	graph := NewGraphModel()
+	graph.SetNode("Producer", GraphNode{})
+	graph.SetNode("Consumer", GraphNode{Position: fyne.NewPos(200, 0)})
+	graph.SetLink("Link1", GraphLink{SourceID: "Producer", SourcePad: "out", TargetID: "Consumer", TargetPad: "name"})
+	bound := NewDiagramWidget("Diagram3")
+	binder := NewGraphBinder(bound, graph)
+	binder.CreateNodeCallback = func(diagram *DiagramWidget, nodeID string, node GraphNode) DiagramNode {
+		diagramNode := NewDiagramNode(diagram, nil, nodeID)
+		NewPortPad(diagramNode, "out", PortOutput, "int")
+		NewPortPad(diagramNode, "name", PortInput, "string")
+		return diagramNode
+	}
+	binder.Bind()
+	// Depending on which node was created first, either end is refused
+	link := bound.GetDiagramLink("Link1")
+	assert.NotNil(t, link)
+	assert.True(t, link.GetSourcePad() == nil || link.GetTargetPad() == nil)
+}
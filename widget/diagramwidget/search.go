@@ -0,0 +1,267 @@
+package diagramwidget
+
+import (
+	"image/color"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+)
+
+// defaultDimmedOpacity is the initial value of the DiagramWidget's DimmedOpacity
+const defaultDimmedOpacity = 0.25
+
+// ElementPredicate reports whether a diagram element matches a search
+type ElementPredicate func(DiagramElement) bool
+
+// MatchID returns an ElementPredicate matching the elements whose IDs contain the text, ignoring case
+func MatchID(text string) ElementPredicate {
+	text = strings.ToLower(text)
+	return func(element DiagramElement) bool {
+		return strings.Contains(strings.ToLower(element.GetDiagramElementID()), text)
+	}
+}
+
+// MatchText returns an ElementPredicate matching the elements displaying the text, ignoring case. The text
+// of a node is that of the labels, entries, buttons and other text widgets in its inner object, and the text
+// of a link is that of its anchored texts.
+func MatchText(text string) ElementPredicate {
+	text = strings.ToLower(text)
+	return func(element DiagramElement) bool {
+		for _, elementText := range getElementTexts(element) {
+			if strings.Contains(strings.ToLower(elementText), text) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ClearHighlights removes the highlighting and dimming of the elements and forgets the search results
+func (dw *DiagramWidget) ClearHighlights() {
+	dw.HighlightElements(nil, false)
+}
+
+// FindElements returns the elements matching the predicate, from the back of the diagram to the front
+func (dw *DiagramWidget) FindElements(predicate ElementPredicate) []DiagramElement {
+	matches := []DiagramElement{}
+	for _, element := range dw.GetDiagramElements() {
+		if predicate(element) {
+			matches = append(matches, element)
+		}
+	}
+	return matches
+}
+
+// GetSearchResults returns the elements highlighted by the last search, in the order in which
+// NextSearchResult visits them
+func (dw *DiagramWidget) GetSearchResults() []DiagramElement {
+	return append([]DiagramElement(nil), dw.searchResults...)
+}
+
+// HighlightElements highlights the elements, replacing the previous search results with them. When dimOthers
+// is true, the elements that are not highlighted are dimmed. The highlighted elements are outlined with the
+// HighlightColor and the dimmed ones are faded to the DimmedOpacity.
+func (dw *DiagramWidget) HighlightElements(elements []DiagramElement, dimOthers bool) {
+	dw.searchResults = append([]DiagramElement(nil), elements...)
+	dw.searchIndex = -1
+	dw.highlightedElements = map[string]bool{}
+	for _, element := range elements {
+		dw.highlightedElements[element.GetDiagramElementID()] = true
+	}
+	dw.dimUnhighlightedElements = dimOthers
+	for _, element := range dw.GetDiagramElements() {
+		element.Refresh()
+		// The anchored texts are not refreshed with their link, but they are dimmed with it
+		if link, ok := element.(DiagramLink); ok {
+			bdl := link.getBaseDiagramLink()
+			for _, anchoredTexts := range []map[string]*AnchoredText{bdl.sourceAnchoredText, bdl.midpointAnchoredText, bdl.targetAnchoredText} {
+				for _, anchoredText := range anchoredTexts {
+					anchoredText.Refresh()
+				}
+			}
+		}
+	}
+}
+
+// HighlightNeighbourhood highlights the selected elements and everything within the indicated number of hops
+// of them, and returns the highlighted elements. A hop follows a link from an element to the element at its
+// other end, and the links followed are highlighted too. The ends of a selected link are part of the
+// selection. When dimOthers is true, the rest of the diagram is dimmed.
+func (dw *DiagramWidget) HighlightNeighbourhood(hops int, dimOthers bool) []DiagramElement {
+	distances := map[string]int{}
+	neighbourhood := []DiagramElement{}
+	visit := func(element DiagramElement, distance int) {
+		if element == nil {
+			return
+		}
+		if _, ok := distances[element.GetDiagramElementID()]; ok {
+			return
+		}
+		distances[element.GetDiagramElementID()] = distance
+		neighbourhood = append(neighbourhood, element)
+	}
+	for _, element := range dw.GetDiagramElements() {
+		if !dw.IsSelected(element) {
+			continue
+		}
+		visit(element, 0)
+		if link, ok := element.(DiagramLink); ok {
+			visit(dw.getPadOwner(link.GetSourcePad()), 0)
+			visit(dw.getPadOwner(link.GetTargetPad()), 0)
+		}
+	}
+	// The neighbourhood grows as the elements are visited, so that it is traversed breadth first
+	for i := 0; i < len(neighbourhood); i++ {
+		element := neighbourhood[i]
+		distance := distances[element.GetDiagramElementID()]
+		if distance >= hops {
+			continue
+		}
+		for _, pair := range dw.diagramElementLinkDependencies[element.GetDiagramElementID()] {
+			otherPad := pair.link.sourcePad
+			if pair.pad == otherPad {
+				otherPad = pair.link.targetPad
+			}
+			visit(pair.link.typedLink, distance+1)
+			visit(dw.getPadOwner(otherPad), distance+1)
+		}
+	}
+	dw.HighlightElements(neighbourhood, dimOthers)
+	return neighbourhood
+}
+
+// NextSearchResult scrolls the diagram to the next element highlighted by the last search and returns it.
+// After the last result it wraps around to the first one. It returns nil if there are no results.
+func (dw *DiagramWidget) NextSearchResult() DiagramElement {
+	return dw.stepSearchResults(1)
+}
+
+// PreviousSearchResult scrolls the diagram to the previous element highlighted by the last search and returns
+// it. Before the first result it wraps around to the last one. It returns nil if there are no results.
+func (dw *DiagramWidget) PreviousSearchResult() DiagramElement {
+	return dw.stepSearchResults(-1)
+}
+
+// Search highlights the elements matching the predicate, dimming the others when dimOthers is true, scrolls
+// the diagram to the first match and returns the matches. The matches can then be visited with
+// NextSearchResult and PreviousSearchResult.
+func (dw *DiagramWidget) Search(predicate ElementPredicate, dimOthers bool) []DiagramElement {
+	matches := dw.FindElements(predicate)
+	dw.HighlightElements(matches, dimOthers)
+	dw.NextSearchResult()
+	return matches
+}
+
+// getDimmer returns the color of a translucent rectangle that dims the objects below it, or nil if the
+// element is not dimmed
+func (dw *DiagramWidget) getDimmer(elementID string) color.Color {
+	if !dw.isDimmed(elementID) {
+		return nil
+	}
+	dimmer := color.NRGBAModel.Convert(dw.GetBackgroundColor()).(color.NRGBA)
+	dimmer.A = uint8(255 * (1 - dw.DimmedOpacity))
+	return dimmer
+}
+
+// getHighlightedForegroundColor returns the color in which the element's lines are drawn: the HighlightColor
+// if it is highlighted, and its foreground color faded to the DimmedOpacity if it is dimmed
+func (dw *DiagramWidget) getHighlightedForegroundColor(elementID string, foregroundColor color.Color) color.Color {
+	switch {
+	case dw.isHighlighted(elementID):
+		return dw.HighlightColor
+	case dw.isDimmed(elementID):
+		faded := color.NRGBAModel.Convert(foregroundColor).(color.NRGBA)
+		faded.A = uint8(float32(faded.A) * dw.DimmedOpacity)
+		return faded
+	}
+	return foregroundColor
+}
+
+// getHighlightedStrokeWidth returns the width of the element's lines, which is doubled when it is highlighted
+func (dw *DiagramWidget) getHighlightedStrokeWidth(elementID string, strokeWidth float32) float32 {
+	if dw.isHighlighted(elementID) {
+		return 2 * strokeWidth
+	}
+	return strokeWidth
+}
+
+// getPadOwner returns the element that owns the pad, or nil if there is no pad
+func (dw *DiagramWidget) getPadOwner(pad ConnectionPad) DiagramElement {
+	if pad == nil {
+		return nil
+	}
+	return dw.GetDiagramElement(pad.GetPadOwner().GetDiagramElementID())
+}
+
+// isDimmed returns true if the element with the indicated ID is dimmed
+func (dw *DiagramWidget) isDimmed(elementID string) bool {
+	return dw.dimUnhighlightedElements && !dw.highlightedElements[elementID]
+}
+
+// isHighlighted returns true if the element with the indicated ID is highlighted
+func (dw *DiagramWidget) isHighlighted(elementID string) bool {
+	return dw.highlightedElements[elementID]
+}
+
+// stepSearchResults scrolls to the search result the indicated number of steps from the current one and
+// returns it. The results that have been removed from the diagram are skipped.
+func (dw *DiagramWidget) stepSearchResults(step int) DiagramElement {
+	count := len(dw.searchResults)
+	for i := 0; i < count; i++ {
+		dw.searchIndex = ((dw.searchIndex+step)%count + count) % count
+		element := dw.searchResults[dw.searchIndex]
+		if dw.GetDiagramElement(element.GetDiagramElementID()) == element {
+			dw.scrollToElement(element)
+			return element
+		}
+	}
+	return nil
+}
+
+// getElementTexts returns the texts displayed by the element
+func getElementTexts(element DiagramElement) []string {
+	switch typed := element.(type) {
+	case DiagramNode:
+		return getObjectTexts(typed.getBaseDiagramNode().innerObject)
+	case DiagramLink:
+		texts := []string{}
+		bdl := typed.getBaseDiagramLink()
+		for _, anchoredTexts := range []map[string]*AnchoredText{bdl.sourceAnchoredText, bdl.midpointAnchoredText, bdl.targetAnchoredText} {
+			for _, anchoredText := range anchoredTexts {
+				text, _ := anchoredText.GetDisplayedTextBinding().Get()
+				texts = append(texts, text)
+			}
+		}
+		return texts
+	}
+	return nil
+}
+
+// getObjectTexts returns the texts displayed by the canvas object and, if it is a container, by its contents
+func getObjectTexts(object fyne.CanvasObject) []string {
+	switch typed := object.(type) {
+	case *canvas.Text:
+		return []string{typed.Text}
+	case *widget.Button:
+		return []string{typed.Text}
+	case *widget.Check:
+		return []string{typed.Text}
+	case *widget.Entry:
+		return []string{typed.Text}
+	case *widget.Hyperlink:
+		return []string{typed.Text}
+	case *widget.Label:
+		return []string{typed.Text}
+	case *widget.RichText:
+		return []string{typed.String()}
+	case *fyne.Container:
+		texts := []string{}
+		for _, child := range typed.Objects {
+			texts = append(texts, getObjectTexts(child)...)
+		}
+		return texts
+	}
+	return nil
+}
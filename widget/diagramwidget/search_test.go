@@ -0,0 +1,101 @@
+package diagramwidget
+
+import (
+	"fmt"
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearch(t *testing.T) {
+	test.NewApp()
+	// A chain of four linked nodes and an unlinked one
+	diagram := NewDiagramWidget("Diagram1")
+	nodes := []DiagramNode{}
+	for i, name := range []string{"Alpha", "Beta", "Gamma", "Delta", "Epsilon"} {
+		node := NewDiagramNode(diagram, widget.NewLabel(name), fmt.Sprintf("Node%d", i))
+		node.Move(fyne.NewPos(float32(100+300*i), 100))
+		nodes = append(nodes, node)
+	}
+	links := []DiagramLink{}
+	for i := 0; i < 3; i++ {
+		link := NewDiagramLink(diagram, fmt.Sprintf("Link%d", i))
+		link.SetSourcePad(nodes[i].GetEdgePad())
+		link.SetTargetPad(nodes[i+1].GetEdgePad())
+		links = append(links, link)
+	}
+	links[1].getBaseDiagramLink().AddMidpointAnchoredText("label", "flows into")
+	diagram.Resize(fyne.NewSize(400, 300))
+
+	assert.Equal(t, 5, len(diagram.FindElements(MatchID("node"))))
+	assert.Equal(t, []DiagramElement{links[1]}, diagram.FindElements(MatchText("FLOWS")))
+	assert.Equal(t, []DiagramElement{nodes[2], nodes[3]}, diagram.FindElements(func(element DiagramElement) bool {
+		return element.IsNode() && element.Position().X > 600 && element.Position().X < 1200
+	}))
+
+	// Matches are highlighted and the others dimmed
+	matches := diagram.Search(MatchText("ta"), true)
+	assert.Equal(t, []DiagramElement{nodes[1], nodes[3]}, matches)
+	renderer := test.TempWidgetRenderer(t, nodes[1]).(*diagramNodeRenderer)
+	assert.Equal(t, diagram.HighlightColor, renderer.box.StrokeColor)
+	assert.Equal(t, float32(2), renderer.box.StrokeWidth)
+	assert.NotContains(t, renderer.Objects(), renderer.dimmer)
+	renderer = test.TempWidgetRenderer(t, nodes[0]).(*diagramNodeRenderer)
+	assert.Equal(t, nodes[0].GetForegroundColor(), renderer.box.StrokeColor)
+	assert.Contains(t, renderer.Objects(), renderer.dimmer)
+	line := test.TempWidgetRenderer(t, links[0].getBaseDiagramLink().linkSegments[0]).(*linkSegmentRenderer).line
+	assert.Equal(t, uint8(float32(255)*diagram.DimmedOpacity), line.StrokeColor.(color.NRGBA).A)
+
+	// The view scrolls to the first match, then to the next ones in turn
+	offset := diagram.scrollingContainer.Offset
+	assert.Less(t, float32(0), offset.X)
+	assert.Equal(t, nodes[3], diagram.NextSearchResult())
+	assert.Less(t, offset.X, diagram.scrollingContainer.Offset.X)
+	assert.Equal(t, nodes[1], diagram.NextSearchResult())
+	assert.Equal(t, nodes[3], diagram.PreviousSearchResult())
+	diagram.RemoveElement("Node3")
+	assert.Equal(t, nodes[1], diagram.NextSearchResult())
+	assert.Equal(t, nodes[1], diagram.NextSearchResult())
+
+	diagram.ClearHighlights()
+	assert.Nil(t, diagram.NextSearchResult())
+	renderer = test.TempWidgetRenderer(t, nodes[1]).(*diagramNodeRenderer)
+	assert.Equal(t, nodes[1].GetForegroundColor(), renderer.box.StrokeColor)
+	assert.Equal(t, float32(1), renderer.box.StrokeWidth)
+	assert.NotContains(t, test.TempWidgetRenderer(t, nodes[0]).Objects(), renderer.dimmer)
+}
+
+func TestHighlightNeighbourhood(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	nodes := []DiagramNode{}
+	for i, name := range []string{"Alpha", "Beta", "Gamma", "Delta", "Epsilon"} {
+		node := NewDiagramNode(diagram, widget.NewLabel(name), fmt.Sprintf("Node%d", i))
+		node.Move(fyne.NewPos(float32(100+300*i), 100))
+		nodes = append(nodes, node)
+	}
+	links := []DiagramLink{}
+	for i := 0; i < 3; i++ {
+		link := NewDiagramLink(diagram, fmt.Sprintf("Link%d", i))
+		link.SetSourcePad(nodes[i].GetEdgePad())
+		link.SetTargetPad(nodes[i+1].GetEdgePad())
+		links = append(links, link)
+	}
+	links[1].getBaseDiagramLink().AddMidpointAnchoredText("label", "flows into")
+	diagram.SelectDiagramElementNoCallback("Node0")
+	assert.ElementsMatch(t, []DiagramElement{nodes[0], links[0], nodes[1]}, diagram.HighlightNeighbourhood(1, false))
+	assert.True(t, diagram.isHighlighted("Link0"))
+	assert.False(t, diagram.isDimmed("Node4"))
+	assert.ElementsMatch(t, []DiagramElement{nodes[0], links[0], nodes[1], links[1], nodes[2]}, diagram.HighlightNeighbourhood(2, true))
+	assert.True(t, diagram.isDimmed("Node4"))
+	assert.True(t, diagram.isDimmed("Link2"))
+
+	// The ends of a selected link are part of the selection
+	diagram.ClearSelectionNoCallback()
+	diagram.SelectDiagramElementNoCallback("Link2")
+	assert.ElementsMatch(t, []DiagramElement{links[2], nodes[2], nodes[3]}, diagram.HighlightNeighbourhood(0, true))
+}
@@ -0,0 +1,928 @@
+package diagramwidget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"sort"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// DiagramSerializationVersion is the version of the JSON model produced by Marshal. Unmarshal accepts
+// any model whose version is not greater than this value.
+const DiagramSerializationVersion = 1
+
+const (
+	// BaseDiagramNodeType is the element type used to serialize nodes that do not implement SerializableElement
+	BaseDiagramNodeType = "BaseDiagramNode"
+	// BaseDiagramLinkType is the element type used to serialize links that do not implement SerializableElement
+	BaseDiagramLinkType = "BaseDiagramLink"
+	// BaseDiagramGroupType is the element type used to serialize groups that do not implement SerializableElement
+	BaseDiagramGroupType = "BaseDiagramGroup"
+	// ClassNodeType is the element type used to serialize ClassNodes
+	ClassNodeType = "ClassNode"
+)
+
+// SerializableElement may be implemented by extensions of BaseDiagramNode and BaseDiagramLink that need
+// to be reconstructed by Unmarshal. The element type identifies the factory registered with
+// RegisterNodeFactory or RegisterLinkFactory, and the element data is passed to that factory.
+type SerializableElement interface {
+	// GetElementType returns the name under which the element's factory has been registered
+	GetElementType() string
+	// MarshalElementData returns the application data needed by the factory to re-create the element
+	MarshalElementData() (json.RawMessage, error)
+}
+
+// SerializablePad may be implemented by application pad types, which extend the pads of this package, that
+// need to be reconstructed by Unmarshal. The pad type identifies the factory registered with RegisterPadFactory.
+// Pads of other types that are not provided by this package cannot be serialized.
+type SerializablePad interface {
+	ConnectionPad
+	// GetPadType returns the name under which the pad's factory has been registered
+	GetPadType() string
+}
+
+// NodeFactory creates a DiagramNode in the diagram with the indicated ID. The data is the value that was
+// returned by the node's MarshalElementData. Position, size, properties and pads are restored by
+// Unmarshal after the factory returns.
+type NodeFactory func(diagram *DiagramWidget, nodeID string, data json.RawMessage) (DiagramNode, error)
+
+// LinkFactory creates a DiagramLink in the diagram with the indicated ID. The data is the value that was
+// returned by the link's MarshalElementData. Connections, decorations, anchored texts and properties are
+// restored by Unmarshal after the factory returns.
+type LinkFactory func(diagram *DiagramWidget, linkID string, data json.RawMessage) (DiagramLink, error)
+
+// PadFactory creates a ConnectionPad belonging to the owner. The position of the pad is restored by Unmarshal
+// after the factory returns.
+type PadFactory func(owner DiagramElement) (ConnectionPad, error)
+
+var (
+	factoryLock   sync.RWMutex
+	nodeFactories = map[string]NodeFactory{
+		BaseDiagramNodeType:  newBaseDiagramNodeFromData,
+		BaseDiagramGroupType: newBaseDiagramGroupFromData,
+		ClassNodeType:        newClassNodeFromData,
+	}
+	linkFactories = map[string]LinkFactory{BaseDiagramLinkType: newBaseDiagramLinkFromData}
+	padFactories  = map[string]PadFactory{}
+)
+
+// RegisterNodeFactory registers the factory used by Unmarshal to create nodes of the indicated type
+func RegisterNodeFactory(nodeType string, factory NodeFactory) {
+	factoryLock.Lock()
+	defer factoryLock.Unlock()
+	nodeFactories[nodeType] = factory
+}
+
+// RegisterLinkFactory registers the factory used by Unmarshal to create links of the indicated type
+func RegisterLinkFactory(linkType string, factory LinkFactory) {
+	factoryLock.Lock()
+	defer factoryLock.Unlock()
+	linkFactories[linkType] = factory
+}
+
+// RegisterPadFactory registers the factory used by Unmarshal to create pads of the indicated type
+func RegisterPadFactory(padType string, factory PadFactory) {
+	factoryLock.Lock()
+	defer factoryLock.Unlock()
+	padFactories[padType] = factory
+}
+
+// DiagramModel is the serializable form of the contents of a DiagramWidget
+type DiagramModel struct {
+	Version int         `json:"version"`
+	Nodes   []NodeModel `json:"nodes"`
+	Links   []LinkModel `json:"links"`
+	// ZOrder lists the IDs of the elements from back to front
+	ZOrder []string `json:"zOrder"`
+}
+
+// PositionModel is the serializable form of a fyne.Position
+type PositionModel struct {
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+}
+
+// SizeModel is the serializable form of a fyne.Size
+type SizeModel struct {
+	Width  float32 `json:"width"`
+	Height float32 `json:"height"`
+}
+
+// PropertiesModel is the serializable form of DiagramElementProperties. Colors are
+// represented as #RRGGBBAA strings.
+type PropertiesModel struct {
+	ForegroundColor   string  `json:"foregroundColor,omitempty"`
+	BackgroundColor   string  `json:"backgroundColor,omitempty"`
+	HandleColor       string  `json:"handleColor,omitempty"`
+	PadColor          string  `json:"padColor,omitempty"`
+	TextSize          float32 `json:"textSize"`
+	CaptionTextSize   float32 `json:"captionTextSize"`
+	Padding           float32 `json:"padding"`
+	StrokeWidth       float32 `json:"strokeWidth"`
+	PadStrokeWidth    float32 `json:"padStrokeWidth"`
+	HandleStrokeWidth float32 `json:"handleStrokeWidth"`
+}
+
+// PadModel describes a ConnectionPad belonging to a DiagramElement. Port is only present for a PortPad.
+type PadModel struct {
+	Key      string        `json:"key"`
+	Type     string        `json:"type"`
+	Position PositionModel `json:"position"`
+	Port     *PortModel    `json:"port,omitempty"`
+}
+
+// PortModel describes the characteristics of a PortPad
+type PortModel struct {
+	DataType       string        `json:"dataType,omitempty"`
+	Direction      PortDirection `json:"direction"`
+	MaxConnections int           `json:"maxConnections,omitempty"`
+	Side           PortSide      `json:"side"`
+	Offset         float32       `json:"offset"`
+	AutoPlaced     bool          `json:"autoPlaced,omitempty"`
+}
+
+// PadReference identifies a ConnectionPad by the ID of its owner and the key of the pad on that owner
+type PadReference struct {
+	ElementID string `json:"elementID"`
+	PadKey    string `json:"padKey"`
+}
+
+// NodeModel is the serializable form of a DiagramNode. The position and inner size are unscaled,
+// i.e. they do not depend on the zoom at the time the diagram was saved. Parent is the ID of the
+// group to which the node belongs, and Collapsed indicates whether a group is collapsed.
+type NodeModel struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Parent     string          `json:"parent,omitempty"`
+	Collapsed  bool            `json:"collapsed,omitempty"`
+	Shape      NodeShape       `json:"shape,omitempty"`
+	Pinned     bool            `json:"pinned,omitempty"`
+	Position   PositionModel   `json:"position"`
+	InnerSize  SizeModel       `json:"innerSize"`
+	Properties PropertiesModel `json:"properties"`
+	Pads       []PadModel      `json:"pads,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// DecorationModel is the serializable form of a Decoration
+type DecorationModel struct {
+	Type        string          `json:"type"`
+	StrokeWidth float32         `json:"strokeWidth"`
+	StrokeColor string          `json:"strokeColor,omitempty"`
+	FillColor   string          `json:"fillColor,omitempty"`
+	Points      []PositionModel `json:"points,omitempty"`
+	Closed      bool            `json:"closed,omitempty"`
+	Solid       bool            `json:"solid,omitempty"`
+	Theta       float64         `json:"theta,omitempty"`
+	Length      int             `json:"length,omitempty"`
+}
+
+// AnchoredTextModel is the serializable form of an AnchoredText. The offset is the displacement
+// of the text from its reference point on the link.
+type AnchoredTextModel struct {
+	Key    string        `json:"key"`
+	Text   string        `json:"text"`
+	Offset PositionModel `json:"offset"`
+}
+
+// RouterModel is the serializable form of one of the standard LinkRouters. Application-defined routers
+// are not serialized; the link factory is responsible for setting them.
+type RouterModel struct {
+	Type            string  `json:"type"`
+	Margin          float32 `json:"margin,omitempty"`
+	BendPenalty     float32 `json:"bendPenalty,omitempty"`
+	Curvature       float32 `json:"curvature,omitempty"`
+	SegmentsPerSpan int     `json:"segmentsPerSpan,omitempty"`
+}
+
+// LinkModel is the serializable form of a DiagramLink. Points are the source end, the waypoints and the target end
+// in unscaled diagram coordinates; the positions of the ends are used for ends that are not connected to a pad.
+type LinkModel struct {
+	ID                   string              `json:"id"`
+	Type                 string              `json:"type"`
+	Source               *PadReference       `json:"source,omitempty"`
+	Target               *PadReference       `json:"target,omitempty"`
+	Points               []PositionModel     `json:"points"`
+	Properties           PropertiesModel     `json:"properties"`
+	Pads                 []PadModel          `json:"pads,omitempty"`
+	Router               *RouterModel        `json:"router,omitempty"`
+	LineStyle            LineStyle           `json:"lineStyle,omitempty"`
+	SourceDecorations    []DecorationModel   `json:"sourceDecorations,omitempty"`
+	MidpointDecorations  []DecorationModel   `json:"midpointDecorations,omitempty"`
+	TargetDecorations    []DecorationModel   `json:"targetDecorations,omitempty"`
+	SourceAnchoredText   []AnchoredTextModel `json:"sourceAnchoredText,omitempty"`
+	MidpointAnchoredText []AnchoredTextModel `json:"midpointAnchoredText,omitempty"`
+	TargetAnchoredText   []AnchoredTextModel `json:"targetAnchoredText,omitempty"`
+	Data                 json.RawMessage     `json:"data,omitempty"`
+}
+
+// Marshal returns the JSON representation of all of the nodes and links in the diagram
+func Marshal(dw *DiagramWidget) ([]byte, error) {
+	model, err := NewDiagramModel(dw)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(model, "", "  ")
+}
+
+// Unmarshal adds the nodes and links described by the JSON data to the diagram. The IDs of
+// the new elements must not already be in use in the diagram.
+func Unmarshal(data []byte, dw *DiagramWidget) error {
+	model := &DiagramModel{}
+	if err := json.Unmarshal(data, model); err != nil {
+		return err
+	}
+	return model.AddToDiagram(dw)
+}
+
+// NewDiagramModel creates the serializable model of all of the elements in the diagram
+func NewDiagramModel(dw *DiagramWidget) (*DiagramModel, error) {
+	return newDiagramModelForElements(dw.GetDiagramElements())
+}
+
+// newDiagramModelForElements creates the serializable model of the supplied elements, which are
+// expected to be in z-order. Link connections to pads whose owners are not among the elements are omitted,
+// as is membership of groups that are not among the elements.
+func newDiagramModelForElements(elements []DiagramElement) (*DiagramModel, error) {
+	model := &DiagramModel{
+		Version: DiagramSerializationVersion,
+		Nodes:   []NodeModel{},
+		Links:   []LinkModel{},
+		ZOrder:  []string{},
+	}
+	included := map[string]bool{}
+	for _, element := range elements {
+		included[element.GetDiagramElementID()] = true
+	}
+	for _, element := range elements {
+		model.ZOrder = append(model.ZOrder, element.GetDiagramElementID())
+		switch {
+		case element.IsNode():
+			nodeModel, err := newNodeModel(element.(DiagramNode), included)
+			if err != nil {
+				return nil, err
+			}
+			model.Nodes = append(model.Nodes, nodeModel)
+		case element.IsLink():
+			linkModel, err := newLinkModel(element.(DiagramLink), included)
+			if err != nil {
+				return nil, err
+			}
+			model.Links = append(model.Links, linkModel)
+		}
+	}
+	return model, nil
+}
+
+func newNodeModel(node DiagramNode, included map[string]bool) (NodeModel, error) {
+	bdn := node.getBaseDiagramNode()
+	nodeModel := NodeModel{
+		ID:         bdn.id,
+		Type:       BaseDiagramNodeType,
+		Position:   newPositionModel(bdn.diagram.unscalePosition(bdn.Position())),
+		InnerSize:  newSizeModel(bdn.diagram.unscaleSize(bdn.InnerSize)),
+		Properties: newPropertiesModel(bdn.properties),
+		Shape:      bdn.shape,
+		Pinned:     bdn.pinned,
+	}
+	var err error
+	if nodeModel.Pads, err = newPadModels(bdn.pads); err != nil {
+		return nodeModel, err
+	}
+	if parent := bdn.GetParentGroup(); parent != nil && included[parent.GetDiagramElementID()] {
+		nodeModel.Parent = parent.GetDiagramElementID()
+	}
+	group, isGroup := node.(DiagramGroup)
+	if isGroup {
+		nodeModel.Collapsed = group.IsCollapsed()
+	}
+	if serializable, ok := node.(SerializableElement); ok {
+		nodeModel.Type = serializable.GetElementType()
+		data, err := serializable.MarshalElementData()
+		if err != nil {
+			return nodeModel, err
+		}
+		nodeModel.Data = data
+	} else if isGroup {
+		nodeModel.Type = BaseDiagramGroupType
+		data, err := json.Marshal(baseDiagramGroupData{Title: group.getBaseDiagramGroup().GetTitle()})
+		if err != nil {
+			return nodeModel, err
+		}
+		nodeModel.Data = data
+	} else if label, ok := bdn.innerObject.(*widget.Label); ok {
+		data, err := json.Marshal(baseDiagramNodeData{Label: label.Text})
+		if err != nil {
+			return nodeModel, err
+		}
+		nodeModel.Data = data
+	}
+	return nodeModel, nil
+}
+
+func newLinkModel(link DiagramLink, included map[string]bool) (LinkModel, error) {
+	bdl := link.getBaseDiagramLink()
+	linkModel := LinkModel{
+		ID:                   bdl.id,
+		Type:                 BaseDiagramLinkType,
+		Source:               newPadReference(bdl.sourcePad, included),
+		Target:               newPadReference(bdl.targetPad, included),
+		Points:               []PositionModel{},
+		Properties:           newPropertiesModel(bdl.properties),
+		Router:               newRouterModel(bdl.router),
+		LineStyle:            bdl.lineStyle,
+		SourceAnchoredText:   newAnchoredTextModels(bdl.diagram, bdl.sourceAnchoredText),
+		MidpointAnchoredText: newAnchoredTextModels(bdl.diagram, bdl.midpointAnchoredText),
+		TargetAnchoredText:   newAnchoredTextModels(bdl.diagram, bdl.targetAnchoredText),
+	}
+	for _, linkPoint := range bdl.linkPoints {
+		linkModel.Points = append(linkModel.Points, newPositionModel(bdl.diagram.unscalePosition(bdl.Position().Add(linkPoint.Position()))))
+	}
+	var err error
+	if linkModel.Pads, err = newPadModels(bdl.pads); err != nil {
+		return linkModel, err
+	}
+	if linkModel.SourceDecorations, err = newDecorationModels(bdl.SourceDecorations); err != nil {
+		return linkModel, err
+	}
+	if linkModel.MidpointDecorations, err = newDecorationModels(bdl.MidpointDecorations); err != nil {
+		return linkModel, err
+	}
+	if linkModel.TargetDecorations, err = newDecorationModels(bdl.TargetDecorations); err != nil {
+		return linkModel, err
+	}
+	if serializable, ok := link.(SerializableElement); ok {
+		linkModel.Type = serializable.GetElementType()
+		data, err := serializable.MarshalElementData()
+		if err != nil {
+			return linkModel, err
+		}
+		linkModel.Data = data
+	}
+	return linkModel, nil
+}
+
+func newRouterModel(router LinkRouter) *RouterModel {
+	switch r := router.(type) {
+	case *OrthogonalLinkRouter:
+		return &RouterModel{Type: "Orthogonal", Margin: r.Margin, BendPenalty: r.BendPenalty}
+	case *SplineLinkRouter:
+		return &RouterModel{Type: "Spline", Curvature: r.Curvature, SegmentsPerSpan: r.SegmentsPerSpan}
+	}
+	return nil
+}
+
+func (rm *RouterModel) toRouter() (LinkRouter, error) {
+	switch rm.Type {
+	case "Straight":
+		return NewStraightLinkRouter(), nil
+	case "Orthogonal":
+		return &OrthogonalLinkRouter{Margin: rm.Margin, BendPenalty: rm.BendPenalty}, nil
+	case "Spline":
+		return &SplineLinkRouter{Curvature: rm.Curvature, SegmentsPerSpan: rm.SegmentsPerSpan}, nil
+	}
+	return nil, fmt.Errorf("unknown router type %s", rm.Type)
+}
+
+func newAnchoredTextModels(dw *DiagramWidget, anchoredTexts map[string]*AnchoredText) []AnchoredTextModel {
+	models := []AnchoredTextModel{}
+	for _, key := range sortedKeys(anchoredTexts) {
+		at := anchoredTexts[key]
+		text, _ := at.displayedTextBinding.Get()
+		models = append(models, AnchoredTextModel{
+			Key:    key,
+			Text:   text,
+			Offset: newPositionModel(dw.unscalePosition(fyne.NewPos(float32(at.offset.X), float32(at.offset.Y)))),
+		})
+	}
+	return models
+}
+
+func newDecorationModels(decorations []Decoration) ([]DecorationModel, error) {
+	models := []DecorationModel{}
+	for _, decoration := range decorations {
+		switch d := decoration.(type) {
+		case *Arrowhead:
+			models = append(models, DecorationModel{
+				Type:        "Arrowhead",
+				StrokeWidth: d.StrokeWidth / float32(getDecorationZoom(d.link)),
+				StrokeColor: colorToString(d.StrokeColor),
+				Theta:       d.Theta,
+				Length:      d.Length,
+			})
+		case *Polygon:
+			polygonModel := DecorationModel{
+				Type:        "Polygon",
+				StrokeWidth: d.StrokeWidth / float32(getDecorationZoom(d.link)),
+				StrokeColor: colorToString(d.StrokeColor),
+				FillColor:   colorToString(d.FillColor),
+				Closed:      d.closed,
+				Solid:       d.solid,
+			}
+			for _, point := range d.definingPoints {
+				polygonModel.Points = append(polygonModel.Points, newPositionModel(point))
+			}
+			models = append(models, polygonModel)
+		default:
+			return nil, fmt.Errorf("unable to serialize decoration of type %T", decoration)
+		}
+	}
+	return models, nil
+}
+
+func newPadModels(pads map[string]ConnectionPad) ([]PadModel, error) {
+	models := []PadModel{}
+	for _, key := range sortedKeys(pads) {
+		pad := pads[key]
+		padModel := PadModel{Key: key, Position: newPositionModel(pad.Position())}
+		switch pad := pad.(type) {
+		case SerializablePad:
+			padModel.Type = pad.GetPadType()
+		case *PointPad:
+			padModel.Type = "PointPad"
+		case *RectanglePad:
+			padModel.Type = "RectanglePad"
+		case *EllipsePad:
+			padModel.Type = "EllipsePad"
+		case *PolygonPad:
+			padModel.Type = "PolygonPad"
+		case *PortPad:
+			padModel.Type = "PortPad"
+			padModel.Port = &PortModel{
+				DataType:       pad.DataType,
+				Direction:      pad.Direction,
+				MaxConnections: pad.MaxConnections,
+				Side:           pad.side,
+				Offset:         pad.offset,
+				AutoPlaced:     pad.autoPlaced,
+			}
+		default:
+			return nil, fmt.Errorf("unable to serialize pad %s of type %T", key, pad)
+		}
+		models = append(models, padModel)
+	}
+	return models, nil
+}
+
+func newPadReference(pad ConnectionPad, included map[string]bool) *PadReference {
+	if pad == nil {
+		return nil
+	}
+	owner := pad.GetPadOwner()
+	if !included[owner.GetDiagramElementID()] {
+		return nil
+	}
+	return &PadReference{ElementID: owner.GetDiagramElementID(), PadKey: getPadKey(pad)}
+}
+
+func newPositionModel(position fyne.Position) PositionModel {
+	return PositionModel{X: position.X, Y: position.Y}
+}
+
+func newSizeModel(size fyne.Size) SizeModel {
+	return SizeModel{Width: size.Width, Height: size.Height}
+}
+
+func newPropertiesModel(properties DiagramElementProperties) PropertiesModel {
+	return PropertiesModel{
+		ForegroundColor:   colorToString(properties.ForegroundColor),
+		BackgroundColor:   colorToString(properties.BackgroundColor),
+		HandleColor:       colorToString(properties.HandleColor),
+		PadColor:          colorToString(properties.PadColor),
+		TextSize:          properties.TextSize,
+		CaptionTextSize:   properties.CaptionTextSize,
+		Padding:           properties.Padding,
+		StrokeWidth:       properties.StrokeWidth,
+		PadStrokeWidth:    properties.PadStrokeWidth,
+		HandleStrokeWidth: properties.HandleStrokeWidth,
+	}
+}
+
+// AddToDiagram creates the nodes and links described by the model in the diagram, connects the
+// links to their pads, and places the new elements in front of any existing elements in the
+// order given by the model's ZOrder. The IDs of the elements must be unique and not already used in the
+// diagram. If the model cannot be loaded completely, the diagram is left unchanged and the error is returned.
+func (m *DiagramModel) AddToDiagram(dw *DiagramWidget) error {
+	if m.Version > DiagramSerializationVersion {
+		return fmt.Errorf("unsupported diagram serialization version %d", m.Version)
+	}
+	ids := []string{}
+	for _, nodeModel := range m.Nodes {
+		ids = append(ids, nodeModel.ID)
+	}
+	for _, linkModel := range m.Links {
+		ids = append(ids, linkModel.ID)
+	}
+	modelIDs := map[string]bool{}
+	for _, id := range ids {
+		if modelIDs[id] {
+			return fmt.Errorf("diagram element %s appears more than once in the model", id)
+		}
+		modelIDs[id] = true
+		if dw.GetDiagramElement(id) != nil {
+			return fmt.Errorf("diagram element %s already exists", id)
+		}
+	}
+	// Loading is not an edit that can be undone
+	dw.suspendUndoRecording()
+	defer dw.resumeUndoRecording()
+	if err := m.addElementsToDiagram(dw); err != nil {
+		// The elements that were created are removed, so that a model that cannot be loaded leaves the
+		// diagram unchanged
+		for i := len(ids) - 1; i >= 0; i-- {
+			dw.removeElement(ids[i], nil)
+		}
+		dw.Refresh()
+		return err
+	}
+	dw.adjustBounds()
+	dw.Refresh()
+	return nil
+}
+
+// addElementsToDiagram creates the elements described by the model, stopping at the first error
+func (m *DiagramModel) addElementsToDiagram(dw *DiagramWidget) error {
+	for _, nodeModel := range m.Nodes {
+		if err := nodeModel.addToDiagram(dw); err != nil {
+			return err
+		}
+	}
+	// Group membership is restored once all of the nodes exist
+	for _, nodeModel := range m.Nodes {
+		if nodeModel.Parent == "" {
+			continue
+		}
+		group, ok := dw.GetDiagramNode(nodeModel.Parent).(DiagramGroup)
+		if !ok {
+			return fmt.Errorf("group %s not found", nodeModel.Parent)
+		}
+		group.AddChild(dw.GetDiagramNode(nodeModel.ID))
+	}
+	links := []DiagramLink{}
+	for _, linkModel := range m.Links {
+		link, err := linkModel.createLink(dw)
+		if err != nil {
+			return err
+		}
+		links = append(links, link)
+	}
+	// Connections are made only after all the links exist because links may connect to other links
+	for i, linkModel := range m.Links {
+		if err := linkModel.connect(dw, links[i]); err != nil {
+			return err
+		}
+	}
+	for _, id := range m.ZOrder {
+		dw.bringToFront(id)
+	}
+	// Groups are collapsed once their links are connected so that the links within them are hidden
+	for _, nodeModel := range m.Nodes {
+		if group, ok := dw.GetDiagramNode(nodeModel.ID).(DiagramGroup); ok && nodeModel.Collapsed {
+			group.getBaseDiagramGroup().collapsedInnerSize = fyne.NewSize(nodeModel.InnerSize.Width, nodeModel.InnerSize.Height)
+			group.SetCollapsed(true)
+			group.Move(dw.scalePosition(fyne.NewPos(nodeModel.Position.X, nodeModel.Position.Y)))
+		}
+	}
+	return nil
+}
+
+func (nm *NodeModel) addToDiagram(dw *DiagramWidget) error {
+	factoryLock.RLock()
+	factory := nodeFactories[nm.Type]
+	factoryLock.RUnlock()
+	if factory == nil {
+		return fmt.Errorf("no node factory registered for type %s", nm.Type)
+	}
+	node, err := factory(dw, nm.ID, nm.Data)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return fmt.Errorf("node factory for type %s did not create node %s", nm.Type, nm.ID)
+	}
+	bdn := node.getBaseDiagramNode()
+	properties, err := nm.Properties.toProperties()
+	if err != nil {
+		return err
+	}
+	bdn.SetProperties(properties)
+	bdn.InnerSize = dw.scaleSize(fyne.NewSize(nm.InnerSize.Width, nm.InnerSize.Height))
+	// Setting the shape replaces the edge pad, so it must precede the restoration of the other pads
+	bdn.SetShape(nm.Shape)
+	bdn.pinned = nm.Pinned
+	if err := addPadsFromModels(node, bdn.pads, nm.Pads); err != nil {
+		return err
+	}
+	node.Move(dw.scalePosition(fyne.NewPos(nm.Position.X, nm.Position.Y)))
+	node.Refresh()
+	return nil
+}
+
+func (lm *LinkModel) createLink(dw *DiagramWidget) (DiagramLink, error) {
+	factoryLock.RLock()
+	factory := linkFactories[lm.Type]
+	factoryLock.RUnlock()
+	if factory == nil {
+		return nil, fmt.Errorf("no link factory registered for type %s", lm.Type)
+	}
+	link, err := factory(dw, lm.ID, lm.Data)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, fmt.Errorf("link factory for type %s did not create link %s", lm.Type, lm.ID)
+	}
+	bdl := link.getBaseDiagramLink()
+	properties, err := lm.Properties.toProperties()
+	if err != nil {
+		return nil, err
+	}
+	bdl.SetProperties(properties)
+	if err := addPadsFromModels(link, bdl.pads, lm.Pads); err != nil {
+		return nil, err
+	}
+	if lm.Router != nil {
+		router, err := lm.Router.toRouter()
+		if err != nil {
+			return nil, err
+		}
+		bdl.router = router
+	}
+	bdl.lineStyle = lm.LineStyle
+	if len(lm.Points) >= 2 {
+		// The link position is the origin of the link coordinates, so the diagram coordinates
+		// of unconnected ends can be used directly as long as the link is at the origin.
+		bdl.BaseWidget.Move(fyne.NewPos(0, 0))
+		bdl.linkPoints[0].Move(dw.scalePosition(fyne.NewPos(lm.Points[0].X, lm.Points[0].Y)))
+		last := lm.Points[len(lm.Points)-1]
+		bdl.linkPoints[len(bdl.linkPoints)-1].Move(dw.scalePosition(fyne.NewPos(last.X, last.Y)))
+		waypoints := []fyne.Position{}
+		for _, point := range lm.Points[1 : len(lm.Points)-1] {
+			waypoints = append(waypoints, dw.scalePosition(fyne.NewPos(point.X, point.Y)))
+		}
+		bdl.setWaypoints(waypoints)
+	}
+	return link, nil
+}
+
+func (lm *LinkModel) connect(dw *DiagramWidget, link DiagramLink) error {
+	bdl := link.getBaseDiagramLink()
+	if lm.Source != nil {
+		pad, err := lm.Source.resolve(dw)
+		if err != nil {
+			return err
+		}
+		if !dw.isPortConnectionAllowed(bdl, SOURCE, pad) {
+			return fmt.Errorf("the port rules do not allow the source of link %s to be connected to pad %s of %s", lm.ID, lm.Source.PadKey, lm.Source.ElementID)
+		}
+		link.SetSourcePad(pad)
+	}
+	if lm.Target != nil {
+		pad, err := lm.Target.resolve(dw)
+		if err != nil {
+			return err
+		}
+		if !dw.isPortConnectionAllowed(bdl, TARGET, pad) {
+			return fmt.Errorf("the port rules do not allow the target of link %s to be connected to pad %s of %s", lm.ID, lm.Target.PadKey, lm.Target.ElementID)
+		}
+		link.SetTargetPad(pad)
+	}
+	decorationSets := []struct {
+		models []DecorationModel
+		add    func(Decoration)
+	}{
+		{lm.SourceDecorations, bdl.AddSourceDecoration},
+		{lm.MidpointDecorations, bdl.AddMidpointDecoration},
+		{lm.TargetDecorations, bdl.AddTargetDecoration},
+	}
+	for _, decorationSet := range decorationSets {
+		for _, decorationModel := range decorationSet.models {
+			decoration, err := decorationModel.toDecoration()
+			if err != nil {
+				return err
+			}
+			decorationSet.add(decoration)
+		}
+	}
+	link.Refresh()
+	anchoredTextSets := []struct {
+		models []AnchoredTextModel
+		add    func(string, string) *AnchoredText
+	}{
+		{lm.SourceAnchoredText, bdl.AddSourceAnchoredText},
+		{lm.MidpointAnchoredText, bdl.AddMidpointAnchoredText},
+		{lm.TargetAnchoredText, bdl.AddTargetAnchoredText},
+	}
+	for _, anchoredTextSet := range anchoredTextSets {
+		for _, anchoredTextModel := range anchoredTextSet.models {
+			at := anchoredTextSet.add(anchoredTextModel.Key, anchoredTextModel.Text)
+			at.Displace(dw.scalePosition(fyne.NewPos(anchoredTextModel.Offset.X, anchoredTextModel.Offset.Y)))
+		}
+	}
+	link.Refresh()
+	return nil
+}
+
+func (dm *DecorationModel) toDecoration() (Decoration, error) {
+	strokeColor, err := stringToColor(dm.StrokeColor)
+	if err != nil {
+		return nil, err
+	}
+	switch dm.Type {
+	case "Arrowhead":
+		arrowhead := NewArrowhead()
+		arrowhead.StrokeWidth = dm.StrokeWidth
+		if strokeColor != nil {
+			arrowhead.StrokeColor = strokeColor
+		}
+		arrowhead.Theta = dm.Theta
+		arrowhead.Length = dm.Length
+		return arrowhead, nil
+	case "Polygon":
+		points := []fyne.Position{}
+		for _, point := range dm.Points {
+			points = append(points, fyne.NewPos(point.X, point.Y))
+		}
+		polygon := NewPolygon(points)
+		polygon.StrokeWidth = dm.StrokeWidth
+		polygon.StrokeColor = strokeColor
+		polygon.FillColor, err = stringToColor(dm.FillColor)
+		if err != nil {
+			return nil, err
+		}
+		polygon.closed = dm.Closed
+		polygon.solid = dm.Solid
+		return polygon, nil
+	}
+	return nil, fmt.Errorf("unknown decoration type %s", dm.Type)
+}
+
+func (pr *PadReference) resolve(dw *DiagramWidget) (ConnectionPad, error) {
+	element := dw.GetDiagramElement(pr.ElementID)
+	if element == nil {
+		return nil, fmt.Errorf("pad owner %s not found", pr.ElementID)
+	}
+	pad := element.GetConnectionPads()[pr.PadKey]
+	if pad == nil {
+		return nil, fmt.Errorf("pad %s not found on %s", pr.PadKey, pr.ElementID)
+	}
+	return pad, nil
+}
+
+func (pm *PropertiesModel) toProperties() (DiagramElementProperties, error) {
+	properties := DiagramElementProperties{
+		TextSize:          pm.TextSize,
+		CaptionTextSize:   pm.CaptionTextSize,
+		Padding:           pm.Padding,
+		StrokeWidth:       pm.StrokeWidth,
+		PadStrokeWidth:    pm.PadStrokeWidth,
+		HandleStrokeWidth: pm.HandleStrokeWidth,
+	}
+	var err error
+	if properties.ForegroundColor, err = stringToColor(pm.ForegroundColor); err != nil {
+		return properties, err
+	}
+	if properties.BackgroundColor, err = stringToColor(pm.BackgroundColor); err != nil {
+		return properties, err
+	}
+	if properties.HandleColor, err = stringToColor(pm.HandleColor); err != nil {
+		return properties, err
+	}
+	if properties.PadColor, err = stringToColor(pm.PadColor); err != nil {
+		return properties, err
+	}
+	return properties, nil
+}
+
+// addPadsFromModels adds any pads in the models that the element does not already have. Pads that
+// already exist (e.g. the default pad or pads created by a factory) are left untouched.
+func addPadsFromModels(owner DiagramElement, pads map[string]ConnectionPad, padModels []PadModel) error {
+	for _, padModel := range padModels {
+		if pads[padModel.Key] != nil {
+			continue
+		}
+		var pad ConnectionPad
+		switch padModel.Type {
+		case "PointPad":
+			pad = NewPointPad(owner)
+		case "RectanglePad":
+			pad = NewRectanglePad(owner)
+		case "EllipsePad":
+			pad = NewEllipsePad(owner)
+		case "PolygonPad":
+			node, ok := owner.(DiagramNode)
+			if !ok {
+				return fmt.Errorf("unable to create polygon pad %s on %s", padModel.Key, owner.GetDiagramElementID())
+			}
+			pad = NewPolygonPad(node)
+		case "PortPad":
+			node, ok := owner.(DiagramNode)
+			if !ok || padModel.Port == nil {
+				return fmt.Errorf("unable to create port %s on %s", padModel.Key, owner.GetDiagramElementID())
+			}
+			port := NewPortPad(node, padModel.Key, padModel.Port.Direction, padModel.Port.DataType)
+			port.MaxConnections = padModel.Port.MaxConnections
+			if !padModel.Port.AutoPlaced {
+				port.SetPlacement(padModel.Port.Side, padModel.Port.Offset)
+			}
+			// The port has already been positioned and added to the pads
+			continue
+		default:
+			factoryLock.RLock()
+			factory := padFactories[padModel.Type]
+			factoryLock.RUnlock()
+			if factory == nil {
+				return fmt.Errorf("unable to create pad %s of type %s", padModel.Key, padModel.Type)
+			}
+			var err error
+			if pad, err = factory(owner); err != nil {
+				return err
+			}
+		}
+		pad.Move(fyne.NewPos(padModel.Position.X, padModel.Position.Y))
+		pad.Hide()
+		pads[padModel.Key] = pad
+	}
+	return nil
+}
+
+// baseDiagramNodeData is the element data for a BaseDiagramNode. When the inner object is a
+// Label, its text is preserved.
+type baseDiagramNodeData struct {
+	Label string `json:"label,omitempty"`
+}
+
+func newBaseDiagramNodeFromData(diagram *DiagramWidget, nodeID string, data json.RawMessage) (DiagramNode, error) {
+	var obj fyne.CanvasObject
+	if len(data) > 0 {
+		nodeData := baseDiagramNodeData{}
+		if err := json.Unmarshal(data, &nodeData); err != nil {
+			return nil, err
+		}
+		obj = widget.NewLabel(nodeData.Label)
+	}
+	return NewDiagramNode(diagram, obj, nodeID), nil
+}
+
+// baseDiagramGroupData is the element data for a BaseDiagramGroup
+type baseDiagramGroupData struct {
+	Title string `json:"title"`
+}
+
+func newBaseDiagramGroupFromData(diagram *DiagramWidget, groupID string, data json.RawMessage) (DiagramNode, error) {
+	groupData := baseDiagramGroupData{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &groupData); err != nil {
+			return nil, err
+		}
+	}
+	return NewDiagramGroup(diagram, groupData.Title, groupID), nil
+}
+
+func newBaseDiagramLinkFromData(diagram *DiagramWidget, linkID string, data json.RawMessage) (DiagramLink, error) {
+	return NewDiagramLink(diagram, linkID), nil
+}
+
+// getPadKey returns the key under which the pad is stored by its owner
+func getPadKey(pad ConnectionPad) string {
+	for key, ownerPad := range pad.GetPadOwner().GetConnectionPads() {
+		if ownerPad == pad {
+			return key
+		}
+	}
+	return ""
+}
+
+// sortedKeys returns the keys of the map in ascending order so that the serialized form is stable
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func colorToString(c color.Color) string {
+	if c == nil {
+		return ""
+	}
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x%02x", nrgba.R, nrgba.G, nrgba.B, nrgba.A)
+}
+
+func stringToColor(s string) (color.Color, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var r, g, b, a uint8
+	if n, err := fmt.Sscanf(s, "#%02x%02x%02x%02x", &r, &g, &b, &a); err != nil || n != 4 {
+		return nil, errors.New("invalid color " + s)
+	}
+	return color.NRGBA{R: r, G: g, B: b, A: a}, nil
+}
@@ -0,0 +1,158 @@
+package diagramwidget
+
+import (
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalUnmarshalRoundTrip(t *testing.T) {
+	app := test.NewApp()
+	assert.NotNil(t, app)
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, widget.NewLabel("Node 1"), "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, widget.NewLabel("Node 2"), "Node2")
+	node2.Move(fyne.NewPos(300, 200))
+	node3 := NewDiagramNode(diagram, nil, "Node3")
+	node3.Move(fyne.NewPos(150, 400))
+	node3.SetPinned(true)
+	link1 := NewDiagramLink(diagram, "Link1")
+	link1.SetSourcePad(node1.GetEdgePad())
+	link1.SetTargetPad(node2.GetEdgePad())
+	link1.SetForegroundColor(color.NRGBA{R: 255, G: 64, B: 64, A: 255})
+	link1.AddTargetDecoration(NewArrowhead())
+	link1.AddSourceDecoration(NewPolygon([]fyne.Position{{X: 0, Y: 0}, {X: 8, Y: 4}, {X: 16, Y: 0}, {X: 8, Y: -4}}))
+	link1.AddMidpointAnchoredText("name", "Link 1").Displace(fyne.NewPos(10, -20))
+	link2 := NewDiagramLink(diagram, "Link2")
+	link2.SetSourcePad(link1.GetMidPad())
+	link2.SetTargetPad(node3.GetEdgePad())
+	diagram.SendToBack("Node2")
+
+	data, err := Marshal(diagram)
+	assert.NoError(t, err)
+
+	restored := NewDiagramWidget("Diagram2")
+	assert.NoError(t, Unmarshal(data, restored))
+
+	originalIDs := []string{}
+	for _, element := range diagram.GetDiagramElements() {
+		originalIDs = append(originalIDs, element.GetDiagramElementID())
+	}
+	restoredIDs := []string{}
+	for _, element := range restored.GetDiagramElements() {
+		restoredIDs = append(restoredIDs, element.GetDiagramElementID())
+	}
+	assert.Equal(t, originalIDs, restoredIDs)
+
+	for _, node := range diagram.GetDiagramNodes() {
+		restoredNode := restored.GetDiagramNode(node.GetDiagramElementID())
+		assert.NotNil(t, restoredNode)
+		assert.Equal(t, node.Position(), restoredNode.Position())
+		assert.Equal(t, node.getBaseDiagramNode().InnerSize, restoredNode.getBaseDiagramNode().InnerSize)
+	}
+	label, ok := restored.GetDiagramNode("Node1").getBaseDiagramNode().innerObject.(*widget.Label)
+	assert.True(t, ok)
+	assert.Equal(t, "Node 1", label.Text)
+	assert.True(t, restored.GetDiagramNode("Node3").IsPinned())
+	assert.False(t, restored.GetDiagramNode("Node1").IsPinned())
+
+	restoredLink1 := restored.GetDiagramLink("Link1")
+	assert.Equal(t, restored.GetDiagramNode("Node1").GetEdgePad(), restoredLink1.GetSourcePad())
+	assert.Equal(t, restored.GetDiagramNode("Node2").GetEdgePad(), restoredLink1.GetTargetPad())
+	assert.Equal(t, colorToString(link1.GetForegroundColor()), colorToString(restoredLink1.GetForegroundColor()))
+	assert.Equal(t, 1, len(restoredLink1.getBaseDiagramLink().SourceDecorations))
+	assert.Equal(t, 1, len(restoredLink1.getBaseDiagramLink().TargetDecorations))
+	restoredText := restoredLink1.getBaseDiagramLink().GetMidpointAnchoredText("name")
+	assert.NotNil(t, restoredText)
+	assert.Equal(t, link1.GetMidpointAnchoredText("name").offset, restoredText.offset)
+
+	restoredLink2 := restored.GetDiagramLink("Link2")
+	assert.Equal(t, restoredLink1.getBaseDiagramLink().GetMidPad(), restoredLink2.GetSourcePad())
+	assert.Equal(t, restored.GetDiagramNode("Node3").GetEdgePad(), restoredLink2.GetTargetPad())
+	assert.Equal(t, len(diagram.diagramElementLinkDependencies), len(restored.diagramElementLinkDependencies))
+}
+
+func TestUnmarshalRejectsFutureVersion(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	err := Unmarshal([]byte(`{"version": 99, "nodes": [], "links": []}`), diagram)
+	assert.Error(t, err)
+}
+
+func TestAddToDiagramLeavesDiagramUnchangedOnError(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(200, 0))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	model, err := NewDiagramModel(diagram)
+	assert.NoError(t, err)
+	existing := NewDiagramWidget("Diagram2")
+	NewDiagramNode(existing, nil, "Existing")
+
+	// The IDs must be unique within the model
+	duplicated := *model
+	duplicated.Links = append([]LinkModel{}, model.Links...)
+	duplicated.Links[0].ID = "Node2"
+	assert.Error(t, duplicated.AddToDiagram(existing))
+	assert.Equal(t, 1, len(existing.GetDiagramElements()))
+
+	// A link connected to a missing pad fails once the nodes have been created, which are then removed
+	broken := *model
+	broken.Links = append([]LinkModel{}, model.Links...)
+	broken.Links[0].Target = &PadReference{ElementID: "Node2", PadKey: "missing"}
+	assert.Error(t, broken.AddToDiagram(existing))
+	assert.Equal(t, 1, len(existing.GetDiagramElements()))
+	assert.Nil(t, existing.GetDiagramNode("Node1"))
+	assert.Equal(t, 0, len(existing.diagramElementLinkDependencies))
+	assert.False(t, existing.CanUndo())
+
+	assert.NoError(t, model.AddToDiagram(existing))
+	assert.Equal(t, 4, len(existing.GetDiagramElements()))
+}
+
+// plainTestPad is a pad type that cannot be serialized
+type plainTestPad struct {
+	*PointPad
+}
+
+// typedTestPad is a pad type with a registered factory
+type typedTestPad struct {
+	*PointPad
+}
+
+func (pad *typedTestPad) GetPadType() string {
+	return "TypedTestPad"
+}
+
+func TestMarshalPadTypes(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node := NewDiagramNode(diagram, nil, "Node1")
+	pads := node.GetConnectionPads()
+	pads["plain"] = &plainTestPad{NewPointPad(node)}
+	_, err := Marshal(diagram)
+	assert.Error(t, err)
+
+	delete(pads, "plain")
+	pads["typed"] = &typedTestPad{NewPointPad(node)}
+	data, err := Marshal(diagram)
+	assert.NoError(t, err)
+	restored := NewDiagramWidget("Diagram2")
+	assert.Error(t, Unmarshal(data, restored))
+	assert.Equal(t, 0, len(restored.GetDiagramElements()))
+	RegisterPadFactory("TypedTestPad", func(owner DiagramElement) (ConnectionPad, error) {
+		return &typedTestPad{NewPointPad(owner)}, nil
+	})
+	assert.NoError(t, Unmarshal(data, restored))
+	_, ok := restored.GetDiagramNode("Node1").GetConnectionPads()["typed"].(*typedTestPad)
+	assert.True(t, ok)
+}
@@ -0,0 +1,578 @@
+package diagramwidget
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"fyne.io/x/fyne/widget/diagramwidget/geometry/r2"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/math/fixed"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// NodeShape identifies the outline drawn around the inner object of a node
+type NodeShape int
+
+const (
+	// RectangleShape draws the node as a rectangle. This is the default.
+	RectangleShape NodeShape = iota
+	// RoundedRectangleShape draws the node as a rectangle with rounded corners
+	RoundedRectangleShape
+	// EllipseShape draws the node as an ellipse enclosing the inner object
+	EllipseShape
+	// DiamondShape draws the node as a diamond (rhombus) enclosing the inner object
+	DiamondShape
+	// ParallelogramShape draws the node as a parallelogram leaning to the right
+	ParallelogramShape
+	// CylinderShape draws the node as a cylinder, as is commonly used for databases
+	CylinderShape
+)
+
+const (
+	// roundedCornerRadius is the radius of the corners of a RoundedRectangleShape at zoom 1
+	roundedCornerRadius float32 = 8
+	// parallelogramSlant is the horizontal offset of the top of a ParallelogramShape relative to its
+	// bottom, as a fraction of its height
+	parallelogramSlant float32 = 0.25
+	// cylinderCapRatio is the vertical radius of the ends of a CylinderShape as a fraction of its width
+	cylinderCapRatio float32 = 0.1
+	// ellipseSegments is the number of segments used to approximate an ellipse
+	ellipseSegments = 48
+	// arcSegments is the number of segments used to approximate a quarter of an ellipse
+	arcSegments = 6
+)
+
+// GetShape returns the outline drawn around the node's inner object
+func (bdn *BaseDiagramNode) GetShape() NodeShape {
+	return bdn.shape
+}
+
+// SetShape sets the outline drawn around the node's inner object. The node's edge pad is replaced
+// by one that follows the outline: an EllipsePad for an EllipseShape, a RectanglePad for a
+// RectangleShape, and a PolygonPad for the others. Links connected to the edge pad remain
+// connected to the new edge pad. The bounding box of the node grows so that the inner object
+// remains within the outline.
+func (bdn *BaseDiagramNode) SetShape(shape NodeShape) {
+	if shape == bdn.shape {
+		return
+	}
+	oldShape := bdn.shape
+	oldPad := bdn.pads["default"]
+	var newPad ConnectionPad
+	switch shape {
+	case RectangleShape:
+		newPad = NewRectanglePad(bdn)
+	case EllipseShape:
+		newPad = NewEllipsePad(bdn)
+	default:
+		newPad = NewPolygonPad(bdn.typedNode)
+	}
+	newPad.Hide()
+	bdn.setShape(shape, newPad)
+	bdn.diagram.recordCommand("Change Shape", &shapeChangeCommand{
+		node:     bdn.typedNode,
+		oldShape: oldShape,
+		oldPad:   oldPad,
+		newShape: shape,
+		newPad:   newPad,
+	})
+}
+
+// setShape sets the shape and installs the edge pad, moving the connections of the current edge pad to it
+func (bdn *BaseDiagramNode) setShape(shape NodeShape, edgePad ConnectionPad) {
+	oldPad := bdn.pads["default"]
+	bdn.shape = shape
+	bdn.pads["default"] = edgePad
+	for i, pair := range bdn.diagram.diagramElementLinkDependencies[bdn.id] {
+		if pair.pad != oldPad {
+			continue
+		}
+		bdn.diagram.diagramElementLinkDependencies[bdn.id][i].pad = edgePad
+		if pair.link.sourcePad == oldPad {
+			pair.link.sourcePad = edgePad
+		}
+		if pair.link.targetPad == oldPad {
+			pair.link.targetPad = edgePad
+		}
+	}
+	bdn.Refresh()
+	bdn.refreshParentGroup()
+	bdn.diagram.adjustBounds()
+}
+
+// paddedInnerSize returns the size of the inner object plus the padding on all sides
+func (bdn *BaseDiagramNode) paddedInnerSize() fyne.Size {
+	inner := bdn.effectiveInnerSize()
+	return fyne.Size{
+		Width:  inner.Width + 2*bdn.scaledPadding(),
+		Height: inner.Height + 2*bdn.scaledPadding(),
+	}
+}
+
+// shapeMargins returns the space on each side between the padded inner object and the bounding box
+// of the node's shape. The margins are chosen so that the padded inner object fits within the outline.
+func (bdn *BaseDiagramNode) shapeMargins() (left, top, right, bottom float32) {
+	content := bdn.paddedInnerSize()
+	switch bdn.shape {
+	case EllipseShape:
+		// The ellipse through the corners of the content with the same aspect ratio is larger by √2
+		horizontal := (math.Sqrt2 - 1) * content.Width / 2
+		vertical := (math.Sqrt2 - 1) * content.Height / 2
+		return horizontal, vertical, horizontal, vertical
+	case DiamondShape:
+		// The diamond through the corners of the content with the same aspect ratio is twice its size
+		return content.Width / 2, content.Height / 2, content.Width / 2, content.Height / 2
+	case ParallelogramShape:
+		slant := parallelogramSlant * content.Height
+		return slant, 0, slant, 0
+	case CylinderShape:
+		// The whole of the upper end is visible, but only the lower half of the bottom end
+		capHeight := cylinderCapRatio * content.Width
+		return 0, 2 * capHeight, 0, capHeight
+	}
+	return 0, 0, 0, 0
+}
+
+// shapeSize returns the size of the bounding box of the node's shape
+func (bdn *BaseDiagramNode) shapeSize() fyne.Size {
+	left, top, right, bottom := bdn.shapeMargins()
+	return bdn.paddedInnerSize().AddWidthHeight(left+right, top+bottom)
+}
+
+// shapeOutline returns the closed outline of a shape with the indicated size and the open polylines
+// of any additional details (e.g. the rim of a cylinder). The points are relative to the top left corner
+// of the shape's bounding box. Curves are approximated by line segments.
+func shapeOutline(shape NodeShape, size fyne.Size, cornerRadius float32) ([]fyne.Position, [][]fyne.Position) {
+	width := size.Width
+	height := size.Height
+	switch shape {
+	case RoundedRectangleShape:
+		radius := float32(math.Min(float64(cornerRadius), math.Min(float64(width), float64(height))/2))
+		outline := []fyne.Position{}
+		// The corners are traversed clockwise starting at the upper left
+		corners := []fyne.Position{
+			fyne.NewPos(radius, radius),
+			fyne.NewPos(width-radius, radius),
+			fyne.NewPos(width-radius, height-radius),
+			fyne.NewPos(radius, height-radius),
+		}
+		for i, corner := range corners {
+			outline = append(outline, arcPoints(corner, fyne.NewSize(radius, radius), math.Pi*(1+float64(i)/2), math.Pi/2, arcSegments)...)
+		}
+		return outline, nil
+	case EllipseShape:
+		ellipse := r2.MakeEllipseInBox(r2.MakeBox(r2.V2(0, 0), r2.V2(float64(width), float64(height))))
+		outline := []fyne.Position{}
+		for _, point := range ellipse.Points(ellipseSegments) {
+			outline = append(outline, fyne.NewPos(float32(point.X), float32(point.Y)))
+		}
+		return outline, nil
+	case DiamondShape:
+		return []fyne.Position{
+			fyne.NewPos(width/2, 0),
+			fyne.NewPos(width, height/2),
+			fyne.NewPos(width/2, height),
+			fyne.NewPos(0, height/2),
+		}, nil
+	case ParallelogramShape:
+		slant := parallelogramSlant * height
+		return []fyne.Position{
+			fyne.NewPos(slant, 0),
+			fyne.NewPos(width, 0),
+			fyne.NewPos(width-slant, height),
+			fyne.NewPos(0, height),
+		}, nil
+	case CylinderShape:
+		capHeight := float32(math.Min(float64(cylinderCapRatio*width), float64(height)/3))
+		radii := fyne.NewSize(width/2, capHeight)
+		top := fyne.NewPos(width/2, capHeight)
+		bottom := fyne.NewPos(width/2, height-capHeight)
+		// The upper half of the top end, then the lower half of the bottom end
+		outline := arcPoints(top, radii, math.Pi, math.Pi, 2*arcSegments)
+		outline = append(outline, arcPoints(bottom, radii, 0, math.Pi, 2*arcSegments)...)
+		// The lower half of the top end is the rim
+		rim := arcPoints(top, radii, 0, math.Pi, 2*arcSegments)
+		return outline, [][]fyne.Position{rim}
+	}
+	return []fyne.Position{
+		fyne.NewPos(0, 0),
+		fyne.NewPos(width, 0),
+		fyne.NewPos(width, height),
+		fyne.NewPos(0, height),
+	}, nil
+}
+
+// arcPoints returns the points of an elliptical arc with the indicated center and radii, starting at the
+// start angle and sweeping clockwise (in window coordinates) through the sweep angle. Both ends are included.
+func arcPoints(center fyne.Position, radii fyne.Size, start, sweep float64, segments int) []fyne.Position {
+	points := []fyne.Position{}
+	for i := 0; i <= segments; i++ {
+		angle := start + sweep*float64(i)/float64(segments)
+		points = append(points, fyne.NewPos(
+			center.X+radii.Width*float32(math.Cos(angle)),
+			center.Y+radii.Height*float32(math.Sin(angle)),
+		))
+	}
+	return points
+}
+
+// rasterizeOutline draws the closed outline and the open detail polylines into the image. The points
+// are displaced by the offset and then multiplied by the scale. A nil fill color leaves the outline unfilled.
+func rasterizeOutline(img *image.RGBA, scale float32, offset fyne.Position, outline []fyne.Position, details [][]fyne.Position,
+	fillColor color.Color, strokeColor color.Color, strokeWidth float32) {
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+	scanner := rasterx.NewScannerGV(width, height, img, img.Bounds())
+	toFixed := func(point fyne.Position) fixed.Point26_6 {
+		return rasterx.ToFixedP(float64((point.X+offset.X)*scale), float64((point.Y+offset.Y)*scale))
+	}
+	if fillColor != nil && len(outline) > 2 {
+		filler := rasterx.NewFiller(width, height, scanner)
+		filler.SetColor(fillColor)
+		for i, point := range outline {
+			if i == 0 {
+				filler.Start(toFixed(point))
+			} else {
+				filler.Line(toFixed(point))
+			}
+		}
+		filler.Stop(true)
+		filler.Draw()
+	}
+	if strokeColor == nil || strokeWidth <= 0 {
+		return
+	}
+	dasher := rasterx.NewDasher(width, height, scanner)
+	dasher.SetColor(strokeColor)
+	dasher.SetStroke(fixed.Int26_6(float64(strokeWidth*scale)*64), 0, nil, nil, nil, 0, nil, 0)
+	polylines := append([][]fyne.Position{outline}, details...)
+	for i, polyline := range polylines {
+		for j, point := range polyline {
+			if j == 0 {
+				dasher.Start(toFixed(point))
+			} else {
+				dasher.Line(toFixed(point))
+			}
+		}
+		// Only the outline is closed
+		dasher.Stop(i == 0)
+	}
+	dasher.Draw()
+}
+
+/***********************************
+	EllipsePad
+*************************************/
+
+// Validate that EllipsePad implements ConnectionPad
+var _ ConnectionPad = (*EllipsePad)(nil)
+
+// EllipsePad provides a ConnectionPad corresponding to the ellipse inscribed in the bounds of the
+// DiagramElement owning the pad. It is the edge pad of nodes with an EllipseShape.
+type EllipsePad struct {
+	widget.BaseWidget
+	connectionPad
+}
+
+// NewEllipsePad creates an EllipsePad and associates it with the DiagramElement. The size of the
+// pad becomes the size of the padOwner.
+func NewEllipsePad(padOwner DiagramElement) *EllipsePad {
+	ep := &EllipsePad{}
+	ep.connectionPad.padOwner = padOwner
+	ep.BaseWidget.ExtendBaseWidget(ep)
+	ep.lineWidth = padOwner.GetProperties().PadStrokeWidth
+	ep.padColor = color.Transparent
+	return ep
+}
+
+// CreateRenderer creates the WidgetRenderer for the EllipsePad
+func (ep *EllipsePad) CreateRenderer() fyne.WidgetRenderer {
+	return newOutlinePadRenderer(ep, &ep.connectionPad, func(size fyne.Size) []fyne.Position {
+		outline, _ := shapeOutline(EllipseShape, size, 0)
+		return outline
+	})
+}
+
+// GetCenterInDiagramCoordinates returns the center of the pad in the diagram's coordinate system
+func (ep *EllipsePad) GetCenterInDiagramCoordinates() fyne.Position {
+	r2Center := ep.makeEllipse().C
+	return fyne.NewPos(float32(r2Center.X), float32(r2Center.Y))
+}
+
+// getConnectionPointInDiagramCoordinates returns the point at which the connection should be made from a reference point.
+// The reference point is in diagram coordinates and the returned point is also in diagram coordinates.
+// For an EllipsePad this point is the intersection of a line segment from the reference point to the center
+// of the ellipse and the ellipse. If the reference point is within the ellipse, the returned point is the
+// point at which the ray from the center through the reference point leaves the ellipse.
+func (ep *EllipsePad) getConnectionPointInDiagramCoordinates(referencePoint fyne.Position) fyne.Position {
+	ellipse := ep.makeEllipse()
+	r2ReferencePoint := r2.MakeVec2(float64(referencePoint.X), float64(referencePoint.Y))
+	connectionPoint := ellipse.ProjectToPerimeter(r2ReferencePoint)
+	if !ellipse.Contains(r2ReferencePoint) {
+		linkLine := r2.MakeLineFromEndpoints(ellipse.C, r2ReferencePoint)
+		if intersection, ok := ellipse.Intersect(linkLine); ok {
+			connectionPoint = intersection
+		}
+	}
+	return fyne.NewPos(float32(connectionPoint.X), float32(connectionPoint.Y))
+}
+
+// makeEllipse returns an r2 ellipse representing the ellipse pad's position and size in the
+// diagram's coordinate system
+func (ep *EllipsePad) makeEllipse() r2.Ellipse {
+	diagramCoordinatePosition := ep.padOwner.Position().Add(ep.Position())
+	r2Position := r2.V2(float64(diagramCoordinatePosition.X), float64(diagramCoordinatePosition.Y))
+	s := r2.V2(
+		float64(ep.Size().Width),
+		float64(ep.Size().Height),
+	)
+	return r2.MakeEllipseInBox(r2.MakeBox(r2Position, s))
+}
+
+// MouseDown responds to mouse down events
+func (ep *EllipsePad) MouseDown(event *desktop.MouseEvent) {
+	connectionTransaction := ep.padOwner.GetDiagram().ConnectionTransaction
+	if connectionTransaction != nil {
+		link := connectionTransaction.Link
+		if link.isConnectionAllowed(connectionTransaction.LinkPoint, ep) {
+			padOwnerPosition := ep.padOwner.Position()
+			pseudoEvent := &fyne.DragEvent{
+				Dragged: fyne.NewDelta(event.Position.X+padOwnerPosition.X, event.Position.Y+padOwnerPosition.Y),
+			}
+			// the link point has to be changed before the handle is dragged
+			connectionTransaction.LinkPoint = connectionTransaction.Link.GetLinkPoints()[1]
+			link.GetHandle(TARGET.ToString()).Dragged(pseudoEvent)
+			link.SetSourcePad(ep)
+			link.GetDiagram().SelectDiagramElement(link)
+			link.ShowHandles()
+		}
+	}
+}
+
+// MouseIn responds to the mouse entering the bounds of the EllipsePad
+func (ep *EllipsePad) MouseIn(event *desktop.MouseEvent) {
+	conTrans := ep.padOwner.GetDiagram().ConnectionTransaction
+	if conTrans != nil && conTrans.Link.isConnectionAllowed(conTrans.LinkPoint, ep) {
+		ep.padColor = ep.padOwner.GetProperties().PadColor
+		conTrans.PendingPad = ep
+		ep.Show()
+	} else {
+		ep.padColor = color.Transparent
+	}
+	ep.Refresh()
+}
+
+// MouseMoved responds to mouse movements within the ellipse pad
+func (ep *EllipsePad) MouseMoved(event *desktop.MouseEvent) {
+}
+
+// MouseOut responds to mouse movements leaving the ellipse pad
+func (ep *EllipsePad) MouseOut() {
+	ep.padColor = color.Transparent
+	conTrans := ep.padOwner.GetDiagram().ConnectionTransaction
+	if conTrans != nil && conTrans.PendingPad == ep {
+		conTrans.PendingPad = nil
+	}
+	ep.Refresh()
+}
+
+// MouseUp responds to mouse up events
+func (ep *EllipsePad) MouseUp(event *desktop.MouseEvent) {
+
+}
+
+// SetPadColor sets the color to be used in rendering the pad
+func (ep *EllipsePad) SetPadColor(c color.Color) {
+	ep.padColor = c
+	ep.Refresh()
+}
+
+/***********************************
+	PolygonPad
+*************************************/
+
+// Validate that PolygonPad implements ConnectionPad
+var _ ConnectionPad = (*PolygonPad)(nil)
+
+// PolygonPad provides a ConnectionPad corresponding to the outline of the shape of the node owning the pad.
+// Curved outlines are approximated by line segments. It is the edge pad of nodes whose shape is neither a
+// RectangleShape nor an EllipseShape.
+type PolygonPad struct {
+	widget.BaseWidget
+	connectionPad
+}
+
+// NewPolygonPad creates a PolygonPad and associates it with the node. The size of the pad becomes the
+// size of the node.
+func NewPolygonPad(padOwner DiagramNode) *PolygonPad {
+	pp := &PolygonPad{}
+	pp.connectionPad.padOwner = padOwner
+	pp.BaseWidget.ExtendBaseWidget(pp)
+	pp.lineWidth = padOwner.GetProperties().PadStrokeWidth
+	pp.padColor = color.Transparent
+	return pp
+}
+
+// CreateRenderer creates the WidgetRenderer for the PolygonPad
+func (pp *PolygonPad) CreateRenderer() fyne.WidgetRenderer {
+	bdn := pp.padOwner.(DiagramNode).getBaseDiagramNode()
+	return newOutlinePadRenderer(pp, &pp.connectionPad, func(size fyne.Size) []fyne.Position {
+		outline, _ := shapeOutline(bdn.shape, size, bdn.diagram.scaled(roundedCornerRadius))
+		return outline
+	})
+}
+
+// GetCenterInDiagramCoordinates returns the center of the pad in the diagram's coordinate system
+func (pp *PolygonPad) GetCenterInDiagramCoordinates() fyne.Position {
+	return pp.padOwner.Position().Add(pp.Position()).AddXY(pp.Size().Width/2, pp.Size().Height/2)
+}
+
+// getConnectionPointInDiagramCoordinates returns the point at which the connection should be made from a reference point.
+// The reference point is in diagram coordinates and the returned point is also in diagram coordinates.
+// For a PolygonPad this point is the intersection of a line segment from the reference point to the center
+// of the pad and the outline of the node's shape. If the reference point is within the outline, the returned
+// point is the point on the outline that is nearest the reference point.
+func (pp *PolygonPad) getConnectionPointInDiagramCoordinates(referencePoint fyne.Position) fyne.Position {
+	polygon := pp.makePolygon()
+	center := pp.GetCenterInDiagramCoordinates()
+	r2Center := r2.MakeVec2(float64(center.X), float64(center.Y))
+	r2ReferencePoint := r2.MakeVec2(float64(referencePoint.X), float64(referencePoint.Y))
+	connectionPoint := r2Center
+	if polygon.Contains(r2ReferencePoint) {
+		connectionPoint = polygon.FindPerimeterPointNearestPoint(r2ReferencePoint)
+	} else if intersection, ok := polygon.Intersect(r2.MakeLineFromEndpoints(r2Center, r2ReferencePoint)); ok {
+		connectionPoint = intersection
+	}
+	return fyne.NewPos(float32(connectionPoint.X), float32(connectionPoint.Y))
+}
+
+// makePolygon returns an r2 polygon representing the outline of the owner's shape in the diagram's
+// coordinate system
+func (pp *PolygonPad) makePolygon() r2.Polygon {
+	bdn := pp.padOwner.(DiagramNode).getBaseDiagramNode()
+	outline, _ := shapeOutline(bdn.shape, pp.Size(), bdn.diagram.scaled(roundedCornerRadius))
+	origin := pp.padOwner.Position().Add(pp.Position())
+	points := []r2.Vec2{}
+	for _, point := range outline {
+		points = append(points, r2.V2(float64(origin.X+point.X), float64(origin.Y+point.Y)))
+	}
+	return r2.MakePolygon(points)
+}
+
+// MouseDown responds to mouse down events
+func (pp *PolygonPad) MouseDown(event *desktop.MouseEvent) {
+	connectionTransaction := pp.padOwner.GetDiagram().ConnectionTransaction
+	if connectionTransaction != nil {
+		link := connectionTransaction.Link
+		if link.isConnectionAllowed(connectionTransaction.LinkPoint, pp) {
+			padOwnerPosition := pp.padOwner.Position()
+			pseudoEvent := &fyne.DragEvent{
+				Dragged: fyne.NewDelta(event.Position.X+padOwnerPosition.X, event.Position.Y+padOwnerPosition.Y),
+			}
+			// the link point has to be changed before the handle is dragged
+			connectionTransaction.LinkPoint = connectionTransaction.Link.GetLinkPoints()[1]
+			link.GetHandle(TARGET.ToString()).Dragged(pseudoEvent)
+			link.SetSourcePad(pp)
+			link.GetDiagram().SelectDiagramElement(link)
+			link.ShowHandles()
+		}
+	}
+}
+
+// MouseIn responds to the mouse entering the bounds of the PolygonPad
+func (pp *PolygonPad) MouseIn(event *desktop.MouseEvent) {
+	conTrans := pp.padOwner.GetDiagram().ConnectionTransaction
+	if conTrans != nil && conTrans.Link.isConnectionAllowed(conTrans.LinkPoint, pp) {
+		pp.padColor = pp.padOwner.GetProperties().PadColor
+		conTrans.PendingPad = pp
+		pp.Show()
+	} else {
+		pp.padColor = color.Transparent
+	}
+	pp.Refresh()
+}
+
+// MouseMoved responds to mouse movements within the polygon pad
+func (pp *PolygonPad) MouseMoved(event *desktop.MouseEvent) {
+}
+
+// MouseOut responds to mouse movements leaving the polygon pad
+func (pp *PolygonPad) MouseOut() {
+	pp.padColor = color.Transparent
+	conTrans := pp.padOwner.GetDiagram().ConnectionTransaction
+	if conTrans != nil && conTrans.PendingPad == pp {
+		conTrans.PendingPad = nil
+	}
+	pp.Refresh()
+}
+
+// MouseUp responds to mouse up events
+func (pp *PolygonPad) MouseUp(event *desktop.MouseEvent) {
+
+}
+
+// SetPadColor sets the color to be used in rendering the pad
+func (pp *PolygonPad) SetPadColor(c color.Color) {
+	pp.padColor = c
+	pp.Refresh()
+}
+
+// outlinePadRenderer draws the outline of a pad that covers its owner, e.g. an EllipsePad or a PolygonPad
+type outlinePadRenderer struct {
+	pad     ConnectionPad
+	cp      *connectionPad
+	outline func(size fyne.Size) []fyne.Position
+	raster  *canvas.Raster
+}
+
+func newOutlinePadRenderer(pad ConnectionPad, cp *connectionPad, outline func(size fyne.Size) []fyne.Position) *outlinePadRenderer {
+	opr := &outlinePadRenderer{
+		pad:     pad,
+		cp:      cp,
+		outline: outline,
+	}
+	opr.raster = canvas.NewRaster(opr.draw)
+	return opr
+}
+
+func (opr *outlinePadRenderer) Destroy() {
+
+}
+
+// draw rasterizes the outline at the resolution of the raster, inset so that the stroke is not clipped
+func (opr *outlinePadRenderer) draw(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	size := opr.pad.Size()
+	if size.Width <= 0 || size.Height <= 0 || opr.cp.padColor == nil || opr.cp.padColor == color.Transparent {
+		return img
+	}
+	scale := float32(width) / size.Width
+	inset := opr.cp.lineWidth / 2
+	outline := opr.outline(size.SubtractWidthHeight(opr.cp.lineWidth, opr.cp.lineWidth))
+	rasterizeOutline(img, scale, fyne.NewPos(inset, inset), outline, nil, nil, opr.cp.padColor, opr.cp.lineWidth)
+	return img
+}
+
+func (opr *outlinePadRenderer) Layout(size fyne.Size) {
+	padOwnerSize := opr.cp.padOwner.Size()
+	opr.pad.Resize(padOwnerSize)
+	opr.raster.Resize(padOwnerSize)
+}
+
+func (opr *outlinePadRenderer) MinSize() fyne.Size {
+	return opr.cp.padOwner.Size()
+}
+
+func (opr *outlinePadRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{opr.raster}
+}
+
+func (opr *outlinePadRenderer) Refresh() {
+	opr.raster.Resize(opr.pad.Size())
+	opr.raster.Refresh()
+}
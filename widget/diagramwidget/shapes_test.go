@@ -0,0 +1,111 @@
+package diagramwidget
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeShapeConnectionPoints(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	rectangle := NewDiagramNode(diagram, nil, "Rectangle")
+	ellipse := NewDiagramNode(diagram, nil, "Ellipse")
+	ellipse.SetShape(EllipseShape)
+	diamond := NewDiagramNode(diagram, nil, "Diamond")
+	diamond.SetShape(DiamondShape)
+
+	// The shapes grow so that the padded inner object remains within the outline
+	rectangleSize := rectangle.Size()
+	assert.InDelta(t, math.Sqrt2*float64(rectangleSize.Width), float64(ellipse.Size().Width), 0.01)
+	assert.Equal(t, fyne.NewSize(2*rectangleSize.Width, 2*rectangleSize.Height), diamond.Size())
+	assert.Equal(t, fyne.NewPos(rectangleSize.Width/2, rectangleSize.Height/2), diamond.getBaseDiagramNode().innerPos().
+		SubtractXY(diamond.GetProperties().Padding, diamond.GetProperties().Padding))
+
+	// A connection from the lower right is made on the outline rather than the bounding box
+	_, ok := ellipse.GetEdgePad().(*EllipsePad)
+	assert.True(t, ok)
+	center := ellipse.getBaseDiagramNode().Center()
+	point := ellipse.GetEdgePad().getConnectionPointInDiagramCoordinates(center.AddXY(100, 100))
+	radii := fyne.NewSize(ellipse.Size().Width/2, ellipse.Size().Height/2)
+	dx := float64((point.X - center.X) / radii.Width)
+	dy := float64((point.Y - center.Y) / radii.Height)
+	assert.InDelta(t, 1, dx*dx+dy*dy, 0.001)
+	assert.InDelta(t, point.X-center.X, point.Y-center.Y, 0.001)
+
+	_, ok = diamond.GetEdgePad().(*PolygonPad)
+	assert.True(t, ok)
+	center = diamond.getBaseDiagramNode().Center()
+	point = diamond.GetEdgePad().getConnectionPointInDiagramCoordinates(center.AddXY(100, 100))
+	size := diamond.Size()
+	assert.InDelta(t, 1, (point.X-center.X)/(size.Width/2)+(point.Y-center.Y)/(size.Height/2), 0.001)
+	// A reference point within the outline is connected to the nearest point of the outline
+	point = diamond.GetEdgePad().getConnectionPointInDiagramCoordinates(center.AddXY(0, size.Height/2-1))
+	assert.InDelta(t, center.Y+size.Height/2, point.Y, 1)
+	assert.InDelta(t, center.X, point.X, 1)
+}
+
+func TestSetShapeKeepsLinks(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(200, 0))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	originalPad := node2.GetEdgePad()
+
+	node2.SetShape(CylinderShape)
+	assert.Equal(t, CylinderShape, node2.GetShape())
+	assert.NotEqual(t, originalPad, node2.GetEdgePad())
+	assert.Equal(t, node2.GetEdgePad(), link.GetTargetPad())
+	assert.Equal(t, node2.GetEdgePad(), diagram.diagramElementLinkDependencies["Node2"][0].pad)
+
+	// Undo restores the original pad, so the link is connected as it was
+	diagram.Undo()
+	assert.Equal(t, RectangleShape, node2.GetShape())
+	assert.Equal(t, originalPad, node2.GetEdgePad())
+	assert.Equal(t, originalPad, link.GetTargetPad())
+	diagram.Redo()
+	assert.Equal(t, CylinderShape, node2.GetShape())
+
+	// The shape survives serialization and export
+	data, err := Marshal(diagram)
+	assert.NoError(t, err)
+	restored := NewDiagramWidget("Diagram2")
+	assert.NoError(t, Unmarshal(data, restored))
+	restoredNode := restored.GetDiagramNode("Node2")
+	assert.Equal(t, CylinderShape, restoredNode.GetShape())
+	assert.Equal(t, restoredNode.GetEdgePad(), restored.GetDiagramLink("Link1").GetTargetPad())
+	assert.Equal(t, node2.Size(), restoredNode.Size())
+	var buffer bytes.Buffer
+	assert.NoError(t, ExportSVG(restored, &buffer))
+	assert.Equal(t, 1, strings.Count(buffer.String(), "<polygon"))
+}
+
+func TestClassNode(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node := NewClassNode(diagram, "Node1", "Account", []string{"- balance: int"}, []string{"+ deposit(int)", "+ withdraw(int)"})
+	assert.Equal(t, 2, len(node.separators))
+	// The name, two separators, one attribute and two operations
+	assert.Equal(t, 6, len(node.innerObject.(*fyne.Container).Objects))
+	assert.Equal(t, float32(0), node.innerPos().X)
+	assert.Equal(t, node.Size().Width, node.separators[0].Size().Width)
+
+	data, err := Marshal(diagram)
+	assert.NoError(t, err)
+	restored := NewDiagramWidget("Diagram2")
+	assert.NoError(t, Unmarshal(data, restored))
+	restoredNode, ok := restored.GetDiagramNode("Node1").(*ClassNode)
+	assert.True(t, ok)
+	assert.Equal(t, "Account", restoredNode.GetClassName())
+	assert.Equal(t, []string{"- balance: int"}, restoredNode.GetAttributes())
+	assert.Equal(t, []string{"+ deposit(int)", "+ withdraw(int)"}, restoredNode.GetOperations())
+}
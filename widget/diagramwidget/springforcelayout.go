@@ -58,9 +58,12 @@ func calculateForce(dw *DiagramWidget, n1, n2 DiagramNode, targetLength float64)
 }
 
 // StepForceLayout calculates one step of force directed graph layout, with
-// the target distance between adjacent nodes being targetLength.
+// the target distance between adjacent nodes being targetLength. The targetLength is specified at zoom 1.
+// The step is only recorded for undo when it is made within an undo group, e.g. one opened with
+// StartUndoGroup around a whole series of steps.
 func StepForceLayout(dw *DiagramWidget, targetLength float64) {
 	deltas := make(map[int]r2.Vec2)
+	targetLength *= float64(dw.zoom)
 
 	// calculate all the deltas from the current state
 	for k, nk := range dw.GetDiagramNodes() {
@@ -74,7 +77,12 @@ func StepForceLayout(dw *DiagramWidget, targetLength float64) {
 		}
 	}
 
-	// flip into current state
+	// flip into current state. A single step is not recorded for undo, so that the many steps of a layout
+	// run do not flood the undo history; callers wanting a run to be undoable wrap it in an undo group.
+	if dw.commands.groupDepth == 0 {
+		dw.suspendUndoRecording()
+		defer dw.resumeUndoRecording()
+	}
 	for k, nk := range dw.GetDiagramNodes() {
 		dw.DisplaceNode(nk, fyne.Position{X: float32(deltas[k].X), Y: float32(deltas[k].Y)})
 	}
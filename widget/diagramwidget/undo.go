@@ -0,0 +1,418 @@
+package diagramwidget
+
+import (
+	"container/list"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+const defaultMaxUndoDepth = 100
+
+// diagramCommand is a reversible modification of the diagram
+type diagramCommand interface {
+	undo()
+	redo()
+}
+
+// commandGroup is the unit of undo and redo: all of the commands recorded between StartUndoGroup
+// and EndUndoGroup (or a single command recorded outside of a group)
+type commandGroup struct {
+	name     string
+	commands []diagramCommand
+}
+
+func (cg *commandGroup) undo() {
+	for i := len(cg.commands) - 1; i >= 0; i-- {
+		cg.commands[i].undo()
+	}
+}
+
+func (cg *commandGroup) redo() {
+	for _, command := range cg.commands {
+		command.redo()
+	}
+}
+
+// commandStack holds the undo and redo history of a diagram
+type commandStack struct {
+	undoStack  []*commandGroup
+	redoStack  []*commandGroup
+	openGroup  *commandGroup
+	groupDepth int
+	// openDisplacements holds the displacements recorded in the open group since its last other command, by node,
+	// so that further displacements of the same nodes are merged into them
+	openDisplacements map[DiagramNode]*displaceNodeCommand
+	// suspended is non-zero while commands are being replayed or while the diagram is being
+	// modified in ways that should not be recorded
+	suspended int
+}
+
+// CanRedo returns true if there is an undone modification that can be redone
+func (dw *DiagramWidget) CanRedo() bool {
+	return len(dw.commands.redoStack) > 0
+}
+
+// CanUndo returns true if there is a modification that can be undone
+func (dw *DiagramWidget) CanUndo() bool {
+	return len(dw.commands.undoStack) > 0
+}
+
+// ClearUndoHistory discards all of the undo and redo history
+func (dw *DiagramWidget) ClearUndoHistory() {
+	dw.commands.undoStack = nil
+	dw.commands.redoStack = nil
+	dw.notifyUndoRedoStateChanged()
+}
+
+// EndUndoGroup closes the group opened by the matching StartUndoGroup. When the outermost group
+// is closed, the modifications recorded in it become a single undo entry.
+func (dw *DiagramWidget) EndUndoGroup() {
+	if dw.commands.groupDepth == 0 {
+		return
+	}
+	dw.commands.groupDepth--
+	if dw.commands.groupDepth > 0 {
+		return
+	}
+	group := dw.commands.openGroup
+	dw.commands.openGroup = nil
+	dw.commands.openDisplacements = nil
+	if len(group.commands) > 0 {
+		dw.pushUndoGroup(group)
+	}
+}
+
+// GetRedoName returns the name of the modification that Redo would re-apply
+func (dw *DiagramWidget) GetRedoName() string {
+	if !dw.CanRedo() {
+		return ""
+	}
+	return dw.commands.redoStack[len(dw.commands.redoStack)-1].name
+}
+
+// GetUndoName returns the name of the modification that Undo would revert
+func (dw *DiagramWidget) GetUndoName() string {
+	if !dw.CanUndo() {
+		return ""
+	}
+	return dw.commands.undoStack[len(dw.commands.undoStack)-1].name
+}
+
+// Redo re-applies the most recently undone modification
+func (dw *DiagramWidget) Redo() {
+	if !dw.CanRedo() || dw.commands.groupDepth > 0 {
+		return
+	}
+	group := dw.commands.redoStack[len(dw.commands.redoStack)-1]
+	dw.commands.redoStack = dw.commands.redoStack[:len(dw.commands.redoStack)-1]
+	dw.commands.suspended++
+	group.redo()
+	dw.commands.suspended--
+	dw.commands.undoStack = append(dw.commands.undoStack, group)
+	dw.Refresh()
+	dw.notifyUndoRedoStateChanged()
+}
+
+// RegisterShortcuts adds the diagram's keyboard shortcuts to the canvas: Undo (Ctrl+Z),
+// Redo (Ctrl+Y or Ctrl+Shift+Z), Copy (Ctrl+C), Cut (Ctrl+X) and Paste (Ctrl+V). The shortcuts
+// are only triggered when the focused object does not handle them itself.
+func (dw *DiagramWidget) RegisterShortcuts(c fyne.Canvas) {
+	c.AddShortcut(&fyne.ShortcutUndo{}, func(fyne.Shortcut) { dw.Undo() })
+	c.AddShortcut(&fyne.ShortcutRedo{}, func(fyne.Shortcut) { dw.Redo() })
+	c.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift},
+		func(fyne.Shortcut) { dw.Redo() })
+	c.AddShortcut(&fyne.ShortcutCopy{}, func(fyne.Shortcut) { dw.Copy() })
+	c.AddShortcut(&fyne.ShortcutCut{}, func(fyne.Shortcut) { dw.Cut() })
+	c.AddShortcut(&fyne.ShortcutPaste{}, func(fyne.Shortcut) { dw.Paste() })
+}
+
+// StartUndoGroup opens a group so that all of the modifications made until the matching EndUndoGroup
+// are undone and redone as a single step. Groups may be nested, in which case the outermost group's
+// name is used.
+func (dw *DiagramWidget) StartUndoGroup(name string) {
+	if dw.commands.groupDepth == 0 {
+		dw.commands.openGroup = &commandGroup{name: name}
+		dw.commands.openDisplacements = map[DiagramNode]*displaceNodeCommand{}
+	}
+	dw.commands.groupDepth++
+}
+
+// Undo reverts the most recent modification
+func (dw *DiagramWidget) Undo() {
+	if !dw.CanUndo() || dw.commands.groupDepth > 0 {
+		return
+	}
+	group := dw.commands.undoStack[len(dw.commands.undoStack)-1]
+	dw.commands.undoStack = dw.commands.undoStack[:len(dw.commands.undoStack)-1]
+	dw.commands.suspended++
+	group.undo()
+	dw.commands.suspended--
+	dw.commands.redoStack = append(dw.commands.redoStack, group)
+	dw.Refresh()
+	dw.notifyUndoRedoStateChanged()
+}
+
+func (dw *DiagramWidget) notifyUndoRedoStateChanged() {
+	if dw.UndoRedoStateChangedCallback != nil {
+		dw.UndoRedoStateChangedCallback(dw.CanUndo(), dw.CanRedo())
+	}
+}
+
+func (dw *DiagramWidget) pushUndoGroup(group *commandGroup) {
+	dw.commands.undoStack = append(dw.commands.undoStack, group)
+	if dw.MaxUndoDepth > 0 && len(dw.commands.undoStack) > dw.MaxUndoDepth {
+		dw.commands.undoStack = dw.commands.undoStack[len(dw.commands.undoStack)-dw.MaxUndoDepth:]
+	}
+	dw.commands.redoStack = nil
+	dw.notifyUndoRedoStateChanged()
+}
+
+// recordCommand adds the command to the open group or, if there is none, makes it an undo entry
+// on its own. Displacements of the same node within a group are merged, as long as only other
+// displacements have been recorded since, so that e.g. a layout run of many steps stays compact.
+func (dw *DiagramWidget) recordCommand(name string, command diagramCommand) {
+	if dw.commands.suspended > 0 {
+		return
+	}
+	if dw.commands.openGroup == nil {
+		dw.pushUndoGroup(&commandGroup{name: name, commands: []diagramCommand{command}})
+		return
+	}
+	group := dw.commands.openGroup
+	if displace, ok := command.(*displaceNodeCommand); ok {
+		if previous, ok := dw.commands.openDisplacements[displace.node]; ok {
+			previous.delta = previous.delta.Add(displace.delta)
+			return
+		}
+		dw.commands.openDisplacements[displace.node] = displace
+	} else if len(dw.commands.openDisplacements) > 0 {
+		dw.commands.openDisplacements = map[DiagramNode]*displaceNodeCommand{}
+	}
+	group.commands = append(group.commands, command)
+}
+
+// suspendUndoRecording stops recording until resumeUndoRecording is called
+func (dw *DiagramWidget) suspendUndoRecording() {
+	dw.commands.suspended++
+}
+
+func (dw *DiagramWidget) resumeUndoRecording() {
+	dw.commands.suspended--
+}
+
+// addElementsCommand records the addition of elements to the diagram, e.g. by Paste
+type addElementsCommand struct {
+	diagram    *DiagramWidget
+	elementIDs []string
+	// removed holds the elements removed by the most recent undo
+	removed []removedElement
+}
+
+func (c *addElementsCommand) undo() {
+	c.removed = nil
+	for i := len(c.elementIDs) - 1; i >= 0; i-- {
+		if element := c.diagram.GetDiagramElement(c.elementIDs[i]); element != nil {
+			c.diagram.removeElementFromSelection(element)
+		}
+		c.removed = c.diagram.removeElement(c.elementIDs[i], c.removed)
+	}
+	c.diagram.drawingArea.Refresh()
+}
+
+func (c *addElementsCommand) redo() {
+	c.diagram.restoreElements(c.removed)
+}
+
+// displaceNodeCommand records the displacement of a node. The delta is unscaled so that the
+// command remains valid when the zoom changes.
+type displaceNodeCommand struct {
+	diagram *DiagramWidget
+	node    DiagramNode
+	delta   fyne.Position
+}
+
+func newDisplaceNodeCommand(diagram *DiagramWidget, node DiagramNode, delta fyne.Position) *displaceNodeCommand {
+	return &displaceNodeCommand{diagram: diagram, node: node, delta: diagram.unscalePosition(delta)}
+}
+
+func (c *displaceNodeCommand) undo() {
+	delta := c.diagram.scalePosition(c.delta)
+	c.diagram.DisplaceNode(c.node, fyne.NewPos(-delta.X, -delta.Y))
+}
+
+func (c *displaceNodeCommand) redo() {
+	c.diagram.DisplaceNode(c.node, c.diagram.scalePosition(c.delta))
+}
+
+// nodeGeometryCommand records a change in the position and inner size of a node, e.g. when
+// it is resized with a handle. The positions and sizes are unscaled.
+type nodeGeometryCommand struct {
+	node         DiagramNode
+	oldPosition  fyne.Position
+	oldInnerSize fyne.Size
+	newPosition  fyne.Position
+	newInnerSize fyne.Size
+}
+
+func (c *nodeGeometryCommand) apply(position fyne.Position, innerSize fyne.Size) {
+	bdn := c.node.getBaseDiagramNode()
+	bdn.InnerSize = bdn.diagram.scaleSize(innerSize)
+	bdn.Move(bdn.diagram.scalePosition(position))
+	bdn.Refresh()
+	bdn.diagram.adjustBounds()
+}
+
+func (c *nodeGeometryCommand) undo() {
+	c.apply(c.oldPosition, c.oldInnerSize)
+}
+
+func (c *nodeGeometryCommand) redo() {
+	c.apply(c.newPosition, c.newInnerSize)
+}
+
+// padChangeCommand records a change in the pad to which one end of a link is connected
+type padChangeCommand struct {
+	link    DiagramLink
+	linkEnd LinkEnd
+	oldPad  ConnectionPad
+	newPad  ConnectionPad
+}
+
+func (c *padChangeCommand) setPad(pad ConnectionPad) {
+	switch c.linkEnd {
+	case SOURCE:
+		c.link.SetSourcePad(pad)
+	case TARGET:
+		c.link.SetTargetPad(pad)
+	}
+}
+
+func (c *padChangeCommand) undo() {
+	c.setPad(c.oldPad)
+}
+
+func (c *padChangeCommand) redo() {
+	c.setPad(c.newPad)
+}
+
+// shapeChangeCommand records a change in the shape of a node. The edge pads are retained so that
+// the links connected to them, and the other commands referring to them, remain valid.
+type shapeChangeCommand struct {
+	node     DiagramNode
+	oldShape NodeShape
+	oldPad   ConnectionPad
+	newShape NodeShape
+	newPad   ConnectionPad
+}
+
+func (c *shapeChangeCommand) undo() {
+	c.node.getBaseDiagramNode().setShape(c.oldShape, c.oldPad)
+}
+
+func (c *shapeChangeCommand) redo() {
+	c.node.getBaseDiagramNode().setShape(c.newShape, c.newPad)
+}
+
+// removedElement records an element removed from the diagram, its index in the display list
+// and the group to which it belonged, if any
+type removedElement struct {
+	element DiagramElement
+	index   int
+	group   DiagramGroup
+}
+
+// removeElementsCommand records the removal of elements from the diagram. The elements are
+// listed in the order in which they were removed.
+type removeElementsCommand struct {
+	diagram *DiagramWidget
+	removed []removedElement
+}
+
+func (c *removeElementsCommand) undo() {
+	c.diagram.restoreElements(c.removed)
+}
+
+func (c *removeElementsCommand) redo() {
+	for _, re := range c.removed {
+		c.diagram.RemoveElement(re.element.GetDiagramElementID())
+	}
+}
+
+// zOrderCommand records a change in the order of the diagram's display list
+type zOrderCommand struct {
+	diagram  *DiagramWidget
+	oldOrder []DiagramElement
+	newOrder []DiagramElement
+}
+
+func (c *zOrderCommand) undo() {
+	c.diagram.setDisplayOrder(c.oldOrder)
+}
+
+func (c *zOrderCommand) redo() {
+	c.diagram.setDisplayOrder(c.newOrder)
+}
+
+// recordZOrderChange records a z-order change if the display order differs from the old order
+func (dw *DiagramWidget) recordZOrderChange(oldOrder []DiagramElement) {
+	newOrder := dw.GetDiagramElements()
+	for i := range oldOrder {
+		if oldOrder[i] != newOrder[i] {
+			dw.recordCommand("Change Order", &zOrderCommand{diagram: dw, oldOrder: oldOrder, newOrder: newOrder})
+			return
+		}
+	}
+}
+
+// restoreElements puts removed elements back into the display list at their original indices and
+// re-establishes the link dependencies of restored links
+func (dw *DiagramWidget) restoreElements(removed []removedElement) {
+	for i := len(removed) - 1; i >= 0; i-- {
+		re := removed[i]
+		insertIntoList(dw.DiagramElements, re.element, re.index)
+		if _, ok := re.element.(DiagramGroup); ok {
+			dw.groupCount++
+		}
+		if re.group != nil {
+			attachToGroup(re.element.(DiagramNode), re.group)
+		}
+		if re.element.IsLink() {
+			link := re.element.(DiagramLink)
+			bdl := link.getBaseDiagramLink()
+			if bdl.sourcePad != nil {
+				dw.addLinkDependency(bdl.sourcePad.GetPadOwner(), bdl, bdl.sourcePad)
+			}
+			if bdl.targetPad != nil {
+				dw.addLinkDependency(bdl.targetPad.GetPadOwner(), bdl, bdl.targetPad)
+			}
+		}
+		re.element.Refresh()
+		dw.graphBindersElementChanged(re.element)
+	}
+	dw.updateGroupVisibility()
+	dw.adjustBounds()
+	dw.drawingArea.Refresh()
+}
+
+// setDisplayOrder replaces the display list with the supplied elements
+func (dw *DiagramWidget) setDisplayOrder(elements []DiagramElement) {
+	dw.DiagramElements.Init()
+	for _, element := range elements {
+		dw.DiagramElements.PushBack(element)
+	}
+	dw.drawingArea.Refresh()
+}
+
+// insertIntoList inserts the value so that it has the indicated index in the list
+func insertIntoList(l *list.List, value interface{}, index int) {
+	i := 0
+	for listElement := l.Front(); listElement != nil; listElement = listElement.Next() {
+		if i == index {
+			l.InsertBefore(value, listElement)
+			return
+		}
+		i++
+	}
+	l.PushBack(value)
+}
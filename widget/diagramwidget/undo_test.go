@@ -0,0 +1,129 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndoRedoDisplaceAndRemove(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(300, 100))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	diagram.ClearUndoHistory()
+
+	callbackCount := 0
+	diagram.UndoRedoStateChangedCallback = func(canUndo, canRedo bool) {
+		callbackCount++
+	}
+
+	diagram.DisplaceNode(node1, fyne.NewPos(20, 30))
+	assert.Equal(t, fyne.NewPos(120, 130), node1.Position())
+	assert.True(t, diagram.CanUndo())
+	assert.Equal(t, "Move", diagram.GetUndoName())
+	diagram.Undo()
+	assert.Equal(t, fyne.NewPos(100, 100), node1.Position())
+	assert.True(t, diagram.CanRedo())
+	diagram.Redo()
+	assert.Equal(t, fyne.NewPos(120, 130), node1.Position())
+	assert.Equal(t, 3, callbackCount)
+
+	diagram.RemoveElement("Node2")
+	assert.Nil(t, diagram.GetDiagramElement("Node2"))
+	assert.Nil(t, diagram.GetDiagramElement("Link1"))
+	diagram.Undo()
+	assert.Equal(t, node2, diagram.GetDiagramNode("Node2"))
+	assert.NotNil(t, diagram.GetDiagramLink("Link1"))
+	assert.Equal(t, 2, len(diagram.diagramElementLinkDependencies))
+	assert.Equal(t, []DiagramElement{node1, node2, link}, diagram.GetDiagramElements())
+	diagram.Redo()
+	assert.Nil(t, diagram.GetDiagramElement("Link1"))
+}
+
+func TestUndoGroupsDragGestureAndZOrder(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	diagram.ClearUndoHistory()
+
+	bdn := node1.getBaseDiagramNode()
+	for i := 0; i < 5; i++ {
+		bdn.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(2, 1)})
+	}
+	bdn.DragEnd()
+	assert.Equal(t, fyne.NewPos(110, 105), node1.Position())
+	assert.Equal(t, 1, len(diagram.commands.undoStack))
+	diagram.Undo()
+	assert.Equal(t, fyne.NewPos(100, 100), node1.Position())
+	assert.False(t, diagram.CanUndo())
+
+	diagram.BringToFront("Node1")
+	assert.Equal(t, []DiagramElement{node2, node1}, diagram.GetDiagramElements())
+	diagram.Undo()
+	assert.Equal(t, []DiagramElement{node1, node2}, diagram.GetDiagramElements())
+}
+
+func TestUndoAfterStepForceLayout(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	diagram.MaxUndoDepth = 100
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(300, 100))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	diagram.ClearUndoHistory()
+
+	// The steps of a layout run do not push the user's modification out of the undo history
+	diagram.DisplaceNode(node1, fyne.NewPos(20, 30))
+	for i := 0; i < 120; i++ {
+		StepForceLayout(diagram, 300)
+	}
+	assert.Equal(t, 1, len(diagram.commands.undoStack))
+	assert.Equal(t, "Move", diagram.GetUndoName())
+	position := node1.Position()
+	diagram.Undo()
+	assert.Equal(t, position.Subtract(fyne.NewPos(20, 30)), node1.Position())
+
+	// A run wrapped in an undo group is a single entry
+	node2.Move(fyne.NewPos(900, 700))
+	start1, start2 := node1.Position(), node2.Position()
+	diagram.StartUndoGroup("Layout")
+	for i := 0; i < 120; i++ {
+		StepForceLayout(diagram, 300)
+	}
+	diagram.EndUndoGroup()
+	assert.NotEqual(t, start1, node1.Position())
+	assert.Equal(t, "Layout", diagram.GetUndoName())
+	assert.Equal(t, 2, len(diagram.commands.undoStack[len(diagram.commands.undoStack)-1].commands))
+	diagram.Undo()
+	// The merged displacements are float32 sums of the steps
+	for _, pair := range [][2]fyne.Position{{start1, node1.Position()}, {start2, node2.Position()}} {
+		assert.InDelta(t, pair[0].X, pair[1].X, 1e-3)
+		assert.InDelta(t, pair[0].Y, pair[1].Y, 1e-3)
+	}
+	// Displacements separated by another modification are not merged
+	start1 = node1.Position()
+	diagram.StartUndoGroup("Arrange")
+	diagram.DisplaceNode(node1, fyne.NewPos(10, 0))
+	diagram.BringToFront("Node1")
+	diagram.DisplaceNode(node1, fyne.NewPos(10, 0))
+	diagram.DisplaceNode(node1, fyne.NewPos(5, 0))
+	diagram.EndUndoGroup()
+	assert.Equal(t, 3, len(diagram.commands.undoStack[len(diagram.commands.undoStack)-1].commands))
+	diagram.Undo()
+	assert.Equal(t, start1, node1.Position())
+	assert.Equal(t, "Node1", diagram.GetDiagramElements()[0].GetDiagramElementID())
+}
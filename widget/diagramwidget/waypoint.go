@@ -0,0 +1,237 @@
+package diagramwidget
+
+import (
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// waypointHandlePrefix begins the keys of the handles of a link's waypoints. It is followed by the index of
+// the waypoint.
+const waypointHandlePrefix = "waypoint"
+
+// GetWaypoints returns the positions of the link's waypoints, the bend points between its source and
+// target, in diagram coordinates
+func (bdl *BaseDiagramLink) GetWaypoints() []fyne.Position {
+	waypoints := []fyne.Position{}
+	for _, linkPoint := range bdl.linkPoints[1 : len(bdl.linkPoints)-1] {
+		waypoints = append(waypoints, bdl.Position().Add(linkPoint.Position()))
+	}
+	return waypoints
+}
+
+// InsertWaypoint inserts a waypoint at the position (in diagram coordinates) before the waypoint with the
+// indicated index. An index equal to the number of waypoints adds the waypoint after the last one, and an
+// index that is out of range is ignored.
+func (bdl *BaseDiagramLink) InsertWaypoint(index int, position fyne.Position) {
+	waypoints := bdl.GetWaypoints()
+	if index < 0 || index > len(waypoints) {
+		return
+	}
+	waypoints = append(waypoints[:index], append([]fyne.Position{position}, waypoints[index:]...)...)
+	bdl.replaceWaypoints("Add Waypoint", waypoints)
+}
+
+// RemoveWaypoint removes the waypoint with the indicated index. An index that is out of range is ignored.
+func (bdl *BaseDiagramLink) RemoveWaypoint(index int) {
+	waypoints := bdl.GetWaypoints()
+	if index < 0 || index >= len(waypoints) {
+		return
+	}
+	waypoints = append(waypoints[:index], waypoints[index+1:]...)
+	bdl.replaceWaypoints("Remove Waypoint", waypoints)
+}
+
+// SetWaypoints replaces the link's waypoints with the positions (in diagram coordinates), so that the link
+// follows the polyline from its source through the waypoints to its target. The path between the points
+// is determined by the link's LinkRouter. An empty slice removes all of the waypoints.
+func (bdl *BaseDiagramLink) SetWaypoints(waypoints []fyne.Position) {
+	bdl.replaceWaypoints("Edit Waypoints", waypoints)
+}
+
+// getUnscaledWaypoints returns the positions of the waypoints in unscaled diagram coordinates
+func (bdl *BaseDiagramLink) getUnscaledWaypoints() []fyne.Position {
+	waypoints := bdl.GetWaypoints()
+	for i, waypoint := range waypoints {
+		waypoints[i] = bdl.diagram.unscalePosition(waypoint)
+	}
+	return waypoints
+}
+
+// handleTappedSecondary shows the context menu of a waypoint's handle, from which the waypoint can be removed
+func (bdl *BaseDiagramLink) handleTappedSecondary(handle *Handle, event *fyne.PointEvent) {
+	index, ok := parseWaypointHandleKey(bdl.getHandleKey(handle))
+	if !ok {
+		return
+	}
+	canvas := bdl.diagram.getCanvas()
+	if canvas == nil {
+		return
+	}
+	widget.ShowPopUpMenuAtPosition(bdl.newWaypointMenu(index), canvas, event.AbsolutePosition)
+}
+
+// insertWaypointOnSegment inserts a waypoint on the segment at the point (in link coordinates) closest to
+// the indicated point. Since the router may add segments between the waypoints, the new waypoint's index is
+// the number of waypoints that the path passes through before reaching the segment.
+func (bdl *BaseDiagramLink) insertWaypointOnSegment(segment *LinkSegment, point fyne.Position) {
+	segmentIndex := -1
+	for i, linkSegment := range bdl.linkSegments {
+		if linkSegment == segment {
+			segmentIndex = i
+		}
+	}
+	routePoints := bdl.getRoutePoints()
+	if segmentIndex < 0 || segmentIndex >= len(routePoints)-1 {
+		return
+	}
+	next := 1
+	for i := 1; i <= segmentIndex && next < len(bdl.linkPoints)-1; i++ {
+		if samePosition(routePoints[i], bdl.linkPoints[next].Position()) {
+			next++
+		}
+	}
+	position := getClosestPointOnSegment(point, routePoints[segmentIndex], routePoints[segmentIndex+1])
+	bdl.InsertWaypoint(next-1, bdl.Position().Add(position))
+	bdl.diagram.SelectDiagramElement(bdl)
+}
+
+// newWaypointMenu returns the context menu of the waypoint with the indicated index
+func (bdl *BaseDiagramLink) newWaypointMenu(index int) *fyne.Menu {
+	return fyne.NewMenu("", fyne.NewMenuItem("Remove Waypoint", func() {
+		bdl.RemoveWaypoint(index)
+	}))
+}
+
+// replaceWaypoints replaces the link's waypoints with new ones (in diagram coordinates) as an undo entry
+// with the indicated name
+func (bdl *BaseDiagramLink) replaceWaypoints(name string, waypoints []fyne.Position) {
+	newWaypoints := []fyne.Position{}
+	for _, waypoint := range waypoints {
+		newWaypoints = append(newWaypoints, bdl.diagram.unscalePosition(waypoint))
+	}
+	bdl.diagram.recordCommand(name, &waypointsCommand{
+		link:         bdl.typedLink,
+		oldWaypoints: bdl.getUnscaledWaypoints(),
+		newWaypoints: newWaypoints,
+	})
+	bdl.setWaypoints(waypoints)
+	bdl.diagram.adjustBounds()
+}
+
+// setWaypoints replaces the link's waypoints with the positions (in diagram coordinates), creating or
+// removing their link points and handles as necessary
+func (bdl *BaseDiagramLink) setWaypoints(waypoints []fyne.Position) {
+	linkPoints := []*LinkPoint{bdl.linkPoints[0]}
+	for i, waypoint := range waypoints {
+		var linkPoint *LinkPoint
+		if i+1 < len(bdl.linkPoints)-1 {
+			linkPoint = bdl.linkPoints[i+1]
+		} else {
+			linkPoint = NewLinkPoint(bdl)
+		}
+		linkPoint.Move(waypoint.Subtract(bdl.Position()))
+		linkPoints = append(linkPoints, linkPoint)
+	}
+	bdl.linkPoints = append(linkPoints, bdl.linkPoints[len(bdl.linkPoints)-1])
+	// The handles of new waypoints are visible if the link is selected
+	visible := bdl.GetSourceHandle().Visible()
+	for key := range bdl.handles {
+		if index, ok := parseWaypointHandleKey(key); ok && index >= len(waypoints) {
+			delete(bdl.handles, key)
+		}
+	}
+	for i := range waypoints {
+		key := waypointHandlePrefix + strconv.Itoa(i)
+		if _, ok := bdl.handles[key]; !ok {
+			handle := NewHandle(bdl)
+			if !visible {
+				handle.Hide()
+			}
+			bdl.handles[key] = handle
+		}
+	}
+	bdl.Refresh()
+}
+
+// waypointDragged moves the waypoint with the indicated index. The waypoints are recorded at the start of
+// the drag so that the whole drag is a single undo entry.
+func (bdl *BaseDiagramLink) waypointDragged(index int, event *fyne.DragEvent) {
+	if bdl.waypointDragStart == nil {
+		bdl.waypointDragStart = bdl.getUnscaledWaypoints()
+		bdl.diagram.dragResidual = fyne.NewPos(0, 0)
+	}
+	linkPoint := bdl.linkPoints[index+1]
+	currentPosition := linkPoint.Position()
+	delta := bdl.diagram.snapDrag(bdl.Position().Add(currentPosition), fyne.NewPos(event.Dragged.DX, event.Dragged.DY), true, true)
+	linkPoint.Move(currentPosition.Add(delta))
+	bdl.Refresh()
+}
+
+// waypointDragEnd records the movement of a waypoint by a drag for undo
+func (bdl *BaseDiagramLink) waypointDragEnd() {
+	if bdl.waypointDragStart == nil {
+		return
+	}
+	bdl.diagram.recordCommand("Move Waypoint", &waypointsCommand{
+		link:         bdl.typedLink,
+		oldWaypoints: bdl.waypointDragStart,
+		newWaypoints: bdl.getUnscaledWaypoints(),
+	})
+	bdl.waypointDragStart = nil
+	bdl.diagram.adjustBounds()
+}
+
+// getClosestPointOnSegment returns the point on the segment from p1 to p2 that is closest to the point
+func getClosestPointOnSegment(point fyne.Position, p1 fyne.Position, p2 fyne.Position) fyne.Position {
+	segment := toR2(p2.Subtract(p1))
+	lengthSquared := segment.Dot(segment)
+	if lengthSquared == 0 {
+		return p1
+	}
+	fraction := toR2(point.Subtract(p1)).Dot(segment) / lengthSquared
+	if fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+	return p1.AddXY(float32(segment.X*fraction), float32(segment.Y*fraction))
+}
+
+// parseWaypointHandleKey returns the index of the waypoint whose handle has the key, and false if the key
+// is not that of a waypoint's handle
+func parseWaypointHandleKey(key string) (int, bool) {
+	if !strings.HasPrefix(key, waypointHandlePrefix) {
+		return 0, false
+	}
+	index, err := strconv.Atoi(strings.TrimPrefix(key, waypointHandlePrefix))
+	return index, err == nil
+}
+
+// waypointsCommand records the replacement of a link's waypoints. The waypoints are in unscaled diagram
+// coordinates.
+type waypointsCommand struct {
+	link         DiagramLink
+	oldWaypoints []fyne.Position
+	newWaypoints []fyne.Position
+}
+
+func (c *waypointsCommand) apply(waypoints []fyne.Position) {
+	bdl := c.link.getBaseDiagramLink()
+	scaled := []fyne.Position{}
+	for _, waypoint := range waypoints {
+		scaled = append(scaled, bdl.diagram.scalePosition(waypoint))
+	}
+	bdl.setWaypoints(scaled)
+	bdl.diagram.adjustBounds()
+}
+
+func (c *waypointsCommand) undo() {
+	c.apply(c.oldWaypoints)
+}
+
+func (c *waypointsCommand) redo() {
+	c.apply(c.newWaypoints)
+}
@@ -0,0 +1,137 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetWaypoints(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(500, 100))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	diagram.ClearUndoHistory()
+	text := link.AddMidpointAnchoredText("name", "Link 1")
+	link.AddMidpointDecoration(NewArrowhead())
+	source := link.Position().Add(link.linkPoints[0].Position())
+	target := link.Position().Add(link.linkPoints[1].Position())
+
+	waypoint := fyne.NewPos((source.X+target.X)/2, source.Y+300)
+	link.SetWaypoints([]fyne.Position{waypoint})
+	assert.Equal(t, []fyne.Position{waypoint}, link.GetWaypoints())
+	assert.Equal(t, 2, len(link.linkSegments))
+	assert.Equal(t, 3, len(link.handles))
+	assert.False(t, link.GetHandle("waypoint0").Visible())
+	// The path is symmetrical, so its midpoint is the waypoint
+	midpoint := link.Position().Add(text.referencePosition)
+	assert.InDelta(t, waypoint.X, midpoint.X, 0.01)
+	assert.InDelta(t, waypoint.Y, midpoint.Y, 0.01)
+	assert.Equal(t, text.referencePosition, link.MidpointDecorations[0].Position())
+
+	assert.Equal(t, "Edit Waypoints", diagram.GetUndoName())
+	diagram.Undo()
+	assert.Equal(t, 0, len(link.GetWaypoints()))
+	assert.Equal(t, 1, len(link.linkSegments))
+	assert.Equal(t, 2, len(link.handles))
+	diagram.Redo()
+	assert.Equal(t, []fyne.Position{waypoint}, link.GetWaypoints())
+
+	// Waypoints are saved with the diagram
+	data, err := Marshal(diagram)
+	assert.NoError(t, err)
+	restored := NewDiagramWidget("Diagram2")
+	assert.NoError(t, Unmarshal(data, restored))
+	assert.Equal(t, []fyne.Position{waypoint}, restored.GetDiagramLink("Link1").GetWaypoints())
+}
+
+func TestEditWaypoints(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(500, 100))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	diagram.ClearUndoHistory()
+	source := link.Position().Add(link.linkPoints[0].Position())
+	target := link.Position().Add(link.linkPoints[1].Position())
+
+	// Double-tapping a segment inserts a waypoint on it and selects the link
+	segment := link.linkSegments[0]
+	tapped := link.Position().Add(segment.Position())
+	segment.DoubleTapped(&fyne.PointEvent{Position: fyne.NewPos(100, 2)})
+	assert.Equal(t, []fyne.Position{fyne.NewPos(tapped.X+100, source.Y)}, link.GetWaypoints())
+	assert.True(t, diagram.IsSelected(link))
+	assert.True(t, link.GetHandle("waypoint0").Visible())
+	assert.Equal(t, "Add Waypoint", diagram.GetUndoName())
+
+	// The new waypoint is inserted between the waypoints at either end of the tapped segment
+	link.linkSegments[1].DoubleTapped(&fyne.PointEvent{Position: fyne.NewPos(50, 0)})
+	waypoints := link.GetWaypoints()
+	assert.Equal(t, 2, len(waypoints))
+	assert.Less(t, waypoints[0].X, waypoints[1].X)
+	assert.Less(t, waypoints[1].X, target.X)
+
+	// Dragging a waypoint's handle moves it as a single undo entry
+	handle := link.GetHandle("waypoint1")
+	handle.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(10, 20)})
+	handle.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(10, 20)})
+	handle.DragEnd()
+	assert.Equal(t, waypoints[1].Add(fyne.NewPos(20, 40)), link.GetWaypoints()[1])
+	assert.Equal(t, waypoints[1].Add(fyne.NewPos(20, 40)), link.Position().Add(handle.Position()).AddXY(handle.handleSize/2, handle.handleSize/2))
+	assert.Equal(t, "Move Waypoint", diagram.GetUndoName())
+	diagram.Undo()
+	assert.Equal(t, waypoints, link.GetWaypoints())
+
+	// The context menu of a waypoint's handle removes the waypoint
+	menu := link.newWaypointMenu(0)
+	assert.Equal(t, "Remove Waypoint", menu.Items[0].Label)
+	menu.Items[0].Action()
+	assert.Equal(t, waypoints[1:], link.GetWaypoints())
+	assert.Nil(t, link.GetHandle("waypoint1"))
+	assert.Equal(t, "Remove Waypoint", diagram.GetUndoName())
+
+	// Indices that are out of range are ignored
+	link.RemoveWaypoint(1)
+	link.InsertWaypoint(2, fyne.NewPos(0, 0))
+	assert.Equal(t, waypoints[1:], link.GetWaypoints())
+}
+
+func TestWaypointsWithOrthogonalRouting(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(500, 100))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	diagram.ClearUndoHistory()
+	link.SetRouter(NewOrthogonalLinkRouter())
+	source := link.Position().Add(link.linkPoints[0].Position())
+	waypoint := fyne.NewPos(source.X+100, source.Y+200)
+	link.SetWaypoints([]fyne.Position{waypoint})
+	// The path passes through the waypoint
+	passes := false
+	for _, point := range link.getRoutePoints() {
+		passes = passes || link.Position().Add(point) == waypoint
+	}
+	assert.True(t, passes)
+
+	// A waypoint inserted on the last segment follows the existing waypoint
+	last := link.linkSegments[len(link.linkSegments)-1]
+	last.DoubleTapped(&fyne.PointEvent{Position: fyne.NewPos(last.Size().Width/2, last.Size().Height/2)})
+	assert.Equal(t, 2, len(link.GetWaypoints()))
+	assert.Equal(t, waypoint, link.GetWaypoints()[0])
+}
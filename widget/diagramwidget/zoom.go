@@ -0,0 +1,311 @@
+package diagramwidget
+
+import (
+	"image/color"
+	"math"
+
+	"fyne.io/x/fyne/widget/diagramwidget/geometry/r2"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+)
+
+const (
+	// MinZoom is the smallest zoom factor supported by the DiagramWidget
+	MinZoom float32 = 0.1
+	// MaxZoom is the largest zoom factor supported by the DiagramWidget
+	MaxZoom float32 = 8
+	// zoomStep is the factor applied by ZoomIn and ZoomOut and by each mouse wheel step
+	zoomStep float32 = 1.2
+	// zoomFitMargin is the space left around the elements by ZoomToFit and ZoomToSelection
+	zoomFitMargin float32 = 20
+)
+
+// The DiagramWidget implements zoom by scaling the geometry of its elements: the positions and sizes of the
+// elements are always in drawing area coordinates, i.e. they already reflect the zoom. This keeps the
+// interactive behavior (drags, pad hit-testing, connection transactions) independent of the zoom. Widget
+// sizes that come from the theme (e.g. text) are scaled with a theme override on the drawing area.
+// Undo entries, serialized diagrams, and exported images use unscaled (zoom 1) coordinates.
+//
+// Consequently Move, Position, Size and InnerSize of the elements use drawing area coordinates: a node moved
+// to (100, 100) at zoom 2 is at (50, 50) once the zoom is set back to 1. Applications positioning elements
+// at unscaled coordinates convert them with DrawingAreaPosition and UnscaledPosition. The unscaled values of
+// the geometry are remembered when it is scaled, so zooming in and back out restores it exactly.
+
+// geometryKey identifies a scaled value: the object holding it and which of its values it is
+type geometryKey struct {
+	object interface{}
+	part   int
+}
+
+// unscaledValue is the unscaled value of a position or size, and the scaled value last set from it
+type unscaledValue struct {
+	x, y             float64
+	scaledX, scaledY float64
+}
+
+// DrawingAreaPosition converts an unscaled position (or displacement) to the drawing area coordinates used by
+// Move and Position at the current zoom
+func (dw *DiagramWidget) DrawingAreaPosition(p fyne.Position) fyne.Position {
+	return dw.scalePosition(p)
+}
+
+// GetZoom returns the current zoom factor. A value of 1 means that the diagram is displayed at its natural size.
+func (dw *DiagramWidget) GetZoom() float32 {
+	return dw.zoom
+}
+
+// UnscaledPosition converts a position (or displacement) in drawing area coordinates, such as the Position of
+// an element, to unscaled coordinates
+func (dw *DiagramWidget) UnscaledPosition(p fyne.Position) fyne.Position {
+	return dw.unscalePosition(p)
+}
+
+// SetZoom changes the zoom factor, keeping the center of the visible area in place. The zoom is
+// limited to the range MinZoom to MaxZoom.
+func (dw *DiagramWidget) SetZoom(zoom float32) {
+	viewport := dw.scrollingContainer.Size()
+	center := dw.scrollingContainer.Offset.AddXY(viewport.Width/2, viewport.Height/2)
+	dw.SetZoomAt(zoom, center)
+}
+
+// SetZoomAt changes the zoom factor, keeping the indicated point (in drawing area coordinates) at the
+// same place in the visible area. This is used, for example, to zoom around the mouse cursor.
+func (dw *DiagramWidget) SetZoomAt(zoom float32, pivot fyne.Position) {
+	viewportPivot := pivot.Subtract(dw.scrollingContainer.Offset)
+	ratio := dw.applyZoom(zoom)
+	if ratio == 1 {
+		return
+	}
+	dw.scrollTo(fyne.NewPos(pivot.X*ratio, pivot.Y*ratio).Subtract(viewportPivot))
+}
+
+// ZoomIn increases the zoom by one step
+func (dw *DiagramWidget) ZoomIn() {
+	dw.SetZoom(steppedZoom(dw.zoom, 1))
+}
+
+// ZoomOut decreases the zoom by one step
+func (dw *DiagramWidget) ZoomOut() {
+	dw.SetZoom(steppedZoom(dw.zoom, -1))
+}
+
+// ZoomToFit sets the zoom and scroll position so that all of the diagram's elements are visible
+func (dw *DiagramWidget) ZoomToFit() {
+	dw.zoomToElements(dw.GetDiagramElements())
+}
+
+// ZoomToSelection sets the zoom and scroll position so that the selected elements fill the visible area
+func (dw *DiagramWidget) ZoomToSelection() {
+	elements := []DiagramElement{}
+	for _, element := range dw.selection {
+		elements = append(elements, element)
+	}
+	dw.zoomToElements(elements)
+}
+
+func (dw *DiagramWidget) zoomToElements(elements []DiagramElement) {
+	if len(elements) == 0 {
+		return
+	}
+	topLeft, bottomRight := getElementBounds(elements)
+	viewport := dw.scrollingContainer.Size()
+	if viewport.IsZero() {
+		viewport = dw.Size()
+	}
+	width := (bottomRight.X - topLeft.X) / dw.zoom
+	height := (bottomRight.Y - topLeft.Y) / dw.zoom
+	zoom := MaxZoom
+	if width > 0 {
+		zoom = float32(math.Min(float64(zoom), float64((viewport.Width-2*zoomFitMargin)/width)))
+	}
+	if height > 0 {
+		zoom = float32(math.Min(float64(zoom), float64((viewport.Height-2*zoomFitMargin)/height)))
+	}
+	ratio := dw.applyZoom(zoom)
+	center := fyne.NewPos((topLeft.X+bottomRight.X)/2*ratio, (topLeft.Y+bottomRight.Y)/2*ratio)
+	dw.scrollTo(center.SubtractXY(viewport.Width/2, viewport.Height/2))
+}
+
+// applyZoom sets the zoom factor, scales the geometry of the elements, and returns the ratio of the new
+// zoom to the old one
+func (dw *DiagramWidget) applyZoom(zoom float32) float32 {
+	zoom = float32(math.Max(float64(MinZoom), math.Min(float64(MaxZoom), float64(zoom))))
+	if zoom == dw.zoom {
+		return 1
+	}
+	ratio := zoom / dw.zoom
+	previous := dw.zoom
+	dw.zoom = zoom
+	dw.rescale(previous, ratio)
+	if dw.ZoomChangedCallback != nil {
+		dw.ZoomChangedCallback(zoom)
+	}
+	return ratio
+}
+
+// rescale scales the geometry of all of the elements from the previous zoom to the current one. The ratio is
+// that of the current zoom to the previous one.
+func (dw *DiagramWidget) rescale(previous, ratio float32) {
+	geometry := dw.unscaledGeometry
+	dw.unscaledGeometry = map[geometryKey]unscaledValue{}
+	// scaleVec derives the new value from the unscaled one remembered when the value was last scaled, unless it
+	// has been changed since
+	scaleVec := func(object interface{}, part int, v r2.Vec2) r2.Vec2 {
+		key := geometryKey{object: object, part: part}
+		value, ok := geometry[key]
+		if !ok || value.scaledX != v.X || value.scaledY != v.Y {
+			value.x, value.y = v.X/float64(previous), v.Y/float64(previous)
+		}
+		value.scaledX, value.scaledY = value.x*float64(dw.zoom), value.y*float64(dw.zoom)
+		dw.unscaledGeometry[key] = value
+		return r2.V2(value.scaledX, value.scaledY)
+	}
+	scale := func(object interface{}, part int, p fyne.Position) fyne.Position {
+		key := geometryKey{object: object, part: part}
+		scaled := scaleVec(object, part, toR2(p))
+		value := dw.unscaledGeometry[key]
+		// The value is compared as it is stored, i.e. as a float32
+		value.scaledX, value.scaledY = float64(float32(scaled.X)), float64(float32(scaled.Y))
+		dw.unscaledGeometry[key] = value
+		return fyne.NewPos(float32(scaled.X), float32(scaled.Y))
+	}
+	for _, element := range dw.GetDiagramElements() {
+		if element.IsNode() {
+			bdn := element.(DiagramNode).getBaseDiagramNode()
+			innerSize := scale(bdn, 1, fyne.NewPos(bdn.InnerSize.Width, bdn.InnerSize.Height))
+			bdn.InnerSize = fyne.NewSize(innerSize.X, innerSize.Y)
+			bdn.BaseWidget.Move(scale(bdn, 0, bdn.Position()))
+			continue
+		}
+		bdl := element.(DiagramLink).getBaseDiagramLink()
+		bdl.BaseWidget.Move(scale(bdl, 0, bdl.Position()))
+		for _, linkPoint := range bdl.linkPoints {
+			linkPoint.Move(scale(linkPoint, 0, linkPoint.Position()))
+		}
+		for i, point := range bdl.routePoints {
+			bdl.routePoints[i] = scale(bdl, 1+i, point)
+		}
+		for _, anchoredTexts := range []map[string]*AnchoredText{bdl.sourceAnchoredText, bdl.midpointAnchoredText, bdl.targetAnchoredText} {
+			for _, at := range anchoredTexts {
+				at.offset = scaleVec(at, 0, at.offset)
+				at.referencePosition = scale(at, 1, at.referencePosition)
+				at.BaseWidget.Move(at.referencePosition.AddXY(float32(at.offset.X), float32(at.offset.Y)))
+			}
+		}
+	}
+	dw.zoomContainer.Refresh()
+
+	// Nodes are refreshed before links so that the links connect to the resized nodes
+	for _, node := range dw.GetDiagramNodes() {
+		node.Refresh()
+	}
+	for _, link := range dw.GetDiagramLinks() {
+		link.Refresh()
+	}
+	dw.drawingArea.Resize(fyne.NewSize(dw.DesiredSize.Width*ratio, dw.DesiredSize.Height*ratio))
+	dw.adjustBounds()
+}
+
+// steppedZoom returns the zoom the number of steps (see zoomStep) away from the zoom. When the zoom is a whole
+// number of steps away from 1, the result is computed from the number of steps, so that zooming in and back
+// out returns exactly to the same zoom.
+func steppedZoom(zoom float32, steps int) float32 {
+	level := math.Log(float64(zoom)) / math.Log(float64(zoomStep))
+	if rounded := math.Round(level); math.Abs(level-rounded) < 1e-3 {
+		return float32(math.Pow(float64(zoomStep), rounded+float64(steps)))
+	}
+	return zoom * float32(math.Pow(float64(zoomStep), float64(steps)))
+}
+
+// applyZoomTheme applies the zoom theme to an element that has been added to the diagram. Refreshing the zoom
+// container would apply it to every element, so the theme is applied to the element alone by a throwaway override.
+// At a zoom of 1 the zoom theme matches the application theme, and the element picks it up when the zoom changes.
+func (dw *DiagramWidget) applyZoomTheme(element fyne.CanvasObject) {
+	if dw.zoom != 1 && dw.zoomContainer != nil {
+		container.NewThemeOverride(element, dw.zoomContainer.Theme)
+	}
+}
+
+// panBy scrolls the visible area of the diagram by the delta
+func (dw *DiagramWidget) panBy(delta fyne.Position) {
+	dw.scrollTo(dw.scrollingContainer.Offset.Subtract(delta))
+}
+
+// scrollTo scrolls the visible area of the diagram to the offset and updates the minimaps
+func (dw *DiagramWidget) scrollTo(offset fyne.Position) {
+	dw.scrollingContainer.ScrollToOffset(offset)
+	dw.refreshMinimapViewports()
+}
+
+// scaled returns the value multiplied by the zoom
+func (dw *DiagramWidget) scaled(value float32) float32 {
+	return value * dw.zoom
+}
+
+// scalePosition converts an unscaled position (or displacement) to drawing area coordinates
+func (dw *DiagramWidget) scalePosition(p fyne.Position) fyne.Position {
+	return fyne.NewPos(p.X*dw.zoom, p.Y*dw.zoom)
+}
+
+// scaleSize converts an unscaled size to drawing area coordinates
+func (dw *DiagramWidget) scaleSize(s fyne.Size) fyne.Size {
+	return fyne.NewSize(s.Width*dw.zoom, s.Height*dw.zoom)
+}
+
+// unscalePosition converts a position (or displacement) in drawing area coordinates to unscaled coordinates
+func (dw *DiagramWidget) unscalePosition(p fyne.Position) fyne.Position {
+	return fyne.NewPos(p.X/dw.zoom, p.Y/dw.zoom)
+}
+
+// unscaleSize converts a size in drawing area coordinates to an unscaled size
+func (dw *DiagramWidget) unscaleSize(s fyne.Size) fyne.Size {
+	return fyne.NewSize(s.Width/dw.zoom, s.Height/dw.zoom)
+}
+
+// getElementBounds returns the upper left and lower right corners of the box enclosing the elements
+func getElementBounds(elements []DiagramElement) (fyne.Position, fyne.Position) {
+	topLeft := elements[0].Position()
+	bottomRight := topLeft
+	for _, element := range elements {
+		position := element.Position()
+		size := element.Size()
+		topLeft = fyne.NewPos(float32(math.Min(float64(topLeft.X), float64(position.X))),
+			float32(math.Min(float64(topLeft.Y), float64(position.Y))))
+		bottomRight = fyne.NewPos(float32(math.Max(float64(bottomRight.X), float64(position.X+size.Width))),
+			float32(math.Max(float64(bottomRight.Y), float64(position.Y+size.Height))))
+	}
+	return topLeft, bottomRight
+}
+
+// isZoomModifierPressed returns true if the modifier that turns mouse wheel scrolling into zooming is pressed
+func isZoomModifierPressed() bool {
+	return getCurrentKeyModifiers()&fyne.KeyModifierShortcutDefault != 0
+}
+
+// zoomTheme scales the sizes of the application theme by the diagram's zoom
+type zoomTheme struct {
+	diagram *DiagramWidget
+}
+
+var _ fyne.Theme = (*zoomTheme)(nil)
+
+func (zt *zoomTheme) baseTheme() fyne.Theme {
+	return fyne.CurrentApp().Settings().Theme()
+}
+
+func (zt *zoomTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	return zt.baseTheme().Color(name, variant)
+}
+
+func (zt *zoomTheme) Font(style fyne.TextStyle) fyne.Resource {
+	return zt.baseTheme().Font(style)
+}
+
+func (zt *zoomTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
+	return zt.baseTheme().Icon(name)
+}
+
+func (zt *zoomTheme) Size(name fyne.ThemeSizeName) float32 {
+	return zt.baseTheme().Size(name) * zt.diagram.zoom
+}
@@ -0,0 +1,156 @@
+package diagramwidget
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZoomScalesGeometry(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(300, 200))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	nodeSize := node1.Size()
+
+	zoomChanges := []float32{}
+	diagram.ZoomChangedCallback = func(zoom float32) {
+		zoomChanges = append(zoomChanges, zoom)
+	}
+	diagram.SetZoomAt(2, fyne.NewPos(0, 0))
+	assert.Equal(t, float32(2), diagram.GetZoom())
+	assert.Equal(t, fyne.NewPos(200, 200), node1.Position())
+	assert.Equal(t, fyne.NewPos(600, 400), node2.Position())
+	assert.Equal(t, fyne.NewSize(nodeSize.Width*2, nodeSize.Height*2), node1.Size())
+	// The link remains attached to the scaled nodes
+	bdl := link.getBaseDiagramLink()
+	sourcePoint := bdl.Position().Add(bdl.linkPoints[0].Position())
+	assert.True(t, node1.getBaseDiagramNode().R2Box().Contains(toR2(sourcePoint)))
+
+	diagram.SetZoomAt(1, fyne.NewPos(0, 0))
+	assert.Equal(t, fyne.NewPos(100, 100), node1.Position())
+	assert.Equal(t, nodeSize, node1.Size())
+	assert.Equal(t, []float32{2, 1}, zoomChanges)
+
+	diagram.SetZoom(100)
+	assert.Equal(t, MaxZoom, diagram.GetZoom())
+	diagram.SetZoom(0)
+	assert.Equal(t, MinZoom, diagram.GetZoom())
+}
+
+func TestZoomThemeAppliedToAddedElements(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	label1 := widget.NewLabel("Node 1")
+	NewDiagramNode(diagram, label1, "Node1")
+	padding := fyne.CurrentApp().Settings().Theme().Size(theme.SizeNamePadding)
+	diagram.SetZoom(2)
+	assert.Equal(t, 2*padding, label1.Theme().Size(theme.SizeNamePadding))
+
+	// A node added while zoomed is themed too
+	label2 := widget.NewLabel("Node 2")
+	NewDiagramNode(diagram, label2, "Node2")
+	assert.Equal(t, 2*padding, label2.Theme().Size(theme.SizeNamePadding))
+	diagram.SetZoom(1)
+	assert.Equal(t, padding, label2.Theme().Size(theme.SizeNamePadding))
+}
+
+func TestZoomIndependentUndoAndSerialization(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	diagram.ClearUndoHistory()
+
+	diagram.DisplaceNode(node1, fyne.NewPos(20, 30))
+	diagram.SetZoomAt(2, fyne.NewPos(0, 0))
+	assert.Equal(t, fyne.NewPos(240, 260), node1.Position())
+
+	// A drag moves the node by the screen distance regardless of the zoom
+	node1.getBaseDiagramNode().Dragged(&fyne.DragEvent{Dragged: fyne.Delta{DX: 10, DY: 10}})
+	node1.getBaseDiagramNode().DragEnd()
+	assert.Equal(t, fyne.NewPos(250, 270), node1.Position())
+
+	model, err := newDiagramModelForElements(diagram.GetDiagramElements())
+	assert.NoError(t, err)
+	assert.Equal(t, PositionModel{X: 125, Y: 135}, model.Nodes[0].Position)
+	assert.Equal(t, SizeModel{Width: defaultWidth, Height: defaultHeight}, model.Nodes[0].InnerSize)
+
+	diagram.Undo()
+	assert.Equal(t, fyne.NewPos(240, 260), node1.Position())
+	diagram.SetZoomAt(1, fyne.NewPos(0, 0))
+	diagram.Undo()
+	assert.Equal(t, fyne.NewPos(100, 100), node1.Position())
+}
+
+func TestZoomToFit(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	diagram.Resize(fyne.NewSize(400, 300))
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(100, 100))
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(900, 700))
+
+	diagram.ZoomToFit()
+	assert.Less(t, diagram.GetZoom(), float32(1))
+	topLeft, bottomRight := getElementBounds(diagram.GetDiagramElements())
+	assert.LessOrEqual(t, bottomRight.X-topLeft.X, float32(400))
+	assert.LessOrEqual(t, bottomRight.Y-topLeft.Y, float32(300))
+
+	diagram.SelectDiagramElement(node1)
+	diagram.ZoomToSelection()
+	assert.Greater(t, diagram.GetZoom(), float32(1))
+}
+
+func TestZoomRoundTrip(t *testing.T) {
+	test.NewApp()
+	diagram := NewDiagramWidget("Diagram1")
+	node1 := NewDiagramNode(diagram, nil, "Node1")
+	node1.Move(fyne.NewPos(333.3, 333.3))
+	node1.getBaseDiagramNode().InnerSize = fyne.NewSize(58, 41.7)
+	node2 := NewDiagramNode(diagram, nil, "Node2")
+	node2.Move(fyne.NewPos(500, 100))
+	link := NewDiagramLink(diagram, "Link1")
+	link.SetSourcePad(node1.GetEdgePad())
+	link.SetTargetPad(node2.GetEdgePad())
+	link.AddMidpointAnchoredText("label", "Label")
+	label := link.getBaseDiagramLink().midpointAnchoredText["label"]
+	label.Displace(fyne.NewPos(13.7, -7.3))
+	offset := label.offset
+
+	// Zooming in and back out restores the geometry exactly
+	for round := 0; round < 50; round++ {
+		for i := 0; i < 7; i++ {
+			diagram.ZoomIn()
+		}
+		for i := 0; i < 7; i++ {
+			diagram.ZoomOut()
+		}
+	}
+	assert.Equal(t, float32(1), diagram.GetZoom())
+	assert.Equal(t, fyne.NewPos(333.3, 333.3), node1.Position())
+	assert.Equal(t, fyne.NewSize(58, 41.7), node1.getBaseDiagramNode().InnerSize)
+	assert.Equal(t, offset, label.offset)
+	diagram.SetZoom(2)
+	diagram.SetZoom(1)
+	assert.Equal(t, fyne.NewPos(333.3, 333.3), node1.Position())
+
+	// Positions set while zoomed are in drawing area coordinates
+	diagram.SetZoom(2)
+	node1.Move(fyne.NewPos(100, 100))
+	assert.Equal(t, fyne.NewPos(50, 50), diagram.UnscaledPosition(node1.Position()))
+	node2.Move(diagram.DrawingAreaPosition(fyne.NewPos(200, 300)))
+	diagram.SetZoom(1)
+	assert.Equal(t, fyne.NewPos(50, 50), node1.Position())
+	assert.Equal(t, fyne.NewPos(200, 300), node2.Position())
+}
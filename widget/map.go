@@ -1,10 +1,14 @@
 package widget
 
 import (
+	"context"
+	"fmt"
 	"image"
 	"math"
 	"net/http"
 	"net/url"
+	"sort"
+	"time"
 
 	"github.com/nfnt/resize"
 
@@ -20,15 +24,50 @@ import (
 
 const tileSize = 256
 
+// maxLatitude is the latitude beyond which the Web Mercator projection of the tiles is not defined
+const maxLatitude = 85.0511287798
+
+// maxZoom is the highest zoom level of the tiles
+const maxZoom = 19
+
+// maxPlaceholderLevels is the number of lower zoom levels searched for a tile to upscale in place of a tile
+// that has not been downloaded yet
+const maxPlaceholderLevels = 4
+
 // Map widget renders an interactive map using OpenStreetMap tile data.
 type Map struct {
 	widget.BaseWidget
 
-	pixels     *image.NRGBA
-	w, h       int
-	zoom, x, y int
-
-	cl *http.Client
+	// OnViewChanged is called with the latitude and longitude of the middle of the map, and its zoom level,
+	// each time that the map is panned or zoomed.
+	OnViewChanged func(latitude, longitude float64, zoom int) `json:"-"`
+
+	pixels *image.NRGBA
+	w, h   int
+	zoom   int
+	// centerX and centerY are the position of the middle of the map, as a fraction of the width and height of
+	// the map of the whole world
+	centerX, centerY float64
+	// zoomScale is the scale at which the tiles of the zoom level are drawn, which is 1 except while the map is
+	// animated between zoom levels
+	zoomScale float64
+
+	animation       *fyne.Animation
+	finishAnimation func() // completes the animation if it is stopped early
+	dragVelocity    fyne.Delta
+	lastDrag        time.Time
+	scrolled        float32 // the scroll distance since the last zoom step
+	focused         bool
+
+	cl      *http.Client
+	cache   TileCache
+	fetcher *tileFetcher
+
+	overlays       *mapOverlayLayer
+	markers        []*MapMarker
+	polylines      []*MapPolyline
+	polygons       []*MapPolygon
+	clusterMaxZoom int // markers are clustered at this zoom level and below
 
 	tileSource       string // url to download xyz tiles (example: "https://tile.openstreetmap.org/%d/%d/%d.png")
 	hideAttribution  bool   // enable copyright attribution
@@ -88,9 +127,31 @@ func WithHTTPClient(client *http.Client) MapOption {
 	}
 }
 
+// WithTileCache configures the map to store its tiles in the provided cache, for example one created by
+// NewDiskTileCache. By default, each map holds its most recently used tiles in a memory cache.
+func WithTileCache(cache TileCache) MapOption {
+	return func(m *Map) {
+		m.cache = cache
+	}
+}
+
+// WithMaxTileFetches configures the number of tiles that the map downloads at once. It defaults to 2, as
+// required by the OpenStreetMap tile usage policy.
+func WithMaxTileFetches(count int) MapOption {
+	return func(m *Map) {
+		if count > 0 {
+			m.fetcher.maxWorkers = count
+		}
+	}
+}
+
 // NewMap creates a new instance of the map widget.
 func NewMap() *Map {
-	m := &Map{cl: &http.Client{}}
+	m := &Map{centerX: 0.5, centerY: 0.5, zoomScale: 1, cl: &http.Client{}, cache: NewMemoryTileCache(defaultTileCacheSize), clusterMaxZoom: defaultClusterMaxZoom}
+	m.overlays = newMapOverlayLayer(m)
+	m.fetcher = newTileFetcher(defaultMaxTileFetches, m.fetchTile, func() {
+		fyne.Do(m.Refresh)
+	})
 	WithOsmTiles()(m)
 	m.ExtendBaseWidget(m)
 	return m
@@ -112,64 +173,102 @@ func (m *Map) MinSize() fyne.Size {
 	return fyne.NewSize(64, 64)
 }
 
+// Center returns the latitude and longitude of the middle of the map.
+func (m *Map) Center() (latitude, longitude float64) {
+	return worldToLatLon(m.centerX*tileSize, m.centerY*tileSize, 0)
+}
+
+// FitBounds shows the area between the south west and north east corners, in the middle of the map and at the
+// highest zoom level at which it fits in the current size of the map.
+func (m *Map) FitBounds(southWest, northEast LatLon) {
+	m.stopAnimation()
+	west, south := latLonToWorld(southWest.Latitude, southWest.Longitude, 0)
+	east, north := latLonToWorld(northEast.Latitude, northEast.Longitude, 0)
+	size := m.Size()
+	scale := math.Min(float64(size.Width)/math.Abs(east-west), float64(size.Height)/math.Abs(south-north))
+	zoom := maxZoom
+	if scale < math.Exp2(maxZoom) {
+		zoom = int(math.Max(0, math.Floor(math.Log2(scale))))
+	}
+
+	m.zoom = zoom
+	m.setCenter((west+east)/2/tileSize, (north+south)/2/tileSize)
+}
+
+// LatLonToPosition returns the position in the map of the latitude and longitude.
+func (m *Map) LatLonToPosition(latitude, longitude float64) fyne.Position {
+	return m.worldToPosition(latLonToWorld(latitude, longitude, m.zoom))
+}
+
+// Pan moves the map by the distance, in the coordinates of the widget. Positive distances move the map to the
+// East and the South.
+func (m *Map) Pan(dx, dy float32) {
+	m.stopAnimation()
+	m.pan(dx, dy)
+}
+
 // PanEast will move the map to the East by 1 tile.
 func (m *Map) PanEast() {
-	m.x++
-	m.Refresh()
+	m.Pan(tileSize, 0)
 }
 
 // PanNorth will move the map to the North by 1 tile.
 func (m *Map) PanNorth() {
-	m.y--
-	m.Refresh()
+	m.Pan(0, -tileSize)
 }
 
 // PanSouth will move the map to the South by 1 tile.
 func (m *Map) PanSouth() {
-	m.y++
-	m.Refresh()
+	m.Pan(0, tileSize)
 }
 
 // PanWest will move the map to the west by 1 tile.
 func (m *Map) PanWest() {
-	m.x--
-	m.Refresh()
+	m.Pan(-tileSize, 0)
 }
 
-// Zoom sets the zoom level to a specific value, between 0 and 19.
-func (m *Map) Zoom(zoom int) {
-	if zoom < 0 || zoom > 19 {
+// PositionToLatLon returns the latitude and longitude at the position in the map.
+func (m *Map) PositionToLatLon(pos fyne.Position) (latitude, longitude float64) {
+	originX, originY := m.viewOrigin()
+	worldSize := m.worldSize()
+	return worldToLatLon((originX+float64(pos.X))/worldSize*tileSize, (originY+float64(pos.Y))/worldSize*tileSize, 0)
+}
+
+// SetCenter moves the map so that the latitude and longitude are in its middle.
+func (m *Map) SetCenter(latitude, longitude float64) {
+	m.stopAnimation()
+	x, y := latLonToWorld(latitude, longitude, 0)
+	m.setCenter(x/tileSize, y/tileSize)
+}
+
+// SetZoom sets the zoom level to a specific value, between 0 and 19, keeping the middle of the map in place.
+func (m *Map) SetZoom(zoom int) {
+	if zoom < 0 || zoom > maxZoom {
 		return
 	}
-	delta := zoom - m.zoom
-	if delta > 0 {
-		for i := 0; i < delta; i++ {
-			m.zoomInStep()
-		}
-	} else if delta < 0 {
-		for i := 0; i > delta; i-- {
-			m.zoomOutStep()
-		}
-	}
-	m.Refresh()
+	m.stopAnimation()
+	m.zoom = zoom
+	m.viewChanged()
+}
+
+// Zoom sets the zoom level to a specific value, between 0 and 19.
+func (m *Map) Zoom(zoom int) {
+	m.SetZoom(zoom)
 }
 
 // ZoomIn steps the scale of this map to be one step zoomed in.
 func (m *Map) ZoomIn() {
-	if m.zoom >= 19 {
-		return
-	}
-	m.zoomInStep()
-	m.Refresh()
+	m.SetZoom(m.zoom + 1)
+}
+
+// ZoomLevel returns the current zoom level of the map.
+func (m *Map) ZoomLevel() int {
+	return m.zoom
 }
 
 // ZoomOut steps the scale of this map to be one step zoomed out.
 func (m *Map) ZoomOut() {
-	if m.zoom <= 0 {
-		return
-	}
-	m.zoomOutStep()
-	m.Refresh()
+	m.SetZoom(m.zoom - 1)
 }
 
 // CreateRenderer returns the renderer for this widget.
@@ -201,7 +300,7 @@ func (m *Map) CreateRenderer() fyne.WidgetRenderer {
 
 	overlay := container.NewBorder(nil, copyright, move, zoom)
 
-	c := container.NewStack(canvas.NewRaster(m.draw), container.NewPadded(overlay))
+	c := container.NewStack(canvas.NewRaster(m.draw), m.overlays, container.NewPadded(overlay))
 	return widget.NewSimpleRenderer(c)
 }
 
@@ -219,54 +318,195 @@ func (m *Map) draw(w, h int) image.Image {
 
 	if m.w != w || m.h != h {
 		m.pixels = image.NewNRGBA(image.Rect(0, 0, w, h))
+		m.w, m.h = w, h
+	} else {
+		draw.Draw(m.pixels, m.pixels.Bounds(), image.Transparent, image.Point{}, draw.Src)
 	}
 
-	midTileX := (w - tileSize*2) / 2
-	midTileY := (h - tileSize*2) / 2
-	if m.zoom == 0 {
-		midTileX += tileSize / 2
-		midTileY += tileSize / 2
+	// The tiles are placed relative to the top left corner of the map. While the map is animated between zoom
+	// levels they are scaled, and their edges are rounded to a pixel.
+	count := 1 << m.zoom
+	size := float64(tileSize) * m.zoomScale
+	originX := m.centerX*size*float64(count) - float64(w)/2
+	originY := m.centerY*size*float64(count) - float64(h)/2
+	firstTileX, lastTileX := int(math.Floor(originX/size)), int(math.Floor((originX+float64(w-1))/size))
+	firstTileY, lastTileY := int(math.Floor(originY/size)), int(math.Floor((originY+float64(h-1))/size))
+	mx, my := int(math.Floor((originX+float64(w)/2)/size)), int(math.Floor((originY+float64(h)/2)/size))
+	edge := func(tile int, origin float64) int {
+		return int(math.Round(float64(tile)*size - origin))
 	}
 
-	count := 1 << m.zoom
-	mx := m.x + int(float32(count)/2-0.5)
-	my := m.y + int(float32(count)/2-0.5)
-	firstTileX := mx - int(math.Ceil(float64(midTileX)/float64(tileSize)))
-	firstTileY := my - int(math.Ceil(float64(midTileY)/float64(tileSize)))
-
-	for x := firstTileX; (x-firstTileX)*tileSize <= w+tileSize; x++ {
-		for y := firstTileY; (y-firstTileY)*tileSize <= h+tileSize; y++ {
-			if x < 0 || y < 0 || x >= int(count) || y >= int(count) {
+	// The tiles that are missing or have expired are downloaded in the background, and drawn when they arrive
+	missing := []tileKey{}
+	for x := firstTileX; x <= lastTileX; x++ {
+		for y := firstTileY; y <= lastTileY; y++ {
+			if x < 0 || y < 0 || x >= count || y >= count {
 				continue
 			}
 
-			src, err := getTile(m.tileSource, x, y, m.zoom, m.cl)
-			if err != nil {
-				fyne.LogError("tile fetch error", err)
+			key := tileKey{x: x, y: y, zoom: m.zoom}
+			area := image.Rect(edge(x, originX), edge(y, originY), edge(x+1, originX), edge(y+1, originY))
+			src, fresh := m.getCachedTile(key)
+			if !fresh {
+				missing = append(missing, key)
+			}
+			if src == nil {
+				m.drawPlaceholder(key, area)
+				continue
+			}
+			if area.Dx() != tileSize || area.Dy() != tileSize {
+				draw.ApproxBiLinear.Scale(m.pixels, area, src, src.Bounds(), draw.Over, nil)
 				continue
 			}
 
-			pos := image.Pt(midTileX+(x-mx)*tileSize,
-				midTileY+(y-my)*tileSize)
 			scaled := src
 			if scale > 1 {
 				scaled = resize.Resize(uint(tileSize), uint(tileSize), src, resize.Lanczos2)
 			}
-			draw.Copy(m.pixels, pos, scaled, image.Rect(0, 0, tileSize, tileSize), draw.Over, nil)
+			draw.Copy(m.pixels, area.Min, scaled, image.Rect(0, 0, tileSize, tileSize), draw.Over, nil)
 		}
 	}
+	// The tiles nearest the middle of the map are downloaded first
+	sort.SliceStable(missing, func(i, j int) bool {
+		return tileDistance(missing[i], mx, my) < tileDistance(missing[j], mx, my)
+	})
+	m.fetcher.request(missing)
 
 	return m.pixels
 }
 
-func (m *Map) zoomInStep() {
+// drawPlaceholder fills the area of a tile that has not been downloaded yet with the corresponding part of a
+// tile of a lower zoom level, upscaled, or with the tiles of the next zoom level, downscaled, on a plain color
+func (m *Map) drawPlaceholder(key tileKey, area image.Rectangle) {
+	for levels := 1; levels <= maxPlaceholderLevels && levels <= key.zoom; levels++ {
+		parent := tileKey{x: key.x >> levels, y: key.y >> levels, zoom: key.zoom - levels}
+		src, _ := m.getCachedTile(parent)
+		if src == nil {
+			continue
+		}
+		size := tileSize >> levels
+		offset := image.Pt((key.x-parent.x<<levels)*size, (key.y-parent.y<<levels)*size)
+		part := image.Rectangle{Min: offset, Max: offset.Add(image.Pt(size, size))}.Add(src.Bounds().Min)
+		draw.ApproxBiLinear.Scale(m.pixels, area, src, part, draw.Over, nil)
+		return
+	}
+	draw.Draw(m.pixels, area, image.NewUniform(theme.Color(theme.ColorNameInputBackground)), image.Point{}, draw.Over)
+	if key.zoom >= maxZoom {
+		return
+	}
+	// The tiles of the next zoom level are in the cache after zooming out
+	middle := area.Min.Add(area.Size().Div(2))
+	for i := 0; i < 4; i++ {
+		child := tileKey{x: key.x*2 + i%2, y: key.y*2 + i/2, zoom: key.zoom + 1}
+		src, _ := m.getCachedTile(child)
+		if src == nil {
+			continue
+		}
+		part := image.Rectangle{Min: area.Min, Max: middle}
+		if i%2 == 1 {
+			part.Min.X, part.Max.X = middle.X, area.Max.X
+		}
+		if i/2 == 1 {
+			part.Min.Y, part.Max.Y = middle.Y, area.Max.Y
+		}
+		draw.ApproxBiLinear.Scale(m.pixels, part, src, src.Bounds(), draw.Over, nil)
+	}
+}
+
+// fetchTile downloads the tile into the cache. It is called by the tile fetcher's workers.
+func (m *Map) fetchTile(ctx context.Context, key tileKey) error {
+	_, err := getTile(ctx, m.tileSource, key.x, key.y, key.zoom, m.cl, m.cache)
+	return err
+}
+
+// getCachedTile returns the tile from the cache, or nil if it is not there, and whether it is fresh
+func (m *Map) getCachedTile(key tileKey) (image.Image, bool) {
+	if m.tileSource == "" {
+		return nil, false
+	}
+	tile, ok := m.cache.Get(fmt.Sprintf(m.tileSource, key.zoom, key.x, key.y))
+	if !ok {
+		return nil, false
+	}
+	return tile.Image, tile.Expires.IsZero() || time.Now().Before(tile.Expires)
+}
+
+// pan moves the map by the distance, in the coordinates of the widget, without stopping its animation
+func (m *Map) pan(dx, dy float32) {
+	worldSize := m.worldSize()
+	m.setCenter(m.centerX+float64(dx)/worldSize, m.centerY+float64(dy)/worldSize)
+}
+
+// setCenter moves the middle of the map to the position, as a fraction of the map of the whole world, keeping
+// it within the map
+func (m *Map) setCenter(x, y float64) {
+	m.centerX = math.Max(0, math.Min(1, x))
+	m.centerY = math.Max(0, math.Min(1, y))
+	m.viewChanged()
+}
+
+// viewChanged refreshes the map after it has been panned or zoomed, and notifies OnViewChanged
+func (m *Map) viewChanged() {
+	m.Refresh()
+	if f := m.OnViewChanged; f != nil {
+		latitude, longitude := m.Center()
+		f(latitude, longitude, m.zoom)
+	}
+}
+
+// viewOrigin returns the position of the top left corner of the widget on the map of the whole world at the
+// current zoom level (see latLonToWorld), scaled while the map is animated between zoom levels
+func (m *Map) viewOrigin() (float64, float64) {
+	size := m.Size()
+	worldSize := m.worldSize()
+	return m.centerX*worldSize - float64(size.Width)/2, m.centerY*worldSize - float64(size.Height)/2
+}
+
+// worldSize returns the width and height of the map of the whole world as it is currently drawn
+func (m *Map) worldSize() float64 {
+	return tileSize * math.Exp2(float64(m.zoom)) * m.zoomScale
+}
+
+// worldToPosition returns the position in the widget of the position on the map of the whole world at the
+// current zoom level (see latLonToWorld)
+func (m *Map) worldToPosition(x, y float64) fyne.Position {
+	originX, originY := m.viewOrigin()
+	return fyne.NewPos(float32(x*m.zoomScale-originX), float32(y*m.zoomScale-originY))
+}
+
+// zoomInAt zooms in by one step, with the position on the map of the whole world at the current zoom level (see
+// latLonToWorld) in the middle of the map
+func (m *Map) zoomInAt(x, y float64) {
+	if m.zoom >= maxZoom {
+		return
+	}
+	m.stopAnimation()
+	worldSize := tileSize * math.Exp2(float64(m.zoom))
 	m.zoom++
-	m.x *= 2
-	m.y *= 2
+	m.setCenter(x/worldSize, y/worldSize)
+}
+
+// latLonToWorld returns the position of the latitude and longitude on the Web Mercator map of the whole world
+// at the zoom level, which is made of tiles of tileSize
+func latLonToWorld(latitude, longitude float64, zoom int) (float64, float64) {
+	latitude = math.Max(-maxLatitude, math.Min(maxLatitude, latitude))
+	size := float64(tileSize) * math.Exp2(float64(zoom))
+	radians := latitude * math.Pi / 180
+	x := (longitude + 180) / 360 * size
+	y := (1 - math.Log(math.Tan(radians)+1/math.Cos(radians))/math.Pi) / 2 * size
+	return x, y
+}
+
+// worldToLatLon returns the latitude and longitude of the position on the Web Mercator map of the whole world
+// at the zoom level (see latLonToWorld)
+func worldToLatLon(x, y float64, zoom int) (float64, float64) {
+	size := float64(tileSize) * math.Exp2(float64(zoom))
+	longitude := x/size*360 - 180
+	latitude := math.Atan(math.Sinh(math.Pi*(1-2*y/size))) * 180 / math.Pi
+	return latitude, longitude
 }
 
-func (m *Map) zoomOutStep() {
-	m.zoom--
-	m.x /= 2
-	m.y /= 2
+// tileDistance returns the square of the distance, in tiles, between the tile and the one at x, y
+func tileDistance(key tileKey, x, y int) int {
+	return (key.x-x)*(key.x-x) + (key.y-y)*(key.y-y)
 }
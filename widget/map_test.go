@@ -13,18 +13,79 @@ func TestMap_Pan(t *testing.T) {
 	m := NewMap()
 	m.Resize(fyne.NewSize(200, 200))
 	m.Zoom(3)
-	assert.Equal(t, 0, m.x)
-	assert.Equal(t, 0, m.y)
+	lat, lon := m.Center()
+	assert.InDelta(t, 0, lat, 1e-9)
+	assert.InDelta(t, 0, lon, 1e-9)
 
 	m.PanSouth()
 	m.PanEast()
-	assert.Equal(t, 1, m.x)
-	assert.Equal(t, 1, m.y)
+	lat, lon = m.Center()
+	assert.InDelta(t, -40.9799, lat, 1e-4)
+	assert.InDelta(t, 45, lon, 1e-9)
 
 	m.PanNorth()
 	m.PanWest()
-	assert.Equal(t, 0, m.x)
-	assert.Equal(t, 0, m.y)
+	lat, lon = m.Center()
+	assert.InDelta(t, 0, lat, 1e-9)
+	assert.InDelta(t, 0, lon, 1e-9)
+
+	// The map can be moved by a fraction of a tile
+	m.Pan(1, 0)
+	_, lon = m.Center()
+	assert.InDelta(t, 360.0/2048, lon, 1e-9)
+}
+
+func TestMap_Center(t *testing.T) {
+	m := NewMap()
+	m.Resize(fyne.NewSize(200, 200))
+	changes := 0
+	m.OnViewChanged = func(latitude, longitude float64, zoom int) {
+		changes++
+		assert.Equal(t, 12, zoom)
+	}
+	m.Zoom(12)
+	assert.Equal(t, 1, changes)
+
+	m.SetCenter(51.5072, -0.1276)
+	assert.Equal(t, 2, changes)
+	lat, lon := m.Center()
+	assert.InDelta(t, 51.5072, lat, 1e-9)
+	assert.InDelta(t, -0.1276, lon, 1e-9)
+	assert.Equal(t, 12, m.ZoomLevel())
+
+	// The middle of the map is at the center
+	pos := m.LatLonToPosition(51.5072, -0.1276)
+	assert.InDelta(t, 100, pos.X, 1e-3)
+	assert.InDelta(t, 100, pos.Y, 1e-3)
+	lat, lon = m.PositionToLatLon(fyne.NewPos(100, 100))
+	assert.InDelta(t, 51.5072, lat, 1e-9)
+	assert.InDelta(t, -0.1276, lon, 1e-9)
+
+	// Positions and coordinates convert both ways
+	lat, lon = m.PositionToLatLon(fyne.NewPos(10, 150))
+	pos = m.LatLonToPosition(lat, lon)
+	assert.InDelta(t, 10, pos.X, 1e-3)
+	assert.InDelta(t, 150, pos.Y, 1e-3)
+	assert.Less(t, lat, 51.5072)
+	assert.Less(t, lon, -0.1276)
+}
+
+func TestMap_FitBounds(t *testing.T) {
+	m := NewMap()
+	m.Resize(fyne.NewSize(512, 512))
+	m.FitBounds(LatLon{Latitude: -40, Longitude: -40}, LatLon{Latitude: 40, Longitude: 40})
+	// The 80 degrees of latitude are 497 pixels high at zoom level 3, and would be 995 at level 4
+	assert.Equal(t, 3, m.ZoomLevel())
+	lat, lon := m.Center()
+	assert.InDelta(t, 0, lat, 1e-9)
+	assert.InDelta(t, 0, lon, 1e-9)
+	for _, corner := range []LatLon{{Latitude: -40, Longitude: -40}, {Latitude: 40, Longitude: 40}} {
+		pos := m.LatLonToPosition(corner.Latitude, corner.Longitude)
+		assert.True(t, pos.X >= 0 && pos.X <= 512 && pos.Y >= 0 && pos.Y <= 512)
+	}
+
+	m.FitBounds(LatLon{Latitude: 51.5, Longitude: -0.1}, LatLon{Latitude: 51.5, Longitude: -0.1})
+	assert.Equal(t, 19, m.ZoomLevel())
 }
 
 func TestMap_Zoom(t *testing.T) {
@@ -1,40 +1,340 @@
 package widget
 
 import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"image"
 	"image/png"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
 )
 
-var tileMap = make(map[string]image.Image)
+// defaultTileCacheSize is the number of tiles held by the memory cache of a map created without a TileCache
+const defaultTileCacheSize = 256
+
+// CachedTile is a map tile held by a TileCache, together with the information needed to decide when it must
+// be downloaded again.
+type CachedTile struct {
+	Image image.Image
+	// Data is the encoded tile, as downloaded
+	Data []byte
+	// Expires is the time after which the tile must be revalidated with the tile server. A zero time means
+	// that the server did not specify an expiry time.
+	Expires time.Time
+	// ETag and LastModified are the validators sent by the tile server, if any
+	ETag         string
+	LastModified string
+}
+
+// TileCache stores the tiles displayed by a Map, keyed by their URL, so that they are not downloaded again.
+// Implementations must be safe for concurrent use.
+type TileCache interface {
+	// Get returns the tile stored for the URL, even if it has expired, and false if there is none
+	Get(url string) (*CachedTile, bool)
+	// Put stores the tile for the URL
+	Put(url string, tile *CachedTile)
+}
+
+// memoryTileCache is a TileCache holding a bounded number of decoded tiles in memory, discarding the least
+// recently used tile when it is full
+type memoryTileCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	// order holds the memoryTileCacheEntries, the most recently used at the front
+	order *list.List
+}
+
+// memoryTileCacheEntry is an element of the order of a memoryTileCache
+type memoryTileCacheEntry struct {
+	url  string
+	tile *CachedTile
+}
+
+// NewMemoryTileCache creates a TileCache that holds at most size tiles in memory, discarding the least
+// recently used tile to make room for a new one.
+func NewMemoryTileCache(size int) TileCache {
+	if size < 1 {
+		size = 1
+	}
+	return &memoryTileCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *memoryTileCache) Get(url string) (*CachedTile, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[url]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(entry)
+	return entry.Value.(*memoryTileCacheEntry).tile, true
+}
+
+func (c *memoryTileCache) Put(url string, tile *CachedTile) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The decoded image is all that is needed to draw the tile
+	stored := *tile
+	stored.Data = nil
+	if entry, ok := c.entries[url]; ok {
+		entry.Value.(*memoryTileCacheEntry).tile = &stored
+		c.order.MoveToFront(entry)
+		return
+	}
+	c.entries[url] = c.order.PushFront(&memoryTileCacheEntry{url: url, tile: &stored})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryTileCacheEntry).url)
+	}
+}
+
+// diskTileCache is a TileCache persisting the tiles in a directory, with a memory cache in front of it so that
+// the tiles on display are not decoded again for each frame
+type diskTileCache struct {
+	dir    string
+	expiry time.Duration
+	memory TileCache
+}
+
+// diskTileMetadata is stored next to each tile in a diskTileCache
+type diskTileMetadata struct {
+	URL          string    `json:"url"`
+	Expires      time.Time `json:"expires"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+}
+
+// NewDiskTileCache creates a TileCache that stores the tiles in the directory, creating it if necessary, so
+// that they survive restarts of the application. The tiles expire when the tile server's Cache-Control or
+// Expires headers say so, or after the expiry duration if the server does not specify it. Expired tiles are
+// revalidated with the server before they are displayed again, and are still displayed if it cannot be
+// reached. The most recently used tiles are also held in memory.
+func NewDiskTileCache(dir string, expiry time.Duration) (TileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &diskTileCache{
+		dir:    dir,
+		expiry: expiry,
+		memory: NewMemoryTileCache(defaultTileCacheSize),
+	}, nil
+}
+
+func (c *diskTileCache) Get(url string) (*CachedTile, bool) {
+	if tile, ok := c.memory.Get(url); ok {
+		return tile, true
+	}
+
+	path := c.path(url)
+	metadataBytes, err := os.ReadFile(path + ".json")
+	if err != nil {
+		return nil, false
+	}
+	var metadata diskTileMetadata
+	if err := json.Unmarshal(metadataBytes, &metadata); err != nil || metadata.URL != url {
+		return nil, false
+	}
+	data, err := os.ReadFile(path + ".png")
+	if err != nil {
+		return nil, false
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+	tile := &CachedTile{
+		Image:        img,
+		Data:         data,
+		Expires:      metadata.Expires,
+		ETag:         metadata.ETag,
+		LastModified: metadata.LastModified,
+	}
+	c.memory.Put(url, tile)
+	return tile, true
+}
+
+func (c *diskTileCache) Put(url string, tile *CachedTile) {
+	stored := *tile
+	if stored.Expires.IsZero() {
+		stored.Expires = time.Now().Add(c.expiry)
+	}
+	c.memory.Put(url, &stored)
+
+	path := c.path(url)
+	if stored.Data != nil {
+		if err := writeFileAtomically(path+".png", stored.Data); err != nil {
+			fyne.LogError("tile cache write error", err)
+			return
+		}
+	} else if _, err := os.Stat(path + ".png"); err != nil {
+		// Only the expiry of a tile already on disk can be updated without its data
+		return
+	}
+	metadata, err := json.Marshal(diskTileMetadata{
+		URL:          url,
+		Expires:      stored.Expires,
+		ETag:         stored.ETag,
+		LastModified: stored.LastModified,
+	})
+	if err == nil {
+		err = writeFileAtomically(path+".json", metadata)
+	}
+	if err != nil {
+		fyne.LogError("tile cache write error", err)
+	}
+}
+
+// path returns the path, without extension, of the files holding the tile for the URL
+func (c *diskTileCache) path(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:]))
+}
 
-func getTile(tileSource string, x, y, zoom int, cl *http.Client) (image.Image, error) {
+// writeFileAtomically writes the data to a temporary file that is then renamed, so that concurrent readers
+// never see a partially written file
+func writeFileAtomically(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+	}
+	return err
+}
+
+func getTile(ctx context.Context, tileSource string, x, y, zoom int, cl *http.Client, cache TileCache) (image.Image, error) {
 	if tileSource == "" {
 		return nil, errors.New("no tileSource provided")
 	}
 
 	u := fmt.Sprintf(tileSource, zoom, x, y)
-	if tile, ok := tileMap[u]; ok {
-		return tile, nil
+	cached, ok := cache.Get(u)
+	if ok && (cached.Expires.IsZero() || time.Now().Before(cached.Expires)) {
+		return cached.Image, nil
 	}
 
-	req, err := http.NewRequest("GET", u, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("User-Agent", "Fyne-X Map Widget/0.1")
+	if ok {
+		// The expired tile is revalidated rather than downloaded again if the server supports it
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
 	res, err := cl.Do(req)
 	if err != nil {
+		if ok {
+			// A stale tile is better than none while the server cannot be reached
+			return cached.Image, nil
+		}
 		return nil, err
 	}
 	defer res.Body.Close()
 
-	img, err := png.Decode(res.Body)
-	if err == nil {
-		tileMap[u] = img
+	expires, store := getTileExpiry(res.Header, time.Now())
+	if ok && res.StatusCode == http.StatusNotModified {
+		revalidated := *cached
+		revalidated.Expires = expires
+		if store {
+			cache.Put(u, &revalidated)
+		}
+		return cached.Image, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tile server returned %s for %s", res.Status, u)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if store {
+		cache.Put(u, &CachedTile{
+			Image:        img,
+			Data:         data,
+			Expires:      expires,
+			ETag:         res.Header.Get("ETag"),
+			LastModified: res.Header.Get("Last-Modified"),
+		})
+	} else {
+		// The map draws the tiles from the cache, so a tile that must not be stored is kept only until it is
+		// displayed: it has already expired and has no data to be written to disk
+		cache.Put(u, &CachedTile{Image: img, Expires: time.Now()})
+	}
+	return img, nil
+}
+
+// getTileExpiry returns the expiry time of a tile according to the Cache-Control and Expires headers of the
+// response, which is zero if they do not specify it, and whether the tile may be stored at all
+func getTileExpiry(header http.Header, now time.Time) (time.Time, bool) {
+	maxAge := -1
+	noCache := false
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store":
+			return time.Time{}, false
+		case directive == "no-cache":
+			noCache = true
+		case strings.HasPrefix(directive, "max-age="):
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && seconds >= 0 {
+				maxAge = seconds
+			}
+		}
+	}
+	switch {
+	case noCache:
+		return now, true
+	case maxAge >= 0:
+		return now.Add(time.Duration(maxAge) * time.Second), true
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		if t, err := http.ParseTime(expires); err == nil {
+			return t, true
+		}
+		// An invalid Expires header means that the tile has already expired
+		return now, true
 	}
-	return img, err
+	return time.Time{}, true
 }
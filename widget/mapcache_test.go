@@ -0,0 +1,174 @@
+package widget
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestTileServer starts a tile server counting its requests. The headers are added to each response, and
+// requests with a matching If-None-Match header are answered with 304 Not Modified.
+func newTestTileServer(t *testing.T, headers map[string]string) (*httptest.Server, *int32) {
+	var tile bytes.Buffer
+	assert.NoError(t, png.Encode(&tile, image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))))
+	requests := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		for key, value := range headers {
+			w.Header().Set(key, value)
+		}
+		if etag, ok := headers["ETag"]; ok && r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write(tile.Bytes())
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestMemoryTileCache_Eviction(t *testing.T) {
+	cache := NewMemoryTileCache(2)
+	cache.Put("a", &CachedTile{})
+	cache.Put("b", &CachedTile{})
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+	// "b" is now the least recently used tile
+	cache.Put("c", &CachedTile{})
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	_, ok = cache.Get("a")
+	assert.True(t, ok)
+	_, ok = cache.Get("c")
+	assert.True(t, ok)
+}
+
+func TestMemoryTileCache_Concurrent(t *testing.T) {
+	cache := NewMemoryTileCache(10)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				url := fmt.Sprintf("%d/%d", i, j%20)
+				cache.Put(url, &CachedTile{})
+				cache.Get(url)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 10, cache.(*memoryTileCache).order.Len())
+	assert.Equal(t, 10, len(cache.(*memoryTileCache).entries))
+}
+
+func TestGetTile_Cached(t *testing.T) {
+	server, requests := newTestTileServer(t, nil)
+	cache := NewMemoryTileCache(10)
+	source := server.URL + "/%d/%d/%d.png"
+	tile, err := getTile(context.Background(), source, 1, 2, 3, server.Client(), cache)
+	assert.NoError(t, err)
+	assert.Equal(t, tileSize, tile.Bounds().Dx())
+	_, err = getTile(context.Background(), source, 1, 2, 3, server.Client(), cache)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+	cached, ok := cache.Get(server.URL + "/3/1/2.png")
+	assert.True(t, ok)
+	assert.Nil(t, cached.Data)
+}
+
+func TestGetTile_Revalidation(t *testing.T) {
+	server, requests := newTestTileServer(t, map[string]string{"Cache-Control": "max-age=0", "ETag": `"v1"`})
+	cache := NewMemoryTileCache(10)
+	source := server.URL + "/%d/%d/%d.png"
+	first, err := getTile(context.Background(), source, 0, 0, 0, server.Client(), cache)
+	assert.NoError(t, err)
+	// The expired tile is revalidated, and the server confirms that it has not changed
+	second, err := getTile(context.Background(), source, 0, 0, 0, server.Client(), cache)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+	assert.Same(t, first, second)
+
+	// The expired tile is still displayed when the server cannot be reached
+	server.Close()
+	third, err := getTile(context.Background(), source, 0, 0, 0, server.Client(), cache)
+	assert.NoError(t, err)
+	assert.Same(t, first, third)
+}
+
+func TestGetTile_NoStore(t *testing.T) {
+	server, requests := newTestTileServer(t, map[string]string{"Cache-Control": "no-store"})
+	cache := NewMemoryTileCache(10)
+	source := server.URL + "/%d/%d/%d.png"
+	_, err := getTile(context.Background(), source, 0, 0, 0, server.Client(), cache)
+	assert.NoError(t, err)
+	_, err = getTile(context.Background(), source, 0, 0, 0, server.Client(), cache)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+}
+
+func TestDiskTileCache_Persistence(t *testing.T) {
+	server, requests := newTestTileServer(t, nil)
+	dir := t.TempDir()
+	cache, err := NewDiskTileCache(dir, time.Hour)
+	assert.NoError(t, err)
+	source := server.URL + "/%d/%d/%d.png"
+	_, err = getTile(context.Background(), source, 0, 0, 0, server.Client(), cache)
+	assert.NoError(t, err)
+
+	// A new cache in the same directory, as after a restart, has the tile
+	restarted, err := NewDiskTileCache(dir, time.Hour)
+	assert.NoError(t, err)
+	tile, err := getTile(context.Background(), source, 0, 0, 0, server.Client(), restarted)
+	assert.NoError(t, err)
+	assert.Equal(t, tileSize, tile.Bounds().Dx())
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+	cached, ok := restarted.Get(server.URL + "/0/0/0.png")
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), cached.Expires, time.Minute)
+
+	// Once the tile has expired it is downloaded again
+	expired, err := NewDiskTileCache(dir, 0)
+	assert.NoError(t, err)
+	cached.Expires = time.Now().Add(-time.Second)
+	cached.Data = nil
+	expired.Put(server.URL+"/0/0/0.png", cached)
+	expired, err = NewDiskTileCache(dir, 0)
+	assert.NoError(t, err)
+	_, err = getTile(context.Background(), source, 0, 0, 0, server.Client(), expired)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+}
+
+func TestGetTileExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires, store := getTileExpiry(http.Header{"Cache-Control": {"public, max-age=600"}}, now)
+	assert.True(t, store)
+	assert.Equal(t, now.Add(10*time.Minute), expires)
+	expires, _ = getTileExpiry(http.Header{"Cache-Control": {"max-age=600, no-cache"}}, now)
+	assert.Equal(t, now, expires)
+	expires, _ = getTileExpiry(http.Header{"Expires": {"Mon, 01 Jan 2024 01:00:00 GMT"}}, now)
+	assert.Equal(t, now.Add(time.Hour), expires)
+	expires, store = getTileExpiry(http.Header{}, now)
+	assert.True(t, store)
+	assert.True(t, expires.IsZero())
+	_, store = getTileExpiry(http.Header{"Cache-Control": {"no-store"}}, now)
+	assert.False(t, store)
+}
+
+func TestMap_WithTileCache(t *testing.T) {
+	cache := NewMemoryTileCache(10)
+	m := NewMapWithOptions(WithTileCache(cache))
+	assert.Same(t, cache, m.cache)
+	assert.NotSame(t, NewMap().cache, NewMap().cache)
+}
@@ -0,0 +1,145 @@
+package widget
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// defaultMaxTileFetches is the number of tiles a map downloads at once unless configured otherwise. It is the
+// limit set by the OpenStreetMap tile usage policy.
+const defaultMaxTileFetches = 2
+
+// tileRefetchDelay is the time after a tile has been downloaded, or has failed to download, before it is
+// requested again. It prevents tiles that the cache does not keep fresh, or that cannot be downloaded, from
+// being requested for every frame.
+const tileRefetchDelay = 10 * time.Second
+
+// tileKey identifies a tile by its coordinates and zoom level
+type tileKey struct {
+	x, y, zoom int
+}
+
+// tileFetch is a download in progress
+type tileFetch struct {
+	cancel context.CancelFunc
+}
+
+// tileFetcher downloads the tiles of a map in the background, with a bounded number of concurrent downloads.
+// The tiles no longer requested are dropped from the queue, and their downloads are cancelled.
+type tileFetcher struct {
+	// fetch downloads the tile, typically into a TileCache
+	fetch func(ctx context.Context, key tileKey) error
+	// loaded is called from the worker after each tile has been downloaded
+	loaded func()
+
+	mu         sync.Mutex
+	maxWorkers int
+	workers    int
+	queue      []tileKey
+	inFlight   map[tileKey]*tileFetch
+	// recent holds the times after which the recently downloaded or failed tiles can be requested again
+	recent map[tileKey]time.Time
+	// running tracks the workers, so that tests can wait for them to finish
+	running sync.WaitGroup
+}
+
+func newTileFetcher(maxWorkers int, fetch func(ctx context.Context, key tileKey) error, loaded func()) *tileFetcher {
+	if maxWorkers < 1 {
+		maxWorkers = 1
+	}
+	return &tileFetcher{
+		fetch:      fetch,
+		loaded:     loaded,
+		maxWorkers: maxWorkers,
+		inFlight:   make(map[tileKey]*tileFetch),
+		recent:     make(map[tileKey]time.Time),
+	}
+}
+
+// request replaces the queue with the tiles, in the order in which they should be downloaded. The downloads
+// in progress of tiles that are not among them are cancelled.
+func (f *tileFetcher) request(keys []tileKey) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	wanted := make(map[tileKey]bool, len(keys))
+	for _, key := range keys {
+		wanted[key] = true
+	}
+	for key, fetch := range f.inFlight {
+		if !wanted[key] {
+			fetch.cancel()
+			delete(f.inFlight, key)
+		}
+	}
+	now := time.Now()
+	for key, retry := range f.recent {
+		if now.After(retry) {
+			delete(f.recent, key)
+		}
+	}
+
+	f.queue = f.queue[:0]
+	for _, key := range keys {
+		if _, ok := f.inFlight[key]; ok {
+			continue
+		}
+		if _, ok := f.recent[key]; ok {
+			continue
+		}
+		f.queue = append(f.queue, key)
+	}
+	for f.workers < f.maxWorkers && f.workers < len(f.queue) {
+		f.workers++
+		f.running.Add(1)
+		go f.work()
+	}
+}
+
+// wait blocks until the queue is empty and all of the downloads have finished
+func (f *tileFetcher) wait() {
+	f.running.Wait()
+}
+
+// work downloads the queued tiles one after another until the queue is empty
+func (f *tileFetcher) work() {
+	defer f.running.Done()
+	for {
+		f.mu.Lock()
+		if len(f.queue) == 0 {
+			f.workers--
+			f.mu.Unlock()
+			return
+		}
+		key := f.queue[0]
+		f.queue = f.queue[1:]
+		ctx, cancel := context.WithCancel(context.Background())
+		fetch := &tileFetch{cancel: cancel}
+		f.inFlight[key] = fetch
+		f.mu.Unlock()
+
+		err := f.fetch(ctx, key)
+		cancelled := ctx.Err() != nil
+		cancel()
+
+		f.mu.Lock()
+		if f.inFlight[key] == fetch {
+			delete(f.inFlight, key)
+		}
+		if !cancelled {
+			f.recent[key] = time.Now().Add(tileRefetchDelay)
+		}
+		f.mu.Unlock()
+
+		switch {
+		case cancelled:
+		case err != nil:
+			fyne.LogError("tile fetch error", err)
+		default:
+			f.loaded()
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package widget
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingTileServer is a tile server that holds each request until it is released or cancelled
+type blockingTileServer struct {
+	*httptest.Server
+	release                             chan struct{}
+	received                            chan struct{}
+	requests, active, maxActive, cancel int32
+}
+
+func newBlockingTileServer(t *testing.T, c color.Color) *blockingTileServer {
+	tile := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+	draw.Draw(tile, tile.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	var data bytes.Buffer
+	assert.NoError(t, png.Encode(&data, tile))
+	s := &blockingTileServer{release: make(chan struct{}), received: make(chan struct{}, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.requests, 1)
+		active := atomic.AddInt32(&s.active, 1)
+		defer atomic.AddInt32(&s.active, -1)
+		for {
+			maxActive := atomic.LoadInt32(&s.maxActive)
+			if active <= maxActive || atomic.CompareAndSwapInt32(&s.maxActive, maxActive, active) {
+				break
+			}
+		}
+		s.received <- struct{}{}
+		select {
+		case <-s.release:
+			_, _ = w.Write(data.Bytes())
+		case <-r.Context().Done():
+			atomic.AddInt32(&s.cancel, 1)
+		}
+	}))
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+// countingTransport counts the requests made by an http.Client
+type countingTransport struct {
+	requests int32
+}
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.requests, 1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestMap_AsyncTiles(t *testing.T) {
+	test.NewApp()
+	red := color.NRGBA{R: 255, A: 255}
+	server := newBlockingTileServer(t, red)
+	transport := &countingTransport{}
+	m := NewMapWithOptions(
+		WithTileSource(server.URL+"/%d/%d/%d.png"),
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithMaxTileFetches(3),
+	)
+	// The test draws the map itself, rather than refreshing it as each tile arrives
+	m.fetcher.loaded = func() {}
+	m.Zoom(2)
+
+	// Drawing does not wait for the tiles, which are replaced by placeholders
+	pixels := m.draw(512, 512).(*image.NRGBA)
+	assert.Equal(t, color.NRGBAModel.Convert(theme.Color(theme.ColorNameInputBackground)), pixels.At(256, 256))
+	for i := 0; i < 3; i++ {
+		<-server.received
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&server.active))
+
+	close(server.release)
+	m.fetcher.wait()
+	pixels = m.draw(512, 512).(*image.NRGBA)
+	assert.Equal(t, red, pixels.At(256, 256))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&server.requests))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&transport.requests))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&server.maxActive))
+
+	// The tiles are downloaded only once
+	m.draw(512, 512)
+	m.fetcher.wait()
+	assert.Equal(t, int32(4), atomic.LoadInt32(&server.requests))
+}
+
+func TestMap_CancelTileFetches(t *testing.T) {
+	test.NewApp()
+	server := newBlockingTileServer(t, color.White)
+	m := NewMapWithOptions(WithTileSource(server.URL + "/%d/%d/%d.png"))
+	m.fetcher.loaded = func() {}
+	m.Zoom(8)
+	m.draw(256, 256)
+	for i := 0; i < defaultMaxTileFetches; i++ {
+		<-server.received
+	}
+
+	// Panning away cancels the downloads of the tiles that are no longer visible
+	for i := 0; i < 10; i++ {
+		m.PanEast()
+	}
+	m.draw(256, 256)
+	for i := 0; i < defaultMaxTileFetches; i++ {
+		<-server.received
+	}
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&server.cancel) == defaultMaxTileFetches
+	}, time.Second, 10*time.Millisecond)
+	close(server.release)
+	m.fetcher.wait()
+}
+
+func TestMap_PlaceholderFromLowerZoom(t *testing.T) {
+	test.NewApp()
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	server := newBlockingTileServer(t, color.White)
+	source := server.URL + "/%d/%d/%d.png"
+	m := NewMapWithOptions(WithTileSource(source))
+	m.fetcher.loaded = func() {}
+	// The cached tile at zoom 0 is red on the left and blue on the right
+	parent := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+	draw.Draw(parent, parent.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(parent, image.Rect(tileSize/2, 0, tileSize, tileSize), image.NewUniform(blue), image.Point{}, draw.Src)
+	m.cache.Put(server.URL+"/0/0/0.png", &CachedTile{Image: parent})
+	m.Zoom(1)
+
+	pixels := m.draw(512, 512).(*image.NRGBA)
+	assert.Equal(t, red, pixels.At(128, 128))
+	assert.Equal(t, blue, pixels.At(384, 384))
+	close(server.release)
+	m.fetcher.wait()
+	pixels = m.draw(512, 512).(*image.NRGBA)
+	assert.Equal(t, color.NRGBA{R: 255, G: 255, B: 255, A: 255}, pixels.At(384, 384))
+}
@@ -0,0 +1,217 @@
+package widget
+
+import (
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+var _ fyne.Draggable = (*Map)(nil)
+var _ fyne.DoubleTappable = (*Map)(nil)
+var _ fyne.Focusable = (*Map)(nil)
+var _ fyne.Scrollable = (*Map)(nil)
+
+const (
+	// zoomDuration is the time taken by the animation from one zoom level to the next
+	zoomDuration = 250 * time.Millisecond
+	// inertiaDuration is the time taken by the map to stop after it has been dragged and released
+	inertiaDuration = 600 * time.Millisecond
+	// inertiaTimeout is the time after the last movement of a drag beyond which the map is released without
+	// inertia, as the pointer was held still
+	inertiaTimeout = 100 * time.Millisecond
+	// minInertiaSpeed and maxInertiaSpeed bound the speed, in the coordinates of the widget per second, at which
+	// the map keeps moving after it has been released
+	minInertiaSpeed = 50
+	maxInertiaSpeed = 4000
+	// scrollZoomDistance is the scroll distance that zooms the map in or out by one step
+	scrollZoomDistance = 10
+	// keyPanDistance is the distance that the map is panned by each press of an arrow key
+	keyPanDistance = tileSize / 4
+)
+
+// DoubleTapped zooms in by one step, keeping the point that was tapped in place.
+//
+// Implements: fyne.DoubleTappable
+func (m *Map) DoubleTapped(e *fyne.PointEvent) {
+	m.zoomAround(e.Position, m.zoom+1)
+}
+
+// DragEnd is called when the map is released after a drag. The map keeps moving, slowing down, at the speed
+// at which it was dragged.
+//
+// Implements: fyne.Draggable
+func (m *Map) DragEnd() {
+	velocity := m.dragVelocity
+	recent := time.Since(m.lastDrag) < inertiaTimeout
+	m.dragVelocity = fyne.Delta{}
+	m.lastDrag = time.Time{}
+
+	speed := math.Hypot(float64(velocity.DX), float64(velocity.DY))
+	if !recent || speed < minInertiaSpeed {
+		return
+	}
+	if speed > maxInertiaSpeed {
+		velocity.DX *= float32(maxInertiaSpeed / speed)
+		velocity.DY *= float32(maxInertiaSpeed / speed)
+	}
+	// The map starts at the speed of the drag with the ease out curve, which halves the distance travelled
+	distance := float32(inertiaDuration.Seconds() / 2)
+	totalX, totalY := velocity.DX*distance, velocity.DY*distance
+	var movedX, movedY float32
+	m.animate(inertiaDuration, fyne.AnimationEaseOut, func(done float32) {
+		m.pan(movedX-totalX*done, movedY-totalY*done)
+		movedX, movedY = totalX*done, totalY*done
+	}, nil)
+}
+
+// Dragged pans the map by the distance that it has been dragged.
+//
+// Implements: fyne.Draggable
+func (m *Map) Dragged(e *fyne.DragEvent) {
+	m.stopAnimation()
+	now := time.Now()
+	if !m.lastDrag.IsZero() {
+		if elapsed := float32(now.Sub(m.lastDrag).Seconds()); elapsed > 0 {
+			m.dragVelocity = fyne.NewDelta(e.Dragged.DX/elapsed, e.Dragged.DY/elapsed)
+		}
+	}
+	m.lastDrag = now
+	m.pan(-e.Dragged.DX, -e.Dragged.DY)
+}
+
+// FocusGained is called when the map has been given focus.
+//
+// Implements: fyne.Focusable
+func (m *Map) FocusGained() {
+	m.focused = true
+}
+
+// FocusLost is called when the map has had focus removed.
+//
+// Implements: fyne.Focusable
+func (m *Map) FocusLost() {
+	m.focused = false
+}
+
+// Scrolled zooms the map in or out, keeping the point under the pointer in place.
+//
+// Implements: fyne.Scrollable
+func (m *Map) Scrolled(e *fyne.ScrollEvent) {
+	if (m.scrolled > 0) != (e.Scrolled.DY > 0) {
+		m.scrolled = 0
+	}
+	m.scrolled += e.Scrolled.DY
+	steps := int(m.scrolled / scrollZoomDistance)
+	if steps == 0 {
+		return
+	}
+	m.scrolled -= float32(steps) * scrollZoomDistance
+	m.zoomAround(e.Position, m.zoom+steps)
+}
+
+// TypedKey pans the map when the arrow keys are pressed while it is focused.
+//
+// Implements: fyne.Focusable
+func (m *Map) TypedKey(key *fyne.KeyEvent) {
+	if !m.focused {
+		return
+	}
+	switch key.Name {
+	case fyne.KeyUp:
+		m.Pan(0, -keyPanDistance)
+	case fyne.KeyDown:
+		m.Pan(0, keyPanDistance)
+	case fyne.KeyLeft:
+		m.Pan(-keyPanDistance, 0)
+	case fyne.KeyRight:
+		m.Pan(keyPanDistance, 0)
+	}
+}
+
+// TypedRune zooms the map in or out when the '+' or '-' key is pressed while it is focused.
+//
+// Implements: fyne.Focusable
+func (m *Map) TypedRune(r rune) {
+	if !m.focused {
+		return
+	}
+	middle := fyne.NewPos(m.Size().Width/2, m.Size().Height/2)
+	switch r {
+	case '+', '=':
+		m.zoomAround(middle, m.zoom+1)
+	case '-':
+		m.zoomAround(middle, m.zoom-1)
+	}
+}
+
+// animate starts an animation of the map, once its current animation has been completed. The finish function, if
+// any, completes the animation if it is stopped early.
+func (m *Map) animate(duration time.Duration, curve fyne.AnimationCurve, tick func(float32), finish func()) {
+	m.stopAnimation()
+	var animation *fyne.Animation
+	animation = fyne.NewAnimation(duration, func(done float32) {
+		if m.animation != animation {
+			return
+		}
+		tick(done)
+		if done >= 1 {
+			m.animation, m.finishAnimation = nil, nil
+		}
+	})
+	animation.Curve = curve
+	m.animation, m.finishAnimation = animation, finish
+	animation.Start()
+}
+
+// stopAnimation stops the current animation of the map, if any, completing it if needed
+func (m *Map) stopAnimation() {
+	if m.animation == nil {
+		return
+	}
+	m.animation.Stop()
+	finish := m.finishAnimation
+	m.animation, m.finishAnimation = nil, nil
+	if finish != nil {
+		finish()
+	}
+}
+
+// zoomAround zooms the map to the zoom level, keeping the point at the position in the widget in place. The
+// intermediate frames are drawn by scaling the tiles of the new zoom level, which are replaced by those of the
+// previous level until they have been downloaded.
+func (m *Map) zoomAround(pos fyne.Position, zoom int) {
+	zoom = int(math.Max(0, math.Min(maxZoom, float64(zoom))))
+	if zoom == m.zoom {
+		return
+	}
+	m.stopAnimation()
+	size := m.Size()
+	originX, originY := m.viewOrigin()
+	worldSize := m.worldSize()
+	pointX, pointY := (originX+float64(pos.X))/worldSize, (originY+float64(pos.Y))/worldSize
+	offsetX, offsetY := float64(pos.X-size.Width/2), float64(pos.Y-size.Height/2)
+	from := math.Exp2(float64(m.zoom - zoom))
+
+	m.zoom = zoom
+	scaleTo := func(scale float64) {
+		m.zoomScale = scale
+		worldSize := m.worldSize()
+		m.centerX = math.Max(0, math.Min(1, pointX-offsetX/worldSize))
+		m.centerY = math.Max(0, math.Min(1, pointY-offsetY/worldSize))
+	}
+	finish := func() {
+		scaleTo(1)
+		m.viewChanged()
+	}
+	scaleTo(from)
+	m.animate(zoomDuration, fyne.AnimationEaseInOut, func(done float32) {
+		if done >= 1 {
+			finish()
+			return
+		}
+		// The scale changes geometrically, so that the zoom appears to have a constant speed
+		scaleTo(math.Pow(from, 1-float64(done)))
+		m.Refresh()
+	}, finish)
+}
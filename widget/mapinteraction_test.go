@@ -0,0 +1,145 @@
+package widget
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap_Dragged(t *testing.T) {
+	test.NewApp()
+	m := NewMap()
+	m.Resize(fyne.NewSize(512, 512))
+	m.Zoom(2)
+
+	// Dragging the map to the right moves its middle to the West
+	m.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(64, 0)})
+	m.DragEnd()
+	lat, lon := m.Center()
+	assert.InDelta(t, 0, lat, 1e-9)
+	assert.InDelta(t, -22.5, lon, 1e-9)
+
+	// The map keeps moving after it has been released during a drag
+	m.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(0, -10)})
+	time.Sleep(10 * time.Millisecond)
+	m.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(0, -10)})
+	m.DragEnd()
+	pos := m.LatLonToPosition(0, -22.5)
+	assert.InDelta(t, 256, pos.X, 1e-3)
+	assert.Less(t, pos.Y, float32(256-20-5))
+}
+
+func TestMap_Scrolled(t *testing.T) {
+	test.NewApp()
+	m := NewMap()
+	m.Resize(fyne.NewSize(512, 512))
+	m.Zoom(2)
+	pointer := fyne.NewPos(100, 400)
+	lat, lon := m.PositionToLatLon(pointer)
+	scroll := func(dy float32) {
+		m.Scrolled(&fyne.ScrollEvent{PointEvent: fyne.PointEvent{Position: pointer}, Scrolled: fyne.NewDelta(0, dy)})
+	}
+
+	// Small scroll distances add up to a zoom step
+	scroll(scrollZoomDistance / 2)
+	assert.Equal(t, 2, m.ZoomLevel())
+	scroll(scrollZoomDistance / 2)
+	assert.Equal(t, 3, m.ZoomLevel())
+	assert.Equal(t, 1.0, m.zoomScale)
+	pos := m.LatLonToPosition(lat, lon)
+	assert.InDelta(t, pointer.X, pos.X, 1e-3)
+	assert.InDelta(t, pointer.Y, pos.Y, 1e-3)
+
+	scroll(-2 * scrollZoomDistance)
+	assert.Equal(t, 1, m.ZoomLevel())
+	pos = m.LatLonToPosition(lat, lon)
+	assert.InDelta(t, pointer.X, pos.X, 1e-3)
+	assert.InDelta(t, pointer.Y, pos.Y, 1e-3)
+}
+
+func TestMap_DoubleTapped(t *testing.T) {
+	test.NewApp()
+	m := NewMap()
+	m.Resize(fyne.NewSize(512, 512))
+	changes := 0
+	m.OnViewChanged = func(float64, float64, int) {
+		changes++
+	}
+	tapped := fyne.NewPos(300, 200)
+	lat, lon := m.PositionToLatLon(tapped)
+	m.DoubleTapped(&fyne.PointEvent{Position: tapped})
+	assert.Equal(t, 1, m.ZoomLevel())
+	assert.Equal(t, 1, changes)
+	pos := m.LatLonToPosition(lat, lon)
+	assert.InDelta(t, tapped.X, pos.X, 1e-3)
+	assert.InDelta(t, tapped.Y, pos.Y, 1e-3)
+}
+
+func TestMap_Keyboard(t *testing.T) {
+	test.NewApp()
+	m := NewMap()
+	m.Resize(fyne.NewSize(512, 512))
+	m.Zoom(2)
+
+	// The keys are ignored until the map is focused
+	m.TypedRune('+')
+	assert.Equal(t, 2, m.ZoomLevel())
+	m.FocusGained()
+	m.TypedRune('+')
+	assert.Equal(t, 3, m.ZoomLevel())
+	m.TypedRune('-')
+	assert.Equal(t, 2, m.ZoomLevel())
+
+	m.TypedKey(&fyne.KeyEvent{Name: fyne.KeyRight})
+	m.TypedKey(&fyne.KeyEvent{Name: fyne.KeyDown})
+	pos := m.LatLonToPosition(0, 0)
+	assert.InDelta(t, 256-keyPanDistance, pos.X, 1e-3)
+	assert.InDelta(t, 256-keyPanDistance, pos.Y, 1e-3)
+
+	m.FocusLost()
+	m.TypedKey(&fyne.KeyEvent{Name: fyne.KeyLeft})
+	assert.Equal(t, pos, m.LatLonToPosition(0, 0))
+}
+
+func TestMap_ZoomFrames(t *testing.T) {
+	test.NewApp()
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	server := newBlockingTileServer(t, color.White)
+	m := NewMapWithOptions(WithTileSource(server.URL + "/%d/%d/%d.png"))
+	m.fetcher.loaded = func() {}
+	// The tiles at zoom 1 are red, except for the bottom right one which is blue
+	for i := 0; i < 4; i++ {
+		tile := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+		c := red
+		if i == 3 {
+			c = blue
+		}
+		draw.Draw(tile, tile.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+		m.cache.Put(fmt.Sprintf(server.URL+"/%d/%d/%d.png", 1, i%2, i/2), &CachedTile{Image: tile})
+	}
+
+	// Zooming out from zoom 1 starts with the map of zoom level 0 at twice its size, drawn from the tiles of zoom 1
+	m.zoomScale = 2
+	pixels := m.draw(512, 512).(*image.NRGBA)
+	assert.Equal(t, red, pixels.At(128, 128))
+	assert.Equal(t, blue, pixels.At(384, 384))
+
+	// Half way through, the map is drawn at 1.5 times its size
+	m.zoomScale = 1.5
+	pixels = m.draw(512, 512).(*image.NRGBA)
+	assert.Equal(t, color.NRGBA{}, pixels.At(60, 60))
+	assert.Equal(t, red, pixels.At(70, 70))
+	assert.Equal(t, blue, pixels.At(440, 440))
+	assert.Equal(t, color.NRGBA{}, pixels.At(450, 450))
+	close(server.release)
+	m.fetcher.wait()
+}
@@ -0,0 +1,479 @@
+package widget
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"golang.org/x/image/vector"
+)
+
+const (
+	// defaultClusterMaxZoom is the highest zoom level at which markers are clustered unless configured otherwise
+	defaultClusterMaxZoom = 10
+	// clusterRadius is the size of the squares of the map within which markers are clustered
+	clusterRadius = 48
+	// clusterSize is the diameter of the circle representing a cluster of markers
+	clusterSize = 32
+	// markerIconSize is the size of the icon of a marker
+	markerIconSize = 24
+)
+
+// LatLon is a geographic position, in degrees
+type LatLon struct {
+	Latitude, Longitude float64
+}
+
+// MapMarker is a point of interest on a Map, displayed as an icon with an optional label at a latitude and
+// longitude. Markers that are close to each other are displayed as a cluster at low zoom levels.
+type MapMarker struct {
+	widget.BaseWidget
+
+	Latitude, Longitude float64
+	Icon                fyne.Resource // a dot is displayed when there is no icon
+	Label               string
+	OnTapped            func()
+
+	m *Map
+}
+
+// NewMapMarker creates a marker at the latitude and longitude, which is displayed once it is added to a Map
+// with AddMarker.
+func NewMapMarker(latitude, longitude float64, icon fyne.Resource, label string, tapped func()) *MapMarker {
+	marker := &MapMarker{Latitude: latitude, Longitude: longitude, Icon: icon, Label: label, OnTapped: tapped}
+	marker.ExtendBaseWidget(marker)
+	return marker
+}
+
+// CreateRenderer returns the renderer for this widget.
+func (mk *MapMarker) CreateRenderer() fyne.WidgetRenderer {
+	r := &mapMarkerRenderer{
+		marker: mk,
+		icon:   canvas.NewImageFromResource(mk.Icon),
+		dot:    canvas.NewCircle(theme.Color(theme.ColorNamePrimary)),
+		label:  canvas.NewText(mk.Label, theme.Color(theme.ColorNameForeground)),
+	}
+	r.icon.FillMode = canvas.ImageFillContain
+	r.dot.StrokeColor = theme.Color(theme.ColorNameBackground)
+	r.dot.StrokeWidth = 2
+	r.label.TextStyle.Bold = true
+	return r
+}
+
+// MoveTo moves the marker to the latitude and longitude.
+func (mk *MapMarker) MoveTo(latitude, longitude float64) {
+	mk.Latitude = latitude
+	mk.Longitude = longitude
+	if mk.m != nil {
+		mk.m.RefreshOverlays()
+	}
+}
+
+// Tapped calls the OnTapped callback of the marker.
+func (mk *MapMarker) Tapped(*fyne.PointEvent) {
+	if mk.OnTapped != nil {
+		mk.OnTapped()
+	}
+}
+
+// MapPolyline is a line on a Map through a series of geographic positions, e.g. a route.
+type MapPolyline struct {
+	Points      []LatLon
+	StrokeColor color.Color
+	StrokeWidth float32
+}
+
+// NewMapPolyline creates a polyline through the points, which is displayed once it is added to a Map with
+// AddPolyline.
+func NewMapPolyline(points []LatLon, strokeColor color.Color, strokeWidth float32) *MapPolyline {
+	return &MapPolyline{Points: points, StrokeColor: strokeColor, StrokeWidth: strokeWidth}
+}
+
+// MapPolygon is an area on a Map enclosed by a series of geographic positions.
+type MapPolygon struct {
+	Points      []LatLon
+	FillColor   color.Color // the polygon is not filled when it is nil
+	StrokeColor color.Color // the polygon is not outlined when it is nil
+	StrokeWidth float32
+}
+
+// NewMapPolygon creates a polygon with the points as vertices, which is displayed once it is added to a Map
+// with AddPolygon.
+func NewMapPolygon(points []LatLon, fillColor, strokeColor color.Color, strokeWidth float32) *MapPolygon {
+	return &MapPolygon{Points: points, FillColor: fillColor, StrokeColor: strokeColor, StrokeWidth: strokeWidth}
+}
+
+// WithMarkerClustering configures the highest zoom level at which markers that are close to each other are
+// displayed as a single cluster. A negative zoom level disables clustering.
+func WithMarkerClustering(maxZoom int) MapOption {
+	return func(m *Map) {
+		m.clusterMaxZoom = maxZoom
+	}
+}
+
+// AddMarker displays the marker on the map.
+func (m *Map) AddMarker(marker *MapMarker) {
+	marker.m = m
+	m.markers = append(m.markers, marker)
+	m.RefreshOverlays()
+}
+
+// AddPolygon displays the polygon on the map.
+func (m *Map) AddPolygon(polygon *MapPolygon) {
+	m.polygons = append(m.polygons, polygon)
+	m.RefreshOverlays()
+}
+
+// AddPolyline displays the polyline on the map.
+func (m *Map) AddPolyline(polyline *MapPolyline) {
+	m.polylines = append(m.polylines, polyline)
+	m.RefreshOverlays()
+}
+
+// RefreshOverlays redraws the markers, polylines and polygons, e.g. after their points or colors have been
+// changed.
+func (m *Map) RefreshOverlays() {
+	for _, marker := range m.markers {
+		marker.Refresh()
+	}
+	m.overlays.Refresh()
+}
+
+// RemoveMarker removes the marker from the map.
+func (m *Map) RemoveMarker(marker *MapMarker) {
+	for i, existing := range m.markers {
+		if existing == marker {
+			m.markers = append(m.markers[:i], m.markers[i+1:]...)
+			marker.m = nil
+			break
+		}
+	}
+	m.RefreshOverlays()
+}
+
+// RemovePolygon removes the polygon from the map.
+func (m *Map) RemovePolygon(polygon *MapPolygon) {
+	for i, existing := range m.polygons {
+		if existing == polygon {
+			m.polygons = append(m.polygons[:i], m.polygons[i+1:]...)
+			break
+		}
+	}
+	m.RefreshOverlays()
+}
+
+// RemovePolyline removes the polyline from the map.
+func (m *Map) RemovePolyline(polyline *MapPolyline) {
+	for i, existing := range m.polylines {
+		if existing == polyline {
+			m.polylines = append(m.polylines[:i], m.polylines[i+1:]...)
+			break
+		}
+	}
+	m.RefreshOverlays()
+}
+
+type mapMarkerRenderer struct {
+	marker *MapMarker
+	icon   *canvas.Image
+	dot    *canvas.Circle
+	label  *canvas.Text
+}
+
+func (r *mapMarkerRenderer) Destroy() {
+}
+
+func (r *mapMarkerRenderer) Layout(size fyne.Size) {
+	iconPos := fyne.NewPos((size.Width-markerIconSize)/2, 0)
+	r.icon.Move(iconPos)
+	r.icon.Resize(fyne.NewSquareSize(markerIconSize))
+	r.dot.Move(iconPos.AddXY(markerIconSize/4, markerIconSize/4))
+	r.dot.Resize(fyne.NewSquareSize(markerIconSize / 2))
+	labelSize := r.label.MinSize()
+	r.label.Move(fyne.NewPos((size.Width-labelSize.Width)/2, markerIconSize))
+	r.label.Resize(labelSize)
+}
+
+func (r *mapMarkerRenderer) MinSize() fyne.Size {
+	if r.marker.Label == "" {
+		return fyne.NewSquareSize(markerIconSize)
+	}
+	labelSize := r.label.MinSize()
+	return fyne.NewSize(float32(math.Max(markerIconSize, float64(labelSize.Width))), markerIconSize+labelSize.Height)
+}
+
+func (r *mapMarkerRenderer) Objects() []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{}
+	if r.marker.Icon == nil {
+		objects = append(objects, r.dot)
+	} else {
+		objects = append(objects, r.icon)
+	}
+	if r.marker.Label != "" {
+		objects = append(objects, r.label)
+	}
+	return objects
+}
+
+func (r *mapMarkerRenderer) Refresh() {
+	r.icon.Resource = r.marker.Icon
+	r.icon.Refresh()
+	r.dot.Refresh()
+	r.label.Text = r.marker.Label
+	r.label.Refresh()
+	r.Layout(r.marker.Size())
+}
+
+// mapMarkerCluster represents several markers that are close to each other. Tapping it zooms in on them.
+type mapMarkerCluster struct {
+	widget.BaseWidget
+
+	m    *Map
+	x, y float64 // the position of the cluster on the map of the whole world
+	text *canvas.Text
+}
+
+func newMapMarkerCluster(m *Map) *mapMarkerCluster {
+	cluster := &mapMarkerCluster{m: m, text: canvas.NewText("", theme.Color(theme.ColorNameForegroundOnPrimary))}
+	cluster.text.Alignment = fyne.TextAlignCenter
+	cluster.text.TextStyle.Bold = true
+	cluster.ExtendBaseWidget(cluster)
+	return cluster
+}
+
+func (c *mapMarkerCluster) CreateRenderer() fyne.WidgetRenderer {
+	r := &mapMarkerClusterRenderer{cluster: c, circle: canvas.NewCircle(theme.Color(theme.ColorNamePrimary))}
+	r.circle.StrokeColor = theme.Color(theme.ColorNameBackground)
+	r.circle.StrokeWidth = 2
+	return r
+}
+
+// Tapped zooms in on the markers of the cluster
+func (c *mapMarkerCluster) Tapped(*fyne.PointEvent) {
+	c.m.zoomInAt(c.x, c.y)
+}
+
+type mapMarkerClusterRenderer struct {
+	cluster *mapMarkerCluster
+	circle  *canvas.Circle
+}
+
+func (r *mapMarkerClusterRenderer) Destroy() {
+}
+
+func (r *mapMarkerClusterRenderer) Layout(size fyne.Size) {
+	r.circle.Resize(size)
+	textSize := r.cluster.text.MinSize()
+	r.cluster.text.Move(fyne.NewPos(0, (size.Height-textSize.Height)/2))
+	r.cluster.text.Resize(fyne.NewSize(size.Width, textSize.Height))
+}
+
+func (r *mapMarkerClusterRenderer) MinSize() fyne.Size {
+	return fyne.NewSquareSize(clusterSize)
+}
+
+func (r *mapMarkerClusterRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.circle, r.cluster.text}
+}
+
+func (r *mapMarkerClusterRenderer) Refresh() {
+	r.circle.Refresh()
+	r.cluster.text.Refresh()
+	r.Layout(r.cluster.Size())
+}
+
+// mapOverlayLayer displays the markers, polylines and polygons of a map above its tiles
+type mapOverlayLayer struct {
+	widget.BaseWidget
+
+	m *Map
+}
+
+func newMapOverlayLayer(m *Map) *mapOverlayLayer {
+	layer := &mapOverlayLayer{m: m}
+	layer.ExtendBaseWidget(layer)
+	return layer
+}
+
+func (l *mapOverlayLayer) CreateRenderer() fyne.WidgetRenderer {
+	r := &mapOverlayRenderer{layer: l}
+	r.shapes = canvas.NewRaster(r.drawShapes)
+	return r
+}
+
+type mapOverlayRenderer struct {
+	layer  *mapOverlayLayer
+	shapes *canvas.Raster
+	// objects are the shapes and the visible markers and clusters
+	objects  []fyne.CanvasObject
+	clusters []*mapMarkerCluster
+}
+
+func (r *mapOverlayRenderer) Destroy() {
+}
+
+func (r *mapOverlayRenderer) Layout(size fyne.Size) {
+	r.shapes.Resize(size)
+	r.placeMarkers(size)
+}
+
+func (r *mapOverlayRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(0, 0)
+}
+
+func (r *mapOverlayRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *mapOverlayRenderer) Refresh() {
+	r.placeMarkers(r.layer.Size())
+	r.shapes.Refresh()
+}
+
+// drawShapes draws the polygons and then the polylines, projected at the current zoom level
+func (r *mapOverlayRenderer) drawShapes(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	m := r.layer.m
+	size := r.layer.Size()
+	if size.Width <= 0 {
+		return img
+	}
+	scale := float32(w) / size.Width
+	project := func(points []LatLon) []fyne.Position {
+		projected := make([]fyne.Position, len(points))
+		for i, point := range points {
+			pos := m.LatLonToPosition(point.Latitude, point.Longitude)
+			projected[i] = fyne.NewPos(pos.X*scale, pos.Y*scale)
+		}
+		return projected
+	}
+
+	for _, polygon := range m.polygons {
+		points := project(polygon.Points)
+		if polygon.FillColor != nil && len(points) > 2 {
+			rasterizer := vector.NewRasterizer(w, h)
+			rasterizer.MoveTo(points[0].X, points[0].Y)
+			for _, point := range points[1:] {
+				rasterizer.LineTo(point.X, point.Y)
+			}
+			rasterizer.ClosePath()
+			rasterizer.Draw(img, img.Bounds(), image.NewUniform(polygon.FillColor), image.Point{})
+		}
+		if polygon.StrokeColor != nil && len(points) > 1 {
+			strokePath(img, append(points, points[0]), polygon.StrokeWidth*scale, polygon.StrokeColor)
+		}
+	}
+	for _, polyline := range m.polylines {
+		if polyline.StrokeColor != nil && len(polyline.Points) > 1 {
+			strokePath(img, project(polyline.Points), polyline.StrokeWidth*scale, polyline.StrokeColor)
+		}
+	}
+	return img
+}
+
+// placeMarkers positions the markers, or the clusters replacing them, and collects the visible ones
+func (r *mapOverlayRenderer) placeMarkers(size fyne.Size) {
+	m := r.layer.m
+	r.objects = []fyne.CanvasObject{r.shapes}
+	visible := func(object fyne.CanvasObject, x, y float64, anchor fyne.Position) {
+		objectSize := object.MinSize()
+		object.Resize(objectSize)
+		position := m.worldToPosition(x, y).Subtract(anchor)
+		object.Move(position)
+		if position.X < size.Width && position.Y < size.Height &&
+			position.X+objectSize.Width > 0 && position.Y+objectSize.Height > 0 {
+			r.objects = append(r.objects, object)
+		}
+	}
+
+	// The markers are grouped by square of the map of the whole world, so that the clusters do not change
+	// as the map is panned
+	type group struct {
+		markers []*MapMarker
+		x, y    float64
+	}
+	groups := []*group{}
+	cells := map[image.Point]*group{}
+	for _, marker := range m.markers {
+		x, y := latLonToWorld(marker.Latitude, marker.Longitude, m.zoom)
+		if m.zoom > m.clusterMaxZoom {
+			groups = append(groups, &group{markers: []*MapMarker{marker}, x: x, y: y})
+			continue
+		}
+		cell := image.Pt(int(math.Floor(x/clusterRadius)), int(math.Floor(y/clusterRadius)))
+		g, ok := cells[cell]
+		if !ok {
+			g = &group{}
+			cells[cell] = g
+			groups = append(groups, g)
+		}
+		g.markers = append(g.markers, marker)
+		g.x += x
+		g.y += y
+	}
+
+	clusters := 0
+	for _, g := range groups {
+		if len(g.markers) == 1 {
+			marker := g.markers[0]
+			x, y := latLonToWorld(marker.Latitude, marker.Longitude, m.zoom)
+			// The middle of the icon is at the position of the marker
+			visible(marker, x, y, fyne.NewPos(marker.MinSize().Width/2, markerIconSize/2))
+			continue
+		}
+		if clusters == len(r.clusters) {
+			r.clusters = append(r.clusters, newMapMarkerCluster(m))
+		}
+		cluster := r.clusters[clusters]
+		clusters++
+		cluster.x = g.x / float64(len(g.markers))
+		cluster.y = g.y / float64(len(g.markers))
+		cluster.text.Text = strconv.Itoa(len(g.markers))
+		cluster.Refresh()
+		visible(cluster, cluster.x, cluster.y, fyne.NewPos(clusterSize/2, clusterSize/2))
+	}
+	r.clusters = r.clusters[:clusters]
+}
+
+// strokePath draws the lines between consecutive points, with round joins
+func strokePath(img *image.RGBA, points []fyne.Position, width float32, c color.Color) {
+	bounds := img.Bounds()
+	rasterizer := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	halfWidth := float64(width) / 2
+	// All of the sub-paths wind the same way, so that their overlaps are not cancelled out
+	for i, point := range points {
+		for j := 0; j < 8; j++ {
+			angle := -float64(j) * math.Pi / 4
+			x := point.X + float32(halfWidth*math.Cos(angle))
+			y := point.Y + float32(halfWidth*math.Sin(angle))
+			if j == 0 {
+				rasterizer.MoveTo(x, y)
+			} else {
+				rasterizer.LineTo(x, y)
+			}
+		}
+		rasterizer.ClosePath()
+		if i == 0 {
+			continue
+		}
+		previous := points[i-1]
+		dx, dy := float64(point.X-previous.X), float64(point.Y-previous.Y)
+		length := math.Hypot(dx, dy)
+		if length == 0 {
+			continue
+		}
+		nx, ny := float32(-dy/length*halfWidth), float32(dx/length*halfWidth)
+		rasterizer.MoveTo(previous.X+nx, previous.Y+ny)
+		rasterizer.LineTo(point.X+nx, point.Y+ny)
+		rasterizer.LineTo(point.X-nx, point.Y-ny)
+		rasterizer.LineTo(previous.X-nx, previous.Y-ny)
+		rasterizer.ClosePath()
+	}
+	rasterizer.Draw(img, bounds, image.NewUniform(c), image.Point{})
+}
@@ -0,0 +1,127 @@
+package widget
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newOverlayTestMap creates a 512 x 512 map in a window, showing the whole world at zoom level 0
+func newOverlayTestMap(t *testing.T, opts ...MapOption) (*Map, *mapOverlayRenderer) {
+	server, _ := newTestTileServer(t, nil)
+	m := NewMapWithOptions(append([]MapOption{WithTileSource(server.URL + "/%d/%d/%d.png")}, opts...)...)
+	m.fetcher.loaded = func() {}
+	w := test.NewWindow(m)
+	t.Cleanup(w.Close)
+	w.SetPadded(false)
+	w.Resize(fyne.NewSize(512, 512))
+	return m, test.TempWidgetRenderer(t, m.overlays).(*mapOverlayRenderer)
+}
+
+// markerPoint returns the position in the map of the point at which the marker is displayed
+func markerPoint(marker *MapMarker) fyne.Position {
+	return marker.Position().AddXY(marker.Size().Width/2, markerIconSize/2)
+}
+
+func TestLatLonToWorld(t *testing.T) {
+	x, y := latLonToWorld(0, 0, 0)
+	assert.InDelta(t, 128, x, 1e-9)
+	assert.InDelta(t, 128, y, 1e-9)
+	x, y = latLonToWorld(90, -180, 1)
+	assert.InDelta(t, 0, x, 1e-9)
+	assert.InDelta(t, 0, y, 1e-6)
+	x, y = latLonToWorld(-maxLatitude, 180, 2)
+	assert.InDelta(t, 1024, x, 1e-9)
+	assert.InDelta(t, 1024, y, 1e-6)
+}
+
+func TestMap_Markers(t *testing.T) {
+	m, renderer := newOverlayTestMap(t)
+	tapped := false
+	marker := NewMapMarker(0, 0, nil, "Origin", func() { tapped = true })
+	m.AddMarker(marker)
+	assert.Contains(t, renderer.Objects(), marker)
+	assert.Equal(t, fyne.NewPos(256, 256), markerPoint(marker))
+	test.Tap(marker)
+	assert.True(t, tapped)
+
+	// The marker follows the map as it zooms and pans
+	m.ZoomIn()
+	m.PanEast()
+	assert.Equal(t, fyne.NewPos(0, 256), markerPoint(marker))
+	m.PanWest()
+	marker.MoveTo(0, 90)
+	assert.Equal(t, fyne.NewPos(384, 256), markerPoint(marker))
+
+	// Markers outside the map are not displayed
+	m.Zoom(3)
+	for i := 0; i < 4; i++ {
+		m.PanEast()
+	}
+	assert.NotContains(t, renderer.Objects(), marker)
+
+	m.RemoveMarker(marker)
+	m.Zoom(0)
+	assert.NotContains(t, renderer.Objects(), marker)
+}
+
+func TestMap_MarkerClustering(t *testing.T) {
+	m, renderer := newOverlayTestMap(t, WithMarkerClustering(1))
+	first := NewMapMarker(10, 10, nil, "", nil)
+	second := NewMapMarker(10.5, 10.5, nil, "", nil)
+	third := NewMapMarker(-40, -100, nil, "", nil)
+	m.AddMarker(first)
+	m.AddMarker(second)
+	m.AddMarker(third)
+
+	// The markers that are close to each other are replaced by a cluster
+	assert.Equal(t, 1, len(renderer.clusters))
+	cluster := renderer.clusters[0]
+	assert.Equal(t, "2", cluster.text.Text)
+	assert.Contains(t, renderer.Objects(), cluster)
+	assert.NotContains(t, renderer.Objects(), first)
+	assert.NotContains(t, renderer.Objects(), second)
+	assert.Contains(t, renderer.Objects(), third)
+
+	// Tapping the cluster zooms in on it, and above the clustering zoom level the markers are separate
+	test.Tap(cluster)
+	assert.Equal(t, 1, m.zoom)
+	test.Tap(renderer.clusters[0])
+	assert.Equal(t, 2, m.zoom)
+	assert.Equal(t, 0, len(renderer.clusters))
+	assert.Contains(t, renderer.Objects(), first)
+	assert.Contains(t, renderer.Objects(), second)
+}
+
+func TestMap_Shapes(t *testing.T) {
+	m, renderer := newOverlayTestMap(t)
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	polygon := NewMapPolygon([]LatLon{{Latitude: 40, Longitude: -40}, {Latitude: 40, Longitude: 40},
+		{Latitude: -40, Longitude: 40}, {Latitude: -40, Longitude: -40}}, red, nil, 0)
+	m.AddPolygon(polygon)
+	polyline := NewMapPolyline([]LatLon{{Latitude: 60, Longitude: -90}, {Latitude: 60, Longitude: 90}}, blue, 4)
+	m.AddPolyline(polyline)
+
+	shapes := renderer.drawShapes(512, 512).(*image.RGBA)
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, shapes.At(256, 256))
+	assert.Equal(t, color.RGBA{}, shapes.At(300, 256))
+	_, y := latLonToWorld(60, 0, 0)
+	assert.Equal(t, color.RGBA{B: 255, A: 255}, shapes.At(256, int(y)+128))
+	assert.Equal(t, color.RGBA{}, shapes.At(100, int(y)+128))
+
+	// The shapes are projected at the current zoom level
+	m.ZoomIn()
+	shapes = renderer.drawShapes(512, 512).(*image.RGBA)
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, shapes.At(300, 256))
+
+	m.RemovePolygon(polygon)
+	m.RemovePolyline(polyline)
+	shapes = renderer.drawShapes(512, 512).(*image.RGBA)
+	assert.Equal(t, color.RGBA{}, shapes.At(256, 256))
+}